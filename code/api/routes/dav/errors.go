@@ -0,0 +1,60 @@
+package dav
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Voltaic314/GhostFS/code/core/items"
+)
+
+// davError is a failure reported with an HTTP status. Condition names the WebDAV precondition that
+// failed (e.g. lock-token-submitted), which is sent as an error document.
+type davError struct {
+	Status    int
+	Message   string
+	Condition string
+}
+
+func (e *davError) Error() string {
+	return fmt.Sprintf("%d: %s", e.Status, e.Message)
+}
+
+// itemError translates a failed item operation into the closest WebDAV status
+func itemError(err *items.ItemError) *davError {
+	switch err.Code {
+	case items.ErrCodeNotFound:
+		return &davError{Status: http.StatusNotFound, Message: err.Message}
+	case items.ErrCodeLocked:
+		return &davError{Status: http.StatusLocked, Message: err.Message, Condition: "lock-token-submitted"}
+	case items.ErrCodeNameConflict, items.ErrCodeNotAFolder, items.ErrCodeInvalidDest:
+		return &davError{Status: http.StatusConflict, Message: err.Message}
+	case items.ErrCodeTooManyItems:
+		return &davError{Status: http.StatusInsufficientStorage, Message: err.Message}
+	case items.ErrCodeInvalidSize:
+		return &davError{Status: http.StatusBadRequest, Message: err.Message}
+	}
+	return &davError{Status: http.StatusForbidden, Message: err.Message}
+}
+
+// writeError sends an error status, with an error document for failed preconditions
+func (req *davRequest) writeError(err error) {
+	var davErr *davError
+	var itemErr *items.ItemError
+	switch {
+	case errors.As(err, &davErr):
+	case errors.As(err, &itemErr):
+		davErr = itemError(itemErr)
+	default:
+		fmt.Printf("❌ WebDAV %s %s failed: %v\n", req.r.Method, req.r.URL.Path, err)
+		davErr = &davError{Status: http.StatusInternalServerError, Message: err.Error()}
+	}
+
+	if davErr.Condition == "" || req.r.Method == http.MethodHead {
+		http.Error(req.w, davErr.Message, davErr.Status)
+		return
+	}
+	req.w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	req.w.WriteHeader(davErr.Status)
+	fmt.Fprintf(req.w, `<?xml version="1.0" encoding="utf-8"?>`+"\n"+`<D:error xmlns:D="DAV:"><D:%s/><D:responsedescription>%s</D:responsedescription></D:error>`, davErr.Condition, escape(davErr.Message))
+}
@@ -0,0 +1,175 @@
+package dav
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	coreTables "github.com/Voltaic314/GhostFS/code/core/tables"
+	"github.com/Voltaic314/GhostFS/code/db"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	"github.com/go-chi/chi/v5"
+)
+
+// Prefix is where the WebDAV server is mounted. Each table is a share at <Prefix>/<table name>, so
+// clients connect to e.g. http://localhost:8086/dav/nodes/.
+const Prefix = "/dav"
+
+// methods are the WebDAV methods chi doesn't know about
+var methods = []string{"PROPFIND", "PROPPATCH", "MKCOL", "COPY", "MOVE", "LOCK", "UNLOCK"}
+
+// RegisterRoutes registers the WebDAV server (class 1 and 2: properties and locks)
+func RegisterRoutes(r chi.Router, server interface{}) {
+	for _, method := range methods {
+		chi.RegisterMethod(method)
+	}
+	h := &handler{server: server}
+	r.Handle("/{table}", h)
+	r.Handle("/{table}/*", h)
+}
+
+// handler serves the WebDAV methods
+type handler struct {
+	server interface{}
+}
+
+// davRequest is one WebDAV call on a resource: the table it's in and the resource's node path
+type davRequest struct {
+	w            http.ResponseWriter
+	r            *http.Request
+	tableManager *tables.TableManager
+	database     *db.DB
+	generator    *tables.DeterministicGenerator
+	table        string // table name, as in the URL
+	tableID      string
+	path         string // node path, "/" for the root
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Cast server to get access to DB and TableManager
+	s := h.server.(interface {
+		GetTableManager() *tables.TableManager
+		GetDB() *db.DB
+		GetDeterministicGenerator() *tables.DeterministicGenerator
+	})
+	req := &davRequest{
+		w:            w,
+		r:            r,
+		tableManager: s.GetTableManager(),
+		database:     s.GetDB(),
+		generator:    s.GetDeterministicGenerator(),
+	}
+
+	var err error
+	req.table, req.path, err = splitPath(r.URL.Path)
+	if err == nil {
+		req.tableID, err = resolveTable(req.database, req.table)
+	}
+	if err == nil {
+		err = dispatch(req)
+	}
+	if err != nil {
+		req.writeError(err)
+	}
+}
+
+// dispatch runs the method's handler
+func dispatch(req *davRequest) error {
+	switch req.r.Method {
+	case http.MethodOptions:
+		req.w.Header().Set("DAV", "1, 2")
+		req.w.Header().Set("MS-Author-Via", "DAV")
+		req.w.Header().Set("Allow", "OPTIONS, GET, HEAD, PUT, DELETE, MKCOL, COPY, MOVE, PROPFIND, PROPPATCH, LOCK, UNLOCK")
+		req.w.WriteHeader(http.StatusOK)
+		return nil
+	case http.MethodGet, http.MethodHead:
+		return get(req)
+	case http.MethodPut:
+		return put(req)
+	case http.MethodDelete:
+		return remove(req)
+	case "MKCOL":
+		return mkcol(req)
+	case "COPY", "MOVE":
+		return copyOrMove(req)
+	case "PROPFIND":
+		return propfind(req)
+	case "PROPPATCH":
+		return proppatch(req)
+	case "LOCK":
+		return lock(req)
+	case "UNLOCK":
+		return unlock(req)
+	}
+	return &davError{Status: http.StatusMethodNotAllowed, Message: fmt.Sprintf("%s is not supported", req.r.Method)}
+}
+
+// splitPath splits a decoded request path below Prefix into the table and the node path. Paths that
+// can't name a node ("." or ".." segments) are refused.
+func splitPath(urlPath string) (string, string, error) {
+	rest := strings.TrimPrefix(strings.TrimPrefix(urlPath, Prefix), "/")
+	table, nodePath, _ := strings.Cut(rest, "/")
+	nodePath = strings.Trim(nodePath, "/")
+	for _, name := range strings.Split(nodePath, "/") {
+		if name == "." || name == ".." {
+			return "", "", &davError{Status: http.StatusBadRequest, Message: fmt.Sprintf("invalid path %s", urlPath)}
+		}
+	}
+	return table, "/" + nodePath, nil
+}
+
+// resolveTable maps a table name (or ID) from the URL to the table's ID
+func resolveTable(database *db.DB, table string) (string, error) {
+	resp, err := coreTables.ListTables(database)
+	if err != nil {
+		return "", err
+	}
+	for _, info := range resp.Tables {
+		if info.TableName == table || info.TableID == table {
+			return info.TableID, nil
+		}
+	}
+	return "", &davError{Status: http.StatusNotFound, Message: fmt.Sprintf("table %s not found", table)}
+}
+
+// href returns the URL path of a node of the request's table. Collections end with a slash.
+func (req *davRequest) href(nodePath string, collection bool) string {
+	href := Prefix + "/" + url.PathEscape(req.table)
+	for _, name := range strings.Split(strings.Trim(nodePath, "/"), "/") {
+		if name != "" {
+			href += "/" + url.PathEscape(name)
+		}
+	}
+	if collection {
+		href += "/"
+	}
+	return href
+}
+
+// destination reads the Destination header of COPY and MOVE as a node path in the request's table
+func (req *davRequest) destination() (string, error) {
+	header := req.r.Header.Get("Destination")
+	if header == "" {
+		return "", &davError{Status: http.StatusBadRequest, Message: "missing Destination header"}
+	}
+	u, err := url.Parse(header)
+	if err != nil {
+		return "", &davError{Status: http.StatusBadRequest, Message: fmt.Sprintf("invalid Destination %s", header)}
+	}
+	if u.Host != "" && u.Host != req.r.Host {
+		return "", &davError{Status: http.StatusBadGateway, Message: "the destination is on another server"}
+	}
+	if !strings.HasPrefix(u.Path, Prefix+"/") {
+		return "", &davError{Status: http.StatusBadGateway, Message: fmt.Sprintf("%s is not a WebDAV path", u.Path)}
+	}
+	table, nodePath, err := splitPath(u.Path)
+	if err != nil {
+		return "", err
+	}
+	if table != req.table && table != req.tableID {
+		return "", &davError{Status: http.StatusBadGateway, Message: "items can only be copied and moved within their table"}
+	}
+	return path.Clean(nodePath), nil
+}
@@ -0,0 +1,210 @@
+package dav
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Voltaic314/GhostFS/code/core/items"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+	"github.com/google/uuid"
+)
+
+// WebDAV locks are GhostFS locks: exclusive write locks held by an owner, so they block the REST
+// API too and seeded locks show up in lock discovery. Lock tokens aren't stored, a lock's token is
+// derived from the node, the owner and the time the lock was acquired.
+
+// defaultLockOwner is the owner of locks requested without an owner element
+const defaultLockOwner = "webdav"
+
+// lockToken returns the token of a node's lock
+func lockToken(node dbTypes.Node, lock *dbTypes.Lock) string {
+	data := fmt.Sprintf("%s\x00%s\x00%d", node.ID, lock.Owner, lock.AcquiredAt.Unix())
+	return "opaquelocktoken:" + uuid.NewSHA1(uuid.NameSpaceURL, []byte(data)).String()
+}
+
+// tokenPattern finds the state tokens of an If header (resource tags are URLs, not lock tokens)
+var tokenPattern = regexp.MustCompile(`<(opaquelocktoken:[^>]+)>`)
+
+// lockOwner returns the owner of a node's lock if the client submitted the lock's token in the If
+// header, or "" if the node isn't locked or the client didn't submit it
+func (req *davRequest) lockOwner(node *dbTypes.Node) string {
+	if node.Lock == nil {
+		return ""
+	}
+	token := lockToken(*node, node.Lock)
+	for _, match := range tokenPattern.FindAllStringSubmatch(req.r.Header.Get("If"), -1) {
+		if match[1] == token {
+			return node.Lock.Owner
+		}
+	}
+	return ""
+}
+
+// releaseLock releases a node's lock before the node is deleted or moved, if the client submitted
+// its token. Without the token the operation fails on the lock.
+func (req *davRequest) releaseLock(node *dbTypes.Node) error {
+	owner := req.lockOwner(node)
+	if owner == "" {
+		return nil
+	}
+	_, err := items.UnlockItem(req.tableManager, req.database, req.generator, items.UnlockItemRequest{TableID: req.tableID, ItemID: node.ID, Owner: owner})
+	return err
+}
+
+type lockInfo struct {
+	XMLName xml.Name `xml:"DAV: lockinfo"`
+	Scope   struct {
+		Exclusive *struct{} `xml:"DAV: exclusive"`
+		Shared    *struct{} `xml:"DAV: shared"`
+	} `xml:"DAV: lockscope"`
+	Owner *struct {
+		Inner []byte `xml:",innerxml"`
+	} `xml:"DAV: owner"`
+}
+
+// ownerName returns the text of a lock's owner element, e.g. "alice@example.com" for
+// <D:href>mailto:alice@example.com</D:href>
+func ownerName(inner []byte) string {
+	var text strings.Builder
+	decoder := xml.NewDecoder(bytes.NewReader(inner))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		if data, ok := token.(xml.CharData); ok {
+			text.Write(data)
+		}
+	}
+	return strings.TrimPrefix(strings.TrimSpace(text.String()), "mailto:")
+}
+
+// parseTimeout reads the Timeout header of a LOCK: the first "Second-n" value, 0 for "Infinite"
+// (held until released) or no header
+func parseTimeout(header string) time.Duration {
+	first, _, _ := strings.Cut(header, ",")
+	seconds, err := strconv.ParseInt(strings.TrimPrefix(strings.TrimSpace(first), "Second-"), 10, 64)
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// lock serves LOCK: a new exclusive lock, or a refresh (no body) of a lock whose token is submitted.
+// Locking a path that doesn't exist creates an empty file, like clients expect before they upload.
+func lock(req *davRequest) error {
+	body, err := io.ReadAll(io.LimitReader(req.r.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if req.path == "/" {
+		return &davError{Status: http.StatusForbidden, Message: "the root can't be locked"}
+	}
+	node, err := req.getNode(req.path)
+	if err != nil {
+		return err
+	}
+	duration := parseTimeout(req.r.Header.Get("Timeout"))
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		if node == nil || req.lockOwner(node) == "" {
+			return &davError{Status: http.StatusPreconditionFailed, Message: "refreshing a lock needs its token in the If header", Condition: "lock-token-matches-request-uri"}
+		}
+		resp, err := items.LockItem(req.tableManager, req.database, req.generator, items.LockItemRequest{TableID: req.tableID, ItemID: node.ID, Owner: node.Lock.Owner, Duration: duration})
+		if err != nil {
+			return err
+		}
+		req.writeLock(resp.Item, http.StatusOK)
+		return nil
+	}
+
+	var info lockInfo
+	if err := xml.Unmarshal(body, &info); err != nil {
+		return &davError{Status: http.StatusBadRequest, Message: fmt.Sprintf("invalid lockinfo: %v", err)}
+	}
+	if info.Scope.Shared != nil {
+		return &davError{Status: http.StatusNotImplemented, Message: "shared locks aren't supported, GhostFS locks are exclusive"}
+	}
+	owner := defaultLockOwner
+	if info.Owner != nil {
+		if name := ownerName(info.Owner.Inner); name != "" {
+			owner = name
+		}
+	}
+
+	status := http.StatusOK
+	if node == nil {
+		parent, err := req.parentFolder(req.path)
+		if err != nil {
+			return err
+		}
+		if node, err = req.createItem(parent, items.NewItem{Name: path.Base(req.path), Type: tables.NodeTypeFile}); err != nil {
+			return err
+		}
+		status = http.StatusCreated
+	} else if node.Lock != nil {
+		return &davError{Status: http.StatusLocked, Message: fmt.Sprintf("%s is locked by %s", req.path, node.Lock.Owner), Condition: "no-conflicting-lock"}
+	}
+
+	resp, err := items.LockItem(req.tableManager, req.database, req.generator, items.LockItemRequest{TableID: req.tableID, ItemID: node.ID, Owner: owner, Duration: duration})
+	if err != nil {
+		return err
+	}
+	req.w.Header().Set("Lock-Token", "<"+lockToken(resp.Item, resp.Item.Lock)+">")
+	req.writeLock(resp.Item, status)
+	return nil
+}
+
+// unlock serves UNLOCK: releases the lock whose token is in the Lock-Token header
+func unlock(req *davRequest) error {
+	node, err := req.getNode(req.path)
+	if err != nil {
+		return err
+	}
+	if node == nil {
+		return &davError{Status: http.StatusNotFound, Message: fmt.Sprintf("%s not found", req.path)}
+	}
+	token := strings.Trim(strings.TrimSpace(req.r.Header.Get("Lock-Token")), "<>")
+	if node.Lock == nil || token != lockToken(*node, node.Lock) {
+		return &davError{Status: http.StatusConflict, Message: "the lock token doesn't match a lock on the resource", Condition: "lock-token-matches-request-uri"}
+	}
+
+	if _, err := items.UnlockItem(req.tableManager, req.database, req.generator, items.UnlockItemRequest{TableID: req.tableID, ItemID: node.ID, Owner: node.Lock.Owner}); err != nil {
+		return err
+	}
+	req.w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// writeLock sends the lock discovery of a node that was just locked
+func (req *davRequest) writeLock(node dbTypes.Node, status int) {
+	req.w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	req.w.WriteHeader(status)
+	fmt.Fprintf(req.w, `<?xml version="1.0" encoding="utf-8"?>`+"\n"+`<D:prop xmlns:D="DAV:"><D:lockdiscovery>%s</D:lockdiscovery></D:prop>`, req.activeLock(node))
+}
+
+// activeLock renders a node's lock as an activelock element. Folder locks cover their contents.
+func (req *davRequest) activeLock(node dbTypes.Node) string {
+	lock := node.Lock
+	depth := "0"
+	if node.Type == tables.NodeTypeFolder {
+		depth = "infinity"
+	}
+	timeout := "Infinite"
+	if lock.ExpiresAt != nil {
+		timeout = fmt.Sprintf("Second-%d", max(int64(time.Until(*lock.ExpiresAt).Seconds()), 0))
+	}
+	return fmt.Sprintf("<D:activelock><D:locktype><D:write/></D:locktype><D:lockscope><D:exclusive/></D:lockscope>"+
+		"<D:depth>%s</D:depth><D:owner>%s</D:owner><D:timeout>%s</D:timeout>"+
+		"<D:locktoken><D:href>%s</D:href></D:locktoken><D:lockroot><D:href>%s</D:href></D:lockroot></D:activelock>",
+		depth, escape(lock.Owner), timeout, lockToken(node, lock), escape(req.href(node.Path, node.Type == tables.NodeTypeFolder)))
+}
@@ -0,0 +1,253 @@
+package dav
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Voltaic314/GhostFS/code/core/items"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+)
+
+// maxPropfindItems caps the resources of a "Depth: infinity" PROPFIND. Generated trees can be
+// unbounded, deeper listings fail with propfind-finite-depth so clients fall back to Depth 1.
+const maxPropfindItems = 10000
+
+// property is a live property of a resource with its rendered (escaped) value
+type property struct {
+	name  xml.Name
+	value string
+}
+
+// properties returns a resource's live properties
+func (req *davRequest) properties(node dbTypes.Node) []property {
+	dav := func(local string, value string) property {
+		return property{name: xml.Name{Space: "DAV:", Local: local}, value: value}
+	}
+	folder := node.Type == tables.NodeTypeFolder
+
+	displayName := node.Name
+	if node.ParentID == "" {
+		displayName = req.table
+	}
+	props := []property{
+		dav("displayname", escape(displayName)),
+		dav("creationdate", node.CreatedAt.UTC().Format(time.RFC3339)),
+		dav("getlastmodified", node.UpdatedAt.UTC().Format(http.TimeFormat)),
+		dav("supportedlock", "<D:lockentry><D:lockscope><D:exclusive/></D:lockscope><D:locktype><D:write/></D:locktype></D:lockentry>"),
+	}
+	if folder {
+		props = append(props, dav("resourcetype", "<D:collection/>"))
+	} else {
+		props = append(props,
+			dav("resourcetype", ""),
+			dav("getcontentlength", fmt.Sprintf("%d", node.Size)),
+			dav("getcontenttype", escape(contentType(node))),
+			dav("getetag", escape(req.etag(node))),
+		)
+	}
+	lockDiscovery := ""
+	if node.Lock != nil {
+		lockDiscovery = req.activeLock(node)
+	}
+	return append(props, dav("lockdiscovery", lockDiscovery))
+}
+
+type propfindBody struct {
+	XMLName  xml.Name  `xml:"DAV: propfind"`
+	AllProp  *struct{} `xml:"DAV: allprop"`
+	PropName *struct{} `xml:"DAV: propname"`
+	Prop     *propList `xml:"DAV: prop"`
+}
+
+type propList struct {
+	Props []struct {
+		XMLName xml.Name
+	} `xml:",any"`
+}
+
+// propfind serves PROPFIND with Depth 0, 1 or infinity (the default). Requests without a body get
+// all properties.
+func propfind(req *davRequest) error {
+	body, err := io.ReadAll(io.LimitReader(req.r.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	var request propfindBody
+	if len(bytes.TrimSpace(body)) > 0 {
+		if err := xml.Unmarshal(body, &request); err != nil {
+			return &davError{Status: http.StatusBadRequest, Message: fmt.Sprintf("invalid propfind: %v", err)}
+		}
+	}
+
+	depth := strings.ToLower(req.r.Header.Get("Depth"))
+	if depth == "" {
+		depth = "infinity"
+	}
+	if depth != "0" && depth != "1" && depth != "infinity" {
+		return &davError{Status: http.StatusBadRequest, Message: fmt.Sprintf("invalid Depth %s", depth)}
+	}
+
+	node, err := req.getNode(req.path)
+	if err != nil {
+		return err
+	}
+	if node == nil {
+		return &davError{Status: http.StatusNotFound, Message: fmt.Sprintf("%s not found", req.path)}
+	}
+
+	nodes := []dbTypes.Node{*node}
+	for next := 0; depth != "0" && next < len(nodes); next++ {
+		if nodes[next].Type != tables.NodeTypeFolder || (depth == "1" && next > 0) {
+			continue
+		}
+		resp, err := items.ListItems(req.tableManager, req.database, req.generator, items.ListItemsRequest{TableID: req.tableID, FolderID: nodes[next].ID})
+		if err != nil {
+			return err
+		}
+		for _, child := range resp.Items {
+			if isResource(child) {
+				nodes = append(nodes, child)
+			}
+		}
+		if len(nodes) > maxPropfindItems {
+			return &davError{Status: http.StatusForbidden, Message: fmt.Sprintf("more than %d resources, use Depth 1", maxPropfindItems), Condition: "propfind-finite-depth"}
+		}
+	}
+
+	var out multistatus
+	for _, node := range nodes {
+		props := req.properties(node)
+		switch {
+		case request.PropName != nil:
+			for i := range props {
+				props[i].value = ""
+			}
+			out.response(req.href(node.Path, node.Type == tables.NodeTypeFolder), propstat{http.StatusOK, props})
+		case request.Prop != nil:
+			var found, missing []property
+			for _, requested := range request.Prop.Props {
+				prop, ok := findProperty(props, requested.XMLName)
+				if ok {
+					found = append(found, prop)
+				} else {
+					missing = append(missing, property{name: requested.XMLName})
+				}
+			}
+			out.response(req.href(node.Path, node.Type == tables.NodeTypeFolder), propstat{http.StatusOK, found}, propstat{http.StatusNotFound, missing})
+		default:
+			out.response(req.href(node.Path, node.Type == tables.NodeTypeFolder), propstat{http.StatusOK, props})
+		}
+	}
+	out.write(req.w)
+	return nil
+}
+
+// findProperty finds a property by name
+func findProperty(props []property, name xml.Name) (property, bool) {
+	for _, prop := range props {
+		if prop.name == name {
+			return prop, true
+		}
+	}
+	return property{}, false
+}
+
+type proppatchBody struct {
+	XMLName xml.Name `xml:"DAV: propertyupdate"`
+	Set     []struct {
+		Prop propList `xml:"DAV: prop"`
+	} `xml:"DAV: set"`
+	Remove []struct {
+		Prop propList `xml:"DAV: prop"`
+	} `xml:"DAV: remove"`
+}
+
+// proppatch serves PROPPATCH. Live properties are computed from the node and dead properties aren't
+// stored, so every change is refused with 403 (which clients treat as "not supported").
+func proppatch(req *davRequest) error {
+	body, err := io.ReadAll(io.LimitReader(req.r.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	var request proppatchBody
+	if err := xml.Unmarshal(body, &request); err != nil {
+		return &davError{Status: http.StatusBadRequest, Message: fmt.Sprintf("invalid propertyupdate: %v", err)}
+	}
+	node, err := req.getNode(req.path)
+	if err != nil {
+		return err
+	}
+	if node == nil {
+		return &davError{Status: http.StatusNotFound, Message: fmt.Sprintf("%s not found", req.path)}
+	}
+
+	var refused []property
+	for _, update := range append(request.Set, request.Remove...) {
+		for _, prop := range update.Prop.Props {
+			refused = append(refused, property{name: prop.XMLName})
+		}
+	}
+	var out multistatus
+	out.response(req.href(node.Path, node.Type == tables.NodeTypeFolder), propstat{http.StatusForbidden, refused})
+	out.write(req.w)
+	return nil
+}
+
+// propstat is a group of properties of a response that share a status
+type propstat struct {
+	status int
+	props  []property
+}
+
+// multistatus builds a 207 Multi-Status document
+type multistatus struct {
+	body strings.Builder
+}
+
+// response adds a resource's properties. Empty propstat groups are left out.
+func (m *multistatus) response(href string, propstats ...propstat) {
+	m.body.WriteString("<D:response><D:href>" + escape(href) + "</D:href>")
+	for _, group := range propstats {
+		if len(group.props) == 0 {
+			continue
+		}
+		m.body.WriteString("<D:propstat><D:prop>")
+		for _, prop := range group.props {
+			m.body.WriteString(renderProperty(prop))
+		}
+		fmt.Fprintf(&m.body, "</D:prop><D:status>HTTP/1.1 %d %s</D:status></D:propstat>", group.status, http.StatusText(group.status))
+	}
+	m.body.WriteString("</D:response>")
+}
+
+func (m *multistatus) write(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusMultiStatus)
+	io.WriteString(w, `<?xml version="1.0" encoding="utf-8"?>`+"\n"+`<D:multistatus xmlns:D="DAV:">`)
+	io.WriteString(w, m.body.String())
+	io.WriteString(w, "</D:multistatus>")
+}
+
+// renderProperty renders a property element. Properties outside the DAV: namespace declare theirs.
+func renderProperty(prop property) string {
+	if prop.name.Space == "DAV:" {
+		if prop.value == "" {
+			return "<D:" + prop.name.Local + "/>"
+		}
+		return "<D:" + prop.name.Local + ">" + prop.value + "</D:" + prop.name.Local + ">"
+	}
+	return fmt.Sprintf(`<%s xmlns="%s"/>`, prop.name.Local, escape(prop.name.Space))
+}
+
+// escape escapes text for XML documents
+func escape(text string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(text))
+	return b.String()
+}
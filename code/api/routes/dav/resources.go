@@ -0,0 +1,290 @@
+package dav
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/Voltaic314/GhostFS/code/core/items"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+)
+
+// Resources are the folders (collections), files and hard links of a table. Symlinks and shortcuts
+// have no WebDAV equivalent and aren't served.
+
+// isResource reports whether a node is served as a resource
+func isResource(node dbTypes.Node) bool {
+	return node.Type == tables.NodeTypeFile || node.Type == tables.NodeTypeHardlink || node.Type == tables.NodeTypeFolder
+}
+
+// getNode returns the node at a path of the request's table, or nil if there is no such resource
+func (req *davRequest) getNode(nodePath string) (*dbTypes.Node, error) {
+	resp, err := items.GetItemByPath(req.tableManager, req.database, req.generator, items.GetItemByPathRequest{TableID: req.tableID, Path: nodePath})
+	var itemErr *items.ItemError
+	if errors.As(err, &itemErr) && itemErr.Code == items.ErrCodeNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !isResource(resp.Item) {
+		return nil, nil
+	}
+	return &resp.Item, nil
+}
+
+// parentFolder returns the folder a new resource at nodePath goes into. Like in WebDAV, missing
+// parents aren't created: the request fails with 409 Conflict.
+func (req *davRequest) parentFolder(nodePath string) (*dbTypes.Node, error) {
+	parent, err := req.getNode(path.Dir(nodePath))
+	if err != nil {
+		return nil, err
+	}
+	if parent == nil || parent.Type != tables.NodeTypeFolder {
+		return nil, &davError{Status: http.StatusConflict, Message: fmt.Sprintf("%s is not a collection", path.Dir(nodePath))}
+	}
+	return parent, nil
+}
+
+// createItem creates one file or folder
+func (req *davRequest) createItem(parent *dbTypes.Node, item items.NewItem) (*dbTypes.Node, error) {
+	resp, err := items.CreateItems(req.tableManager, req.database, req.generator, items.CreateItemsRequest{
+		TableID:  req.tableID,
+		ParentID: parent.ID,
+		Items:    []items.NewItem{item},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result := resp.Results[0]; result.Error != nil {
+		return nil, result.Error
+	}
+	return resp.Results[0].Item, nil
+}
+
+// etag returns a file's ETag, derived from its content seed and size so it changes with the content
+func (req *davRequest) etag(node dbTypes.Node) string {
+	return fmt.Sprintf(`"%x-%x"`, uint64(req.generator.ContentSeed(node)), node.Size)
+}
+
+// contentType guesses a file's type from its extension
+func contentType(node dbTypes.Node) string {
+	if guessed := mime.TypeByExtension(path.Ext(node.Name)); guessed != "" {
+		return guessed
+	}
+	return "application/octet-stream"
+}
+
+// get serves GET and HEAD: a file's generated content, with Range and conditional requests
+func get(req *davRequest) error {
+	node, err := req.getNode(req.path)
+	if err != nil {
+		return err
+	}
+	if node == nil {
+		return &davError{Status: http.StatusNotFound, Message: fmt.Sprintf("%s not found", req.path)}
+	}
+	if node.Type == tables.NodeTypeFolder {
+		req.w.Header().Set("Allow", "OPTIONS, DELETE, MKCOL, COPY, MOVE, PROPFIND, PROPPATCH, LOCK, UNLOCK")
+		return &davError{Status: http.StatusMethodNotAllowed, Message: fmt.Sprintf("%s is a collection, list it with PROPFIND", req.path)}
+	}
+
+	req.w.Header().Set("ETag", req.etag(*node))
+	req.w.Header().Set("Content-Type", contentType(*node))
+	http.ServeContent(req.w, req.r, node.Name, node.UpdatedAt, req.generator.OpenContent(*node, node.Size))
+	return nil
+}
+
+// put serves PUT. Only the size of the body is kept, the file reads back as seeded content. Writing
+// over a file adds a revision; a file locked by the client can be written with its lock token.
+func put(req *davRequest) error {
+	node, err := req.getNode(req.path)
+	if err != nil {
+		return err
+	}
+	if req.path == "/" || (node != nil && node.Type == tables.NodeTypeFolder) {
+		return &davError{Status: http.StatusMethodNotAllowed, Message: fmt.Sprintf("%s is a collection", req.path)}
+	}
+	if err := req.checkConditions(node); err != nil {
+		return err
+	}
+
+	size, err := io.Copy(io.Discard, req.r.Body)
+	if err != nil {
+		return err
+	}
+
+	status := http.StatusCreated
+	if node != nil {
+		resp, err := items.UpdateFile(req.tableManager, req.database, req.generator, items.UpdateFileRequest{
+			TableID:   req.tableID,
+			ItemID:    node.ID,
+			Size:      size,
+			LockOwner: req.lockOwner(node),
+		})
+		if err != nil {
+			return err
+		}
+		node = &resp.Item
+		status = http.StatusNoContent
+	} else {
+		parent, err := req.parentFolder(req.path)
+		if err != nil {
+			return err
+		}
+		node, err = req.createItem(parent, items.NewItem{Name: path.Base(req.path), Type: tables.NodeTypeFile, Size: size})
+		if err != nil {
+			return err
+		}
+	}
+
+	req.w.Header().Set("ETag", req.etag(*node))
+	req.w.WriteHeader(status)
+	return nil
+}
+
+// checkConditions evaluates If-Match and If-None-Match for a PUT (node is nil for a new file)
+func (req *davRequest) checkConditions(node *dbTypes.Node) error {
+	matches := func(header string) bool {
+		for _, value := range strings.Split(header, ",") {
+			value = strings.TrimSpace(value)
+			if value == "*" || (node != nil && strings.TrimPrefix(value, "W/") == req.etag(*node)) {
+				return true
+			}
+		}
+		return false
+	}
+	failed := &davError{Status: http.StatusPreconditionFailed, Message: "precondition failed"}
+	if header := req.r.Header.Get("If-Match"); header != "" && (node == nil || !matches(header)) {
+		return failed
+	}
+	if header := req.r.Header.Get("If-None-Match"); header != "" && node != nil && matches(header) {
+		return failed
+	}
+	return nil
+}
+
+// mkcol serves MKCOL: creates a folder in an existing folder
+func mkcol(req *davRequest) error {
+	if req.r.ContentLength > 0 {
+		return &davError{Status: http.StatusUnsupportedMediaType, Message: "MKCOL doesn't take a body"}
+	}
+	node, err := req.getNode(req.path)
+	if err != nil {
+		return err
+	}
+	if node != nil || req.path == "/" {
+		return &davError{Status: http.StatusMethodNotAllowed, Message: fmt.Sprintf("%s already exists", req.path)}
+	}
+	parent, err := req.parentFolder(req.path)
+	if err != nil {
+		return err
+	}
+	if _, err := req.createItem(parent, items.NewItem{Name: path.Base(req.path), Type: tables.NodeTypeFolder}); err != nil {
+		return err
+	}
+	req.w.WriteHeader(http.StatusCreated)
+	return nil
+}
+
+// remove serves DELETE. Collections are deleted with their contents; tables with a trash keep them there.
+func remove(req *davRequest) error {
+	node, err := req.getNode(req.path)
+	if err != nil {
+		return err
+	}
+	if node == nil {
+		return &davError{Status: http.StatusNotFound, Message: fmt.Sprintf("%s not found", req.path)}
+	}
+	if req.path == "/" {
+		return &davError{Status: http.StatusForbidden, Message: "the root can't be deleted"}
+	}
+	if err := req.deleteNode(node); err != nil {
+		return err
+	}
+	req.w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// deleteNode deletes a node. Its lock goes with it if the client submitted the lock token.
+func (req *davRequest) deleteNode(node *dbTypes.Node) error {
+	if err := req.releaseLock(node); err != nil {
+		return err
+	}
+	_, err := items.DeleteItem(req.tableManager, req.database, req.generator, items.DeleteItemRequest{TableID: req.tableID, ItemID: node.ID})
+	return err
+}
+
+// copyOrMove serves COPY and MOVE within a table. An existing destination is replaced unless the
+// client sent "Overwrite: F". Copies of collections are deep unless "Depth: 0" is sent.
+func copyOrMove(req *davRequest) error {
+	move := req.r.Method == "MOVE"
+	node, err := req.getNode(req.path)
+	if err != nil {
+		return err
+	}
+	if node == nil {
+		return &davError{Status: http.StatusNotFound, Message: fmt.Sprintf("%s not found", req.path)}
+	}
+	if req.path == "/" {
+		return &davError{Status: http.StatusForbidden, Message: "the root can't be copied or moved"}
+	}
+
+	dest, err := req.destination()
+	if err != nil {
+		return err
+	}
+	switch {
+	case dest == req.path:
+		return &davError{Status: http.StatusForbidden, Message: "the source and the destination are the same"}
+	case dest == "/" || strings.HasPrefix(req.path, dest+"/"):
+		return &davError{Status: http.StatusForbidden, Message: "the destination contains the source"}
+	}
+	depth := req.r.Header.Get("Depth")
+	if depth != "" && !strings.EqualFold(depth, "infinity") && (move || depth != "0") {
+		return &davError{Status: http.StatusBadRequest, Message: fmt.Sprintf("invalid Depth %s", depth)}
+	}
+
+	parent, err := req.parentFolder(dest)
+	if err != nil {
+		return err
+	}
+	existing, err := req.getNode(dest)
+	if err != nil {
+		return err
+	}
+	status := http.StatusCreated
+	if existing != nil {
+		if req.r.Header.Get("Overwrite") == "F" {
+			return &davError{Status: http.StatusPreconditionFailed, Message: fmt.Sprintf("%s already exists", dest)}
+		}
+		if err := req.deleteNode(existing); err != nil {
+			return err
+		}
+		status = http.StatusNoContent
+	}
+
+	name := path.Base(dest)
+	switch {
+	case move:
+		// Locks stay with the URL, not the moved resource
+		if err := req.releaseLock(node); err != nil {
+			return err
+		}
+		_, err = items.MoveItem(req.tableManager, req.database, req.generator, items.MoveItemRequest{TableID: req.tableID, ItemID: node.ID, ParentID: parent.ID, Name: name})
+	case node.Type == tables.NodeTypeFolder && depth == "0":
+		_, err = req.createItem(parent, items.NewItem{Name: name, Type: tables.NodeTypeFolder})
+	default:
+		_, err = items.CopyItem(req.tableManager, req.database, req.generator, items.CopyItemRequest{TableID: req.tableID, ItemID: node.ID, ParentID: parent.ID, Name: name})
+	}
+	if err != nil {
+		return err
+	}
+	req.w.WriteHeader(status)
+	return nil
+}
@@ -0,0 +1,94 @@
+package drive
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Voltaic314/GhostFS/code/core/items"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+)
+
+// Page tokens of changes.list are positions in the table's change journal, plus one: a token is the
+// first change to return, like Drive's numeric tokens.
+
+type startPageToken struct {
+	Kind           string `json:"kind"`
+	StartPageToken string `json:"startPageToken"`
+}
+
+// getStartPageToken serves changes.getStartPageToken
+func getStartPageToken(req *driveRequest) (any, error) {
+	resp, err := items.GetChangeToken(req.tableManager, req.database, items.GetChangeTokenRequest{TableID: req.tableID})
+	if err != nil {
+		return nil, err
+	}
+	return req.filter(startPageToken{Kind: "drive#startPageToken", StartPageToken: strconv.FormatInt(resp.Token+1, 10)}, nil)
+}
+
+// change is Drive's Change resource, for files only
+type change struct {
+	Kind       string `json:"kind"`
+	ChangeType string `json:"changeType"`
+	Time       string `json:"time"`
+	Removed    bool   `json:"removed"`
+	FileID     string `json:"fileId"`
+	File       *file  `json:"file,omitempty"`
+}
+
+// changeList is Drive's ChangeList. nextPageToken is set while there are more changes,
+// newStartPageToken on the last page.
+type changeList struct {
+	Kind              string    `json:"kind"`
+	NextPageToken     string    `json:"nextPageToken,omitempty"`
+	NewStartPageToken string    `json:"newStartPageToken,omitempty"`
+	Changes           []*change `json:"changes"`
+}
+
+// listChanges serves changes.list: the files changed through any API since the token, in their
+// current state. Files changed several times are listed once.
+func listChanges(req *driveRequest) (any, error) {
+	params := req.r.URL.Query()
+	pageToken := params.Get("pageToken")
+	if pageToken == "" {
+		return nil, &apiError{Code: http.StatusBadRequest, Reason: "required", Message: "Required parameter: pageToken", Location: "pageToken", LocationType: "parameter"}
+	}
+	token, err := strconv.ParseInt(pageToken, 10, 64)
+	if err != nil || token < 1 {
+		return nil, invalidParameter("pageToken", "Invalid Value")
+	}
+	pageSize, err := pageSizeParam(params.Get("pageSize"))
+	if err != nil {
+		return nil, err
+	}
+	includeRemoved := params.Get("includeRemoved") != "false"
+
+	resp, err := items.ListChanges(req.tableManager, req.database, items.ListChangesRequest{TableID: req.tableID, After: token - 1, Limit: pageSize})
+	if err != nil {
+		return nil, err
+	}
+
+	list := changeList{Kind: "drive#changeList", Changes: []*change{}}
+	checksum := req.mask(defaultChangesFields).wants("changes", "file", "md5Checksum")
+	for _, entry := range resp.Changes {
+		if entry.Item != nil && entry.Item.Type == tables.NodeTypeSymlink {
+			continue
+		}
+		c := &change{Kind: "drive#change", ChangeType: "file", Time: formatTime(entry.ChangedAt), Removed: entry.Removed, FileID: entry.ItemID}
+		if entry.Removed {
+			if !includeRemoved {
+				continue
+			}
+		} else {
+			c.File = req.fileOf(*entry.Item, entry.Trashed, checksum)
+		}
+		list.Changes = append(list.Changes, c)
+	}
+
+	next := strconv.FormatInt(resp.Next+1, 10)
+	if resp.HasMore {
+		list.NextPageToken = next
+	} else {
+		list.NewStartPageToken = next
+	}
+	return req.filter(list, defaultChangesFields)
+}
@@ -0,0 +1,120 @@
+package drive
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Voltaic314/GhostFS/code/core/items"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+)
+
+// apiError is a failed call, sent in Drive's error format:
+// {"error": {"code": 404, "message": "...", "errors": [{"domain": "global", "reason": "notFound", ...}]}}
+type apiError struct {
+	Code         int
+	Reason       string
+	Message      string
+	Location     string
+	LocationType string
+}
+
+func (e *apiError) Error() string {
+	return e.Message
+}
+
+// notFound is Drive's error for an unknown file ID
+func notFound(fileID string) *apiError {
+	return &apiError{
+		Code:         http.StatusNotFound,
+		Reason:       "notFound",
+		Message:      fmt.Sprintf("File not found: %s.", fileID),
+		Location:     "fileId",
+		LocationType: "parameter",
+	}
+}
+
+// invalidParameter is a 400 for a parameter that doesn't validate
+func invalidParameter(name string, format string, args ...any) *apiError {
+	return &apiError{
+		Code:         http.StatusBadRequest,
+		Reason:       "invalid",
+		Message:      fmt.Sprintf(format, args...),
+		Location:     name,
+		LocationType: "parameter",
+	}
+}
+
+// badRequest is a 400 for a request Drive refuses, e.g. {"reason": "badContent"}
+func badRequest(reason string, format string, args ...any) *apiError {
+	return &apiError{Code: http.StatusBadRequest, Reason: reason, Message: fmt.Sprintf(format, args...)}
+}
+
+// forbidden is a 403 for an operation Drive doesn't allow on a file
+func forbidden(reason string, format string, args ...any) *apiError {
+	return &apiError{Code: http.StatusForbidden, Reason: reason, Message: fmt.Sprintf(format, args...)}
+}
+
+// itemFailure translates a failed item operation into the closest Drive error. fileID is the file
+// the operation was on, for not found errors.
+func itemFailure(err error, fileID string) error {
+	var itemErr *items.ItemError
+	if !errors.As(err, &itemErr) {
+		return err
+	}
+	switch itemErr.Code {
+	case items.ErrCodeNotFound:
+		return notFound(fileID)
+	case items.ErrCodeNameConflict:
+		// Drive allows duplicate names, GhostFS tables don't
+		return &apiError{Code: http.StatusConflict, Reason: "duplicate", Message: itemErr.Message}
+	case items.ErrCodeNotAFolder:
+		return badRequest("parentNotAFolder", "The specified parent is not a folder.")
+	case items.ErrCodeInvalidDest:
+		return badRequest("invalidParent", "%s", itemErr.Message)
+	case items.ErrCodeInvalidName, tables.ViolationInvalidCharacter, tables.ViolationReservedName,
+		tables.ViolationNameTooLong, tables.ViolationPathTooLong, tables.ViolationMaxDepth:
+		return &apiError{Code: http.StatusBadRequest, Reason: "invalid", Message: itemErr.Message, Location: "name", LocationType: "other"}
+	case items.ErrCodeInvalidSize:
+		return badRequest("invalid", "%s", itemErr.Message)
+	case items.ErrCodeTooManyItems:
+		return forbidden("limitExceeded", "%s", itemErr.Message)
+	case items.ErrCodeLocked:
+		return forbidden("fileLocked", "%s", itemErr.Message)
+	}
+	return forbidden("insufficientFilePermissions", "The user does not have sufficient permissions for this file. %s", itemErr.Message)
+}
+
+type errorItem struct {
+	Domain       string `json:"domain"`
+	Reason       string `json:"reason"`
+	Message      string `json:"message"`
+	Location     string `json:"location,omitempty"`
+	LocationType string `json:"locationType,omitempty"`
+}
+
+type errorBody struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Errors  []errorItem `json:"errors"`
+}
+
+// writeError sends an error in Drive's format. Other errors are 500s.
+func (req *driveRequest) writeError(err error) {
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) {
+		fmt.Printf("❌ Drive %s %s failed: %v\n", req.r.Method, req.r.URL.Path, err)
+		apiErr = &apiError{Code: http.StatusInternalServerError, Reason: "internalError", Message: "Internal Error"}
+	}
+	req.writeJSON(apiErr.Code, map[string]errorBody{"error": {
+		Code:    apiErr.Code,
+		Message: apiErr.Message,
+		Errors: []errorItem{{
+			Domain:       "global",
+			Reason:       apiErr.Reason,
+			Message:      apiErr.Message,
+			Location:     apiErr.Location,
+			LocationType: apiErr.LocationType,
+		}},
+	}})
+}
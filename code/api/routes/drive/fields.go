@@ -0,0 +1,166 @@
+package drive
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// fieldMask is a parsed fields parameter, e.g. "nextPageToken, files(id, name)" or "files/id". Each
+// field maps to the mask of its subfields, nil for the whole field. "*" selects all fields.
+type fieldMask map[string]fieldMask
+
+// Default fields of the responses, like Drive's
+var (
+	defaultFileFields    = mustParseFields("kind,id,name,mimeType")
+	defaultListFields    = mustParseFields("kind,incompleteSearch,nextPageToken,files(kind,id,name,mimeType)")
+	defaultChangesFields = mustParseFields("kind,nextPageToken,newStartPageToken,changes(kind,changeType,time,removed,fileId,file(kind,id,name,mimeType))")
+)
+
+func mustParseFields(s string) fieldMask {
+	mask, err := parseFields(s)
+	if err != nil {
+		panic(err)
+	}
+	return mask
+}
+
+func parseFields(s string) (fieldMask, error) {
+	mask, rest, err := parseFieldList(s)
+	if err != nil {
+		return nil, err
+	}
+	if rest != "" {
+		return nil, fmt.Errorf("unexpected %q", rest)
+	}
+	return mask, nil
+}
+
+// parseFieldList parses fields up to the end or a closing parenthesis, which is left in rest
+func parseFieldList(s string) (fieldMask, string, error) {
+	mask := fieldMask{}
+	for {
+		end := strings.IndexAny(s, ",()")
+		if end < 0 {
+			end = len(s)
+		}
+		name := strings.TrimSpace(s[:end])
+		s = s[end:]
+		if name == "" {
+			return nil, "", fmt.Errorf("missing field name")
+		}
+
+		var sub fieldMask
+		if strings.HasPrefix(s, "(") {
+			var err error
+			if sub, s, err = parseFieldList(s[1:]); err != nil {
+				return nil, "", err
+			}
+			if !strings.HasPrefix(s, ")") {
+				return nil, "", fmt.Errorf("missing )")
+			}
+			s = s[1:]
+		}
+		mask.add(strings.Split(name, "/"), sub)
+
+		s = strings.TrimSpace(s)
+		if s == "" || s[0] == ')' {
+			return mask, s, nil
+		}
+		if s[0] != ',' {
+			return nil, "", fmt.Errorf("unexpected %q", s)
+		}
+		s = s[1:]
+	}
+}
+
+// add selects a field by its path, with sub as the mask of its subfields
+func (m fieldMask) add(path []string, sub fieldMask) {
+	name := path[0]
+	existing, ok := m[name]
+	if ok && existing == nil {
+		return // already selected whole
+	}
+	if len(path) > 1 {
+		if !ok {
+			existing = fieldMask{}
+			m[name] = existing
+		}
+		existing.add(path[1:], sub)
+		return
+	}
+	if !ok || sub == nil {
+		m[name] = sub
+		return
+	}
+	for field, fieldSub := range sub {
+		existing.add([]string{field}, fieldSub)
+	}
+}
+
+// wants reports whether a field (by its path) is selected
+func (m fieldMask) wants(path ...string) bool {
+	for _, name := range path {
+		if m == nil {
+			return true
+		}
+		if _, all := m["*"]; all {
+			return true
+		}
+		sub, ok := m[name]
+		if !ok {
+			return false
+		}
+		m = sub
+	}
+	return true
+}
+
+// apply keeps the selected fields of a decoded JSON value
+func (m fieldMask) apply(v any) any {
+	if m == nil {
+		return v
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		if _, all := m["*"]; all {
+			return v
+		}
+		selected := make(map[string]any, len(m))
+		for name, sub := range m {
+			if value, ok := v[name]; ok {
+				selected[name] = sub.apply(value)
+			}
+		}
+		return selected
+	case []any:
+		for i := range v {
+			v[i] = m.apply(v[i])
+		}
+	}
+	return v
+}
+
+// mask returns the fields the call selected, or the method's default ones
+func (req *driveRequest) mask(defaults fieldMask) fieldMask {
+	if req.fields != nil {
+		return req.fields
+	}
+	return defaults
+}
+
+// filter keeps the selected fields of a response
+func (req *driveRequest) filter(resource any, defaults fieldMask) (any, error) {
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return req.mask(defaults).apply(value), nil
+}
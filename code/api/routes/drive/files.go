@@ -0,0 +1,589 @@
+package drive
+
+import (
+	"crypto/md5"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Voltaic314/GhostFS/code/core/items"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	folderMimeType   = "application/vnd.google-apps.folder"
+	shortcutMimeType = "application/vnd.google-apps.shortcut"
+	// maxChecksumSize caps the files that get an md5Checksum. Hashing means generating the whole
+	// content, bigger files leave the field out.
+	maxChecksumSize = 16 << 20
+	// timeFormat is Drive's RFC 3339 timestamp format, with milliseconds
+	timeFormat = "2006-01-02T15:04:05.000Z"
+
+	defaultPageSize = 100
+	maxPageSize     = 1000
+	// maxMetadataLength caps the JSON metadata of a request
+	maxMetadataLength = 1 << 20
+)
+
+// file is Drive's File resource. Folders, files (including hard links) and shortcuts are served;
+// symlinks have no Drive equivalent and aren't.
+type file struct {
+	Kind                string               `json:"kind"`
+	ID                  string               `json:"id"`
+	Name                string               `json:"name"`
+	MimeType            string               `json:"mimeType"`
+	Parents             []string             `json:"parents,omitempty"`
+	Size                string               `json:"size,omitempty"`
+	MD5Checksum         string               `json:"md5Checksum,omitempty"`
+	HeadRevisionID      string               `json:"headRevisionId,omitempty"`
+	CreatedTime         string               `json:"createdTime"`
+	ModifiedTime        string               `json:"modifiedTime"`
+	Trashed             bool                 `json:"trashed"`
+	ExplicitlyTrashed   bool                 `json:"explicitlyTrashed"`
+	TrashedTime         string               `json:"trashedTime,omitempty"`
+	Shared              bool                 `json:"shared"`
+	ShortcutDetails     *shortcutDetails     `json:"shortcutDetails,omitempty"`
+	ContentRestrictions []contentRestriction `json:"contentRestrictions,omitempty"`
+
+	modified time.Time
+}
+
+type shortcutDetails struct {
+	TargetID string `json:"targetId"`
+}
+
+// contentRestriction is how Drive shows a locked file
+type contentRestriction struct {
+	ReadOnly         bool   `json:"readOnly"`
+	Reason           string `json:"reason,omitempty"`
+	RestrictionTime  string `json:"restrictionTime"`
+	RestrictingUser  user   `json:"restrictingUser"`
+	OwnerRestricted  bool   `json:"ownerRestricted"`
+	SystemRestricted bool   `json:"systemRestricted"`
+}
+
+type user struct {
+	Kind        string `json:"kind"`
+	DisplayName string `json:"displayName"`
+}
+
+// fileOf returns a node's File resource, trashed if it's in the trash. The md5Checksum is only
+// computed when asked for.
+func (req *driveRequest) fileOf(node dbTypes.Node, trashed *dbTypes.TrashItem, checksum bool) *file {
+	f := &file{
+		Kind:         "drive#file",
+		ID:           node.ID,
+		Name:         node.Name,
+		MimeType:     mimeTypeOf(node),
+		CreatedTime:  formatTime(node.CreatedAt),
+		ModifiedTime: formatTime(node.UpdatedAt),
+		Shared:       !node.Sharing.IsEmpty(),
+		modified:     node.UpdatedAt,
+	}
+	if node.ParentID != "" {
+		f.Parents = []string{node.ParentID}
+	}
+	if trashed != nil {
+		f.Trashed, f.ExplicitlyTrashed = true, true
+		f.TrashedTime = formatTime(trashed.DeletedAt)
+	}
+
+	switch node.Type {
+	case tables.NodeTypeFolder:
+	case tables.NodeTypeShortcut:
+		f.ShortcutDetails = &shortcutDetails{TargetID: node.Target}
+	default:
+		f.Size = strconv.FormatInt(node.Size, 10)
+		f.HeadRevisionID = fmt.Sprintf("%016x", uint64(req.generator.ContentSeed(node)))
+		if checksum && node.Size <= maxChecksumSize {
+			f.MD5Checksum = md5Checksum(req.generator.OpenContent(node, node.Size))
+		}
+	}
+	if node.Lock != nil {
+		f.ContentRestrictions = []contentRestriction{{
+			ReadOnly:        true,
+			Reason:          "Locked by " + node.Lock.Owner,
+			RestrictionTime: formatTime(node.Lock.AcquiredAt),
+			RestrictingUser: user{Kind: "drive#user", DisplayName: node.Lock.Owner},
+		}}
+	}
+	return f
+}
+
+// mimeTypeOf returns a node's MIME type: Drive's folder and shortcut types, or the type of the name's extension
+func mimeTypeOf(node dbTypes.Node) string {
+	switch node.Type {
+	case tables.NodeTypeFolder:
+		return folderMimeType
+	case tables.NodeTypeShortcut:
+		return shortcutMimeType
+	}
+	if mimeType, _, err := mime.ParseMediaType(mime.TypeByExtension(path.Ext(node.Name))); err == nil {
+		return mimeType
+	}
+	return "application/octet-stream"
+}
+
+func md5Checksum(content io.Reader) string {
+	hash := md5.New()
+	io.Copy(hash, content)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeFormat)
+}
+
+// lookup returns the file with an ID ("root" is the root folder), with its trash entry if it's in the trash
+func (req *driveRequest) lookup(fileID string) (*dbTypes.Node, *dbTypes.TrashItem, error) {
+	id := fileID
+	if id == "root" {
+		root, err := items.GetRoot(req.tableManager, req.database, items.GetRootRequest{TableID: req.tableID})
+		if err != nil {
+			return nil, nil, err
+		}
+		id = root.Root.ID
+	}
+
+	resp, err := items.GetItem(req.tableManager, req.database, items.GetItemRequest{TableID: req.tableID, ItemID: id})
+	if err == nil {
+		if resp.Item.Type == tables.NodeTypeSymlink {
+			return nil, nil, notFound(fileID)
+		}
+		return &resp.Item, nil, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, err
+	}
+
+	trashResp, err := items.GetTrashItem(req.tableManager, req.database, items.GetTrashItemRequest{TableID: req.tableID, ItemID: id})
+	if err != nil {
+		return nil, nil, itemFailure(err, fileID)
+	}
+	if trashResp.Item.Type == tables.NodeTypeSymlink {
+		return nil, nil, notFound(fileID)
+	}
+	return &trashResp.Item.Node, &trashResp.Item, nil
+}
+
+// folder returns the folder with an ID, which must not be in the trash
+func (req *driveRequest) folder(folderID string) (*dbTypes.Node, error) {
+	node, trashed, err := req.lookup(folderID)
+	if err != nil {
+		return nil, err
+	}
+	if trashed != nil {
+		return nil, notFound(folderID)
+	}
+	if node.Type != tables.NodeTypeFolder {
+		return nil, badRequest("parentNotAFolder", "The specified parent is not a folder.")
+	}
+	return node, nil
+}
+
+// withDetails looks an item up by path to get its lock and sharing, which lookups by ID don't fill in
+func (req *driveRequest) withDetails(node *dbTypes.Node) (*dbTypes.Node, error) {
+	if node.ParentID == "" {
+		return node, nil
+	}
+	resp, err := items.GetItemByPath(req.tableManager, req.database, req.generator, items.GetItemByPathRequest{TableID: req.tableID, Path: node.Path})
+	if err != nil {
+		return nil, itemFailure(err, node.ID)
+	}
+	return &resp.Item, nil
+}
+
+// fileMetadata is the File resource of create, update and copy requests: the fields that can be set
+type fileMetadata struct {
+	Name     *string  `json:"name"`
+	MimeType string   `json:"mimeType"`
+	Parents  []string `json:"parents"`
+	Trashed  *bool    `json:"trashed"`
+}
+
+// decodeMetadata reads the JSON metadata of a request. An empty body is no metadata.
+func decodeMetadata(body io.Reader) (fileMetadata, error) {
+	var meta fileMetadata
+	data, err := io.ReadAll(io.LimitReader(body, maxMetadataLength))
+	if err != nil {
+		return meta, err
+	}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return meta, nil
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return meta, badRequest("parseError", "Parse Error")
+	}
+	return meta, nil
+}
+
+// getFile serves files.get: the File resource, or the content with alt=media
+func getFile(req *driveRequest) (any, error) {
+	fileID := chi.URLParam(req.r, "fileId")
+	node, trashed, err := req.lookup(fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch alt := req.r.URL.Query().Get("alt"); alt {
+	case "", "json":
+	case "media":
+		if node.Type == tables.NodeTypeFolder || node.Type == tables.NodeTypeShortcut {
+			return nil, forbidden("fileNotDownloadable", "Only files with binary content can be downloaded. Use Export with Docs Editors files.")
+		}
+		return &media{file: req.fileOf(*node, trashed, false), content: req.generator.OpenContent(*node, node.Size)}, nil
+	default:
+		return nil, invalidParameter("alt", "Invalid value '%s'. Values must match the following regular expression: 'json|media'", alt)
+	}
+
+	mask := req.mask(defaultFileFields)
+	if trashed == nil && (mask.wants("shared") || mask.wants("contentRestrictions")) {
+		if node, err = req.withDetails(node); err != nil {
+			return nil, err
+		}
+	}
+	return req.filter(req.fileOf(*node, trashed, mask.wants("md5Checksum")), defaultFileFields)
+}
+
+// fileList is Drive's FileList
+type fileList struct {
+	Kind             string  `json:"kind"`
+	IncompleteSearch bool    `json:"incompleteSearch"`
+	NextPageToken    string  `json:"nextPageToken,omitempty"`
+	Files            []*file `json:"files"`
+}
+
+// listPosition is a files.list page token: the query it belongs to and where the next page starts
+type listPosition struct {
+	Query  string `json:"q"`
+	Offset int    `json:"o"`
+}
+
+// listFiles serves files.list. Queries list a folder's children ('<id>' in parents) or the trash
+// (trashed = true), optionally filtered; results are in the folder's order.
+func listFiles(req *driveRequest) (any, error) {
+	params := req.r.URL.Query()
+	q, err := parseQuery(params.Get("q"))
+	if err != nil {
+		return nil, err
+	}
+	pageSize, err := pageSizeParam(params.Get("pageSize"))
+	if err != nil {
+		return nil, err
+	}
+	offset := 0
+	if token := params.Get("pageToken"); token != "" {
+		data, err := base64.RawURLEncoding.DecodeString(token)
+		var position listPosition
+		if err == nil {
+			err = json.Unmarshal(data, &position)
+		}
+		if err != nil || position.Query != params.Get("q") || position.Offset < 0 {
+			return nil, invalidParameter("pageToken", "Invalid Value")
+		}
+		offset = position.Offset
+	}
+
+	type match struct {
+		node    dbTypes.Node
+		trashed *dbTypes.TrashItem
+		file    *file
+	}
+	var matches []match
+	parentID := ""
+	if q.parent != "" {
+		parent, err := req.folder(q.parent)
+		if err != nil {
+			return nil, err
+		}
+		parentID = parent.ID
+		if q.trashed == nil || !*q.trashed {
+			resp, err := items.ListItems(req.tableManager, req.database, req.generator, items.ListItemsRequest{TableID: req.tableID, FolderID: parent.ID})
+			if err != nil {
+				return nil, err
+			}
+			for _, child := range resp.Items {
+				if f := req.fileOf(child, nil, false); child.Type != tables.NodeTypeSymlink && q.matches(f) {
+					matches = append(matches, match{child, nil, f})
+				}
+			}
+		}
+	}
+	if q.trashed == nil || *q.trashed {
+		resp, err := items.ListTrash(req.tableManager, req.database, items.ListTrashRequest{TableID: req.tableID})
+		if err != nil {
+			return nil, err
+		}
+		for i, item := range resp.Items {
+			if parentID != "" && item.ParentID != parentID {
+				continue
+			}
+			if f := req.fileOf(item.Node, &resp.Items[i], false); item.Type != tables.NodeTypeSymlink && q.matches(f) {
+				matches = append(matches, match{item.Node, &resp.Items[i], f})
+			}
+		}
+	}
+
+	list := fileList{Kind: "drive#fileList", Files: []*file{}}
+	checksum := req.mask(defaultListFields).wants("files", "md5Checksum")
+	for i := offset; i < len(matches) && i < offset+pageSize; i++ {
+		f := matches[i].file
+		if checksum {
+			f = req.fileOf(matches[i].node, matches[i].trashed, true)
+		}
+		list.Files = append(list.Files, f)
+	}
+	if offset+pageSize < len(matches) {
+		data, _ := json.Marshal(listPosition{Query: params.Get("q"), Offset: offset + pageSize})
+		list.NextPageToken = base64.RawURLEncoding.EncodeToString(data)
+	}
+	return req.filter(list, defaultListFields)
+}
+
+// pageSizeParam reads a pageSize parameter
+func pageSizeParam(value string) (int, error) {
+	if value == "" {
+		return defaultPageSize, nil
+	}
+	pageSize, err := strconv.Atoi(value)
+	if err != nil || pageSize < 1 || pageSize > maxPageSize {
+		return 0, invalidParameter("pageSize", "Invalid value '%s'. Values must be within the range: [1, %d]", value, maxPageSize)
+	}
+	return pageSize, nil
+}
+
+// createFile serves files.create without content: folders, or empty files
+func createFile(req *driveRequest) (any, error) {
+	meta, err := decodeMetadata(req.r.Body)
+	if err != nil {
+		return nil, err
+	}
+	node, err := req.create(meta, 0, false)
+	if err != nil {
+		return nil, err
+	}
+	return req.filter(req.fileOf(*node, nil, req.mask(defaultFileFields).wants("md5Checksum")), defaultFileFields)
+}
+
+// create creates a file of a size (or a folder, unless it's an upload) from its metadata. Like
+// Drive, files are named "Untitled" and go to the root unless the metadata says otherwise.
+func (req *driveRequest) create(meta fileMetadata, size int64, upload bool) (*dbTypes.Node, error) {
+	name := "Untitled"
+	if meta.Name != nil && *meta.Name != "" {
+		name = *meta.Name
+	}
+	itemType := tables.NodeTypeFile
+	switch {
+	case meta.MimeType == folderMimeType && !upload:
+		itemType = tables.NodeTypeFolder
+	case strings.HasPrefix(meta.MimeType, "application/vnd.google-apps."):
+		return nil, badRequest("badRequest", "Creating %s files is not supported.", meta.MimeType)
+	}
+	if len(meta.Parents) > 1 {
+		return nil, forbidden("cannotAddParent", "Increasing the number of parents is not allowed. A file must have exactly one parent.")
+	}
+	parentID := "root"
+	if len(meta.Parents) == 1 {
+		parentID = meta.Parents[0]
+	}
+	parent, err := req.folder(parentID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := items.CreateItems(req.tableManager, req.database, req.generator, items.CreateItemsRequest{
+		TableID:  req.tableID,
+		ParentID: parent.ID,
+		Items:    []items.NewItem{{Name: name, Type: itemType, Size: size}},
+	})
+	if err != nil {
+		return nil, itemFailure(err, parentID)
+	}
+	if result := resp.Results[0]; result.Error != nil {
+		return nil, itemFailure(result.Error, parentID)
+	}
+	return resp.Results[0].Item, nil
+}
+
+// updateFile serves files.update without content: renames, moves (addParents / removeParents),
+// trashing and restoring
+func updateFile(req *driveRequest) (any, error) {
+	meta, err := decodeMetadata(req.r.Body)
+	if err != nil {
+		return nil, err
+	}
+	node, trashed, err := req.update(chi.URLParam(req.r, "fileId"), meta)
+	if err != nil {
+		return nil, err
+	}
+	return req.filter(req.fileOf(*node, trashed, req.mask(defaultFileFields).wants("md5Checksum")), defaultFileFields)
+}
+
+// update applies the metadata and the addParents and removeParents parameters of an update to a
+// file. Files keep exactly one parent, like in Drive since folders can't hold files twice.
+func (req *driveRequest) update(fileID string, meta fileMetadata) (*dbTypes.Node, *dbTypes.TrashItem, error) {
+	if meta.Parents != nil {
+		return nil, nil, forbidden("fieldNotWritable", "The parents field is not directly writable in update requests. Use the addParents and removeParents parameters instead.")
+	}
+	node, trashed, err := req.lookup(fileID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if meta.Trashed != nil && !*meta.Trashed && trashed != nil {
+		resp, err := items.RestoreTrashItem(req.tableManager, req.database, req.generator, items.RestoreTrashItemRequest{TableID: req.tableID, ItemID: node.ID})
+		if err != nil {
+			return nil, nil, itemFailure(err, fileID)
+		}
+		node, trashed = &resp.Item, nil
+	}
+
+	parentID := node.ParentID
+	params := req.r.URL.Query()
+	if params.Get("addParents") != "" || params.Get("removeParents") != "" {
+		if parentID, err = req.newParent(node, params.Get("addParents"), params.Get("removeParents")); err != nil {
+			return nil, nil, err
+		}
+	}
+	name := ""
+	if meta.Name != nil && *meta.Name != node.Name {
+		name = *meta.Name
+	}
+	if parentID != node.ParentID || name != "" {
+		if trashed != nil {
+			return nil, nil, forbidden("insufficientFilePermissions", "Files in the trash can't be renamed or moved. Restore them first.")
+		}
+		resp, err := items.MoveItem(req.tableManager, req.database, req.generator, items.MoveItemRequest{TableID: req.tableID, ItemID: node.ID, ParentID: parentID, Name: name})
+		if err != nil {
+			return nil, nil, itemFailure(err, fileID)
+		}
+		node = &resp.Item
+	}
+
+	if meta.Trashed != nil && *meta.Trashed && trashed == nil {
+		if trash := req.tableManager.GetTrashConfig(req.tableName); trash == nil || !trash.Enabled {
+			return nil, nil, forbidden("insufficientFilePermissions", "The %s table has no trash. Delete the file instead.", req.tableName)
+		}
+		if _, err := items.DeleteItem(req.tableManager, req.database, req.generator, items.DeleteItemRequest{TableID: req.tableID, ItemID: node.ID}); err != nil {
+			return nil, nil, itemFailure(err, fileID)
+		}
+		if node, trashed, err = req.lookup(node.ID); err != nil {
+			return nil, nil, err
+		}
+	}
+	return node, trashed, nil
+}
+
+// newParent applies comma-separated addParents and removeParents to a file's parent. The result
+// must be a single parent.
+func (req *driveRequest) newParent(node *dbTypes.Node, addParents string, removeParents string) (string, error) {
+	var parents []string
+	if node.ParentID != "" {
+		parents = append(parents, node.ParentID)
+	}
+	for _, id := range splitIDs(removeParents) {
+		folder, err := req.folder(id)
+		if err != nil {
+			return "", err
+		}
+		for i := range parents {
+			if parents[i] == folder.ID {
+				parents = append(parents[:i], parents[i+1:]...)
+				break
+			}
+		}
+	}
+	for _, id := range splitIDs(addParents) {
+		folder, err := req.folder(id)
+		if err != nil {
+			return "", err
+		}
+		if len(parents) == 0 || parents[0] != folder.ID {
+			parents = append(parents, folder.ID)
+		}
+	}
+
+	switch {
+	case len(parents) > 1:
+		return "", forbidden("cannotAddParent", "Increasing the number of parents is not allowed. A file must have exactly one parent.")
+	case len(parents) == 0:
+		return "", forbidden("cannotRemoveParent", "A file must have exactly one parent.")
+	}
+	return parents[0], nil
+}
+
+func splitIDs(value string) []string {
+	var ids []string
+	for _, id := range strings.Split(value, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// deleteFile serves files.delete: the file is deleted for good, skipping the trash like in Drive
+func deleteFile(req *driveRequest) (any, error) {
+	fileID := chi.URLParam(req.r, "fileId")
+	node, trashed, err := req.lookup(fileID)
+	if err != nil {
+		return nil, err
+	}
+	if trashed != nil {
+		_, err = items.PurgeTrash(req.tableManager, req.database, items.PurgeTrashRequest{TableID: req.tableID, ItemID: node.ID})
+	} else {
+		_, err = items.DeleteItem(req.tableManager, req.database, req.generator, items.DeleteItemRequest{TableID: req.tableID, ItemID: node.ID, Permanent: true})
+	}
+	if err != nil {
+		return nil, itemFailure(err, fileID)
+	}
+	return noContent{}, nil
+}
+
+// copyFile serves files.copy. Like Drive, folders can't be copied and copies are named "Copy of <name>".
+func copyFile(req *driveRequest) (any, error) {
+	fileID := chi.URLParam(req.r, "fileId")
+	meta, err := decodeMetadata(req.r.Body)
+	if err != nil {
+		return nil, err
+	}
+	node, trashed, err := req.lookup(fileID)
+	if err != nil {
+		return nil, err
+	}
+	if node.Type == tables.NodeTypeFolder || trashed != nil {
+		return nil, forbidden("cannotCopyFile", "This file cannot be copied by the user.")
+	}
+	if len(meta.Parents) > 1 {
+		return nil, forbidden("cannotAddParent", "Increasing the number of parents is not allowed. A file must have exactly one parent.")
+	}
+
+	name := "Copy of " + node.Name
+	if meta.Name != nil && *meta.Name != "" {
+		name = *meta.Name
+	}
+	parentID := node.ParentID
+	if len(meta.Parents) == 1 {
+		parent, err := req.folder(meta.Parents[0])
+		if err != nil {
+			return nil, err
+		}
+		parentID = parent.ID
+	}
+	resp, err := items.CopyItem(req.tableManager, req.database, req.generator, items.CopyItemRequest{TableID: req.tableID, ItemID: node.ID, ParentID: parentID, Name: name})
+	if err != nil {
+		return nil, itemFailure(err, fileID)
+	}
+	return req.filter(req.fileOf(resp.Item, nil, req.mask(defaultFileFields).wants("md5Checksum")), defaultFileFields)
+}
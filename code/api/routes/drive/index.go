@@ -0,0 +1,157 @@
+package drive
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	coreTables "github.com/Voltaic314/GhostFS/code/core/tables"
+	"github.com/Voltaic314/GhostFS/code/db"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	"github.com/go-chi/chi/v5"
+)
+
+// Prefix is where the Drive API is mounted. Each table is a drive at <Prefix>/<table>, which stands
+// in for https://www.googleapis.com: clients call e.g. http://localhost:8086/drive/nodes/drive/v3/files
+// and upload to http://localhost:8086/drive/nodes/upload/drive/v3/files.
+const Prefix = "/drive"
+
+// RegisterRoutes registers the Drive API v3 subset. Calls need a bearer token (or access_token
+// parameter), any token is accepted.
+func RegisterRoutes(r chi.Router, server interface{}) {
+	h := &handler{server: server, uploads: newUploadStore()}
+	r.Route("/{table}", func(r chi.Router) {
+		r.Get("/drive/v3/files", h.serve(listFiles))
+		r.Post("/drive/v3/files", h.serve(createFile))
+		r.Get("/drive/v3/files/{fileId}", h.serve(getFile))
+		r.Patch("/drive/v3/files/{fileId}", h.serve(updateFile))
+		r.Delete("/drive/v3/files/{fileId}", h.serve(deleteFile))
+		r.Post("/drive/v3/files/{fileId}/copy", h.serve(copyFile))
+		r.Get("/drive/v3/changes/startPageToken", h.serve(getStartPageToken))
+		r.Get("/drive/v3/changes", h.serve(listChanges))
+
+		// Resumable uploads continue with PUTs to the session URL, the upload URL with an upload_id
+		r.Post("/upload/drive/v3/files", h.serve(uploadFile))
+		r.Put("/upload/drive/v3/files", h.serve(uploadFile))
+		r.Patch("/upload/drive/v3/files/{fileId}", h.serve(uploadFile))
+		r.Put("/upload/drive/v3/files/{fileId}", h.serve(uploadFile))
+	})
+}
+
+// handler serves the API methods. Resumable uploads in progress are kept in memory.
+type handler struct {
+	server  interface{}
+	uploads *uploadStore
+}
+
+// driveRequest is one API call on a table (drive)
+type driveRequest struct {
+	w            http.ResponseWriter
+	r            *http.Request
+	tableManager *tables.TableManager
+	database     *db.DB
+	generator    *tables.DeterministicGenerator
+	uploads      *uploadStore
+	tableID      string
+	tableName    string
+	fields       fieldMask // the fields parameter, nil = the method's default fields
+}
+
+// result is what a method returns besides a JSON resource
+type (
+	// noContent is an empty 204 response
+	noContent struct{}
+	// media is file content to send (alt=media)
+	media struct {
+		file    *file
+		content *tables.ContentReader
+	}
+)
+
+func (h *handler) serve(method func(req *driveRequest) (any, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Cast server to get access to DB and TableManager
+		s := h.server.(interface {
+			GetTableManager() *tables.TableManager
+			GetDB() *db.DB
+			GetDeterministicGenerator() *tables.DeterministicGenerator
+		})
+		req := &driveRequest{
+			w:            w,
+			r:            r,
+			tableManager: s.GetTableManager(),
+			database:     s.GetDB(),
+			generator:    s.GetDeterministicGenerator(),
+			uploads:      h.uploads,
+		}
+
+		var result any
+		err := req.prepare(chi.URLParam(r, "table"))
+		if err == nil {
+			result, err = method(req)
+		}
+		if err != nil {
+			req.writeError(err)
+			return
+		}
+		req.writeResult(result)
+	}
+}
+
+// prepare checks the call's authorization and reads its table and fields parameter
+func (req *driveRequest) prepare(table string) error {
+	if !strings.HasPrefix(req.r.Header.Get("Authorization"), "Bearer ") && req.r.URL.Query().Get("access_token") == "" {
+		return &apiError{
+			Code:         http.StatusUnauthorized,
+			Reason:       "required",
+			Message:      "Login Required.",
+			Location:     "Authorization",
+			LocationType: "header",
+		}
+	}
+
+	if value := req.r.URL.Query().Get("fields"); value != "" {
+		fields, err := parseFields(value)
+		if err != nil {
+			return invalidParameter("fields", "Invalid field selection %s", value)
+		}
+		req.fields = fields
+	}
+
+	resp, err := coreTables.ListTables(req.database)
+	if err != nil {
+		return err
+	}
+	for _, info := range resp.Tables {
+		if info.TableName == table || info.TableID == table {
+			req.tableID, req.tableName = info.TableID, info.TableName
+			return nil
+		}
+	}
+	return &apiError{Code: http.StatusNotFound, Reason: "notFound", Message: fmt.Sprintf("Drive not found: %s.", table)}
+}
+
+// writeResult sends a method's result. Resources come filtered by the fields parameter.
+func (req *driveRequest) writeResult(result any) {
+	switch result := result.(type) {
+	case noContent:
+		req.w.WriteHeader(http.StatusNoContent)
+	case *media:
+		req.w.Header().Set("Content-Type", result.file.MimeType)
+		http.ServeContent(req.w, req.r, "", result.file.modified, result.content)
+	case *uploadProgress:
+		req.writeProgress(result)
+	default:
+		req.writeJSON(http.StatusOK, result)
+	}
+}
+
+func (req *driveRequest) writeJSON(status int, v any) {
+	req.w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	req.w.WriteHeader(status)
+	encoder := json.NewEncoder(req.w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(v)
+}
@@ -0,0 +1,152 @@
+package drive
+
+import (
+	"strings"
+)
+
+// query is a parsed files.list q parameter. Supported terms, joined with "and":
+//
+//	'<id>' in parents
+//	trashed = true | false (or !=)
+//	mimeType = | != '<type>', mimeType contains '<text>'
+//	name = | != '<name>', name contains '<text>'
+//
+// A query must list a folder ('<id>' in parents) or the trash (trashed = true): generated tables
+// have no end, so there is nothing like Drive's search of all files.
+type query struct {
+	parent     string
+	trashed    *bool
+	conditions []condition
+}
+
+// condition is a term on a text field of the files
+type condition struct {
+	field string
+	op    string
+	value string
+}
+
+func parseQuery(q string) (*query, error) {
+	tokens, ok := tokenize(q)
+	if !ok {
+		return nil, invalidParameter("q", "Invalid Value")
+	}
+	parsed := &query{}
+	for len(tokens) > 0 {
+		if len(tokens) < 3 || !parsed.addTerm(tokens[0], tokens[1], tokens[2]) {
+			return nil, invalidParameter("q", "Invalid Value")
+		}
+		tokens = tokens[3:]
+		if len(tokens) > 0 {
+			if !strings.EqualFold(tokens[0].text, "and") || tokens[0].quoted || len(tokens) == 1 {
+				return nil, invalidParameter("q", "Invalid Value")
+			}
+			tokens = tokens[1:]
+		}
+	}
+	if parsed.parent == "" && (parsed.trashed == nil || !*parsed.trashed) {
+		return nil, invalidParameter("q", "Queries must list a folder ('<id>' in parents) or the trash (trashed = true)")
+	}
+	return parsed, nil
+}
+
+// addTerm adds a "<subject> <operator> <object>" term, or reports that it isn't supported
+func (q *query) addTerm(subject, operator, object token) bool {
+	if subject.quoted {
+		if !strings.EqualFold(operator.text, "in") || object.text != "parents" || q.parent != "" {
+			return false
+		}
+		q.parent = subject.text
+		return true
+	}
+
+	switch subject.text {
+	case "trashed":
+		if object.quoted || (object.text != "true" && object.text != "false") || (operator.text != "=" && operator.text != "!=") || q.trashed != nil {
+			return false
+		}
+		trashed := (object.text == "true") == (operator.text == "=")
+		q.trashed = &trashed
+		return true
+	case "name", "mimeType":
+		if !object.quoted || (operator.text != "=" && operator.text != "!=" && operator.text != "contains") {
+			return false
+		}
+		q.conditions = append(q.conditions, condition{field: subject.text, op: operator.text, value: object.text})
+		return true
+	}
+	return false
+}
+
+// matches reports whether a file passes the query's conditions
+func (q *query) matches(f *file) bool {
+	for _, c := range q.conditions {
+		actual := f.Name
+		if c.field == "mimeType" {
+			actual = f.MimeType
+		}
+		switch c.op {
+		case "=":
+			if actual != c.value {
+				return false
+			}
+		case "!=":
+			if actual == c.value {
+				return false
+			}
+		case "contains":
+			if !strings.Contains(strings.ToLower(actual), strings.ToLower(c.value)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// token is a word, an operator or a quoted string (with its escapes resolved)
+type token struct {
+	text   string
+	quoted bool
+}
+
+func tokenize(q string) ([]token, bool) {
+	var tokens []token
+	for i := 0; i < len(q); {
+		switch c := q[i]; {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '\'':
+			var text strings.Builder
+			for i++; ; i++ {
+				if i >= len(q) {
+					return nil, false
+				}
+				if q[i] == '\\' && i+1 < len(q) {
+					i++
+				} else if q[i] == '\'' {
+					break
+				}
+				text.WriteByte(q[i])
+			}
+			tokens = append(tokens, token{text: text.String(), quoted: true})
+			i++
+		case c == '=':
+			tokens = append(tokens, token{text: "="})
+			i++
+		case c == '!' && strings.HasPrefix(q[i:], "!="):
+			tokens = append(tokens, token{text: "!="})
+			i += 2
+		default:
+			end := i
+			for end < len(q) && strings.IndexByte(" \t\n'=!()", q[end]) < 0 {
+				end++
+			}
+			if end == i {
+				return nil, false
+			}
+			tokens = append(tokens, token{text: q[i:end]})
+			i = end
+		}
+	}
+	return tokens, true
+}
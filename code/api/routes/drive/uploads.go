@@ -0,0 +1,290 @@
+package drive
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Voltaic314/GhostFS/code/core/items"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// Uploads create a file (POST to /upload/drive/v3/files) or replace a file's content (PATCH to
+// /upload/drive/v3/files/<id>). The content is read and counted, not stored; replacing content
+// adds a revision.
+
+// uploadSession is a resumable upload in progress
+type uploadSession struct {
+	id       string
+	tableID  string
+	fileID   string // file whose content is replaced ("" = a new file)
+	metadata fileMetadata
+	total    int64 // -1 until the client says
+	received int64
+}
+
+// uploadStore keeps the resumable uploads in progress. Sessions don't survive a restart.
+type uploadStore struct {
+	mu       sync.Mutex
+	sessions map[string]*uploadSession
+}
+
+func newUploadStore() *uploadStore {
+	return &uploadStore{sessions: make(map[string]*uploadSession)}
+}
+
+// uploadProgress is a resumable upload that isn't complete: a new session (sent with its URL) or a
+// partial one (a 308 with the range received so far)
+type uploadProgress struct {
+	location string
+	received int64
+}
+
+func (req *driveRequest) writeProgress(progress *uploadProgress) {
+	if progress.location != "" {
+		req.w.Header().Set("Location", progress.location)
+		req.w.Header().Set("Content-Length", "0")
+		req.w.WriteHeader(http.StatusOK)
+		return
+	}
+	if progress.received > 0 {
+		req.w.Header().Set("Range", fmt.Sprintf("bytes=0-%d", progress.received-1))
+	}
+	req.w.Header().Set("Content-Length", "0")
+	req.w.WriteHeader(http.StatusPermanentRedirect)
+}
+
+// uploadFile serves the upload endpoint: uploadType media, multipart or resumable, and the
+// requests to resumable sessions (with an upload_id)
+func uploadFile(req *driveRequest) (any, error) {
+	params := req.r.URL.Query()
+	if sessionID := params.Get("upload_id"); sessionID != "" {
+		return req.resumeUpload(sessionID)
+	}
+	if req.r.Method == http.MethodPut {
+		return nil, invalidParameter("upload_id", "Required parameter: upload_id")
+	}
+
+	fileID := chi.URLParam(req.r, "fileId")
+	switch uploadType := params.Get("uploadType"); uploadType {
+	case "media":
+		size, err := io.Copy(io.Discard, req.r.Body)
+		if err != nil {
+			return nil, err
+		}
+		return req.commitUpload(fileID, fileMetadata{}, size)
+	case "multipart":
+		meta, size, err := req.readMultipart()
+		if err != nil {
+			return nil, err
+		}
+		return req.commitUpload(fileID, meta, size)
+	case "resumable":
+		return req.startResumable(fileID)
+	default:
+		return nil, invalidParameter("uploadType", "Invalid value '%s'. Values must match the following regular expression: 'media|multipart|resumable'", uploadType)
+	}
+}
+
+// readMultipart reads a multipart/related upload: the JSON metadata, then the content
+func (req *driveRequest) readMultipart() (fileMetadata, int64, error) {
+	mediaType, mediaParams, err := mime.ParseMediaType(req.r.Header.Get("Content-Type"))
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") || mediaParams["boundary"] == "" {
+		return fileMetadata{}, 0, badRequest("badContent", "Multipart uploads need a multipart/related Content-Type with a boundary.")
+	}
+	reader := multipart.NewReader(req.r.Body, mediaParams["boundary"])
+
+	part, err := reader.NextPart()
+	if err != nil {
+		return fileMetadata{}, 0, badRequest("badContent", "Multipart uploads need a metadata part and a media part.")
+	}
+	meta, err := decodeMetadata(part)
+	if err != nil {
+		return fileMetadata{}, 0, err
+	}
+	part, err = reader.NextPart()
+	if err != nil {
+		return fileMetadata{}, 0, badRequest("badContent", "Multipart uploads need a metadata part and a media part.")
+	}
+	size, err := io.Copy(io.Discard, part)
+	if err != nil {
+		return fileMetadata{}, 0, err
+	}
+	return meta, size, nil
+}
+
+// startResumable starts a resumable upload session. The metadata is checked when the upload
+// completes, except for the file and folder it refers to.
+func (req *driveRequest) startResumable(fileID string) (any, error) {
+	meta, err := decodeMetadata(req.r.Body)
+	if err != nil {
+		return nil, err
+	}
+	if fileID != "" {
+		if _, err := req.uploadTarget(fileID); err != nil {
+			return nil, err
+		}
+	} else if len(meta.Parents) == 1 {
+		if _, err := req.folder(meta.Parents[0]); err != nil {
+			return nil, err
+		}
+	}
+
+	session := &uploadSession{id: uuid.NewString(), tableID: req.tableID, fileID: fileID, metadata: meta, total: -1}
+	if length := req.r.Header.Get("X-Upload-Content-Length"); length != "" {
+		if session.total, err = strconv.ParseInt(length, 10, 64); err != nil || session.total < 0 {
+			return nil, badRequest("badRequest", "Invalid X-Upload-Content-Length header: %s", length)
+		}
+	}
+	req.uploads.mu.Lock()
+	req.uploads.sessions[session.id] = session
+	req.uploads.mu.Unlock()
+
+	params := req.r.URL.Query()
+	params.Set("upload_id", session.id)
+	scheme := "http"
+	if req.r.TLS != nil {
+		scheme = "https"
+	}
+	location := url.URL{Scheme: scheme, Host: req.r.Host, Path: req.r.URL.Path, RawQuery: params.Encode()}
+	return &uploadProgress{location: location.String()}, nil
+}
+
+// resumeUpload takes a chunk of a resumable upload (Content-Range: bytes <first>-<last>/<total or *>),
+// a status request (bytes */<total or *>) or the whole content (no Content-Range). The upload
+// completes once the total is known and received.
+func (req *driveRequest) resumeUpload(sessionID string) (any, error) {
+	req.uploads.mu.Lock()
+	session, ok := req.uploads.sessions[sessionID]
+	req.uploads.mu.Unlock()
+	if !ok || session.tableID != req.tableID {
+		return nil, &apiError{Code: http.StatusNotFound, Reason: "notFound", Message: fmt.Sprintf("Upload session not found: %s.", sessionID)}
+	}
+
+	first, total, hasRange, err := parseContentRange(req.r.Header.Get("Content-Range"))
+	if err != nil {
+		return nil, err
+	}
+	size, err := io.Copy(io.Discard, req.r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.uploads.mu.Lock()
+	if !hasRange {
+		first, total = 0, size
+	}
+	switch {
+	case first > session.received:
+		req.uploads.mu.Unlock()
+		return nil, badRequest("badRequest", "The chunk starts at %d, only %d bytes were received.", first, session.received)
+	case total >= 0 && session.total >= 0 && total != session.total:
+		req.uploads.mu.Unlock()
+		return nil, badRequest("badRequest", "The upload's total size changed from %d to %d.", session.total, total)
+	}
+	if total >= 0 {
+		session.total = total
+	}
+	session.received = max(session.received, first+size)
+	if session.total >= 0 && session.received > session.total {
+		req.uploads.mu.Unlock()
+		return nil, badRequest("badRequest", "Received %d bytes of a %d byte upload.", session.received, session.total)
+	}
+	complete := session.total >= 0 && session.received == session.total
+	received := session.received
+	req.uploads.mu.Unlock()
+	if !complete {
+		return &uploadProgress{received: received}, nil
+	}
+
+	result, err := req.commitUpload(session.fileID, session.metadata, session.total)
+	if err != nil {
+		return nil, err
+	}
+	req.uploads.mu.Lock()
+	delete(req.uploads.sessions, session.id)
+	req.uploads.mu.Unlock()
+	return result, nil
+}
+
+// parseContentRange parses the Content-Range of a resumable upload request. first is where the
+// chunk starts, total is -1 when unknown ("*").
+func parseContentRange(header string) (first int64, total int64, ok bool, err error) {
+	if header == "" {
+		return 0, -1, false, nil
+	}
+	invalid := badRequest("badRequest", "Failed to parse Content-Range header.")
+	spec, found := strings.CutPrefix(header, "bytes ")
+	chunk, totalText, hasTotal := strings.Cut(spec, "/")
+	if !found || !hasTotal {
+		return 0, 0, false, invalid
+	}
+	total = -1
+	if totalText != "*" {
+		if total, err = strconv.ParseInt(totalText, 10, 64); err != nil || total < 0 {
+			return 0, 0, false, invalid
+		}
+	}
+
+	if chunk == "*" {
+		// A status request: nothing new, the chunk "starts" at 0
+		return 0, total, true, nil
+	}
+	firstText, lastText, isRange := strings.Cut(chunk, "-")
+	first, err1 := strconv.ParseInt(firstText, 10, 64)
+	last, err2 := strconv.ParseInt(lastText, 10, 64)
+	if !isRange || err1 != nil || err2 != nil || first < 0 || last < first {
+		return 0, 0, false, invalid
+	}
+	return first, total, true, nil
+}
+
+// uploadTarget returns the file an upload replaces the content of
+func (req *driveRequest) uploadTarget(fileID string) (*dbTypes.Node, error) {
+	node, trashed, err := req.lookup(fileID)
+	if err != nil {
+		return nil, err
+	}
+	if trashed != nil {
+		return nil, forbidden("insufficientFilePermissions", "Files in the trash can't be changed. Restore them first.")
+	}
+	if node.Type != tables.NodeTypeFile {
+		return nil, forbidden("insufficientFilePermissions", "Only files can have content uploaded.")
+	}
+	return node, nil
+}
+
+// commitUpload creates a file of the uploaded size, or adds a revision of that size to the file
+// being updated and then applies the metadata
+func (req *driveRequest) commitUpload(fileID string, meta fileMetadata, size int64) (any, error) {
+	mask := req.mask(defaultFileFields)
+	if fileID == "" {
+		node, err := req.create(meta, size, true)
+		if err != nil {
+			return nil, err
+		}
+		return req.filter(req.fileOf(*node, nil, mask.wants("md5Checksum")), defaultFileFields)
+	}
+
+	node, err := req.uploadTarget(fileID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := items.UpdateFile(req.tableManager, req.database, req.generator, items.UpdateFileRequest{TableID: req.tableID, ItemID: node.ID, Size: size}); err != nil {
+		return nil, itemFailure(err, fileID)
+	}
+	updated, trashed, err := req.update(fileID, meta)
+	if err != nil {
+		return nil, err
+	}
+	return req.filter(req.fileOf(*updated, trashed, mask.wants("md5Checksum")), defaultFileFields)
+}
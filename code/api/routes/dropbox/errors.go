@@ -0,0 +1,154 @@
+package dropbox
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Voltaic314/GhostFS/code/core/items"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+)
+
+// Errors are reported like Dropbox does: endpoint errors are 409s with the endpoint's error union
+// and its summary, bad input is a 400 with a plain text message.
+
+// apiError is a failed call. Value is the error union of 409s, Message the text of other statuses.
+type apiError struct {
+	Status  int
+	Value   map[string]any
+	Message string
+}
+
+func (e *apiError) Error() string {
+	if e.Value != nil {
+		return summary(e.Value)
+	}
+	return e.Message
+}
+
+// endpointError is a 409 with an endpoint's error union
+func endpointError(value map[string]any) *apiError {
+	return &apiError{Status: http.StatusConflict, Value: value}
+}
+
+// nest builds a union of nested tags, e.g. nest("path", "not_found") is
+// {".tag": "path", "path": {".tag": "not_found"}}
+func nest(tags ...string) map[string]any {
+	value := map[string]any{".tag": tags[len(tags)-1]}
+	for i := len(tags) - 2; i >= 0; i-- {
+		value = map[string]any{".tag": tags[i], tags[i]: value}
+	}
+	return value
+}
+
+// structUnionField is the member of inlined structs that holds their union, e.g. the WriteError
+// reason of an UploadWriteFailed
+const structUnionField = "reason"
+
+// summary is the error_summary of a union: its tags down to the innermost one, e.g.
+// "path/not_found/...". Struct members are inlined, their union field is followed.
+func summary(value map[string]any) string {
+	var tags []string
+	for value != nil {
+		tag, _ := value[".tag"].(string)
+		tags = append(tags, tag)
+		next, _ := value[tag].(map[string]any)
+		if next == nil {
+			next, _ = value[structUnionField].(map[string]any)
+		}
+		value = next
+	}
+	return strings.Join(tags, "/") + "/..."
+}
+
+// message formats the text of a 400 like Dropbox does
+func (req *dbxRequest) message(text string) string {
+	return fmt.Sprintf("Error in call to API function %q: %s", req.function, text)
+}
+
+// badInput is a 400 for an argument that doesn't validate
+func (req *dbxRequest) badInput(format string, args ...any) *apiError {
+	return &apiError{Status: http.StatusBadRequest, Message: req.message("request body: " + fmt.Sprintf(format, args...))}
+}
+
+// pathError is a LookupError (write false) or WriteError of a path. Endpoints report it under their
+// own tag, e.g. "path" or "from_lookup".
+type pathError struct {
+	write bool
+	tags  []string
+}
+
+func (e *pathError) Error() string {
+	return strings.Join(e.tags, "/")
+}
+
+func lookupFailed(tags ...string) *pathError {
+	return &pathError{tags: tags}
+}
+
+func writeFailed(tags ...string) *pathError {
+	return &pathError{write: true, tags: tags}
+}
+
+// writeFailure translates a failed item operation into the closest WriteError
+func (req *dbxRequest) writeFailure(err error) error {
+	var itemErr *items.ItemError
+	if !errors.As(err, &itemErr) {
+		return err
+	}
+	switch itemErr.Code {
+	case items.ErrCodeNotFound:
+		return lookupFailed("not_found")
+	case items.ErrCodeNameConflict:
+		return writeFailed("conflict", "file")
+	case items.ErrCodeNotAFolder:
+		return writeFailed("conflict", "file_ancestor")
+	case items.ErrCodeInvalidName, tables.ViolationInvalidCharacter, tables.ViolationReservedName:
+		return writeFailed("disallowed_name")
+	case tables.ViolationNameTooLong, tables.ViolationPathTooLong, tables.ViolationMaxDepth:
+		return writeFailed("malformed_path")
+	case items.ErrCodeTooManyItems:
+		return writeFailed("too_many_write_operations")
+	case items.ErrCodeInvalidSize:
+		// Not a WriteError, Dropbox rejects such arguments before trying the write
+		return &apiError{Status: http.StatusBadRequest, Message: req.message(itemErr.Message)}
+	}
+	return writeFailed("no_write_permission")
+}
+
+// reportPath reports a path's failure under the endpoint's tag for lookups or writes. Other errors
+// are returned as they are.
+func reportPath(err error, lookupTag string, writeTag string) error {
+	var pathErr *pathError
+	if !errors.As(err, &pathErr) {
+		return err
+	}
+	tag := lookupTag
+	if pathErr.write {
+		tag = writeTag
+	}
+	return endpointError(nest(append([]string{tag}, pathErr.tags...)...))
+}
+
+// writeError sends an error: the JSON error of 409s, plain text otherwise
+func (req *dbxRequest) writeError(err error) {
+	var apiErr *apiError
+	var pathErr *pathError
+	switch {
+	case errors.As(err, &apiErr):
+	case errors.As(err, &pathErr):
+		apiErr = reportPath(pathErr, "path", "path").(*apiError)
+	default:
+		fmt.Printf("❌ Dropbox %s failed: %v\n", req.function, err)
+		apiErr = &apiError{Status: http.StatusInternalServerError, Message: err.Error()}
+	}
+
+	if apiErr.Value != nil {
+		req.writeJSON(apiErr.Status, map[string]any{"error_summary": summary(apiErr.Value), "error": apiErr.Value})
+		return
+	}
+	req.w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	req.w.WriteHeader(apiErr.Status)
+	fmt.Fprintln(req.w, apiErr.Message)
+}
@@ -0,0 +1,378 @@
+package dropbox
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/Voltaic314/GhostFS/code/core/items"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+)
+
+// maxUploadLength is the most content a single upload call takes, like Dropbox's 150 MiB
+const maxUploadLength = 150 << 20
+
+// downloadResult is a file to send: its metadata (the Dropbox-API-Result header) and content
+type downloadResult struct {
+	metadata metadata
+	modified time.Time
+	content  *tables.ContentReader
+}
+
+type downloadArg struct {
+	Path string `json:"path"`
+	Rev  string `json:"rev"`
+}
+
+// downloadFile serves files/download, of the current version or of an older one by its rev
+func downloadFile(req *dbxRequest) (any, error) {
+	var arg downloadArg
+	if err := req.decodeArg(&arg); err != nil {
+		return nil, err
+	}
+	if err := req.checkPath("path", arg.Path); err != nil {
+		return nil, err
+	}
+	node, err := req.lookup(arg.Path)
+	if err == nil && node.Type == tables.NodeTypeFolder {
+		err = lookupFailed("not_file")
+	}
+	if err == nil && node.Type == tables.NodeTypeSymlink {
+		err = lookupFailed("unsupported_content_type")
+	}
+	if err != nil {
+		return nil, reportPath(err, "path", "path")
+	}
+
+	current := rev(req.generator.ContentSeed(*node))
+	if arg.Rev == "" || arg.Rev == current {
+		return &downloadResult{metadata: req.metadataOf(*node, false), modified: node.UpdatedAt, content: req.generator.OpenContent(*node, node.Size)}, nil
+	}
+	resp, err := items.ListRevisions(req.tableManager, req.database, req.generator, items.ListRevisionsRequest{TableID: req.tableID, ItemID: node.ID})
+	if err != nil {
+		return nil, err
+	}
+	for _, revision := range resp.Revisions {
+		if rev(revision.ContentSeed) == arg.Rev {
+			return &downloadResult{metadata: req.revisionMetadata(*node, revision), modified: revision.ModifiedAt, content: tables.NewContentReader(revision.ContentSeed, revision.Size)}, nil
+		}
+	}
+	return nil, endpointError(nest("path", "not_found"))
+}
+
+// writeDownload sends a file with Range support. The metadata header is ASCII-only JSON, like Dropbox's.
+func (req *dbxRequest) writeDownload(result *downloadResult) {
+	header, _ := json.Marshal(result.metadata)
+	req.w.Header().Set("Dropbox-API-Result", asciiJSON(header))
+	req.w.Header().Set("Content-Type", "application/octet-stream")
+	http.ServeContent(req.w, req.r, "", result.modified, result.content)
+}
+
+// asciiJSON escapes the non-ASCII characters of a JSON document, so it can be sent as a header
+func asciiJSON(data []byte) string {
+	var b strings.Builder
+	for _, r := range string(data) {
+		if r < 0x7f {
+			b.WriteRune(r)
+			continue
+		}
+		for _, unit := range utf16.Encode([]rune{r}) {
+			fmt.Fprintf(&b, `\u%04x`, unit)
+		}
+	}
+	return b.String()
+}
+
+// writeMode is how an upload treats an existing file: "add", "overwrite" or {"update": rev}
+type writeMode struct {
+	Tag    string `json:".tag"`
+	Update string `json:"update"`
+}
+
+func (m *writeMode) UnmarshalJSON(data []byte) error {
+	var tag string
+	if json.Unmarshal(data, &tag) == nil {
+		m.Tag = tag
+		return nil
+	}
+	type plain writeMode
+	return json.Unmarshal(data, (*plain)(m))
+}
+
+// commitInfo is where and how uploaded content is saved
+type commitInfo struct {
+	Path       string     `json:"path"`
+	Mode       *writeMode `json:"mode"`
+	Autorename bool       `json:"autorename"`
+}
+
+// countContent reads the call's content, which is counted, not kept
+func (req *dbxRequest) countContent() (int64, error) {
+	size, err := io.Copy(io.Discard, io.LimitReader(req.r.Body, maxUploadLength+1))
+	if err != nil {
+		return 0, err
+	}
+	if size > maxUploadLength {
+		return 0, endpointError(nest("payload_too_large"))
+	}
+	return size, nil
+}
+
+// uploadFile serves files/upload. Only the size of the content is kept, the file reads back as
+// seeded content. Overwriting a file adds a revision.
+func uploadFile(req *dbxRequest) (any, error) {
+	var arg commitInfo
+	if err := req.decodeArg(&arg); err != nil {
+		return nil, err
+	}
+	if err := req.checkCommit(arg); err != nil {
+		return nil, err
+	}
+	size, err := req.countContent()
+	if err != nil {
+		return nil, err
+	}
+	node, err := req.commit(arg, size)
+	if err != nil {
+		var pathErr *pathError
+		if errors.As(err, &pathErr) {
+			// UploadError.path is an UploadWriteFailed: the WriteError and an upload session to retry with
+			return nil, endpointError(map[string]any{".tag": "path", "reason": nest(pathErr.tags...), "upload_session_id": ""})
+		}
+		return nil, err
+	}
+	return req.metadataOf(*node, false), nil
+}
+
+// checkCommit validates the arguments of an upload
+func (req *dbxRequest) checkCommit(info commitInfo) error {
+	if err := req.checkPath("path", info.Path); err != nil {
+		return err
+	}
+	if info.Mode != nil && info.Mode.Tag != "add" && info.Mode.Tag != "overwrite" && info.Mode.Tag != "update" {
+		return req.badInput("mode: unknown tag '%s'", info.Mode.Tag)
+	}
+	return nil
+}
+
+// commit saves uploaded content of size bytes. Failures are WriteErrors.
+func (req *dbxRequest) commit(info commitInfo, size int64) (*dbTypes.Node, error) {
+	mode := writeMode{Tag: "add"}
+	if info.Mode != nil {
+		mode = *info.Mode
+	}
+	parent, name, err := req.parentFor(info.Path)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := req.findChild(parent, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing != nil {
+		replace := (mode.Tag == "overwrite" && existing.Type != tables.NodeTypeFolder) ||
+			(mode.Tag == "update" && mode.Update == rev(req.generator.ContentSeed(*existing)))
+		switch {
+		case replace && existing.Type != tables.NodeTypeFile:
+			return nil, writeFailed("no_write_permission")
+		case replace:
+			resp, err := items.UpdateFile(req.tableManager, req.database, req.generator, items.UpdateFileRequest{TableID: req.tableID, ItemID: existing.ID, Size: size})
+			if err != nil {
+				return nil, req.writeFailure(err)
+			}
+			return &resp.Item, nil
+		case !info.Autorename:
+			return nil, conflict(existing)
+		}
+		if name, err = req.freeName(parent, name, false); err != nil {
+			return nil, err
+		}
+	} else if mode.Tag == "update" {
+		return nil, writeFailed("conflict", "file")
+	}
+	return req.createItem(parent, items.NewItem{Name: name, Type: tables.NodeTypeFile, Size: size})
+}
+
+type relocationArg struct {
+	FromPath   string `json:"from_path"`
+	ToPath     string `json:"to_path"`
+	Autorename bool   `json:"autorename"`
+}
+
+type relocationResult struct {
+	Metadata metadata `json:"metadata"`
+}
+
+// copyItem serves files/copy_v2. Copies share their originals' content.
+func copyItem(req *dbxRequest) (any, error) {
+	return relocate(req, false)
+}
+
+// moveItem serves files/move_v2. Moving an item to a name that only differs by case renames it.
+func moveItem(req *dbxRequest) (any, error) {
+	return relocate(req, true)
+}
+
+func relocate(req *dbxRequest, move bool) (any, error) {
+	var arg relocationArg
+	if err := req.decodeArg(&arg); err != nil {
+		return nil, err
+	}
+	if err := req.checkPath("from_path", arg.FromPath); err != nil {
+		return nil, err
+	}
+	if err := req.checkPath("to_path", arg.ToPath); err != nil {
+		return nil, err
+	}
+
+	node, err := req.lookup(arg.FromPath)
+	if err == nil && node.ParentID == "" {
+		err = lookupFailed("malformed_path")
+	}
+	if err != nil {
+		return nil, reportPath(err, "from_lookup", "from_write")
+	}
+	parent, name, err := req.parentFor(arg.ToPath)
+	if err != nil {
+		return nil, reportPath(err, "to", "to")
+	}
+	if node.Type == tables.NodeTypeFolder && (parent.ID == node.ID || strings.HasPrefix(parent.Path+"/", node.Path+"/")) {
+		if move {
+			return nil, endpointError(nest("cant_move_folder_into_itself"))
+		}
+		return nil, endpointError(nest("duplicated_or_nested_paths"))
+	}
+
+	existing, err := req.findChild(parent, name)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && !(move && existing.ID == node.ID) {
+		if !arg.Autorename {
+			return nil, reportPath(conflict(existing), "to", "to")
+		}
+		if name, err = req.freeName(parent, name, node.Type == tables.NodeTypeFolder); err != nil {
+			return nil, err
+		}
+	}
+
+	var moved dbTypes.Node
+	if move {
+		var resp *items.MoveItemResponse
+		if resp, err = items.MoveItem(req.tableManager, req.database, req.generator, items.MoveItemRequest{TableID: req.tableID, ItemID: node.ID, ParentID: parent.ID, Name: name}); err == nil {
+			moved = resp.Item
+		}
+	} else {
+		var resp *items.CopyItemResponse
+		if resp, err = items.CopyItem(req.tableManager, req.database, req.generator, items.CopyItemRequest{TableID: req.tableID, ItemID: node.ID, ParentID: parent.ID, Name: name}); err == nil {
+			moved = resp.Item
+		}
+	}
+	if err != nil {
+		return nil, req.relocationFailure(err)
+	}
+	return relocationResult{Metadata: req.metadataOf(moved, false)}, nil
+}
+
+// relocationFailure translates a failed move or copy into a RelocationError. Locks are on the source.
+func (req *dbxRequest) relocationFailure(err error) error {
+	var itemErr *items.ItemError
+	if errors.As(err, &itemErr) {
+		switch itemErr.Code {
+		case items.ErrCodeTooManyItems:
+			return endpointError(nest("too_many_files"))
+		case items.ErrCodeLocked:
+			return endpointError(nest("from_write", "no_write_permission"))
+		}
+	}
+	return reportPath(req.writeFailure(err), "from_lookup", "to")
+}
+
+type deleteArg struct {
+	Path      string `json:"path"`
+	ParentRev string `json:"parent_rev"`
+}
+
+type deleteResult struct {
+	Metadata metadata `json:"metadata"`
+}
+
+// deleteItem serves files/delete_v2. Folders are deleted with their contents; tables with a trash
+// keep them there.
+func deleteItem(req *dbxRequest) (any, error) {
+	var arg deleteArg
+	if err := req.decodeArg(&arg); err != nil {
+		return nil, err
+	}
+	if err := req.checkPath("path", arg.Path); err != nil {
+		return nil, err
+	}
+	node, err := req.lookup(arg.Path)
+	if err == nil && node.ParentID == "" {
+		err = lookupFailed("malformed_path")
+	}
+	if err != nil {
+		return nil, reportPath(err, "path_lookup", "path_write")
+	}
+	if arg.ParentRev != "" && (node.Type == tables.NodeTypeFolder || arg.ParentRev != rev(req.generator.ContentSeed(*node))) {
+		return nil, endpointError(nest("path_write", "conflict", "file"))
+	}
+
+	deleted := req.metadataOf(*node, false)
+	if _, err := items.DeleteItem(req.tableManager, req.database, req.generator, items.DeleteItemRequest{TableID: req.tableID, ItemID: node.ID}); err != nil {
+		var itemErr *items.ItemError
+		if errors.As(err, &itemErr) && itemErr.Code == items.ErrCodeTooManyItems {
+			return nil, endpointError(nest("too_many_files"))
+		}
+		return nil, reportPath(req.writeFailure(err), "path_lookup", "path_write")
+	}
+	return deleteResult{Metadata: deleted}, nil
+}
+
+type createFolderArg struct {
+	Path       string `json:"path"`
+	Autorename bool   `json:"autorename"`
+}
+
+type createFolderResult struct {
+	Metadata metadata `json:"metadata"`
+}
+
+// createFolder serves files/create_folder_v2, creating missing parents too
+func createFolder(req *dbxRequest) (any, error) {
+	var arg createFolderArg
+	if err := req.decodeArg(&arg); err != nil {
+		return nil, err
+	}
+	if err := req.checkPath("path", arg.Path); err != nil {
+		return nil, err
+	}
+	parent, name, err := req.parentFor(arg.Path)
+	if err != nil {
+		return nil, reportPath(err, "path", "path")
+	}
+	existing, err := req.findChild(parent, name)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		if !arg.Autorename {
+			return nil, reportPath(conflict(existing), "path", "path")
+		}
+		if name, err = req.freeName(parent, name, true); err != nil {
+			return nil, err
+		}
+	}
+	folder, err := req.createItem(parent, items.NewItem{Name: name, Type: tables.NodeTypeFolder})
+	if err != nil {
+		return nil, reportPath(err, "path", "path")
+	}
+	return createFolderResult{Metadata: req.metadataOf(*folder, false)}, nil
+}
@@ -0,0 +1,178 @@
+package dropbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	coreTables "github.com/Voltaic314/GhostFS/code/core/tables"
+	"github.com/Voltaic314/GhostFS/code/db"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	"github.com/go-chi/chi/v5"
+)
+
+// Prefix is where the Dropbox API is mounted. Each table is an account at <Prefix>/<table>, which
+// stands in for both api.dropboxapi.com and content.dropboxapi.com: clients call e.g.
+// http://localhost:8086/dropbox/nodes/2/files/list_folder.
+const Prefix = "/dropbox"
+
+// maxArgLength caps the JSON argument of a call
+const maxArgLength = 1 << 20
+
+// style is how an endpoint takes its argument and returns its result
+type style int
+
+const (
+	rpc      style = iota // JSON argument in the body, JSON result
+	upload                // argument in Dropbox-API-Arg, content in the body, JSON result
+	download              // argument in Dropbox-API-Arg, result in Dropbox-API-Result, content in the body
+)
+
+// endpoint is an API function. Download endpoints return a *downloadResult.
+type endpoint struct {
+	style style
+	serve func(req *dbxRequest) (any, error)
+}
+
+// endpoints are the supported API functions, by their path below /2/
+var endpoints = map[string]endpoint{
+	"files/list_folder":              {rpc, listFolder},
+	"files/list_folder/continue":     {rpc, listFolderContinue},
+	"files/get_metadata":             {rpc, getMetadata},
+	"files/download":                 {download, downloadFile},
+	"files/upload":                   {upload, uploadFile},
+	"files/upload_session/start":     {upload, startSession},
+	"files/upload_session/append_v2": {upload, appendSession},
+	"files/upload_session/append":    {upload, appendSessionV1},
+	"files/upload_session/finish":    {upload, finishSession},
+	"files/copy_v2":                  {rpc, copyItem},
+	"files/move_v2":                  {rpc, moveItem},
+	"files/delete_v2":                {rpc, deleteItem},
+	"files/create_folder_v2":         {rpc, createFolder},
+}
+
+// RegisterRoutes registers the Dropbox API v2 subset. Calls need a bearer token, any token is accepted.
+func RegisterRoutes(r chi.Router, server interface{}) {
+	h := &handler{server: server, sessions: newSessionStore()}
+	r.Post("/{table}/2/*", h.ServeHTTP)
+}
+
+// handler serves the API functions. Upload sessions in progress are kept in memory.
+type handler struct {
+	server   interface{}
+	sessions *sessionStore
+}
+
+// dbxRequest is one API call: the function, its decoded argument and the table (account) it's on
+type dbxRequest struct {
+	w            http.ResponseWriter
+	r            *http.Request
+	tableManager *tables.TableManager
+	database     *db.DB
+	generator    *tables.DeterministicGenerator
+	sessions     *sessionStore
+	function     string // e.g. "files/list_folder"
+	arg          []byte
+	tableID      string
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Cast server to get access to DB and TableManager
+	s := h.server.(interface {
+		GetTableManager() *tables.TableManager
+		GetDB() *db.DB
+		GetDeterministicGenerator() *tables.DeterministicGenerator
+	})
+	req := &dbxRequest{
+		w:            w,
+		r:            r,
+		tableManager: s.GetTableManager(),
+		database:     s.GetDB(),
+		generator:    s.GetDeterministicGenerator(),
+		sessions:     h.sessions,
+		function:     chi.URLParam(r, "*"),
+	}
+
+	ep, ok := endpoints[req.function]
+	if !ok {
+		req.writeError(&apiError{Status: http.StatusNotFound, Message: fmt.Sprintf("Unknown API function: %q", req.function)})
+		return
+	}
+	var result any
+	err := req.prepare(ep.style, chi.URLParam(r, "table"))
+	if err == nil {
+		result, err = ep.serve(req)
+	}
+	if err != nil {
+		req.writeError(err)
+		return
+	}
+
+	if ep.style == download {
+		req.writeDownload(result.(*downloadResult))
+		return
+	}
+	req.writeJSON(http.StatusOK, result)
+}
+
+// prepare checks the call's authorization and reads its argument and table
+func (req *dbxRequest) prepare(endpointStyle style, table string) error {
+	if !strings.HasPrefix(req.r.Header.Get("Authorization"), "Bearer ") && req.r.URL.Query().Get("authorization") == "" {
+		return &apiError{Status: http.StatusBadRequest, Message: req.message(`Must provide HTTP header "Authorization" or URL parameter "authorization".`)}
+	}
+
+	if endpointStyle == rpc {
+		contentType := req.r.Header.Get("Content-Type")
+		if !strings.HasPrefix(contentType, "application/json") && contentType != "text/plain; charset=dropbox-cors-hack" {
+			return &apiError{Status: http.StatusBadRequest, Message: req.message(fmt.Sprintf(`Bad HTTP "Content-Type" header: %q. Expecting one of "application/json", "application/json; charset=utf-8", "text/plain; charset=dropbox-cors-hack".`, contentType))}
+		}
+		arg, err := io.ReadAll(io.LimitReader(req.r.Body, maxArgLength))
+		if err != nil {
+			return err
+		}
+		req.arg = arg
+	} else {
+		arg := req.r.Header.Get("Dropbox-API-Arg")
+		if arg == "" {
+			arg = req.r.URL.Query().Get("arg")
+		}
+		if arg == "" {
+			return &apiError{Status: http.StatusBadRequest, Message: req.message(`Must provide HTTP header "Dropbox-API-Arg" or URL parameter "arg".`)}
+		}
+		req.arg = []byte(arg)
+	}
+	if endpointStyle == upload {
+		contentType := req.r.Header.Get("Content-Type")
+		if contentType != "application/octet-stream" && contentType != "text/plain; charset=dropbox-cors-hack" {
+			return &apiError{Status: http.StatusBadRequest, Message: req.message(fmt.Sprintf(`Bad HTTP "Content-Type" header: %q. Expecting one of "application/octet-stream", "text/plain; charset=dropbox-cors-hack".`, contentType))}
+		}
+	}
+
+	resp, err := coreTables.ListTables(req.database)
+	if err != nil {
+		return err
+	}
+	for _, info := range resp.Tables {
+		if info.TableName == table || info.TableID == table {
+			req.tableID = info.TableID
+			return nil
+		}
+	}
+	return &apiError{Status: http.StatusNotFound, Message: fmt.Sprintf("table %s not found", table)}
+}
+
+// decodeArg decodes the call's JSON argument. Unknown fields are ignored, like newer SDKs' fields.
+func (req *dbxRequest) decodeArg(v any) error {
+	if err := json.Unmarshal(req.arg, v); err != nil {
+		return req.badInput("could not decode input as JSON: %v", err)
+	}
+	return nil
+}
+
+func (req *dbxRequest) writeJSON(status int, v any) {
+	req.w.Header().Set("Content-Type", "application/json")
+	req.w.WriteHeader(status)
+	json.NewEncoder(req.w).Encode(v)
+}
@@ -0,0 +1,186 @@
+package dropbox
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+)
+
+const (
+	defaultListLimit = 2000 // entries per page when the client doesn't set a limit
+	maxListLimit     = 2000
+)
+
+// cursor is the position of a listing, passed to list_folder/continue as an opaque string. The
+// listing is a depth-first walk: every frame is a folder being listed and the next child to visit,
+// recursive listings push a frame for every folder they enter.
+type cursor struct {
+	TableID        string  `json:"t"`
+	Recursive      bool    `json:"r,omitempty"`
+	Limit          int     `json:"l"`
+	IncludeSharing bool    `json:"m,omitempty"`
+	Frames         []frame `json:"s"`
+}
+
+type frame struct {
+	FolderID string `json:"f"`
+	Next     int    `json:"n"`
+	LastID   string `json:"a,omitempty"` // the child visited before Next, to find the position again if the folder changed
+}
+
+func (c *cursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+type listFolderArg struct {
+	Path                            string `json:"path"`
+	Recursive                       bool   `json:"recursive"`
+	IncludeHasExplicitSharedMembers bool   `json:"include_has_explicit_shared_members"`
+	Limit                           *int   `json:"limit"`
+}
+
+type listFolderResult struct {
+	Entries []metadata `json:"entries"`
+	Cursor  string     `json:"cursor"`
+	HasMore bool       `json:"has_more"`
+}
+
+// listFolder serves files/list_folder. Recursive listings of a folder start with the folder itself.
+func listFolder(req *dbxRequest) (any, error) {
+	var arg listFolderArg
+	if err := req.decodeArg(&arg); err != nil {
+		return nil, err
+	}
+	if err := req.checkPath("path", arg.Path); err != nil {
+		return nil, err
+	}
+	c := &cursor{TableID: req.tableID, Recursive: arg.Recursive, Limit: defaultListLimit, IncludeSharing: arg.IncludeHasExplicitSharedMembers}
+	if arg.Limit != nil {
+		if *arg.Limit < 1 || *arg.Limit > maxListLimit {
+			return nil, req.badInput("limit: %d is not within the range [1, %d]", *arg.Limit, maxListLimit)
+		}
+		c.Limit = *arg.Limit
+	}
+
+	folder, err := req.lookup(arg.Path)
+	if err == nil && folder.Type != tables.NodeTypeFolder {
+		err = lookupFailed("not_folder")
+	}
+	if err != nil {
+		return nil, reportPath(err, "path", "path")
+	}
+
+	var entries []metadata
+	if arg.Recursive && folder.ParentID != "" {
+		entries = append(entries, req.metadataOf(*folder, c.IncludeSharing))
+	}
+	c.Frames = []frame{{FolderID: folder.ID}}
+	return req.listPage(c, entries)
+}
+
+type listFolderContinueArg struct {
+	Cursor string `json:"cursor"`
+}
+
+// listFolderContinue serves files/list_folder/continue. Once a listing is complete its cursor
+// returns no more entries: changes aren't tracked.
+func listFolderContinue(req *dbxRequest) (any, error) {
+	var arg listFolderContinueArg
+	if err := req.decodeArg(&arg); err != nil {
+		return nil, err
+	}
+	data, err := base64.RawURLEncoding.DecodeString(arg.Cursor)
+	var c cursor
+	if err == nil {
+		err = json.Unmarshal(data, &c)
+	}
+	if err != nil || c.Limit < 1 || c.Limit > maxListLimit {
+		return nil, req.badInput("cursor: invalid cursor")
+	}
+	if c.TableID != req.tableID {
+		return nil, endpointError(nest("reset"))
+	}
+	return req.listPage(&c, nil)
+}
+
+// listPage walks the listing from the cursor until a page is full
+func (req *dbxRequest) listPage(c *cursor, entries []metadata) (*listFolderResult, error) {
+	listed := make(map[string][]dbTypes.Node)
+	for len(entries) < c.Limit && len(c.Frames) > 0 {
+		top := &c.Frames[len(c.Frames)-1]
+		children, ok := listed[top.FolderID]
+		if !ok {
+			var err error
+			children, err = req.listChildren(&dbTypes.Node{ID: top.FolderID})
+			if err != nil {
+				// The folder is gone, the client has to start over
+				if errors.Is(err, sql.ErrNoRows) {
+					return nil, endpointError(nest("reset"))
+				}
+				return nil, err
+			}
+			listed[top.FolderID] = children
+			top.Next = resumePosition(children, top.Next, top.LastID)
+		}
+
+		if top.Next >= len(children) {
+			c.Frames = c.Frames[:len(c.Frames)-1]
+			continue
+		}
+		child := children[top.Next]
+		top.Next++
+		top.LastID = child.ID
+		entries = append(entries, req.metadataOf(child, c.IncludeSharing))
+		if c.Recursive && child.Type == tables.NodeTypeFolder {
+			c.Frames = append(c.Frames, frame{FolderID: child.ID})
+		}
+	}
+
+	if entries == nil {
+		entries = []metadata{}
+	}
+	return &listFolderResult{Entries: entries, Cursor: c.encode(), HasMore: len(c.Frames) > 0}, nil
+}
+
+// resumePosition finds where a listing continues in a folder: after the child visited last, which
+// may have moved if items were added or removed since
+func resumePosition(children []dbTypes.Node, next int, lastID string) int {
+	if lastID == "" || (next > 0 && next <= len(children) && children[next-1].ID == lastID) {
+		return next
+	}
+	for i, child := range children {
+		if child.ID == lastID {
+			return i + 1
+		}
+	}
+	return next
+}
+
+type getMetadataArg struct {
+	Path                            string `json:"path"`
+	IncludeHasExplicitSharedMembers bool   `json:"include_has_explicit_shared_members"`
+}
+
+// getMetadata serves files/get_metadata. Like Dropbox, the root has no metadata.
+func getMetadata(req *dbxRequest) (any, error) {
+	var arg getMetadataArg
+	if err := req.decodeArg(&arg); err != nil {
+		return nil, err
+	}
+	if err := req.checkPath("path", arg.Path); err != nil {
+		return nil, err
+	}
+	if arg.Path == "" {
+		return nil, req.badInput("path: The root folder is unsupported.")
+	}
+	node, err := req.lookup(arg.Path)
+	if err != nil {
+		return nil, reportPath(err, "path", "path")
+	}
+	return req.metadataOf(*node, arg.IncludeHasExplicitSharedMembers), nil
+}
@@ -0,0 +1,311 @@
+package dropbox
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Voltaic314/GhostFS/code/core/items"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+)
+
+const (
+	// hashBlockSize is the block size of Dropbox's content hash
+	hashBlockSize = 4 << 20
+	// maxHashedSize caps the files that get a content_hash. Hashing means generating the whole
+	// content, bigger files leave the (optional) field out.
+	maxHashedSize = 16 << 20
+	// timeFormat is Dropbox's timestamp format, without fractional seconds
+	timeFormat = "2006-01-02T15:04:05Z"
+)
+
+// metadata is Dropbox's Metadata union: FileMetadata, FolderMetadata or DeletedMetadata
+type metadata struct {
+	Tag                      string        `json:".tag"`
+	Name                     string        `json:"name"`
+	ID                       string        `json:"id,omitempty"`
+	PathLower                string        `json:"path_lower"`
+	PathDisplay              string        `json:"path_display"`
+	ClientModified           string        `json:"client_modified,omitempty"`
+	ServerModified           string        `json:"server_modified,omitempty"`
+	Rev                      string        `json:"rev,omitempty"`
+	Size                     *int64        `json:"size,omitempty"`
+	IsDownloadable           *bool         `json:"is_downloadable,omitempty"`
+	ContentHash              string        `json:"content_hash,omitempty"`
+	SymlinkInfo              *symlinkInfo  `json:"symlink_info,omitempty"`
+	FileLockInfo             *fileLockInfo `json:"file_lock_info,omitempty"`
+	HasExplicitSharedMembers *bool         `json:"has_explicit_shared_members,omitempty"`
+}
+
+type symlinkInfo struct {
+	Target string `json:"target"`
+}
+
+type fileLockInfo struct {
+	IsLockholder   bool   `json:"is_lockholder"`
+	LockholderName string `json:"lockholder_name"`
+	Created        string `json:"created"`
+}
+
+// metadataOf returns a node's metadata. Hard links are files with their target's content, symlinks
+// are files with symlink_info that can't be downloaded. Locks show up as file_lock_info, held by
+// someone else.
+func (req *dbxRequest) metadataOf(node dbTypes.Node, includeSharing bool) metadata {
+	m := metadata{
+		Tag:         "file",
+		Name:        node.Name,
+		ID:          "id:" + node.ID,
+		PathLower:   strings.ToLower(node.Path),
+		PathDisplay: node.Path,
+	}
+	if includeSharing {
+		shared := node.Sharing != nil && len(node.Sharing.Members) > 0
+		m.HasExplicitSharedMembers = &shared
+	}
+	if node.Type == tables.NodeTypeFolder {
+		m.Tag = "folder"
+		return m
+	}
+
+	size := node.Size
+	downloadable := node.Type != tables.NodeTypeSymlink
+	m.ClientModified = formatTime(node.UpdatedAt)
+	m.ServerModified = m.ClientModified
+	m.Rev = rev(req.generator.ContentSeed(node))
+	m.Size = &size
+	m.IsDownloadable = &downloadable
+	if node.Type == tables.NodeTypeSymlink {
+		m.SymlinkInfo = &symlinkInfo{Target: node.Target}
+	} else if size <= maxHashedSize {
+		m.ContentHash = contentHash(req.generator.OpenContent(node, size))
+	}
+	if node.Lock != nil {
+		m.FileLockInfo = &fileLockInfo{LockholderName: node.Lock.Owner, Created: formatTime(node.Lock.AcquiredAt)}
+	}
+	return m
+}
+
+// revisionMetadata returns the metadata of an older version of a file
+func (req *dbxRequest) revisionMetadata(node dbTypes.Node, revision dbTypes.Revision) metadata {
+	m := req.metadataOf(node, false)
+	size := revision.Size
+	m.Size = &size
+	m.Rev = rev(revision.ContentSeed)
+	m.ClientModified = formatTime(revision.ModifiedAt)
+	m.ServerModified = m.ClientModified
+	m.ContentHash = ""
+	if size <= maxHashedSize {
+		m.ContentHash = contentHash(tables.NewContentReader(revision.ContentSeed, size))
+	}
+	return m
+}
+
+// rev is the revision identifier of some content. Revisions are identified by their content seed,
+// so a file's rev changes with its content.
+func rev(contentSeed int64) string {
+	return fmt.Sprintf("%016x", uint64(contentSeed))
+}
+
+// contentHash computes Dropbox's content hash: the SHA-256 of the SHA-256 of every 4 MiB block
+func contentHash(content *tables.ContentReader) string {
+	var blockHashes []byte
+	block := make([]byte, min(hashBlockSize, content.Size()))
+	for offset := int64(0); offset < content.Size(); offset += hashBlockSize {
+		n, _ := content.ReadAt(block, offset)
+		sum := sha256.Sum256(block[:n])
+		blockHashes = append(blockHashes, sum[:]...)
+	}
+	sum := sha256.Sum256(blockHashes)
+	return hex.EncodeToString(sum[:])
+}
+
+// checkPath validates a path argument. Dropbox paths are "" (the root), "/a/b" or "id:<id>[/a/b]".
+func (req *dbxRequest) checkPath(field string, p string) error {
+	if p != "" && !strings.HasPrefix(p, "/") && !strings.HasPrefix(p, "id:") {
+		return req.badInput("%s: '%s' did not match pattern '(/(.|[\\r\\n])*)?|id:.*|(ns:[0-9]+(/.*)?)'", field, p)
+	}
+	if p == "/" {
+		return req.badInput(`%s: Specify the root folder as an empty string rather than as "/".`, field)
+	}
+	return nil
+}
+
+// splitPath resolves the start of a path (the root or an ID) and returns the names below it
+func (req *dbxRequest) splitPath(p string) (*dbTypes.Node, []string, error) {
+	var start *dbTypes.Node
+	rest := p
+	if strings.HasPrefix(p, "id:") {
+		var id string
+		id, rest, _ = strings.Cut(strings.TrimPrefix(p, "id:"), "/")
+		resp, err := items.GetItem(req.tableManager, req.database, items.GetItemRequest{TableID: req.tableID, ItemID: id})
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, lookupFailed("not_found")
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		start = &resp.Item
+	} else {
+		resp, err := items.GetRoot(req.tableManager, req.database, items.GetRootRequest{TableID: req.tableID})
+		if err != nil {
+			return nil, nil, err
+		}
+		start = &resp.Root
+		rest = strings.TrimPrefix(rest, "/")
+	}
+
+	if rest == "" {
+		return start, nil, nil
+	}
+	names := strings.Split(rest, "/")
+	for _, name := range names {
+		if name == "" || name == "." || name == ".." {
+			return nil, nil, lookupFailed("malformed_path")
+		}
+	}
+	return start, names, nil
+}
+
+// lookup returns the node at a path. Like Dropbox, paths are case-insensitive.
+func (req *dbxRequest) lookup(p string) (*dbTypes.Node, error) {
+	node, names, err := req.splitPath(p)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		if node.Type != tables.NodeTypeFolder {
+			return nil, lookupFailed("not_found")
+		}
+		if node, err = req.findChild(node, name); err != nil {
+			return nil, err
+		}
+		if node == nil {
+			return nil, lookupFailed("not_found")
+		}
+	}
+	return node, nil
+}
+
+// findChild returns the child of a folder with a name, preferring an exact match over one that only
+// differs by case, or nil. Shortcuts have no Dropbox equivalent and aren't found.
+func (req *dbxRequest) findChild(folder *dbTypes.Node, name string) (*dbTypes.Node, error) {
+	children, err := req.listChildren(folder)
+	if err != nil {
+		return nil, err
+	}
+	var match *dbTypes.Node
+	for i := range children {
+		switch {
+		case children[i].Name == name:
+			return &children[i], nil
+		case match == nil && strings.EqualFold(children[i].Name, name):
+			match = &children[i]
+		}
+	}
+	return match, nil
+}
+
+// listChildren lists a folder without its shortcuts
+func (req *dbxRequest) listChildren(folder *dbTypes.Node) ([]dbTypes.Node, error) {
+	resp, err := items.ListItems(req.tableManager, req.database, req.generator, items.ListItemsRequest{TableID: req.tableID, FolderID: folder.ID})
+	if err != nil {
+		return nil, err
+	}
+	children := resp.Items[:0]
+	for _, child := range resp.Items {
+		if child.Type != tables.NodeTypeShortcut {
+			children = append(children, child)
+		}
+	}
+	return children, nil
+}
+
+// parentFor returns the folder a new item at a path goes into and the item's name. Missing folders
+// on the way are created, like Dropbox does.
+func (req *dbxRequest) parentFor(p string) (*dbTypes.Node, string, error) {
+	folder, names, err := req.splitPath(p)
+	if err != nil {
+		// WriteErrors have no not_found, paths that start at an unknown ID are malformed
+		var pathErr *pathError
+		if errors.As(err, &pathErr) {
+			return nil, "", writeFailed("malformed_path")
+		}
+		return nil, "", err
+	}
+	if len(names) == 0 {
+		return nil, "", writeFailed("malformed_path")
+	}
+
+	for _, name := range names[:len(names)-1] {
+		if folder.Type != tables.NodeTypeFolder {
+			return nil, "", writeFailed("conflict", "file_ancestor")
+		}
+		child, err := req.findChild(folder, name)
+		if err != nil {
+			return nil, "", err
+		}
+		if child == nil {
+			if child, err = req.createItem(folder, items.NewItem{Name: name, Type: tables.NodeTypeFolder}); err != nil {
+				return nil, "", err
+			}
+		}
+		folder = child
+	}
+	if folder.Type != tables.NodeTypeFolder {
+		return nil, "", writeFailed("conflict", "file_ancestor")
+	}
+	return folder, names[len(names)-1], nil
+}
+
+// conflict returns the WriteError of a name that is taken by an existing item
+func conflict(existing *dbTypes.Node) *pathError {
+	if existing.Type == tables.NodeTypeFolder {
+		return writeFailed("conflict", "folder")
+	}
+	return writeFailed("conflict", "file")
+}
+
+// freeName returns a name that isn't taken in a folder: the name itself or, like Dropbox's
+// autorename, "name (1).ext", "name (2).ext", ...
+func (req *dbxRequest) freeName(folder *dbTypes.Node, name string, isFolder bool) (string, error) {
+	ext := path.Ext(name)
+	if isFolder || ext == name {
+		ext = ""
+	}
+	base := strings.TrimSuffix(name, ext)
+	candidate := name
+	for n := 1; ; n++ {
+		existing, err := req.findChild(folder, candidate)
+		if err != nil || existing == nil {
+			return candidate, err
+		}
+		candidate = fmt.Sprintf("%s (%d)%s", base, n, ext)
+	}
+}
+
+// createItem creates one file or folder. Failures are WriteErrors.
+func (req *dbxRequest) createItem(parent *dbTypes.Node, item items.NewItem) (*dbTypes.Node, error) {
+	resp, err := items.CreateItems(req.tableManager, req.database, req.generator, items.CreateItemsRequest{
+		TableID:  req.tableID,
+		ParentID: parent.ID,
+		Items:    []items.NewItem{item},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result := resp.Results[0]; result.Error != nil {
+		return nil, req.writeFailure(result.Error)
+	}
+	return resp.Results[0].Item, nil
+}
+
+// formatTime formats a timestamp like Dropbox
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeFormat)
+}
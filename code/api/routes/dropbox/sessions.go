@@ -0,0 +1,166 @@
+package dropbox
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// uploadSession is an upload in progress. Like single uploads, the content is counted, not kept.
+type uploadSession struct {
+	id      string
+	tableID string
+	offset  int64
+	closed  bool
+}
+
+// sessionStore keeps the upload sessions in progress. Sessions don't survive a restart.
+type sessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]*uploadSession
+}
+
+func newSessionStore() *sessionStore {
+	return &sessionStore{sessions: make(map[string]*uploadSession)}
+}
+
+// sessionLookupError is an UploadSessionLookupError, e.g. {".tag": "incorrect_offset", "correct_offset": 8}
+func sessionLookupError(tag string, session *uploadSession) map[string]any {
+	value := map[string]any{".tag": tag}
+	if tag == "incorrect_offset" {
+		value["correct_offset"] = session.offset
+	}
+	return value
+}
+
+// appendContent adds the call's content to a session at offset. Failures are UploadSessionLookupErrors.
+func (req *dbxRequest) appendContent(sessionID string, offset int64, closeSession bool) (*uploadSession, map[string]any, error) {
+	req.sessions.mu.Lock()
+	session, ok := req.sessions.sessions[sessionID]
+	req.sessions.mu.Unlock()
+	switch {
+	case !ok || session.tableID != req.tableID:
+		return nil, sessionLookupError("not_found", nil), nil
+	case session.closed:
+		return nil, sessionLookupError("closed", nil), nil
+	case offset != session.offset:
+		return nil, sessionLookupError("incorrect_offset", session), nil
+	}
+
+	size, err := req.countContent()
+	if err != nil {
+		var apiErr *apiError
+		if errors.As(err, &apiErr) && apiErr.Value != nil {
+			return nil, sessionLookupError("payload_too_large", nil), nil
+		}
+		return nil, nil, err
+	}
+	req.sessions.mu.Lock()
+	defer req.sessions.mu.Unlock()
+	if offset != session.offset {
+		return nil, sessionLookupError("incorrect_offset", session), nil
+	}
+	session.offset += size
+	session.closed = closeSession
+	return session, nil, nil
+}
+
+type startSessionArg struct {
+	Close bool `json:"close"`
+}
+
+type startSessionResult struct {
+	SessionID string `json:"session_id"`
+}
+
+// startSession serves files/upload_session/start
+func startSession(req *dbxRequest) (any, error) {
+	var arg startSessionArg
+	if err := req.decodeArg(&arg); err != nil {
+		return nil, err
+	}
+	size, err := req.countContent()
+	if err != nil {
+		return nil, err
+	}
+	session := &uploadSession{id: "pid_upload_session:" + uuid.NewString(), tableID: req.tableID, offset: size, closed: arg.Close}
+	req.sessions.mu.Lock()
+	req.sessions.sessions[session.id] = session
+	req.sessions.mu.Unlock()
+	return startSessionResult{SessionID: session.id}, nil
+}
+
+type sessionCursor struct {
+	SessionID string `json:"session_id"`
+	Offset    int64  `json:"offset"`
+}
+
+type appendSessionArg struct {
+	Cursor sessionCursor `json:"cursor"`
+	Close  bool          `json:"close"`
+}
+
+// appendSession serves files/upload_session/append_v2
+func appendSession(req *dbxRequest) (any, error) {
+	var arg appendSessionArg
+	if err := req.decodeArg(&arg); err != nil {
+		return nil, err
+	}
+	_, lookupErr, err := req.appendContent(arg.Cursor.SessionID, arg.Cursor.Offset, arg.Close)
+	if err != nil {
+		return nil, err
+	}
+	if lookupErr != nil {
+		return nil, endpointError(lookupErr)
+	}
+	return nil, nil
+}
+
+// appendSessionV1 serves the deprecated files/upload_session/append, which takes the cursor as argument
+func appendSessionV1(req *dbxRequest) (any, error) {
+	var arg sessionCursor
+	if err := req.decodeArg(&arg); err != nil {
+		return nil, err
+	}
+	_, lookupErr, err := req.appendContent(arg.SessionID, arg.Offset, false)
+	if err != nil {
+		return nil, err
+	}
+	if lookupErr != nil {
+		return nil, endpointError(lookupErr)
+	}
+	return nil, nil
+}
+
+type finishSessionArg struct {
+	Cursor sessionCursor `json:"cursor"`
+	Commit commitInfo    `json:"commit"`
+}
+
+// finishSession serves files/upload_session/finish: the last content and the commit of the file
+func finishSession(req *dbxRequest) (any, error) {
+	var arg finishSessionArg
+	if err := req.decodeArg(&arg); err != nil {
+		return nil, err
+	}
+	if err := req.checkCommit(arg.Commit); err != nil {
+		return nil, err
+	}
+	session, lookupErr, err := req.appendContent(arg.Cursor.SessionID, arg.Cursor.Offset, true)
+	if err != nil {
+		return nil, err
+	}
+	if lookupErr != nil {
+		return nil, endpointError(map[string]any{".tag": "lookup_failed", "lookup_failed": lookupErr})
+	}
+
+	node, err := req.commit(arg.Commit, session.offset)
+	if err != nil {
+		return nil, reportPath(err, "path", "path")
+	}
+	req.sessions.mu.Lock()
+	delete(req.sessions.sessions, session.id)
+	req.sessions.mu.Unlock()
+	return req.metadataOf(*node, false), nil
+}
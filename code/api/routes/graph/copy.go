@@ -0,0 +1,181 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/Voltaic314/GhostFS/code/core/items"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// Copies run in the background like in Graph: the copy call returns 202 with a monitor URL, which
+// reports the progress and the new item's ID once it's done.
+
+// copyRetention is how long finished copies can still be monitored
+const copyRetention = time.Hour
+
+// copyOperation is a copy in progress or finished
+type copyOperation struct {
+	id         string
+	tableID    string
+	status     string // inProgress, completed or failed
+	resourceID string
+	failure    *apiError
+	finished   time.Time
+}
+
+// copyStore keeps the copies. Copies don't survive a restart.
+type copyStore struct {
+	mu         sync.Mutex
+	operations map[string]*copyOperation
+}
+
+func newCopyStore() *copyStore {
+	return &copyStore{operations: make(map[string]*copyOperation)}
+}
+
+// copyStatus is the status a monitor URL reports
+type copyStatus struct {
+	Operation          string     `json:"operation"`
+	Status             string     `json:"status"`
+	PercentageComplete float64    `json:"percentageComplete"`
+	ResourceID         string     `json:"resourceId,omitempty"`
+	Error              *copyError `json:"error,omitempty"`
+}
+
+type copyError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// copyItem starts copying an item, with its subtree for folders, to a folder of the drive
+// (parentReference, default: the item's folder) under a name (default: its own)
+func (req *graphRequest) copyItem(addr address) (any, error) {
+	var body struct {
+		Name            string `json:"name"`
+		ParentReference *struct {
+			DriveID string `json:"driveId"`
+			ID      string `json:"id"`
+			Path    string `json:"path"`
+		} `json:"parentReference"`
+	}
+	if err := decodeBody(req.r.Body, &body); err != nil {
+		return nil, err
+	}
+	behavior := req.r.URL.Query().Get("@microsoft.graph.conflictBehavior")
+	switch behavior {
+	case "", "fail", "rename", "replace":
+	default:
+		return nil, invalidRequest("Invalid @microsoft.graph.conflictBehavior: %s. Values are fail, rename or replace.", behavior)
+	}
+	node, err := req.resolve(addr)
+	if err != nil {
+		return nil, err
+	}
+	if node.ParentID == "" {
+		return nil, invalidRequest("The root folder can't be copied.")
+	}
+	parentID := node.ParentID
+	if ref := body.ParentReference; ref != nil {
+		parent, err := req.reference(ref.DriveID, ref.ID, ref.Path)
+		if err != nil {
+			return nil, err
+		}
+		parentID = parent.ID
+	}
+	name := node.Name
+	if body.Name != "" {
+		name = body.Name
+	}
+
+	copies := req.h.copies
+	operation := &copyOperation{id: uuid.NewString(), tableID: req.tableID, status: "inProgress"}
+	copies.mu.Lock()
+	for id, finished := range copies.operations {
+		if !finished.finished.IsZero() && time.Since(finished.finished) > copyRetention {
+			delete(copies.operations, id)
+		}
+	}
+	copies.operations[operation.id] = operation
+	copies.mu.Unlock()
+
+	go func() {
+		resourceID, err := req.copy(node.ID, parentID, name, behavior)
+		copies.mu.Lock()
+		defer copies.mu.Unlock()
+		operation.finished = time.Now()
+		if err == nil {
+			operation.status, operation.resourceID = "completed", resourceID
+			return
+		}
+		operation.status = "failed"
+		if !errors.As(err, &operation.failure) {
+			fmt.Printf("❌ Graph copy of %s failed: %v\n", node.ID, err)
+			operation.failure = &apiError{Status: http.StatusInternalServerError, Code: "generalException", Message: "An unspecified error has occurred."}
+		}
+	}()
+
+	return accepted{location: req.baseURL() + "/monitor/" + req.tableID + "/" + operation.id}, nil
+}
+
+// copy copies an item, resolving a name that is taken like createItem does, and returns the copy's ID
+func (req *graphRequest) copy(itemID string, parentID string, name string, behavior string) (string, error) {
+	if behavior == "replace" {
+		parent, err := items.GetItem(req.tableManager, req.database, items.GetItemRequest{TableID: req.tableID, ItemID: parentID})
+		if err != nil {
+			return "", err
+		}
+		existing, err := items.GetItemByPath(req.tableManager, req.database, req.generator, items.GetItemByPathRequest{TableID: req.tableID, Path: path.Join(parent.Item.Path, name)})
+		var itemErr *items.ItemError
+		switch {
+		case errors.As(err, &itemErr) && itemErr.Code == items.ErrCodeNotFound:
+		case err != nil:
+			return "", itemFailure(err)
+		case existing.Item.ID != itemID:
+			if _, err := items.DeleteItem(req.tableManager, req.database, req.generator, items.DeleteItemRequest{TableID: req.tableID, ItemID: existing.Item.ID}); err != nil {
+				return "", itemFailure(err)
+			}
+		}
+	}
+
+	candidate := name
+	for i := 1; ; i++ {
+		resp, err := items.CopyItem(req.tableManager, req.database, req.generator, items.CopyItemRequest{TableID: req.tableID, ItemID: itemID, ParentID: parentID, Name: candidate})
+		if err == nil {
+			return resp.Item.ID, nil
+		}
+		var itemErr *items.ItemError
+		if behavior != "rename" || i > maxRenames || !errors.As(err, &itemErr) || itemErr.Code != items.ErrCodeNameConflict {
+			return "", itemFailure(err)
+		}
+		candidate = numberedName(name, i)
+	}
+}
+
+// monitorCopy serves a copy's monitor URL: 202 while it's in progress, then its outcome
+func monitorCopy(req *graphRequest) (any, error) {
+	copies := req.h.copies
+	copies.mu.Lock()
+	defer copies.mu.Unlock()
+	operation, ok := copies.operations[chi.URLParam(req.r, "operationId")]
+	if !ok || operation.tableID != req.tableID {
+		return nil, itemNotFound()
+	}
+
+	status := copyStatus{Operation: "itemCopy", Status: operation.status}
+	switch operation.status {
+	case "inProgress":
+		return accepted{resource: status}, nil
+	case "failed":
+		status.Error = &copyError{Code: operation.failure.Code, Message: operation.failure.Message}
+	default:
+		status.PercentageComplete = 100
+		status.ResourceID = operation.resourceID
+	}
+	return status, nil
+}
@@ -0,0 +1,75 @@
+package graph
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Voltaic314/GhostFS/code/core/items"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+)
+
+// Delta tokens are positions in the table's change journal. Without a token, a delta query starts
+// at the beginning of the journal: the items changed through any API, not the whole (generated)
+// drive. token=latest skips to the current position.
+
+// delta serves root/delta: the items changed since the token, in their current state. Deleted and
+// trashed items have the deleted facet.
+func (req *graphRequest) delta(addr address) (any, error) {
+	node, err := req.resolve(addr)
+	if err != nil {
+		return nil, err
+	}
+	if node.ParentID != "" {
+		return nil, notSupported("Delta is only supported on the root folder.")
+	}
+	params := req.r.URL.Query()
+	pageSize, err := pageSizeParam(params.Get("$top"))
+	if err != nil {
+		return nil, err
+	}
+	current, err := items.GetChangeToken(req.tableManager, req.database, items.GetChangeTokenRequest{TableID: req.tableID})
+	if err != nil {
+		return nil, err
+	}
+
+	var after int64
+	switch token := params.Get("token"); token {
+	case "":
+	case "latest":
+		return collection{Value: []*driveItem{}, DeltaLink: req.link(map[string]string{"token": strconv.FormatInt(current.Token, 10)})}, nil
+	default:
+		after, err = strconv.ParseInt(token, 10, 64)
+		if err != nil || after < 0 || after > current.Token {
+			return nil, &apiError{Status: http.StatusGone, Code: "resyncRequired", Message: "The delta token is invalid. Start over without a token."}
+		}
+	}
+
+	resp, err := items.ListChanges(req.tableManager, req.database, items.ListChangesRequest{TableID: req.tableID, After: after, Limit: pageSize})
+	if err != nil {
+		return nil, err
+	}
+	value := []*driveItem{}
+	for _, change := range resp.Changes {
+		if change.Item != nil && change.Item.Type == tables.NodeTypeSymlink {
+			continue
+		}
+		if change.Removed || change.Trashed != nil {
+			value = append(value, &driveItem{
+				ID:              change.ItemID,
+				ParentReference: &itemReference{DriveID: req.tableID, DriveType: driveType},
+				Deleted:         &deletedFacet{State: "deleted"},
+			})
+			continue
+		}
+		value = append(value, req.itemOf(*change.Item))
+	}
+
+	page := collection{Value: value}
+	link := req.link(map[string]string{"token": strconv.FormatInt(resp.Next, 10)})
+	if resp.HasMore {
+		page.NextLink = link
+	} else {
+		page.DeltaLink = link
+	}
+	return page, nil
+}
@@ -0,0 +1,111 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Voltaic314/GhostFS/code/core/items"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	"github.com/google/uuid"
+)
+
+// apiError is a failed call, sent in Graph's error format:
+// {"error": {"code": "itemNotFound", "message": "...", "innerError": {"date": "...", "request-id": "..."}}}
+type apiError struct {
+	Status     int
+	Code       string
+	Message    string
+	RetryAfter time.Duration // sent as Retry-After when throttled
+}
+
+func (e *apiError) Error() string {
+	return e.Message
+}
+
+// itemNotFound is Graph's error for an unknown item, path or session
+func itemNotFound() *apiError {
+	return &apiError{Status: http.StatusNotFound, Code: "itemNotFound", Message: "The resource could not be found."}
+}
+
+// invalidRequest is a 400 for a request that is malformed or incorrect
+func invalidRequest(format string, args ...any) *apiError {
+	return &apiError{Status: http.StatusBadRequest, Code: "invalidRequest", Message: fmt.Sprintf(format, args...)}
+}
+
+// notSupported is a 400 for a request the API understands but doesn't implement
+func notSupported(format string, args ...any) *apiError {
+	return &apiError{Status: http.StatusBadRequest, Code: "notSupported", Message: fmt.Sprintf(format, args...)}
+}
+
+// activityLimitReached is Graph's throttling error, with how long to wait
+func activityLimitReached(wait time.Duration) *apiError {
+	return &apiError{
+		Status:     http.StatusTooManyRequests,
+		Code:       "activityLimitReached",
+		Message:    "The app or user has been throttled.",
+		RetryAfter: wait,
+	}
+}
+
+// itemFailure translates a failed item operation into the closest Graph error
+func itemFailure(err error) error {
+	var itemErr *items.ItemError
+	if !errors.As(err, &itemErr) {
+		return err
+	}
+	switch itemErr.Code {
+	case items.ErrCodeNotFound:
+		return itemNotFound()
+	case items.ErrCodeNameConflict:
+		return &apiError{Status: http.StatusConflict, Code: "nameAlreadyExists", Message: "The specified item name already exists."}
+	case items.ErrCodeNotAFolder, items.ErrCodeInvalidDest:
+		return invalidRequest("%s", itemErr.Message)
+	case items.ErrCodeInvalidName, tables.ViolationInvalidCharacter, tables.ViolationReservedName,
+		tables.ViolationNameTooLong, tables.ViolationPathTooLong, tables.ViolationMaxDepth:
+		return invalidRequest("The provided name is not valid: %s", itemErr.Message)
+	case items.ErrCodeInvalidSize:
+		return invalidRequest("%s", itemErr.Message)
+	case items.ErrCodeTooManyItems:
+		return &apiError{Status: http.StatusInsufficientStorage, Code: "quotaLimitReached", Message: itemErr.Message}
+	case items.ErrCodeLocked:
+		return &apiError{Status: http.StatusLocked, Code: "resourceLocked", Message: itemErr.Message}
+	}
+	return &apiError{Status: http.StatusForbidden, Code: "accessDenied", Message: itemErr.Message}
+}
+
+type innerError struct {
+	Date            string `json:"date"`
+	RequestID       string `json:"request-id"`
+	ClientRequestID string `json:"client-request-id,omitempty"`
+}
+
+type errorBody struct {
+	Code       string     `json:"code"`
+	Message    string     `json:"message"`
+	InnerError innerError `json:"innerError"`
+}
+
+// writeError sends an error in Graph's format. Other errors are 500s.
+func (req *graphRequest) writeError(err error) {
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) {
+		fmt.Printf("❌ Graph %s %s failed: %v\n", req.r.Method, req.r.URL.Path, err)
+		apiErr = &apiError{Status: http.StatusInternalServerError, Code: "generalException", Message: "An unspecified error has occurred."}
+	}
+	if apiErr.RetryAfter > 0 {
+		req.w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(apiErr.RetryAfter.Seconds()))))
+	}
+	req.writeJSON(apiErr.Status, map[string]errorBody{"error": {
+		Code:    apiErr.Code,
+		Message: apiErr.Message,
+		InnerError: innerError{
+			Date:            time.Now().UTC().Format(time.RFC3339),
+			RequestID:       uuid.NewString(),
+			ClientRequestID: req.r.Header.Get("client-request-id"),
+		},
+	}})
+}
@@ -0,0 +1,202 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	coreTables "github.com/Voltaic314/GhostFS/code/core/tables"
+	"github.com/Voltaic314/GhostFS/code/db"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+	"github.com/go-chi/chi/v5"
+)
+
+// Prefix is where the Graph API is mounted, standing in for https://graph.microsoft.com: clients
+// call e.g. http://localhost:8086/graph/v1.0/drives/nodes/root/children. Each table is a drive.
+const Prefix = "/graph"
+
+// RegisterRoutes registers the driveItem API subset. Calls need a bearer token, any token is
+// accepted. Upload sessions, download URLs and copy monitors are pre-authenticated like in Graph.
+func RegisterRoutes(r chi.Router, server interface{}) {
+	h := &handler{server: server, uploads: newUploadStore(), copies: newCopyStore()}
+	r.Get("/v1.0/drives", h.serve(listDrives, true))
+	r.Get("/v1.0/drives/{table}", h.serve(getDrive, true))
+	// Items are addressed as root, items/<id>, root:/<path>: or items/<id>:/<path>:, optionally
+	// followed by /<action>, which chi patterns can't express
+	r.HandleFunc("/v1.0/drives/{table}/*", h.serve(dispatch, true))
+
+	r.Get("/download/{table}/{itemId}", h.serve(download, false))
+	r.Put("/upload/{table}/{sessionId}", h.serve(uploadFragment, false))
+	r.Get("/upload/{table}/{sessionId}", h.serve(getUploadSession, false))
+	r.Delete("/upload/{table}/{sessionId}", h.serve(cancelUploadSession, false))
+	r.Get("/monitor/{table}/{operationId}", h.serve(monitorCopy, false))
+}
+
+// handler serves the API methods. Upload sessions and copies are kept in memory.
+type handler struct {
+	server   interface{}
+	uploads  *uploadStore
+	copies   *copyStore
+	throttle throttle
+}
+
+// graphRequest is one API call, on a table (drive) unless it lists the drives
+type graphRequest struct {
+	w            http.ResponseWriter
+	r            *http.Request
+	h            *handler
+	tableManager *tables.TableManager
+	database     *db.DB
+	generator    *tables.DeterministicGenerator
+	tableID      string
+	tableName    string
+}
+
+// result is what a method returns besides a JSON resource
+type (
+	// noContent is an empty 204 response
+	noContent struct{}
+	// created is a resource created by the call (201)
+	created struct{ resource any }
+	// accepted is a call that isn't complete (202): an upload in progress, or an operation that
+	// goes on in the background, with its monitor URL
+	accepted struct {
+		location string
+		resource any
+	}
+	// redirect sends the client elsewhere (302), e.g. to a download URL
+	redirect struct{ location string }
+	// content is a file's content to send
+	content struct{ node dbTypes.Node }
+)
+
+func (h *handler) serve(method func(req *graphRequest) (any, error), authenticated bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Cast server to get access to DB and TableManager
+		s := h.server.(interface {
+			GetTableManager() *tables.TableManager
+			GetDB() *db.DB
+			GetDeterministicGenerator() *tables.DeterministicGenerator
+		})
+		req := &graphRequest{
+			w:            w,
+			r:            r,
+			h:            h,
+			tableManager: s.GetTableManager(),
+			database:     s.GetDB(),
+			generator:    s.GetDeterministicGenerator(),
+		}
+
+		var result any
+		err := req.prepare(authenticated)
+		if err == nil {
+			result, err = method(req)
+		}
+		if err != nil {
+			req.writeError(err)
+			return
+		}
+		req.writeResult(result)
+	}
+}
+
+// prepare throttles the call, checks its authorization and resolves its table
+func (req *graphRequest) prepare(authenticated bool) error {
+	if config := req.tableManager.GetGraphConfig(); config != nil && config.RequestsPerMinute > 0 {
+		if wait := req.h.throttle.take(config.RequestsPerMinute); wait > 0 {
+			return activityLimitReached(wait)
+		}
+	}
+	if authenticated && !strings.HasPrefix(req.r.Header.Get("Authorization"), "Bearer ") {
+		return &apiError{Status: http.StatusUnauthorized, Code: "InvalidAuthenticationToken", Message: "Access token is empty."}
+	}
+
+	table := chi.URLParam(req.r, "table")
+	if table == "" {
+		return nil
+	}
+	resp, err := coreTables.ListTables(req.database)
+	if err != nil {
+		return err
+	}
+	for _, info := range resp.Tables {
+		if info.TableName == table || info.TableID == table {
+			req.tableID, req.tableName = info.TableID, info.TableName
+			return nil
+		}
+	}
+	return &apiError{Status: http.StatusNotFound, Code: "itemNotFound", Message: fmt.Sprintf("Drive not found: %s.", table)}
+}
+
+// baseURL is the absolute URL of the API's mount point, for the links in responses
+func (req *graphRequest) baseURL() string {
+	scheme := "http"
+	if req.r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + req.r.Host + Prefix
+}
+
+// writeResult sends a method's result
+func (req *graphRequest) writeResult(result any) {
+	switch result := result.(type) {
+	case noContent:
+		req.w.WriteHeader(http.StatusNoContent)
+	case created:
+		req.writeJSON(http.StatusCreated, result.resource)
+	case accepted:
+		if result.location != "" {
+			req.w.Header().Set("Location", result.location)
+		}
+		req.writeJSON(http.StatusAccepted, result.resource)
+	case redirect:
+		http.Redirect(req.w, req.r, result.location, http.StatusFound)
+	case content:
+		req.w.Header().Set("Content-Type", mimeTypeOf(result.node))
+		req.w.Header().Set("ETag", eTagOf(result.node))
+		http.ServeContent(req.w, req.r, "", result.node.UpdatedAt, req.generator.OpenContent(result.node, result.node.Size))
+	default:
+		req.writeJSON(http.StatusOK, result)
+	}
+}
+
+func (req *graphRequest) writeJSON(status int, v any) {
+	req.w.Header().Set("Content-Type", "application/json; odata.metadata=minimal; charset=utf-8")
+	req.w.WriteHeader(status)
+	if v == nil {
+		return
+	}
+	encoder := json.NewEncoder(req.w)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(v)
+}
+
+// drive is Graph's drive resource
+type drive struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	DriveType string `json:"driveType"`
+}
+
+// driveType is what the tables are reported as, OneDrive for Business / SharePoint
+const driveType = "business"
+
+// listDrives lists the tables as drives
+func listDrives(req *graphRequest) (any, error) {
+	resp, err := coreTables.ListTables(req.database)
+	if err != nil {
+		return nil, err
+	}
+	drives := []drive{}
+	for _, info := range resp.Tables {
+		drives = append(drives, drive{ID: info.TableID, Name: info.TableName, DriveType: driveType})
+	}
+	return collection{Value: drives}, nil
+}
+
+// getDrive returns the table as a drive
+func getDrive(req *graphRequest) (any, error) {
+	return drive{ID: req.tableID, Name: req.tableName, DriveType: driveType}, nil
+}
@@ -0,0 +1,588 @@
+package graph
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Voltaic314/GhostFS/code/core/items"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	// timeFormat is Graph's timestamp format
+	timeFormat = "2006-01-02T15:04:05.000Z"
+
+	defaultPageSize = 200
+	maxPageSize     = 1000
+	// maxRenames caps the names tried by the rename conflict behavior
+	maxRenames = 1000
+	// maxMetadataLength caps the JSON body of a request
+	maxMetadataLength = 1 << 20
+)
+
+// driveItem is Graph's driveItem resource. Folders, files (including hard links) and shortcuts (as
+// remote items) are served; symlinks have no OneDrive equivalent and aren't.
+type driveItem struct {
+	ID                   string         `json:"id"`
+	Name                 string         `json:"name,omitempty"`
+	Size                 int64          `json:"size"`
+	ETag                 string         `json:"eTag,omitempty"`
+	CTag                 string         `json:"cTag,omitempty"`
+	CreatedDateTime      string         `json:"createdDateTime,omitempty"`
+	LastModifiedDateTime string         `json:"lastModifiedDateTime,omitempty"`
+	ParentReference      *itemReference `json:"parentReference,omitempty"`
+	File                 *fileFacet     `json:"file,omitempty"`
+	Folder               *folderFacet   `json:"folder,omitempty"`
+	Root                 *struct{}      `json:"root,omitempty"`
+	RemoteItem           *remoteItem    `json:"remoteItem,omitempty"`
+	Shared               *sharedFacet   `json:"shared,omitempty"`
+	Deleted              *deletedFacet  `json:"deleted,omitempty"`
+	DownloadURL          string         `json:"@microsoft.graph.downloadUrl,omitempty"`
+}
+
+type itemReference struct {
+	DriveID   string `json:"driveId"`
+	DriveType string `json:"driveType"`
+	ID        string `json:"id,omitempty"`
+	Path      string `json:"path,omitempty"`
+}
+
+type fileFacet struct {
+	MimeType string `json:"mimeType"`
+}
+
+type folderFacet struct{}
+
+type remoteItem struct {
+	ID              string        `json:"id"`
+	ParentReference itemReference `json:"parentReference"`
+}
+
+type sharedFacet struct {
+	Scope string `json:"scope"`
+}
+
+type deletedFacet struct {
+	State string `json:"state"`
+}
+
+// collection is a page of a collection. nextLink is set while there are more pages; delta queries
+// end with a deltaLink instead.
+type collection struct {
+	Value     any    `json:"value"`
+	NextLink  string `json:"@odata.nextLink,omitempty"`
+	DeltaLink string `json:"@odata.deltaLink,omitempty"`
+}
+
+// itemOf returns a node's driveItem
+func (req *graphRequest) itemOf(node dbTypes.Node) *driveItem {
+	item := &driveItem{
+		ID:                   node.ID,
+		Name:                 node.Name,
+		ETag:                 eTagOf(node),
+		CreatedDateTime:      formatTime(node.CreatedAt),
+		LastModifiedDateTime: formatTime(node.UpdatedAt),
+		ParentReference:      &itemReference{DriveID: req.tableID, DriveType: driveType},
+	}
+	if node.ParentID == "" {
+		item.Root = &struct{}{}
+	} else {
+		item.ParentReference.ID = node.ParentID
+		item.ParentReference.Path = "/drives/" + req.tableID + "/root:" + strings.TrimSuffix(path.Dir(node.Path), "/")
+	}
+	if !node.Sharing.IsEmpty() {
+		item.Shared = &sharedFacet{Scope: "users"}
+	}
+
+	switch node.Type {
+	case tables.NodeTypeFolder:
+		item.Folder = &folderFacet{}
+	case tables.NodeTypeShortcut:
+		item.RemoteItem = &remoteItem{ID: node.Target, ParentReference: itemReference{DriveID: req.tableID, DriveType: driveType}}
+	default:
+		item.Size = node.Size
+		item.CTag = fmt.Sprintf(`"c:{%s},%016x"`, node.ID, uint64(req.generator.ContentSeed(node)))
+		item.File = &fileFacet{MimeType: mimeTypeOf(node)}
+		item.DownloadURL = req.baseURL() + "/download/" + req.tableID + "/" + node.ID
+	}
+	return item
+}
+
+// eTagOf returns an item's eTag, which changes when the item is modified
+func eTagOf(node dbTypes.Node) string {
+	return fmt.Sprintf(`"{%s},%d"`, node.ID, node.UpdatedAt.UnixMilli())
+}
+
+// mimeTypeOf returns the type of a file's extension
+func mimeTypeOf(node dbTypes.Node) string {
+	if mimeType, _, err := mime.ParseMediaType(mime.TypeByExtension(path.Ext(node.Name))); err == nil {
+		return mimeType
+	}
+	return "application/octet-stream"
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeFormat)
+}
+
+// address is where a call points: root or items/<id>, optionally a path below it (root:/a/b:), and
+// the action on the item (children, content, ...)
+type address struct {
+	base   string // "root" or an item ID
+	path   string // path below the base ("" = the base itself)
+	action string
+}
+
+// parseAddress parses what follows /drives/<table>/
+func parseAddress(rest string) (address, error) {
+	var addr address
+	var remainder string
+	switch {
+	case rest == "root" || strings.HasPrefix(rest, "root:") || strings.HasPrefix(rest, "root/"):
+		addr.base, remainder = "root", rest[len("root"):]
+	case strings.HasPrefix(rest, "items/"):
+		id := rest[len("items/"):]
+		end := strings.IndexAny(id, ":/")
+		if end < 0 {
+			end = len(id)
+		}
+		addr.base, remainder = id[:end], id[end:]
+	}
+	if addr.base == "" {
+		return addr, invalidRequest("Invalid request. Items are addressed as root, items/{item-id} or root:/{path}:.")
+	}
+
+	switch {
+	case strings.HasPrefix(remainder, ":"):
+		// The path ends at the last ":/" (followed by the action) or at a trailing ":"
+		p := remainder[1:]
+		if end := strings.LastIndex(p, ":/"); end >= 0 {
+			p, addr.action = p[:end], p[end+2:]
+		} else {
+			p = strings.TrimSuffix(p, ":")
+		}
+		addr.path = strings.Trim(p, "/")
+	case strings.HasPrefix(remainder, "/"):
+		addr.action = remainder[1:]
+	case remainder != "":
+		return addr, invalidRequest("Invalid request. Unexpected segment %s.", remainder)
+	}
+	// Actions may be written as OData functions, e.g. microsoft.graph.delta()
+	addr.action = strings.TrimSuffix(strings.TrimPrefix(addr.action, "microsoft.graph."), "()")
+	if strings.Contains(addr.action, "/") {
+		return addr, invalidRequest("Unsupported segment type: %s.", addr.action)
+	}
+	return addr, nil
+}
+
+// dispatch serves the calls on an item
+func dispatch(req *graphRequest) (any, error) {
+	rest := chi.URLParam(req.r, "*")
+	if req.r.URL.RawPath != "" {
+		// chi matches the escaped path when there is one
+		unescaped, err := url.PathUnescape(rest)
+		if err != nil {
+			return nil, invalidRequest("Invalid request path.")
+		}
+		rest = unescaped
+	}
+	addr, err := parseAddress(rest)
+	if err != nil {
+		return nil, err
+	}
+
+	method := req.r.Method
+	switch {
+	case addr.action == "" && method == http.MethodGet:
+		return req.getItem(addr)
+	case addr.action == "" && method == http.MethodPatch:
+		return req.updateItem(addr)
+	case addr.action == "" && method == http.MethodDelete:
+		return req.deleteItem(addr)
+	case addr.action == "children" && method == http.MethodGet:
+		return req.listChildren(addr)
+	case addr.action == "children" && method == http.MethodPost:
+		return req.createFolder(addr)
+	case addr.action == "content" && method == http.MethodGet:
+		return req.getContent(addr)
+	case addr.action == "content" && method == http.MethodPut:
+		return req.putContent(addr)
+	case addr.action == "createUploadSession" && method == http.MethodPost:
+		return req.createUploadSession(addr)
+	case addr.action == "copy" && method == http.MethodPost:
+		return req.copyItem(addr)
+	case addr.action == "delta" && method == http.MethodGet:
+		return req.delta(addr)
+	case addr.action == "", addr.action == "children", addr.action == "content", addr.action == "createUploadSession",
+		addr.action == "copy", addr.action == "delta":
+		return nil, &apiError{Status: http.StatusMethodNotAllowed, Code: "invalidRequest", Message: fmt.Sprintf("%s is not allowed on %s.", method, addr.action)}
+	}
+	return nil, invalidRequest("Unsupported segment type: %s.", addr.action)
+}
+
+// resolve returns the item an address points at
+func (req *graphRequest) resolve(addr address) (*dbTypes.Node, error) {
+	var node *dbTypes.Node
+	if addr.base == "root" {
+		resp, err := items.GetRoot(req.tableManager, req.database, items.GetRootRequest{TableID: req.tableID})
+		if err != nil {
+			return nil, err
+		}
+		node = &resp.Root
+	} else {
+		resp, err := items.GetItem(req.tableManager, req.database, items.GetItemRequest{TableID: req.tableID, ItemID: addr.base})
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, itemNotFound()
+		}
+		if err != nil {
+			return nil, err
+		}
+		node = &resp.Item
+	}
+
+	if addr.path != "" {
+		resp, err := items.GetItemByPath(req.tableManager, req.database, req.generator, items.GetItemByPathRequest{TableID: req.tableID, Path: path.Join(node.Path, addr.path)})
+		if err != nil {
+			return nil, itemFailure(err)
+		}
+		node = &resp.Item
+	}
+	if node.Type == tables.NodeTypeSymlink {
+		return nil, itemNotFound()
+	}
+	return node, nil
+}
+
+// folder returns the folder an address points at
+func (req *graphRequest) folder(addr address) (*dbTypes.Node, error) {
+	node, err := req.resolve(addr)
+	if err != nil {
+		return nil, err
+	}
+	if node.Type != tables.NodeTypeFolder {
+		return nil, invalidRequest("The item is not a folder.")
+	}
+	return node, nil
+}
+
+// getItem returns an item. Items addressed by ID are looked up again by path for their sharing,
+// which lookups by ID don't fill in.
+func (req *graphRequest) getItem(addr address) (any, error) {
+	node, err := req.resolve(addr)
+	if err != nil {
+		return nil, err
+	}
+	if addr.path == "" && node.ParentID != "" {
+		resp, err := items.GetItemByPath(req.tableManager, req.database, req.generator, items.GetItemByPathRequest{TableID: req.tableID, Path: node.Path})
+		if err != nil {
+			return nil, itemFailure(err)
+		}
+		node = &resp.Item
+	}
+	return req.itemOf(*node), nil
+}
+
+// itemPatch is the body of an update: a new name and/or parent
+type itemPatch struct {
+	Name            *string `json:"name"`
+	ParentReference *struct {
+		DriveID string `json:"driveId"`
+		ID      string `json:"id"`
+		Path    string `json:"path"`
+	} `json:"parentReference"`
+}
+
+// decodeBody reads the JSON body of a request. An empty body leaves v as it is.
+func decodeBody(body io.Reader, v any) error {
+	data, err := io.ReadAll(io.LimitReader(body, maxMetadataLength))
+	if err != nil {
+		return err
+	}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return invalidRequest("Invalid request. The body is not valid JSON.")
+	}
+	return nil
+}
+
+// updateItem renames and/or moves an item
+func (req *graphRequest) updateItem(addr address) (any, error) {
+	var patch itemPatch
+	if err := decodeBody(req.r.Body, &patch); err != nil {
+		return nil, err
+	}
+	node, err := req.resolve(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	move := items.MoveItemRequest{TableID: req.tableID, ItemID: node.ID}
+	if patch.Name != nil && *patch.Name != node.Name {
+		if *patch.Name == "" {
+			return nil, invalidRequest("The provided name cannot be empty.")
+		}
+		move.Name = *patch.Name
+	}
+	if ref := patch.ParentReference; ref != nil {
+		parent, err := req.reference(ref.DriveID, ref.ID, ref.Path)
+		if err != nil {
+			return nil, err
+		}
+		if parent.ID != node.ParentID {
+			move.ParentID = parent.ID
+		}
+	}
+	if move.Name == "" && move.ParentID == "" {
+		return req.itemOf(*node), nil
+	}
+	if node.ParentID == "" {
+		return nil, &apiError{Status: http.StatusForbidden, Code: "accessDenied", Message: "The root folder can't be renamed or moved."}
+	}
+	resp, err := items.MoveItem(req.tableManager, req.database, req.generator, move)
+	if err != nil {
+		return nil, itemFailure(err)
+	}
+	return req.itemOf(resp.Item), nil
+}
+
+// reference resolves a parentReference to a folder of this drive, by ID or by path
+// (/drive/root:/a/b or /drives/<id>/root:/a/b)
+func (req *graphRequest) reference(driveID string, itemID string, itemPath string) (*dbTypes.Node, error) {
+	if driveID != "" && driveID != req.tableID && driveID != req.tableName {
+		return nil, notSupported("Items can't be moved or copied to another drive.")
+	}
+	switch {
+	case itemID != "":
+		return req.folder(address{base: itemID})
+	case itemPath != "":
+		_, rootPath, found := strings.Cut(itemPath, "root:")
+		if !found {
+			return nil, invalidRequest("Invalid parentReference path: %s.", itemPath)
+		}
+		return req.folder(address{base: "root", path: strings.Trim(rootPath, "/")})
+	}
+	return nil, invalidRequest("The parentReference needs an id or a path.")
+}
+
+// deleteItem deletes an item. Tables with a trash keep it there, like OneDrive's recycle bin.
+func (req *graphRequest) deleteItem(addr address) (any, error) {
+	node, err := req.resolve(addr)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := items.DeleteItem(req.tableManager, req.database, req.generator, items.DeleteItemRequest{TableID: req.tableID, ItemID: node.ID}); err != nil {
+		return nil, itemFailure(err)
+	}
+	return noContent{}, nil
+}
+
+// listChildren lists a folder's children, in the folder's order, $top at a time
+func (req *graphRequest) listChildren(addr address) (any, error) {
+	params := req.r.URL.Query()
+	pageSize, err := pageSizeParam(params.Get("$top"))
+	if err != nil {
+		return nil, err
+	}
+	offset := 0
+	if token := params.Get("$skiptoken"); token != "" {
+		if offset, err = strconv.Atoi(token); err != nil || offset < 0 {
+			return nil, invalidRequest("Invalid $skiptoken: %s.", token)
+		}
+	}
+	folder, err := req.folder(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := items.ListItems(req.tableManager, req.database, req.generator, items.ListItemsRequest{TableID: req.tableID, FolderID: folder.ID})
+	if err != nil {
+		return nil, err
+	}
+	var children []dbTypes.Node
+	for _, child := range resp.Items {
+		if child.Type != tables.NodeTypeSymlink {
+			children = append(children, child)
+		}
+	}
+
+	page := collection{}
+	value := []*driveItem{}
+	for i := offset; i < len(children) && i < offset+pageSize; i++ {
+		value = append(value, req.itemOf(children[i]))
+	}
+	page.Value = value
+	if offset+pageSize < len(children) {
+		page.NextLink = req.link(map[string]string{"$skiptoken": strconv.Itoa(offset + pageSize)})
+	}
+	return page, nil
+}
+
+// pageSizeParam reads a $top parameter
+func pageSizeParam(value string) (int, error) {
+	if value == "" {
+		return defaultPageSize, nil
+	}
+	pageSize, err := strconv.Atoi(value)
+	if err != nil || pageSize < 1 || pageSize > maxPageSize {
+		return 0, invalidRequest("Invalid $top: %s. Values must be between 1 and %d.", value, maxPageSize)
+	}
+	return pageSize, nil
+}
+
+// link returns the absolute URL of the current call with some query parameters replaced
+func (req *graphRequest) link(params map[string]string) string {
+	query := req.r.URL.Query()
+	for name, value := range params {
+		query.Set(name, value)
+	}
+	return req.baseURL() + strings.TrimPrefix(req.r.URL.Path, Prefix) + "?" + query.Encode()
+}
+
+// newFolder is the body of a folder creation
+type newFolder struct {
+	Name             string           `json:"name"`
+	Folder           *json.RawMessage `json:"folder"`
+	ConflictBehavior string           `json:"@microsoft.graph.conflictBehavior"`
+}
+
+// createFolder creates a folder in a folder. Files are created by uploading them.
+func (req *graphRequest) createFolder(addr address) (any, error) {
+	var body newFolder
+	if err := decodeBody(req.r.Body, &body); err != nil {
+		return nil, err
+	}
+	if body.Folder == nil {
+		return nil, invalidRequest("Only folders can be created in children. Upload files with PUT content or an upload session.")
+	}
+	if body.Name == "" {
+		return nil, invalidRequest("The provided name cannot be empty.")
+	}
+	parent, err := req.folder(addr)
+	if err != nil {
+		return nil, err
+	}
+	behavior := body.ConflictBehavior
+	if behavior == "" {
+		behavior = req.r.URL.Query().Get("@microsoft.graph.conflictBehavior")
+	}
+	node, _, err := req.createItem(parent, body.Name, tables.NodeTypeFolder, 0, behavior)
+	if err != nil {
+		return nil, err
+	}
+	return created{req.itemOf(*node)}, nil
+}
+
+// createItem creates a file of a size or a folder in a folder. A name that is taken is resolved
+// the way the conflict behavior says: fail (the default), rename ("name 1.txt") or replace. Replacing
+// a file with a file adds a revision to it, other items are deleted first. created is false for
+// a replaced file.
+func (req *graphRequest) createItem(parent *dbTypes.Node, name string, itemType string, size int64, behavior string) (node *dbTypes.Node, created bool, err error) {
+	switch behavior {
+	case "", "fail", "rename":
+	case "replace":
+		existing, err := items.GetItemByPath(req.tableManager, req.database, req.generator, items.GetItemByPathRequest{TableID: req.tableID, Path: path.Join(parent.Path, name)})
+		var itemErr *items.ItemError
+		switch {
+		case errors.As(err, &itemErr) && itemErr.Code == items.ErrCodeNotFound:
+		case err != nil:
+			return nil, false, itemFailure(err)
+		case existing.Item.Type == tables.NodeTypeFile && itemType == tables.NodeTypeFile:
+			resp, err := items.UpdateFile(req.tableManager, req.database, req.generator, items.UpdateFileRequest{TableID: req.tableID, ItemID: existing.Item.ID, Size: size})
+			if err != nil {
+				return nil, false, itemFailure(err)
+			}
+			return &resp.Item, false, nil
+		default:
+			if _, err := items.DeleteItem(req.tableManager, req.database, req.generator, items.DeleteItemRequest{TableID: req.tableID, ItemID: existing.Item.ID}); err != nil {
+				return nil, false, itemFailure(err)
+			}
+		}
+	default:
+		return nil, false, invalidRequest("Invalid @microsoft.graph.conflictBehavior: %s. Values are fail, rename or replace.", behavior)
+	}
+
+	candidate := name
+	for i := 1; ; i++ {
+		resp, err := items.CreateItems(req.tableManager, req.database, req.generator, items.CreateItemsRequest{
+			TableID:  req.tableID,
+			ParentID: parent.ID,
+			Items:    []items.NewItem{{Name: candidate, Type: itemType, Size: size}},
+		})
+		if err != nil {
+			return nil, false, itemFailure(err)
+		}
+		result := resp.Results[0]
+		if result.Error == nil {
+			return result.Item, true, nil
+		}
+		var itemErr *items.ItemError
+		if behavior != "rename" || i > maxRenames || !errors.As(result.Error, &itemErr) || itemErr.Code != items.ErrCodeNameConflict {
+			return nil, false, itemFailure(result.Error)
+		}
+		candidate = numberedName(name, i)
+	}
+}
+
+// numberedName returns the name the rename conflict behavior tries next, e.g. "report 1.pdf"
+func numberedName(name string, n int) string {
+	ext := path.Ext(name)
+	if ext == name {
+		ext = ""
+	}
+	return fmt.Sprintf("%s %d%s", strings.TrimSuffix(name, ext), n, ext)
+}
+
+// getContent redirects to the download URL of a file, like Graph does
+func (req *graphRequest) getContent(addr address) (any, error) {
+	node, err := req.resolve(addr)
+	if err != nil {
+		return nil, err
+	}
+	if node.Type == tables.NodeTypeFolder || node.Type == tables.NodeTypeShortcut {
+		return nil, itemNotFound()
+	}
+	return redirect{req.itemOf(*node).DownloadURL}, nil
+}
+
+// download serves a download URL: the content of a file, with Range support
+func download(req *graphRequest) (any, error) {
+	resp, err := items.GetItem(req.tableManager, req.database, items.GetItemRequest{TableID: req.tableID, ItemID: chi.URLParam(req.r, "itemId")})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, itemNotFound()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if resp.Item.Type != tables.NodeTypeFile && resp.Item.Type != tables.NodeTypeHardlink {
+		return nil, itemNotFound()
+	}
+	return content{resp.Item}, nil
+}
+
+// putContent uploads a file in one request: to a path (root:/a/b.txt:/content, created or
+// replaced) or to an existing file (items/<id>/content). The content is read and counted, not stored.
+func (req *graphRequest) putContent(addr address) (any, error) {
+	target, err := req.uploadTarget(addr, req.r.URL.Query().Get("@microsoft.graph.conflictBehavior"))
+	if err != nil {
+		return nil, err
+	}
+	size, err := io.Copy(io.Discard, req.r.Body)
+	if err != nil {
+		return nil, err
+	}
+	return req.commitUpload(target, size)
+}
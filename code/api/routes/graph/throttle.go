@@ -0,0 +1,37 @@
+package graph
+
+import (
+	"sync"
+	"time"
+)
+
+// throttle is a token bucket shared by all calls: a full minute's worth of requests can be made at
+// once, then they're refilled at the configured rate
+type throttle struct {
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// take takes a request from the bucket. It returns 0 if the request may proceed, else how long
+// until it may.
+func (t *throttle) take(perMinute int) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	capacity := float64(perMinute)
+	rate := capacity / 60 // per second
+	if t.last.IsZero() {
+		t.tokens = capacity
+	} else {
+		t.tokens = min(capacity, t.tokens+now.Sub(t.last).Seconds()*rate)
+	}
+	t.last = now
+
+	if t.tokens < 1 {
+		return time.Duration((1 - t.tokens) / rate * float64(time.Second))
+	}
+	t.tokens--
+	return 0
+}
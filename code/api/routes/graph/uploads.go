@@ -0,0 +1,274 @@
+package graph
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Voltaic314/GhostFS/code/core/items"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// Large files are uploaded through an upload session: createUploadSession returns an upload URL,
+// which takes the content in byte ranges (PUT with Content-Range: bytes <first>-<last>/<total>).
+// The content is read and counted, not stored.
+
+// uploadSessionLifetime is how long an upload session stays valid after its last fragment
+const uploadSessionLifetime = 24 * time.Hour
+
+// uploadTarget is what an upload writes: the content of an existing file, or a file of a folder
+type uploadTarget struct {
+	fileID   string // file whose content is replaced ("" = a file in the parent)
+	parentID string
+	name     string
+	behavior string // conflict behavior for the name in the parent
+}
+
+// uploadSession is an upload session in progress
+type uploadSession struct {
+	id       string
+	tableID  string
+	target   uploadTarget
+	total    int64 // -1 until the first fragment
+	received int64
+	expires  time.Time
+}
+
+// uploadStore keeps the upload sessions in progress. Sessions don't survive a restart.
+type uploadStore struct {
+	mu       sync.Mutex
+	sessions map[string]*uploadSession
+}
+
+func newUploadStore() *uploadStore {
+	return &uploadStore{sessions: make(map[string]*uploadSession)}
+}
+
+// uploadStatus is Graph's uploadSession resource
+type uploadStatus struct {
+	UploadURL          string   `json:"uploadUrl,omitempty"`
+	ExpirationDateTime string   `json:"expirationDateTime"`
+	NextExpectedRanges []string `json:"nextExpectedRanges"`
+}
+
+func (session *uploadSession) status() uploadStatus {
+	return uploadStatus{
+		ExpirationDateTime: formatTime(session.expires),
+		NextExpectedRanges: []string{fmt.Sprintf("%d-", session.received)},
+	}
+}
+
+// uploadTarget resolves where an upload to an address goes. Uploads replace an existing file of
+// the same name unless the conflict behavior says otherwise.
+func (req *graphRequest) uploadTarget(addr address, behavior string) (uploadTarget, error) {
+	switch behavior {
+	case "":
+		behavior = "replace"
+	case "fail", "rename", "replace":
+	default:
+		return uploadTarget{}, invalidRequest("Invalid @microsoft.graph.conflictBehavior: %s. Values are fail, rename or replace.", behavior)
+	}
+
+	if addr.path == "" {
+		node, err := req.resolve(addr)
+		if err != nil {
+			return uploadTarget{}, err
+		}
+		if node.Type != tables.NodeTypeFile {
+			return uploadTarget{}, invalidRequest("Only files can have content uploaded.")
+		}
+		return uploadTarget{fileID: node.ID}, nil
+	}
+
+	dir, name := path.Split(addr.path)
+	parent, err := req.folder(address{base: addr.base, path: strings.Trim(dir, "/")})
+	if err != nil {
+		return uploadTarget{}, err
+	}
+	return uploadTarget{parentID: parent.ID, name: name, behavior: behavior}, nil
+}
+
+// commitUpload writes a completed upload: a new revision of the file, or a file created in the
+// parent (201)
+func (req *graphRequest) commitUpload(target uploadTarget, size int64) (any, error) {
+	if target.fileID != "" {
+		resp, err := items.UpdateFile(req.tableManager, req.database, req.generator, items.UpdateFileRequest{TableID: req.tableID, ItemID: target.fileID, Size: size})
+		if err != nil {
+			return nil, itemFailure(err)
+		}
+		return req.itemOf(resp.Item), nil
+	}
+
+	parent, err := items.GetItem(req.tableManager, req.database, items.GetItemRequest{TableID: req.tableID, ItemID: target.parentID})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, itemNotFound()
+	}
+	if err != nil {
+		return nil, err
+	}
+	node, isNew, err := req.createItem(&parent.Item, target.name, tables.NodeTypeFile, size, target.behavior)
+	if err != nil {
+		return nil, err
+	}
+	if isNew {
+		return created{req.itemOf(*node)}, nil
+	}
+	return req.itemOf(*node), nil
+}
+
+// createUploadSession starts an upload session for a path (root:/a/b.txt:/createUploadSession) or
+// an existing file (items/<id>/createUploadSession)
+func (req *graphRequest) createUploadSession(addr address) (any, error) {
+	var body struct {
+		Item struct {
+			ConflictBehavior string `json:"@microsoft.graph.conflictBehavior"`
+		} `json:"item"`
+	}
+	if err := decodeBody(req.r.Body, &body); err != nil {
+		return nil, err
+	}
+	behavior := body.Item.ConflictBehavior
+	if behavior == "" {
+		behavior = req.r.URL.Query().Get("@microsoft.graph.conflictBehavior")
+	}
+	target, err := req.uploadTarget(addr, behavior)
+	if err != nil {
+		return nil, err
+	}
+
+	session := &uploadSession{
+		id:      uuid.NewString(),
+		tableID: req.tableID,
+		target:  target,
+		total:   -1,
+		expires: time.Now().Add(uploadSessionLifetime),
+	}
+	req.h.uploads.mu.Lock()
+	req.h.uploads.sessions[session.id] = session
+	req.h.uploads.mu.Unlock()
+
+	status := session.status()
+	status.UploadURL = req.baseURL() + "/upload/" + req.tableID + "/" + session.id
+	return status, nil
+}
+
+// session returns the upload session of an upload URL
+func (req *graphRequest) session() (*uploadSession, error) {
+	req.h.uploads.mu.Lock()
+	defer req.h.uploads.mu.Unlock()
+	session, ok := req.h.uploads.sessions[chi.URLParam(req.r, "sessionId")]
+	if !ok || session.tableID != req.tableID {
+		return nil, itemNotFound()
+	}
+	if time.Now().After(session.expires) {
+		delete(req.h.uploads.sessions, session.id)
+		return nil, itemNotFound()
+	}
+	return session, nil
+}
+
+// uploadFragment takes a byte range of an upload session. Fragments must come in order; the upload
+// completes with the last byte.
+func uploadFragment(req *graphRequest) (any, error) {
+	session, err := req.session()
+	if err != nil {
+		return nil, err
+	}
+	first, last, total, err := parseContentRange(req.r.Header.Get("Content-Range"))
+	if err != nil {
+		return nil, err
+	}
+	size, err := io.Copy(io.Discard, io.LimitReader(req.r.Body, last-first+2))
+	if err != nil {
+		return nil, err
+	}
+	if size != last-first+1 {
+		return nil, invalidRequest("The fragment is %d bytes, Content-Range says %d.", size, last-first+1)
+	}
+
+	uploads := req.h.uploads
+	uploads.mu.Lock()
+	switch {
+	case session.total >= 0 && total != session.total:
+		uploads.mu.Unlock()
+		return nil, invalidRequest("The upload's total size changed from %d to %d.", session.total, total)
+	case first != session.received:
+		uploads.mu.Unlock()
+		return nil, &apiError{
+			Status:  http.StatusRequestedRangeNotSatisfiable,
+			Code:    "invalidRange",
+			Message: fmt.Sprintf("The fragment starts at %d, the next expected byte is %d.", first, session.received),
+		}
+	}
+	session.total = total
+	session.received += size
+	session.expires = time.Now().Add(uploadSessionLifetime)
+	complete := session.received == session.total
+	status := session.status()
+	uploads.mu.Unlock()
+	if !complete {
+		return accepted{resource: status}, nil
+	}
+
+	result, err := req.commitUpload(session.target, session.total)
+	if err != nil {
+		return nil, err
+	}
+	uploads.mu.Lock()
+	delete(uploads.sessions, session.id)
+	uploads.mu.Unlock()
+	return result, nil
+}
+
+// parseContentRange parses the Content-Range of a fragment: bytes <first>-<last>/<total>
+func parseContentRange(header string) (first int64, last int64, total int64, err error) {
+	if header == "" {
+		return 0, 0, 0, invalidRequest("The Content-Range header is required.")
+	}
+	invalid := invalidRequest("Invalid Content-Range header: %s.", header)
+	spec, found := strings.CutPrefix(header, "bytes ")
+	chunk, totalText, hasTotal := strings.Cut(spec, "/")
+	firstText, lastText, isRange := strings.Cut(chunk, "-")
+	if !found || !hasTotal || !isRange {
+		return 0, 0, 0, invalid
+	}
+	first, err1 := strconv.ParseInt(firstText, 10, 64)
+	last, err2 := strconv.ParseInt(lastText, 10, 64)
+	total, err3 := strconv.ParseInt(totalText, 10, 64)
+	if err1 != nil || err2 != nil || err3 != nil || first < 0 || last < first || last >= total {
+		return 0, 0, 0, invalid
+	}
+	return first, last, total, nil
+}
+
+// getUploadSession returns the status of an upload session
+func getUploadSession(req *graphRequest) (any, error) {
+	session, err := req.session()
+	if err != nil {
+		return nil, err
+	}
+	req.h.uploads.mu.Lock()
+	defer req.h.uploads.mu.Unlock()
+	return session.status(), nil
+}
+
+// cancelUploadSession cancels an upload session
+func cancelUploadSession(req *graphRequest) (any, error) {
+	session, err := req.session()
+	if err != nil {
+		return nil, err
+	}
+	req.h.uploads.mu.Lock()
+	delete(req.h.uploads.sessions, session.id)
+	req.h.uploads.mu.Unlock()
+	return noContent{}, nil
+}
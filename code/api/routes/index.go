@@ -3,7 +3,12 @@ package routes
 import (
 	"time"
 
+	"github.com/Voltaic314/GhostFS/code/api/routes/dav"
+	"github.com/Voltaic314/GhostFS/code/api/routes/drive"
+	"github.com/Voltaic314/GhostFS/code/api/routes/dropbox"
+	"github.com/Voltaic314/GhostFS/code/api/routes/graph"
 	"github.com/Voltaic314/GhostFS/code/api/routes/items"
+	"github.com/Voltaic314/GhostFS/code/api/routes/s3"
 	"github.com/Voltaic314/GhostFS/code/api/routes/tables"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -23,4 +28,19 @@ func RegisterAllRoutes(r chi.Router, server interface{}) {
 	r.Route("/items", func(r chi.Router) {
 		items.RegisterRoutes(r, server)
 	})
+	r.Route(s3.Prefix, func(r chi.Router) {
+		s3.RegisterRoutes(r, server)
+	})
+	r.Route(dav.Prefix, func(r chi.Router) {
+		dav.RegisterRoutes(r, server)
+	})
+	r.Route(dropbox.Prefix, func(r chi.Router) {
+		dropbox.RegisterRoutes(r, server)
+	})
+	r.Route(drive.Prefix, func(r chi.Router) {
+		drive.RegisterRoutes(r, server)
+	})
+	r.Route(graph.Prefix, func(r chi.Router) {
+		graph.RegisterRoutes(r, server)
+	})
 }
@@ -0,0 +1,86 @@
+package items
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Voltaic314/GhostFS/code/core/items"
+	"github.com/Voltaic314/GhostFS/code/db"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	"github.com/Voltaic314/GhostFS/code/types/api"
+)
+
+// Request/Response structs for this endpoint
+type CopyItemRequest struct {
+	ID       string `json:"id"`
+	ParentID string `json:"parent_id,omitempty"` // Destination folder (omit to copy next to the original)
+	Name     string `json:"name,omitempty"`      // Name of the copy (omit to keep the name)
+}
+
+type CopyRequest struct {
+	TableID string            `json:"table_id"`
+	Items   []CopyItemRequest `json:"items"`
+}
+
+type CopiedItem struct {
+	SourceID string `json:"source_id"`
+	ID       string `json:"id,omitempty"`
+	ParentID string `json:"parent_id,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Path     string `json:"path,omitempty"`
+	Copied   int    `json:"copied,omitempty"` // Nodes written, including descendants
+	Error    string `json:"error,omitempty"`
+	Code     string `json:"code,omitempty"` // e.g. "name_conflict", "too_many_items"
+}
+
+type CopyResponseData struct {
+	TableID string       `json:"table_id"`
+	Items   []CopiedItem `json:"items"`
+}
+
+// HandleCopy handles requests to copy one or more items (folders with their contents)
+func HandleCopy(w http.ResponseWriter, r *http.Request, server interface{}) {
+	var req CopyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.BadRequest(w, "Invalid JSON")
+		return
+	}
+
+	// Cast server to get access to DB and TableManager
+	s := server.(interface {
+		GetTableManager() *tables.TableManager
+		GetDB() *db.DB
+		GetDeterministicGenerator() *tables.DeterministicGenerator
+	})
+
+	copiedItems := make([]CopiedItem, 0, len(req.Items))
+	for _, item := range req.Items {
+		coreResp, err := items.CopyItem(s.GetTableManager(), s.GetDB(), s.GetDeterministicGenerator(), items.CopyItemRequest{
+			TableID:  req.TableID,
+			ItemID:   item.ID,
+			ParentID: item.ParentID,
+			Name:     item.Name,
+		})
+		var itemErr *items.ItemError
+		if errors.As(err, &itemErr) {
+			copiedItems = append(copiedItems, CopiedItem{SourceID: item.ID, Error: itemErr.Message, Code: itemErr.Code})
+			continue
+		}
+		if err != nil {
+			api.InternalError(w, err.Error())
+			return
+		}
+		copied := coreResp.Item
+		copiedItems = append(copiedItems, CopiedItem{
+			SourceID: item.ID,
+			ID:       copied.ID,
+			ParentID: copied.ParentID,
+			Name:     copied.Name,
+			Path:     copied.Path,
+			Copied:   coreResp.Copied,
+		})
+	}
+
+	api.Success(w, CopyResponseData{TableID: req.TableID, Items: copiedItems})
+}
@@ -2,57 +2,71 @@ package items
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+
+	"github.com/Voltaic314/GhostFS/code/core/items"
+	"github.com/Voltaic314/GhostFS/code/db"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	"github.com/Voltaic314/GhostFS/code/types/api"
 )
 
 // DeleteRequest represents a request to delete one or more items
 type DeleteRequest struct {
-	TableID string   `json:"table_id"`
-	ItemIDs []string `json:"item_ids"` // Array of item IDs to delete
+	TableID   string   `json:"table_id"`
+	ItemIDs   []string `json:"item_ids"`            // Array of item IDs to delete
+	Permanent bool     `json:"permanent,omitempty"` // Skip the table's trash
 }
 
-// DeleteItemResponse represents the result of deleting a single item
-type DeleteItemResponse struct {
-	Success bool   `json:"success"`
-	Error   string `json:"error,omitempty"`
+// DeletedItem represents the result of deleting a single item
+type DeletedItem struct {
 	ID      string `json:"id"`
+	Path    string `json:"path,omitempty"`
+	Trashed bool   `json:"trashed,omitempty"` // false if the item was deleted permanently
+	Error   string `json:"error,omitempty"`
+	Code    string `json:"code,omitempty"` // e.g. "not_found"
 }
 
-// DeleteResponse represents the response from deleting items
-type DeleteResponse struct {
-	Success bool                 `json:"success"`
-	Error   string               `json:"error,omitempty"`
-	TableID string               `json:"table_id,omitempty"`
-	Items   []DeleteItemResponse `json:"items,omitempty"`
+// DeleteResponseData represents the response from deleting items
+type DeleteResponseData struct {
+	TableID string        `json:"table_id"`
+	Items   []DeletedItem `json:"items"`
 }
 
-// HandleDelete handles requests to delete one or more items (files and/or folders)
+// HandleDelete handles requests to delete one or more items (files and/or folders). Tables with a
+// trash keep deleted items there unless permanent is set.
 func HandleDelete(w http.ResponseWriter, r *http.Request, server interface{}) {
 	var req DeleteRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		api.BadRequest(w, "Invalid JSON")
 		return
 	}
 
-	// TODO: Implement actual item deletion logic using server
-	// Loop through req.ItemIDs and delete each one from the database
-	// Return success/failure for each item
+	// Cast server to get access to DB and TableManager
+	s := server.(interface {
+		GetTableManager() *tables.TableManager
+		GetDB() *db.DB
+		GetDeterministicGenerator() *tables.DeterministicGenerator
+	})
 
-	// For now, return placeholder responses
-	var deletedItems []DeleteItemResponse
+	deletedItems := make([]DeletedItem, 0, len(req.ItemIDs))
 	for _, itemID := range req.ItemIDs {
-		deletedItems = append(deletedItems, DeleteItemResponse{
-			Success: true,
-			ID:      itemID,
+		coreResp, err := items.DeleteItem(s.GetTableManager(), s.GetDB(), s.GetDeterministicGenerator(), items.DeleteItemRequest{
+			TableID:   req.TableID,
+			ItemID:    itemID,
+			Permanent: req.Permanent,
 		})
+		var itemErr *items.ItemError
+		if errors.As(err, &itemErr) {
+			deletedItems = append(deletedItems, DeletedItem{ID: itemID, Error: itemErr.Message, Code: itemErr.Code})
+			continue
+		}
+		if err != nil {
+			api.InternalError(w, err.Error())
+			return
+		}
+		deletedItems = append(deletedItems, DeletedItem{ID: itemID, Path: coreResp.Item.Path, Trashed: coreResp.Trashed})
 	}
 
-	response := DeleteResponse{
-		Success: true,
-		TableID: req.TableID,
-		Items:   deletedItems,
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	api.Success(w, DeleteResponseData{TableID: req.TableID, Items: deletedItems})
 }
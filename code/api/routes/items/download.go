@@ -2,9 +2,16 @@ package items
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+
+	"github.com/Voltaic314/GhostFS/code/core/items"
+	"github.com/Voltaic314/GhostFS/code/db"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	"github.com/Voltaic314/GhostFS/code/types/api"
+	"github.com/go-chi/chi/v5"
 )
 
 // DownloadRequest represents a request to download one or more files
@@ -17,6 +24,7 @@ type DownloadRequest struct {
 type DownloadItemResponse struct {
 	Success     bool   `json:"success"`
 	Error       string `json:"error,omitempty"`
+	Code        string `json:"code,omitempty"`
 	FileID      string `json:"file_id"`
 	Filename    string `json:"filename,omitempty"`
 	DownloadURL string `json:"download_url,omitempty"`
@@ -31,7 +39,9 @@ type DownloadResponse struct {
 	Files   []DownloadItemResponse `json:"files,omitempty"`
 }
 
-// HandleDownload handles requests to get download URLs for one or more files
+// HandleDownload handles requests to get download URLs for one or more files. Each file reports
+// its name and the size of its content (a symlink's target's), or the error that keeps it from
+// being downloaded.
 func HandleDownload(w http.ResponseWriter, r *http.Request, server interface{}) {
 	var req DownloadRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -39,19 +49,35 @@ func HandleDownload(w http.ResponseWriter, r *http.Request, server interface{})
 		return
 	}
 
-	// TODO: Implement actual download logic using server
-	// Loop through req.FileIDs and generate download URLs for each
-	// Only works for files, not folders
+	// Cast server to get access to DB and TableManager
+	s := server.(interface {
+		GetTableManager() *tables.TableManager
+		GetDB() *db.DB
+		GetDeterministicGenerator() *tables.DeterministicGenerator
+	})
+
+	downloadFiles := make([]DownloadItemResponse, 0, len(req.FileIDs))
+	for _, fileID := range req.FileIDs {
+		coreResp, err := items.OpenFile(s.GetTableManager(), s.GetDB(), s.GetDeterministicGenerator(), items.OpenFileRequest{
+			TableID: req.TableID,
+			ItemID:  fileID,
+		})
+		var itemErr *items.ItemError
+		if errors.As(err, &itemErr) {
+			downloadFiles = append(downloadFiles, DownloadItemResponse{FileID: fileID, Error: itemErr.Error(), Code: itemErr.Code})
+			continue
+		}
+		if err != nil {
+			api.InternalError(w, err.Error())
+			return
+		}
 
-	// For now, return placeholder responses
-	var downloadFiles []DownloadItemResponse
-	for i, fileID := range req.FileIDs {
 		downloadFiles = append(downloadFiles, DownloadItemResponse{
 			Success:     true,
 			FileID:      fileID,
-			Filename:    fmt.Sprintf("file_%d.txt", i+1),
-			DownloadURL: fmt.Sprintf("http://localhost:8086/download/%s", fileID),
-			Size:        1024,
+			Filename:    coreResp.Item.Name,
+			DownloadURL: contentURL(r, req.TableID, fileID),
+			Size:        coreResp.Content.Size(),
 		})
 	}
 
@@ -65,23 +91,35 @@ func HandleDownload(w http.ResponseWriter, r *http.Request, server interface{})
 	json.NewEncoder(w).Encode(response)
 }
 
-// HandleDownloadFile handles actual file downloads (returns file data)
-func HandleDownloadFile(w http.ResponseWriter, r *http.Request) {
-	// TODO: Get file ID from URL path and serve actual file data
-	// For now, generate random binary data
-	fileSize := int64(1024)
-	filename := "example.txt"
-
-	// Set headers
-	w.Header().Set("Content-Type", "application/octet-stream")
-	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
-	w.Header().Set("Content-Length", strconv.FormatInt(fileSize, 10))
+// contentURL returns the URL HandleContent serves a file at, on the host the request came to
+func contentURL(r *http.Request, tableID, fileID string) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s/items/content/%s/%s", scheme, r.Host, tableID, fileID)
+}
 
-	// Generate random binary data
-	randomData := make([]byte, fileSize)
-	for i := range randomData {
-		randomData[i] = byte(i % 256)
+// HandleContent streams the current content of a file (supports Range requests)
+func HandleContent(w http.ResponseWriter, r *http.Request, server interface{}) {
+	// Cast server to get access to DB and TableManager
+	s := server.(interface {
+		GetTableManager() *tables.TableManager
+		GetDB() *db.DB
+		GetDeterministicGenerator() *tables.DeterministicGenerator
+	})
+	coreResp, err := items.OpenFile(s.GetTableManager(), s.GetDB(), s.GetDeterministicGenerator(), items.OpenFileRequest{
+		TableID: chi.URLParam(r, "tableID"),
+		ItemID:  chi.URLParam(r, "fileID"),
+	})
+	if err != nil {
+		writeItemError(w, err)
+		return
 	}
 
-	w.Write(randomData)
+	file := coreResp.File
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", coreResp.Item.Name))
+	w.Header().Set("ETag", strconv.Quote(fmt.Sprintf("%s-%d", file.ID, s.GetDeterministicGenerator().ContentSeed(file))))
+	http.ServeContent(w, r, coreResp.Item.Name, file.UpdatedAt, coreResp.Content)
 }
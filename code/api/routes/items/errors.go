@@ -0,0 +1,26 @@
+package items
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Voltaic314/GhostFS/code/core/items"
+	"github.com/Voltaic314/GhostFS/code/types/api"
+)
+
+// writeItemError maps core errors of single-item endpoints to responses: unknown items are 404s,
+// locked ones 423s, other provider-like failures (e.g. not_a_file) are 400s. The response's code is
+// the item error code.
+func writeItemError(w http.ResponseWriter, err error) {
+	var itemErr *items.ItemError
+	switch {
+	case errors.As(err, &itemErr) && itemErr.Code == items.ErrCodeNotFound:
+		api.NewItemErrorResponse(itemErr.Code, itemErr.Error()).SendError(w, http.StatusNotFound)
+	case errors.As(err, &itemErr) && itemErr.Code == items.ErrCodeLocked:
+		api.NewItemErrorResponse(itemErr.Code, itemErr.Error()).SendError(w, http.StatusLocked)
+	case errors.As(err, &itemErr):
+		api.NewItemErrorResponse(itemErr.Code, itemErr.Error()).SendError(w, http.StatusBadRequest)
+	default:
+		api.InternalError(w, err.Error())
+	}
+}
@@ -15,12 +15,57 @@ func RegisterRoutes(r chi.Router, server interface{}) {
 	r.Post("/new", func(w http.ResponseWriter, r *http.Request) {
 		HandleNew(w, r, server)
 	})
+	r.Post("/move", func(w http.ResponseWriter, r *http.Request) {
+		HandleMove(w, r, server)
+	})
+	r.Post("/copy", func(w http.ResponseWriter, r *http.Request) {
+		HandleCopy(w, r, server)
+	})
 	r.Post("/delete", func(w http.ResponseWriter, r *http.Request) {
 		HandleDelete(w, r, server)
 	})
+	r.Post("/list_trash", func(w http.ResponseWriter, r *http.Request) {
+		HandleListTrash(w, r, server)
+	})
+	r.Post("/restore", func(w http.ResponseWriter, r *http.Request) {
+		HandleRestore(w, r, server)
+	})
+	r.Post("/purge", func(w http.ResponseWriter, r *http.Request) {
+		HandlePurge(w, r, server)
+	})
+	r.Post("/lock", func(w http.ResponseWriter, r *http.Request) {
+		HandleLock(w, r, server)
+	})
+	r.Post("/unlock", func(w http.ResponseWriter, r *http.Request) {
+		HandleUnlock(w, r, server)
+	})
+	r.Post("/sharing", func(w http.ResponseWriter, r *http.Request) {
+		HandleSharing(w, r, server)
+	})
+	r.Post("/sharing/members", func(w http.ResponseWriter, r *http.Request) {
+		HandleMembers(w, r, server)
+	})
+	r.Post("/sharing/links/create", func(w http.ResponseWriter, r *http.Request) {
+		HandleCreateLink(w, r, server)
+	})
+	r.Post("/sharing/links/delete", func(w http.ResponseWriter, r *http.Request) {
+		HandleDeleteLink(w, r, server)
+	})
 	r.Post("/download", func(w http.ResponseWriter, r *http.Request) {
 		HandleDownload(w, r, server)
 	})
+	r.Get("/content/{tableID}/{fileID}", func(w http.ResponseWriter, r *http.Request) {
+		HandleContent(w, r, server)
+	})
+	r.Post("/revisions", func(w http.ResponseWriter, r *http.Request) {
+		HandleRevisions(w, r, server)
+	})
+	r.Post("/revisions/download", func(w http.ResponseWriter, r *http.Request) {
+		HandleRevisionDownload(w, r, server)
+	})
+	r.Post("/revisions/restore", func(w http.ResponseWriter, r *http.Request) {
+		HandleRevisionRestore(w, r, server)
+	})
 	r.Get("/get_root", func(w http.ResponseWriter, r *http.Request) {
 		HandleGetRoot(w, r, server)
 	})
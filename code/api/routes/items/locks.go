@@ -0,0 +1,89 @@
+package items
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/Voltaic314/GhostFS/code/core/items"
+	"github.com/Voltaic314/GhostFS/code/db"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	"github.com/Voltaic314/GhostFS/code/types/api"
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+)
+
+// Request/Response structs for the lock endpoints
+type LockRequest struct {
+	TableID         string `json:"table_id"`
+	ItemID          string `json:"item_id"`
+	Owner           string `json:"owner"`
+	DurationSeconds int64  `json:"duration_seconds,omitempty"` // Omit to hold the lock until released
+}
+
+type UnlockRequest struct {
+	TableID string `json:"table_id"`
+	ItemID  string `json:"item_id"`
+	Owner   string `json:"owner"`
+	Force   bool   `json:"force,omitempty"` // Release someone else's lock
+}
+
+type LockResponseData struct {
+	TableID string       `json:"table_id"`
+	Item    dbTypes.Node `json:"item"`
+}
+
+// HandleLock handles requests to acquire (or refresh) an exclusive lock on an item
+func HandleLock(w http.ResponseWriter, r *http.Request, server interface{}) {
+	var req LockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.BadRequest(w, "Invalid JSON")
+		return
+	}
+
+	// Cast server to get access to DB and TableManager
+	s := server.(interface {
+		GetTableManager() *tables.TableManager
+		GetDB() *db.DB
+		GetDeterministicGenerator() *tables.DeterministicGenerator
+	})
+	coreResp, err := items.LockItem(s.GetTableManager(), s.GetDB(), s.GetDeterministicGenerator(), items.LockItemRequest{
+		TableID:  req.TableID,
+		ItemID:   req.ItemID,
+		Owner:    req.Owner,
+		Duration: time.Duration(req.DurationSeconds) * time.Second,
+	})
+	if err != nil {
+		writeItemError(w, err)
+		return
+	}
+
+	api.Success(w, LockResponseData{TableID: req.TableID, Item: coreResp.Item})
+}
+
+// HandleUnlock handles requests to release the lock on an item
+func HandleUnlock(w http.ResponseWriter, r *http.Request, server interface{}) {
+	var req UnlockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.BadRequest(w, "Invalid JSON")
+		return
+	}
+
+	// Cast server to get access to DB and TableManager
+	s := server.(interface {
+		GetTableManager() *tables.TableManager
+		GetDB() *db.DB
+		GetDeterministicGenerator() *tables.DeterministicGenerator
+	})
+	coreResp, err := items.UnlockItem(s.GetTableManager(), s.GetDB(), s.GetDeterministicGenerator(), items.UnlockItemRequest{
+		TableID: req.TableID,
+		ItemID:  req.ItemID,
+		Owner:   req.Owner,
+		Force:   req.Force,
+	})
+	if err != nil {
+		writeItemError(w, err)
+		return
+	}
+
+	api.Success(w, LockResponseData{TableID: req.TableID, Item: coreResp.Item})
+}
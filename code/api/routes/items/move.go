@@ -0,0 +1,77 @@
+package items
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Voltaic314/GhostFS/code/core/items"
+	"github.com/Voltaic314/GhostFS/code/db"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	"github.com/Voltaic314/GhostFS/code/types/api"
+)
+
+// Request/Response structs for this endpoint
+type MoveItemRequest struct {
+	ID       string `json:"id"`
+	ParentID string `json:"parent_id,omitempty"` // Destination folder (omit to rename in place)
+	Name     string `json:"name,omitempty"`      // New name (omit to keep the name)
+}
+
+type MoveRequest struct {
+	TableID string            `json:"table_id"`
+	Items   []MoveItemRequest `json:"items"`
+}
+
+type MovedItem struct {
+	ID       string `json:"id"`
+	ParentID string `json:"parent_id,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Path     string `json:"path,omitempty"`
+	Error    string `json:"error,omitempty"`
+	Code     string `json:"code,omitempty"` // e.g. "name_conflict", "path_too_long"
+}
+
+type MoveResponseData struct {
+	TableID string      `json:"table_id"`
+	Items   []MovedItem `json:"items"`
+}
+
+// HandleMove handles requests to move and/or rename one or more items (files and/or folders)
+func HandleMove(w http.ResponseWriter, r *http.Request, server interface{}) {
+	var req MoveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.BadRequest(w, "Invalid JSON")
+		return
+	}
+
+	// Cast server to get access to DB and TableManager
+	s := server.(interface {
+		GetTableManager() *tables.TableManager
+		GetDB() *db.DB
+		GetDeterministicGenerator() *tables.DeterministicGenerator
+	})
+
+	movedItems := make([]MovedItem, 0, len(req.Items))
+	for _, item := range req.Items {
+		coreResp, err := items.MoveItem(s.GetTableManager(), s.GetDB(), s.GetDeterministicGenerator(), items.MoveItemRequest{
+			TableID:  req.TableID,
+			ItemID:   item.ID,
+			ParentID: item.ParentID,
+			Name:     item.Name,
+		})
+		var itemErr *items.ItemError
+		if errors.As(err, &itemErr) {
+			movedItems = append(movedItems, MovedItem{ID: item.ID, Error: itemErr.Message, Code: itemErr.Code})
+			continue
+		}
+		if err != nil {
+			api.InternalError(w, err.Error())
+			return
+		}
+		moved := coreResp.Item
+		movedItems = append(movedItems, MovedItem{ID: moved.ID, ParentID: moved.ParentID, Name: moved.Name, Path: moved.Path})
+	}
+
+	api.Success(w, MoveResponseData{TableID: req.TableID, Items: movedItems})
+}
@@ -2,9 +2,12 @@ package items
 
 import (
 	"encoding/json"
-	"fmt"
+	"errors"
 	"net/http"
 
+	"github.com/Voltaic314/GhostFS/code/core/items"
+	"github.com/Voltaic314/GhostFS/code/db"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
 	"github.com/Voltaic314/GhostFS/code/types/api"
 )
 
@@ -22,10 +25,13 @@ type CreateRequest struct {
 }
 
 type CreatedItem struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
-	Type string `json:"type"`
-	Size int64  `json:"size,omitempty"`
+	ID    string `json:"id,omitempty"`
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Size  int64  `json:"size,omitempty"`
+	Path  string `json:"path,omitempty"`
+	Error string `json:"error,omitempty"`
+	Code  string `json:"code,omitempty"` // e.g. "name_conflict", "invalid_name"
 }
 
 type CreateResponseData struct {
@@ -42,19 +48,44 @@ func HandleNew(w http.ResponseWriter, r *http.Request, server interface{}) {
 		return
 	}
 
-	// TODO: Implement actual item creation logic using server
-	// Loop through req.Items and create each one in the database
-	// Return success/failure for each item
+	// Cast server to get access to DB and TableManager
+	s := server.(interface {
+		GetTableManager() *tables.TableManager
+		GetDB() *db.DB
+		GetDeterministicGenerator() *tables.DeterministicGenerator
+	})
 
-	// For now, return placeholder responses
-	var createdItems []CreatedItem
+	// Convert API request to core request
+	coreReq := items.CreateItemsRequest{
+		TableID:  req.TableID,
+		ParentID: req.ParentID,
+	}
 	for _, item := range req.Items {
-		createdItems = append(createdItems, CreatedItem{
-			ID:   fmt.Sprintf("placeholder-%s-id", item.Type),
-			Name: item.Name,
-			Type: item.Type,
-			Size: item.Size,
-		})
+		coreReq.Items = append(coreReq.Items, items.NewItem{Name: item.Name, Type: item.Type, Size: item.Size})
+	}
+
+	// Call core logic
+	coreResp, err := items.CreateItems(s.GetTableManager(), s.GetDB(), s.GetDeterministicGenerator(), coreReq)
+	var itemErr *items.ItemError
+	if errors.As(err, &itemErr) {
+		writeItemError(w, itemErr)
+		return
+	}
+	if err != nil {
+		api.InternalError(w, err.Error())
+		return
+	}
+
+	// Report success/failure for each item
+	createdItems := make([]CreatedItem, 0, len(coreResp.Results))
+	for i, result := range coreResp.Results {
+		created := CreatedItem{Name: req.Items[i].Name, Type: req.Items[i].Type, Size: req.Items[i].Size}
+		if result.Error != nil {
+			created.Error, created.Code = result.Error.Message, result.Error.Code
+		} else {
+			created.ID, created.Path, created.Size = result.Item.ID, result.Item.Path, result.Item.Size
+		}
+		createdItems = append(createdItems, created)
 	}
 
 	// Return successful response
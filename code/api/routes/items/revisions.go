@@ -0,0 +1,130 @@
+package items
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Voltaic314/GhostFS/code/core/items"
+	"github.com/Voltaic314/GhostFS/code/db"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	"github.com/Voltaic314/GhostFS/code/types/api"
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+)
+
+// Request/Response structs for the revision endpoints
+type RevisionsRequest struct {
+	TableID string `json:"table_id"`
+	ItemID  string `json:"item_id"`
+}
+
+type RevisionsResponseData struct {
+	TableID   string             `json:"table_id"`
+	ItemID    string             `json:"item_id"`
+	Path      string             `json:"path"`
+	Revisions []dbTypes.Revision `json:"revisions"` // oldest first, the last one is current
+}
+
+type RevisionRequest struct {
+	TableID    string `json:"table_id"`
+	ItemID     string `json:"item_id"`
+	RevisionID string `json:"revision_id"`
+	Author     string `json:"author,omitempty"` // only for restore
+}
+
+type RestoreRevisionResponseData struct {
+	TableID  string           `json:"table_id"`
+	Item     dbTypes.Node     `json:"item"`
+	Revision dbTypes.Revision `json:"revision"` // the new current revision
+}
+
+// HandleRevisions handles requests to list the versions of a file
+func HandleRevisions(w http.ResponseWriter, r *http.Request, server interface{}) {
+	var req RevisionsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.BadRequest(w, "Invalid JSON")
+		return
+	}
+
+	// Cast server to get access to DB and TableManager
+	s := server.(interface {
+		GetTableManager() *tables.TableManager
+		GetDB() *db.DB
+		GetDeterministicGenerator() *tables.DeterministicGenerator
+	})
+	coreResp, err := items.ListRevisions(s.GetTableManager(), s.GetDB(), s.GetDeterministicGenerator(), items.ListRevisionsRequest{
+		TableID: req.TableID,
+		ItemID:  req.ItemID,
+	})
+	if err != nil {
+		writeItemError(w, err)
+		return
+	}
+
+	api.Success(w, RevisionsResponseData{
+		TableID:   req.TableID,
+		ItemID:    req.ItemID,
+		Path:      coreResp.Item.Path,
+		Revisions: coreResp.Revisions,
+	})
+}
+
+// HandleRevisionDownload streams the content of one version of a file (supports Range requests)
+func HandleRevisionDownload(w http.ResponseWriter, r *http.Request, server interface{}) {
+	var req RevisionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.BadRequest(w, "Invalid JSON")
+		return
+	}
+
+	// Cast server to get access to DB and TableManager
+	s := server.(interface {
+		GetTableManager() *tables.TableManager
+		GetDB() *db.DB
+		GetDeterministicGenerator() *tables.DeterministicGenerator
+	})
+	coreResp, err := items.OpenRevision(s.GetTableManager(), s.GetDB(), s.GetDeterministicGenerator(), items.OpenRevisionRequest{
+		TableID:    req.TableID,
+		ItemID:     req.ItemID,
+		RevisionID: req.RevisionID,
+	})
+	if err != nil {
+		writeItemError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", coreResp.Item.Name))
+	w.Header().Set("X-Revision-Id", coreResp.Revision.ID)
+	w.Header().Set("ETag", strconv.Quote(fmt.Sprintf("%s-%s", coreResp.Item.ID, coreResp.Revision.ID)))
+	http.ServeContent(w, r, coreResp.Item.Name, coreResp.Revision.ModifiedAt, coreResp.Content)
+}
+
+// HandleRevisionRestore handles requests to make an older version of a file current again
+func HandleRevisionRestore(w http.ResponseWriter, r *http.Request, server interface{}) {
+	var req RevisionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.BadRequest(w, "Invalid JSON")
+		return
+	}
+
+	// Cast server to get access to DB and TableManager
+	s := server.(interface {
+		GetTableManager() *tables.TableManager
+		GetDB() *db.DB
+		GetDeterministicGenerator() *tables.DeterministicGenerator
+	})
+	coreResp, err := items.RestoreRevision(s.GetTableManager(), s.GetDB(), s.GetDeterministicGenerator(), items.RestoreRevisionRequest{
+		TableID:    req.TableID,
+		ItemID:     req.ItemID,
+		RevisionID: req.RevisionID,
+		Author:     req.Author,
+	})
+	if err != nil {
+		writeItemError(w, err)
+		return
+	}
+
+	api.Success(w, RestoreRevisionResponseData{TableID: req.TableID, Item: coreResp.Item, Revision: coreResp.Revision})
+}
@@ -0,0 +1,176 @@
+package items
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/Voltaic314/GhostFS/code/core/items"
+	"github.com/Voltaic314/GhostFS/code/db"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	"github.com/Voltaic314/GhostFS/code/types/api"
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+)
+
+// Request/Response structs for the sharing endpoints
+type SharingRequest struct {
+	TableID string `json:"table_id"`
+	ItemID  string `json:"item_id"`
+}
+
+type MembersRequest struct {
+	TableID string                `json:"table_id"`
+	ItemID  string                `json:"item_id"`
+	Add     []dbTypes.ShareMember `json:"add,omitempty"`    // New members, or new roles for existing ones
+	Remove  []string              `json:"remove,omitempty"` // Emails of members to remove
+}
+
+type CreateLinkRequest struct {
+	TableID   string     `json:"table_id"`
+	ItemID    string     `json:"item_id"`
+	Scope     string     `json:"scope,omitempty"`      // "anyone" (default) or "organization"
+	Role      string     `json:"role,omitempty"`       // "viewer" (default) or "editor"
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // Omit for a link that never expires
+	Password  bool       `json:"password,omitempty"`   // The link asks for a password
+}
+
+type DeleteLinkRequest struct {
+	TableID string `json:"table_id"`
+	ItemID  string `json:"item_id"`
+	LinkID  string `json:"link_id"`
+}
+
+type SharingResponseData struct {
+	TableID string          `json:"table_id"`
+	ItemID  string          `json:"item_id"`
+	Path    string          `json:"path"`
+	Sharing dbTypes.Sharing `json:"sharing"`
+}
+
+type CreateLinkResponseData struct {
+	SharingResponseData
+	Link dbTypes.SharedLink `json:"link"`
+}
+
+// HandleSharing handles requests to read who an item is shared with and its shared links
+func HandleSharing(w http.ResponseWriter, r *http.Request, server interface{}) {
+	var req SharingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.BadRequest(w, "Invalid JSON")
+		return
+	}
+
+	// Cast server to get access to DB and TableManager
+	s := server.(interface {
+		GetTableManager() *tables.TableManager
+		GetDB() *db.DB
+		GetDeterministicGenerator() *tables.DeterministicGenerator
+	})
+	coreResp, err := items.GetSharing(s.GetTableManager(), s.GetDB(), s.GetDeterministicGenerator(), items.GetSharingRequest{
+		TableID: req.TableID,
+		ItemID:  req.ItemID,
+	})
+	if err != nil {
+		writeItemError(w, err)
+		return
+	}
+
+	api.Success(w, sharingResponseData(req.TableID, coreResp))
+}
+
+// HandleMembers handles requests to share an item with people, change their roles or remove them
+func HandleMembers(w http.ResponseWriter, r *http.Request, server interface{}) {
+	var req MembersRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.BadRequest(w, "Invalid JSON")
+		return
+	}
+
+	// Cast server to get access to DB and TableManager
+	s := server.(interface {
+		GetTableManager() *tables.TableManager
+		GetDB() *db.DB
+		GetDeterministicGenerator() *tables.DeterministicGenerator
+	})
+	coreResp, err := items.UpdateMembers(s.GetTableManager(), s.GetDB(), s.GetDeterministicGenerator(), items.UpdateMembersRequest{
+		TableID: req.TableID,
+		ItemID:  req.ItemID,
+		Add:     req.Add,
+		Remove:  req.Remove,
+	})
+	if err != nil {
+		writeItemError(w, err)
+		return
+	}
+
+	api.Success(w, sharingResponseData(req.TableID, coreResp))
+}
+
+// HandleCreateLink handles requests to add a shared link to an item
+func HandleCreateLink(w http.ResponseWriter, r *http.Request, server interface{}) {
+	var req CreateLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.BadRequest(w, "Invalid JSON")
+		return
+	}
+
+	// Cast server to get access to DB and TableManager
+	s := server.(interface {
+		GetTableManager() *tables.TableManager
+		GetDB() *db.DB
+		GetDeterministicGenerator() *tables.DeterministicGenerator
+	})
+	coreResp, err := items.CreateLink(s.GetTableManager(), s.GetDB(), s.GetDeterministicGenerator(), items.CreateLinkRequest{
+		TableID:   req.TableID,
+		ItemID:    req.ItemID,
+		Scope:     req.Scope,
+		Role:      req.Role,
+		ExpiresAt: req.ExpiresAt,
+		Password:  req.Password,
+	})
+	if err != nil {
+		writeItemError(w, err)
+		return
+	}
+
+	api.Success(w, CreateLinkResponseData{
+		SharingResponseData: sharingResponseData(req.TableID, &coreResp.SharingResponse),
+		Link:                coreResp.Link,
+	})
+}
+
+// HandleDeleteLink handles requests to remove a shared link from an item
+func HandleDeleteLink(w http.ResponseWriter, r *http.Request, server interface{}) {
+	var req DeleteLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.BadRequest(w, "Invalid JSON")
+		return
+	}
+
+	// Cast server to get access to DB and TableManager
+	s := server.(interface {
+		GetTableManager() *tables.TableManager
+		GetDB() *db.DB
+		GetDeterministicGenerator() *tables.DeterministicGenerator
+	})
+	coreResp, err := items.DeleteLink(s.GetTableManager(), s.GetDB(), s.GetDeterministicGenerator(), items.DeleteLinkRequest{
+		TableID: req.TableID,
+		ItemID:  req.ItemID,
+		LinkID:  req.LinkID,
+	})
+	if err != nil {
+		writeItemError(w, err)
+		return
+	}
+
+	api.Success(w, sharingResponseData(req.TableID, coreResp))
+}
+
+func sharingResponseData(tableID string, resp *items.SharingResponse) SharingResponseData {
+	return SharingResponseData{
+		TableID: tableID,
+		ItemID:  resp.Item.ID,
+		Path:    resp.Item.Path,
+		Sharing: resp.Sharing,
+	}
+}
@@ -0,0 +1,116 @@
+package items
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Voltaic314/GhostFS/code/core/items"
+	"github.com/Voltaic314/GhostFS/code/db"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	"github.com/Voltaic314/GhostFS/code/types/api"
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+)
+
+// Request/Response structs for the trash endpoints
+type ListTrashRequest struct {
+	TableID string `json:"table_id"`
+}
+
+type ListTrashResponseData struct {
+	TableID string              `json:"table_id"`
+	Items   []dbTypes.TrashItem `json:"items"` // path and parent_id are where the items were deleted from
+}
+
+type RestoreRequest struct {
+	TableID  string `json:"table_id"`
+	ItemID   string `json:"item_id"`
+	ParentID string `json:"parent_id,omitempty"` // Folder to restore into (omit for the original folder)
+	Name     string `json:"name,omitempty"`      // Name to restore under (omit for the original name)
+}
+
+type RestoreResponseData struct {
+	TableID string       `json:"table_id"`
+	Item    dbTypes.Node `json:"item"`
+}
+
+type PurgeRequest struct {
+	TableID string `json:"table_id"`
+	ItemID  string `json:"item_id,omitempty"` // Omit to empty the whole trash
+}
+
+type PurgeResponseData struct {
+	TableID string `json:"table_id"`
+	Purged  int64  `json:"purged"`
+}
+
+// HandleListTrash handles requests to list the items in a table's trash
+func HandleListTrash(w http.ResponseWriter, r *http.Request, server interface{}) {
+	var req ListTrashRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.BadRequest(w, "Invalid JSON")
+		return
+	}
+
+	// Cast server to get access to DB and TableManager
+	s := server.(interface {
+		GetTableManager() *tables.TableManager
+		GetDB() *db.DB
+	})
+	coreResp, err := items.ListTrash(s.GetTableManager(), s.GetDB(), items.ListTrashRequest{TableID: req.TableID})
+	if err != nil {
+		writeItemError(w, err)
+		return
+	}
+
+	api.Success(w, ListTrashResponseData{TableID: req.TableID, Items: coreResp.Items})
+}
+
+// HandleRestore handles requests to restore an item from the trash
+func HandleRestore(w http.ResponseWriter, r *http.Request, server interface{}) {
+	var req RestoreRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.BadRequest(w, "Invalid JSON")
+		return
+	}
+
+	// Cast server to get access to DB and TableManager
+	s := server.(interface {
+		GetTableManager() *tables.TableManager
+		GetDB() *db.DB
+		GetDeterministicGenerator() *tables.DeterministicGenerator
+	})
+	coreResp, err := items.RestoreTrashItem(s.GetTableManager(), s.GetDB(), s.GetDeterministicGenerator(), items.RestoreTrashItemRequest{
+		TableID:  req.TableID,
+		ItemID:   req.ItemID,
+		ParentID: req.ParentID,
+		Name:     req.Name,
+	})
+	if err != nil {
+		writeItemError(w, err)
+		return
+	}
+
+	api.Success(w, RestoreResponseData{TableID: req.TableID, Item: coreResp.Item})
+}
+
+// HandlePurge handles requests to permanently delete an item from the trash, or empty it
+func HandlePurge(w http.ResponseWriter, r *http.Request, server interface{}) {
+	var req PurgeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.BadRequest(w, "Invalid JSON")
+		return
+	}
+
+	// Cast server to get access to DB and TableManager
+	s := server.(interface {
+		GetTableManager() *tables.TableManager
+		GetDB() *db.DB
+	})
+	coreResp, err := items.PurgeTrash(s.GetTableManager(), s.GetDB(), items.PurgeTrashRequest{TableID: req.TableID, ItemID: req.ItemID})
+	if err != nil {
+		writeItemError(w, err)
+		return
+	}
+
+	api.Success(w, PurgeResponseData{TableID: req.TableID, Purged: coreResp.Purged})
+}
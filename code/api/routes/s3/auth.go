@@ -0,0 +1,371 @@
+package s3
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+)
+
+const (
+	signingAlgorithm = "AWS4-HMAC-SHA256"
+	signingService   = "s3"
+	unsignedPayload  = "UNSIGNED-PAYLOAD"
+	amzDateFormat    = "20060102T150405Z"
+	maxClockSkew     = 15 * time.Minute
+	maxPresignExpiry = 7 * 24 * time.Hour
+)
+
+// signature is the parsed Signature Version 4 of a request, from its Authorization header or
+// from the query of a presigned URL
+type signature struct {
+	accessKeyID   string
+	scope         string // date/region/service/aws4_request
+	signingDate   string
+	region        string
+	service       string
+	signedHeaders []string
+	signature     string
+	date          time.Time
+	payloadHash   string
+	presigned     bool
+}
+
+// authenticate checks a request's Signature Version 4 against the configured key pair and region
+// (for the s3 service) and returns the payload to read: decoded if it was sent aws-chunked, and
+// failing at the end if it doesn't match a signed SHA-256. Chunk signatures of streamed uploads
+// aren't checked.
+func authenticate(r *http.Request, config *tables.S3Config, now time.Time) (io.Reader, error) {
+	sig, err := parseSignature(r)
+	if err != nil {
+		return nil, err
+	}
+	if sig.accessKeyID != config.AccessKeyID {
+		return nil, &s3Error{Status: http.StatusForbidden, Code: "InvalidAccessKeyId", Message: "The AWS Access Key Id you provided does not exist in our records."}
+	}
+	if region := config.GetRegion(); sig.region != region {
+		return nil, errMalformedAuth(fmt.Sprintf("the region '%s' is wrong; expecting '%s'", sig.region, region))
+	}
+	if sig.service != signingService {
+		return nil, errMalformedAuth(fmt.Sprintf("incorrect service '%s'. This endpoint belongs to '%s'.", sig.service, signingService))
+	}
+
+	if sig.presigned {
+		expires, err := strconv.Atoi(r.URL.Query().Get("X-Amz-Expires"))
+		if err != nil || expires < 0 || time.Duration(expires)*time.Second > maxPresignExpiry {
+			return nil, &s3Error{Status: http.StatusBadRequest, Code: "AuthorizationQueryParametersError", Message: "X-Amz-Expires must be between 0 and 604800 seconds"}
+		}
+		if now.After(sig.date.Add(time.Duration(expires) * time.Second)) {
+			return nil, &s3Error{Status: http.StatusForbidden, Code: "AccessDenied", Message: "Request has expired"}
+		}
+	} else if skew := now.Sub(sig.date); skew > maxClockSkew || skew < -maxClockSkew {
+		return nil, &s3Error{Status: http.StatusForbidden, Code: "RequestTimeTooSkewed", Message: "The difference between the request time and the current time is too large."}
+	}
+
+	signingKey := deriveSigningKey(config.SecretAccessKey, sig.signingDate, sig.region, sig.service)
+	valid := false
+	for _, uri := range canonicalURIs(r) {
+		stringToSign := strings.Join([]string{
+			signingAlgorithm,
+			sig.date.Format(amzDateFormat),
+			sig.scope,
+			hexSHA256([]byte(canonicalRequest(r, uri, sig))),
+		}, "\n")
+		expected := hex.EncodeToString(hmacSHA256(signingKey, []byte(stringToSign)))
+		if hmac.Equal([]byte(expected), []byte(sig.signature)) {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return nil, &s3Error{Status: http.StatusForbidden, Code: "SignatureDoesNotMatch", Message: "The request signature we calculated does not match the signature you provided. Check your key and signing method."}
+	}
+
+	switch {
+	case sig.payloadHash == unsignedPayload:
+		return r.Body, nil
+	case strings.HasPrefix(sig.payloadHash, "STREAMING-"):
+		return &chunkedReader{r: bufio.NewReader(r.Body)}, nil
+	case len(sig.payloadHash) == sha256.Size*2:
+		return &verifyingReader{r: r.Body, hash: sha256.New(), expected: strings.ToLower(sig.payloadHash)}, nil
+	}
+	return nil, errInvalidArgument(fmt.Sprintf("invalid x-amz-content-sha256 %q", sig.payloadHash))
+}
+
+// parseSignature reads the signature of a request
+func parseSignature(r *http.Request) (*signature, error) {
+	query := r.URL.Query()
+	sig := &signature{}
+	var credential, dateValue string
+
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		fields, ok := strings.CutPrefix(auth, signingAlgorithm+" ")
+		if !ok {
+			return nil, &s3Error{Status: http.StatusBadRequest, Code: "InvalidRequest", Message: "only AWS4-HMAC-SHA256 signatures are supported"}
+		}
+		for _, field := range strings.Split(fields, ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(field), "=")
+			switch name {
+			case "Credential":
+				credential = value
+			case "SignedHeaders":
+				sig.signedHeaders = strings.Split(value, ";")
+			case "Signature":
+				sig.signature = value
+			}
+		}
+		dateValue = r.Header.Get("X-Amz-Date")
+		if dateValue == "" {
+			if date, err := http.ParseTime(r.Header.Get("Date")); err == nil {
+				dateValue = date.UTC().Format(amzDateFormat)
+			}
+		}
+		sig.payloadHash = r.Header.Get("X-Amz-Content-Sha256")
+		if sig.payloadHash == "" {
+			return nil, &s3Error{Status: http.StatusBadRequest, Code: "InvalidRequest", Message: "Missing required header for this request: x-amz-content-sha256"}
+		}
+	} else if query.Get("X-Amz-Algorithm") != "" {
+		if query.Get("X-Amz-Algorithm") != signingAlgorithm {
+			return nil, &s3Error{Status: http.StatusBadRequest, Code: "AuthorizationQueryParametersError", Message: "only AWS4-HMAC-SHA256 signatures are supported"}
+		}
+		sig.presigned = true
+		credential = query.Get("X-Amz-Credential")
+		sig.signedHeaders = strings.Split(query.Get("X-Amz-SignedHeaders"), ";")
+		sig.signature = query.Get("X-Amz-Signature")
+		dateValue = query.Get("X-Amz-Date")
+		sig.payloadHash = unsignedPayload
+		if hash := r.Header.Get("X-Amz-Content-Sha256"); hash != "" {
+			sig.payloadHash = hash
+		}
+	} else {
+		return nil, &s3Error{Status: http.StatusForbidden, Code: "AccessDenied", Message: "Anonymous access is not allowed, sign requests with the configured access key"}
+	}
+
+	parts := strings.Split(credential, "/")
+	if len(parts) != 5 || parts[4] != "aws4_request" || sig.signature == "" || len(sig.signedHeaders) == 0 {
+		return nil, errMalformedAuth("")
+	}
+	if !slices.Contains(sig.signedHeaders, "host") {
+		return nil, errMalformedAuth("the host header must be signed")
+	}
+	sig.accessKeyID, sig.signingDate, sig.region, sig.service = parts[0], parts[1], parts[2], parts[3]
+	sig.scope = strings.Join(parts[1:], "/")
+
+	date, err := time.Parse(amzDateFormat, dateValue)
+	if err != nil {
+		return nil, &s3Error{Status: http.StatusForbidden, Code: "AccessDenied", Message: "AWS authentication requires a valid Date or x-amz-date header"}
+	}
+	if sig.signingDate != date.Format("20060102") {
+		return nil, errMalformedAuth("Invalid credential date. Date is not the same as X-Amz-Date.")
+	}
+	sig.date = date
+	return sig, nil
+}
+
+// canonicalURIs returns the candidate canonical paths of a request: the decoded path encoded the
+// way the AWS SDKs do, and the path exactly as sent (for clients that sign what they send)
+func canonicalURIs(r *http.Request) []string {
+	uris := []string{awsEscape(r.URL.Path, true)}
+	if raw, _, _ := strings.Cut(r.RequestURI, "?"); raw != uris[0] && strings.HasPrefix(raw, "/") {
+		uris = append(uris, raw)
+	}
+	return uris
+}
+
+// canonicalRequest builds the canonical form of a request that gets signed
+func canonicalRequest(r *http.Request, uri string, sig *signature) string {
+	var headers strings.Builder
+	for _, name := range sig.signedHeaders {
+		headers.WriteString(name)
+		headers.WriteByte(':')
+		headers.WriteString(canonicalHeaderValue(r, name))
+		headers.WriteByte('\n')
+	}
+	return strings.Join([]string{
+		r.Method,
+		uri,
+		canonicalQuery(r.URL.RawQuery, sig.presigned),
+		headers.String(),
+		strings.Join(sig.signedHeaders, ";"),
+		sig.payloadHash,
+	}, "\n")
+}
+
+// canonicalHeaderValue returns a signed header's values, trimmed and with inner spaces collapsed
+func canonicalHeaderValue(r *http.Request, name string) string {
+	var values []string
+	switch name {
+	case "host":
+		values = []string{r.Host}
+	case "content-length":
+		values = r.Header.Values(name)
+		if len(values) == 0 && r.ContentLength >= 0 {
+			values = []string{strconv.FormatInt(r.ContentLength, 10)}
+		}
+	case "transfer-encoding":
+		values = r.TransferEncoding
+	default:
+		values = r.Header.Values(name)
+	}
+	trimmed := make([]string, len(values))
+	for i, value := range values {
+		trimmed[i] = strings.Join(strings.Fields(value), " ")
+	}
+	return strings.Join(trimmed, ",")
+}
+
+// canonicalQuery sorts and re-encodes the query parameters (without the signature of a presigned URL)
+func canonicalQuery(rawQuery string, presigned bool) string {
+	var params []string
+	for _, param := range strings.Split(rawQuery, "&") {
+		if param == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(param, "=")
+		name, _ = url.PathUnescape(name)
+		value, _ = url.PathUnescape(value)
+		if presigned && name == "X-Amz-Signature" {
+			continue
+		}
+		params = append(params, awsEscape(name, false)+"="+awsEscape(value, false))
+	}
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+// awsEscape percent-encodes everything but the unreserved characters (and slashes in paths)
+func awsEscape(s string, path bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' || (path && c == '/') {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func deriveSigningKey(secret, date, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secret), []byte(date))
+	key = hmacSHA256(key, []byte(region))
+	key = hmacSHA256(key, []byte(service))
+	return hmacSHA256(key, []byte("aws4_request"))
+}
+
+func hmacSHA256(key, data []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+func hexSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// verifyingReader fails at the end of a payload whose SHA-256 isn't the signed one
+type verifyingReader struct {
+	r        io.Reader
+	hash     hash.Hash
+	expected string
+}
+
+func (v *verifyingReader) Read(p []byte) (int, error) {
+	n, err := v.r.Read(p)
+	v.hash.Write(p[:n])
+	if err == io.EOF && hex.EncodeToString(v.hash.Sum(nil)) != v.expected {
+		return n, &s3Error{Status: http.StatusBadRequest, Code: "XAmzContentSHA256Mismatch", Message: "The provided 'x-amz-content-sha256' header does not match what was computed."}
+	}
+	return n, err
+}
+
+// chunkedReader decodes an aws-chunked payload: "<hex size>[;chunk-signature=...]\r\n<data>\r\n"
+// chunks, ended by a zero-size chunk and optional trailers (checksums) before a blank line
+type chunkedReader struct {
+	r         *bufio.Reader
+	remaining int64
+	done      bool
+}
+
+func (c *chunkedReader) Read(p []byte) (int, error) {
+	if c.done {
+		return 0, io.EOF
+	}
+	if c.remaining == 0 {
+		line, err := c.readLine()
+		if err == io.EOF {
+			return 0, errIncompleteBody()
+		}
+		if err != nil {
+			return 0, err
+		}
+		sizeField, _, _ := strings.Cut(line, ";")
+		size, err := strconv.ParseInt(strings.TrimSpace(sizeField), 16, 64)
+		if err != nil || size < 0 {
+			return 0, errIncompleteBody()
+		}
+		if size == 0 {
+			// Trailers up to the blank line (or the end of the body)
+			for {
+				line, err := c.readLine()
+				if err == io.EOF || (err == nil && line == "") {
+					break
+				}
+				if err != nil {
+					return 0, err
+				}
+			}
+			c.done = true
+			return 0, io.EOF
+		}
+		c.remaining = size
+	}
+
+	if int64(len(p)) > c.remaining {
+		p = p[:c.remaining]
+	}
+	n, err := c.r.Read(p)
+	c.remaining -= int64(n)
+	if c.remaining == 0 {
+		if line, lineErr := c.readLine(); lineErr != nil || line != "" {
+			return n, errIncompleteBody()
+		}
+	}
+	if err == io.EOF {
+		if c.remaining > 0 {
+			return n, errIncompleteBody()
+		}
+		err = nil
+	}
+	return n, err
+}
+
+func (c *chunkedReader) readLine() (string, error) {
+	line, err := c.r.ReadString('\n')
+	if err == io.EOF && line == "" {
+		return "", io.EOF
+	}
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return string(bytes.TrimRight([]byte(line), "\r\n")), nil
+}
+
+func errIncompleteBody() *s3Error {
+	return &s3Error{Status: http.StatusBadRequest, Code: "IncompleteBody", Message: "The request body is not a valid aws-chunked payload."}
+}
@@ -0,0 +1,110 @@
+package s3
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Voltaic314/GhostFS/code/core/items"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+)
+
+// s3Error is a failure reported with an S3 error code, so SDKs surface it like AWS does
+type s3Error struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (e *s3Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+func errNotImplemented(message string) *s3Error {
+	return &s3Error{Status: http.StatusNotImplemented, Code: "NotImplemented", Message: message}
+}
+
+func errInvalidArgument(message string) *s3Error {
+	return &s3Error{Status: http.StatusBadRequest, Code: "InvalidArgument", Message: message}
+}
+
+// errMalformedAuth rejects a signature whose credential scope or signed headers don't fit the request
+func errMalformedAuth(detail string) *s3Error {
+	message := "The authorization header is malformed."
+	if detail != "" {
+		message = "The authorization header is malformed; " + detail
+	}
+	return &s3Error{Status: http.StatusBadRequest, Code: "AuthorizationHeaderMalformed", Message: message}
+}
+
+func errNoSuchKey() *s3Error {
+	return &s3Error{Status: http.StatusNotFound, Code: "NoSuchKey", Message: "The specified key does not exist."}
+}
+
+func errMalformedXML() *s3Error {
+	return &s3Error{Status: http.StatusBadRequest, Code: "MalformedXML", Message: "The XML you provided was not well-formed or did not validate against our published schema."}
+}
+
+// itemError translates a failed item operation into the closest S3 error
+func itemError(err *items.ItemError) *s3Error {
+	switch err.Code {
+	case items.ErrCodeNotFound:
+		return errNoSuchKey()
+	case items.ErrCodeLocked:
+		return &s3Error{Status: http.StatusConflict, Code: "OperationAborted", Message: err.Message}
+	case tables.ViolationNameTooLong, tables.ViolationPathTooLong:
+		return &s3Error{Status: http.StatusBadRequest, Code: "KeyTooLongError", Message: err.Message}
+	}
+	return &s3Error{Status: http.StatusBadRequest, Code: "InvalidRequest", Message: err.Message}
+}
+
+type errorResponse struct {
+	XMLName   xml.Name `xml:"Error"`
+	Code      string   `xml:"Code"`
+	Message   string   `xml:"Message"`
+	Key       string   `xml:"Key,omitempty"`
+	Bucket    string   `xml:"BucketName,omitempty"`
+	Resource  string   `xml:"Resource"`
+	RequestID string   `xml:"RequestId"`
+}
+
+// writeError sends an error document (just the status for HEAD requests)
+func (req *s3Request) writeError(err error) {
+	var s3Err *s3Error
+	var itemErr *items.ItemError
+	switch {
+	case errors.As(err, &s3Err):
+	case errors.As(err, &itemErr):
+		s3Err = itemError(itemErr)
+	default:
+		fmt.Printf("❌ S3 %s %s failed: %v\n", req.r.Method, req.r.URL.Path, err)
+		s3Err = &s3Error{Status: http.StatusInternalServerError, Code: "InternalError", Message: err.Error()}
+	}
+
+	if req.r.Method == http.MethodHead {
+		req.w.WriteHeader(s3Err.Status)
+		return
+	}
+	req.writeXML(s3Err.Status, errorResponse{
+		Code:      s3Err.Code,
+		Message:   s3Err.Message,
+		Key:       req.key,
+		Bucket:    req.bucket,
+		Resource:  req.r.URL.Path,
+		RequestID: req.requestID,
+	})
+}
+
+// writeXML sends an XML document
+func (req *s3Request) writeXML(status int, v any) {
+	data, err := xml.Marshal(v)
+	if err != nil {
+		http.Error(req.w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	req.w.Header().Set("Content-Type", "application/xml")
+	req.w.WriteHeader(status)
+	req.w.Write([]byte(xml.Header))
+	req.w.Write(data)
+}
@@ -0,0 +1,227 @@
+package s3
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	coreTables "github.com/Voltaic314/GhostFS/code/core/tables"
+	"github.com/Voltaic314/GhostFS/code/db"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	"github.com/go-chi/chi/v5"
+)
+
+// Prefix is where the S3 API is mounted. Buckets are addressed path-style below it, so clients use
+// http://<address>:<port>/s3 as their endpoint with path-style addressing turned on.
+const Prefix = "/s3"
+
+// RegisterRoutes registers the S3-compatible API. Every table is a bucket named after the table.
+func RegisterRoutes(r chi.Router, server interface{}) {
+	h := &handler{server: server, uploads: newUploadStore()}
+	r.Handle("/", h)
+	r.Handle("/*", h)
+}
+
+// handler serves the S3 operations. Multipart uploads in progress are kept in memory.
+type handler struct {
+	server  interface{}
+	uploads *uploadStore
+}
+
+// s3Request is one S3 call: the signed request, the bucket (table) and key it addresses and the
+// payload to read once the signature was checked
+type s3Request struct {
+	w            http.ResponseWriter
+	r            *http.Request
+	body         io.Reader
+	tableManager *tables.TableManager
+	database     *db.DB
+	generator    *tables.DeterministicGenerator
+	config       *tables.S3Config
+	requestID    string
+	bucket       string
+	key          string
+	tableID      string
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Cast server to get access to DB and TableManager
+	s := h.server.(interface {
+		GetTableManager() *tables.TableManager
+		GetDB() *db.DB
+		GetDeterministicGenerator() *tables.DeterministicGenerator
+	})
+	req := &s3Request{
+		w:            w,
+		r:            r,
+		tableManager: s.GetTableManager(),
+		database:     s.GetDB(),
+		generator:    s.GetDeterministicGenerator(),
+		config:       s.GetTableManager().GetS3Config(),
+		requestID:    newRequestID(),
+	}
+	req.bucket, req.key = splitPath(r.URL.Path)
+	w.Header().Set("x-amz-request-id", req.requestID)
+
+	if req.config == nil || !req.config.Enabled {
+		req.writeError(errNotImplemented("the S3 API is disabled, enable it in the s3 config"))
+		return
+	}
+	body, err := authenticate(r, req.config, time.Now().UTC())
+	if err != nil {
+		req.writeError(err)
+		return
+	}
+	req.body = body
+
+	if err := h.dispatch(req); err != nil {
+		req.writeError(err)
+	}
+}
+
+// dispatch picks the S3 operation from the method, the path and the subresource in the query
+func (h *handler) dispatch(req *s3Request) error {
+	query := req.r.URL.Query()
+	method := req.r.Method
+
+	if req.bucket == "" {
+		if method == http.MethodGet {
+			return listBuckets(req)
+		}
+		return errNotImplemented(fmt.Sprintf("%s / is not supported", method))
+	}
+
+	tableID, err := resolveBucket(req.database, req.bucket)
+	if err != nil {
+		return err
+	}
+	req.tableID = tableID
+
+	if req.key == "" {
+		switch {
+		case method == http.MethodGet && query.Has("location"):
+			return getBucketLocation(req)
+		case method == http.MethodGet && query.Has("uploads"):
+			return h.listMultipartUploads(req)
+		case method == http.MethodGet:
+			if err := checkSubresources(query, listParams...); err != nil {
+				return err
+			}
+			if query.Get("list-type") == "2" {
+				return listObjectsV2(req)
+			}
+			return listObjectsV1(req)
+		case method == http.MethodHead:
+			req.w.Header().Set("x-amz-bucket-region", req.config.GetRegion())
+			req.w.WriteHeader(http.StatusOK)
+			return nil
+		case method == http.MethodPost && query.Has("delete"):
+			return deleteObjects(req)
+		case method == http.MethodPut:
+			return &s3Error{Status: http.StatusConflict, Code: "BucketAlreadyOwnedByYou", Message: "buckets are the tables of the database"}
+		}
+		return errNotImplemented(fmt.Sprintf("%s on a bucket is not supported", method))
+	}
+
+	switch method {
+	case http.MethodGet, http.MethodHead:
+		if query.Has("uploadId") && method == http.MethodGet {
+			return h.listParts(req)
+		}
+		if err := checkSubresources(query, objectParams...); err != nil {
+			return err
+		}
+		return getObject(req)
+	case http.MethodPut:
+		if query.Has("uploadId") {
+			return h.uploadPart(req)
+		}
+		if err := checkSubresources(query, objectParams...); err != nil {
+			return err
+		}
+		if req.r.Header.Get("x-amz-copy-source") != "" {
+			return copyObject(req)
+		}
+		return putObject(req)
+	case http.MethodPost:
+		if query.Has("uploads") {
+			return h.createMultipartUpload(req)
+		}
+		if query.Has("uploadId") {
+			return h.completeMultipartUpload(req)
+		}
+	case http.MethodDelete:
+		if query.Has("uploadId") {
+			return h.abortMultipartUpload(req)
+		}
+		if err := checkSubresources(query, objectParams...); err != nil {
+			return err
+		}
+		if err := deleteObject(req, req.key); err != nil {
+			return err
+		}
+		req.w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+	return errNotImplemented(fmt.Sprintf("%s on an object is not supported", method))
+}
+
+// Query parameters of the supported operations. Anything else is a subresource (acl, tagging,
+// versioning, ...) that isn't implemented.
+var (
+	listParams   = []string{"prefix", "delimiter", "marker", "max-keys", "encoding-type", "list-type", "continuation-token", "start-after", "fetch-owner"}
+	objectParams = []string{"partNumber", "response-content-type", "response-content-language", "response-expires", "response-cache-control", "response-content-disposition", "response-content-encoding"}
+)
+
+// checkSubresources rejects query parameters that aren't in allowed. Signature parameters of
+// presigned URLs and the x-id parameter the AWS SDKs add are always allowed.
+func checkSubresources(query url.Values, allowed ...string) error {
+	for name := range query {
+		if name == "x-id" || strings.HasPrefix(name, "X-Amz-") {
+			continue
+		}
+		known := false
+		for _, param := range allowed {
+			if name == param {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return errNotImplemented(fmt.Sprintf("the %s subresource is not supported", name))
+		}
+	}
+	return nil
+}
+
+// splitPath splits a request path below Prefix into the bucket and the object key
+func splitPath(path string) (string, string) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, Prefix), "/")
+	bucket, key, _ := strings.Cut(path, "/")
+	return bucket, key
+}
+
+// resolveBucket maps a bucket name to the ID of the table with that name
+func resolveBucket(database *db.DB, bucket string) (string, error) {
+	resp, err := coreTables.ListTables(database)
+	if err != nil {
+		return "", err
+	}
+	for _, table := range resp.Tables {
+		if table.TableName == bucket {
+			return table.TableID, nil
+		}
+	}
+	return "", &s3Error{Status: http.StatusNotFound, Code: "NoSuchBucket", Message: "The specified bucket does not exist"}
+}
+
+func newRequestID() string {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return strings.ToUpper(hex.EncodeToString(id))
+}
@@ -0,0 +1,362 @@
+package s3
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Voltaic314/GhostFS/code/core/items"
+	coreTables "github.com/Voltaic314/GhostFS/code/core/tables"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+)
+
+// maxListKeys caps the keys of one listing page, like S3
+const maxListKeys = 1000
+
+type owner struct {
+	ID          string `xml:"ID"`
+	DisplayName string `xml:"DisplayName"`
+}
+
+type bucketEntry struct {
+	Name         string `xml:"Name"`
+	CreationDate string `xml:"CreationDate"`
+}
+
+type listBucketsResult struct {
+	XMLName xml.Name      `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListAllMyBucketsResult"`
+	Owner   owner         `xml:"Owner"`
+	Buckets []bucketEntry `xml:"Buckets>Bucket"`
+}
+
+// listBuckets serves ListBuckets: one bucket per table, created with its root
+func listBuckets(req *s3Request) error {
+	resp, err := coreTables.ListTables(req.database)
+	if err != nil {
+		return err
+	}
+
+	result := listBucketsResult{Owner: owner{ID: "ghostfs", DisplayName: "ghostfs"}}
+	for _, table := range resp.Tables {
+		root, err := items.GetRoot(req.tableManager, req.database, items.GetRootRequest{TableID: table.TableID})
+		if err != nil {
+			return err
+		}
+		result.Buckets = append(result.Buckets, bucketEntry{Name: table.TableName, CreationDate: formatTime(root.Root.CreatedAt)})
+	}
+	sort.Slice(result.Buckets, func(i, j int) bool { return result.Buckets[i].Name < result.Buckets[j].Name })
+
+	req.writeXML(http.StatusOK, result)
+	return nil
+}
+
+type locationConstraint struct {
+	XMLName xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ LocationConstraint"`
+	Region  string   `xml:",chardata"`
+}
+
+// getBucketLocation serves GetBucketLocation (us-east-1 is reported as an empty location, like AWS)
+func getBucketLocation(req *s3Request) error {
+	region := req.config.GetRegion()
+	if region == "us-east-1" {
+		region = ""
+	}
+	req.writeXML(http.StatusOK, locationConstraint{Region: region})
+	return nil
+}
+
+type objectEntry struct {
+	Key          string `xml:"Key"`
+	LastModified string `xml:"LastModified"`
+	ETag         string `xml:"ETag"`
+	Size         int64  `xml:"Size"`
+	Owner        *owner `xml:"Owner,omitempty"`
+	StorageClass string `xml:"StorageClass"`
+}
+
+type commonPrefix struct {
+	Prefix string `xml:"Prefix"`
+}
+
+type listObjectsV2Result struct {
+	XMLName               xml.Name       `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListBucketResult"`
+	Name                  string         `xml:"Name"`
+	Prefix                string         `xml:"Prefix"`
+	Delimiter             string         `xml:"Delimiter,omitempty"`
+	MaxKeys               int            `xml:"MaxKeys"`
+	KeyCount              int            `xml:"KeyCount"`
+	IsTruncated           bool           `xml:"IsTruncated"`
+	ContinuationToken     string         `xml:"ContinuationToken,omitempty"`
+	NextContinuationToken string         `xml:"NextContinuationToken,omitempty"`
+	StartAfter            string         `xml:"StartAfter,omitempty"`
+	EncodingType          string         `xml:"EncodingType,omitempty"`
+	Contents              []objectEntry  `xml:"Contents"`
+	CommonPrefixes        []commonPrefix `xml:"CommonPrefixes"`
+}
+
+type listObjectsV1Result struct {
+	XMLName        xml.Name       `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListBucketResult"`
+	Name           string         `xml:"Name"`
+	Prefix         string         `xml:"Prefix"`
+	Marker         string         `xml:"Marker"`
+	NextMarker     string         `xml:"NextMarker,omitempty"`
+	Delimiter      string         `xml:"Delimiter,omitempty"`
+	MaxKeys        int            `xml:"MaxKeys"`
+	IsTruncated    bool           `xml:"IsTruncated"`
+	EncodingType   string         `xml:"EncodingType,omitempty"`
+	Contents       []objectEntry  `xml:"Contents"`
+	CommonPrefixes []commonPrefix `xml:"CommonPrefixes"`
+}
+
+// listObjectsV2 serves ListObjectsV2. Continuation tokens encode the last key or common prefix
+// returned, so pages stay consistent while the tree is generated lazily.
+func listObjectsV2(req *s3Request) error {
+	query := req.r.URL.Query()
+	maxKeys, err := parseMaxKeys(query)
+	if err != nil {
+		return err
+	}
+
+	after := query.Get("start-after")
+	token := query.Get("continuation-token")
+	if token != "" {
+		decoded, err := base64.RawURLEncoding.DecodeString(token)
+		if err != nil {
+			return errInvalidArgument("The continuation token provided is incorrect")
+		}
+		after = string(decoded)
+	}
+
+	l, err := listObjects(req, query.Get("prefix"), query.Get("delimiter"), after, maxKeys, query.Get("fetch-owner") == "true")
+	if err != nil {
+		return err
+	}
+
+	encode := encoder(query)
+	result := listObjectsV2Result{
+		Name:              req.bucket,
+		Prefix:            encode(l.prefix),
+		Delimiter:         encode(l.delimiter),
+		MaxKeys:           maxKeys,
+		KeyCount:          len(l.objects) + len(l.prefixes),
+		IsTruncated:       l.truncated,
+		ContinuationToken: token,
+		StartAfter:        encode(query.Get("start-after")),
+		EncodingType:      query.Get("encoding-type"),
+		Contents:          l.entries(encode),
+		CommonPrefixes:    l.commonPrefixes(encode),
+	}
+	if l.truncated {
+		result.NextContinuationToken = base64.RawURLEncoding.EncodeToString([]byte(l.last))
+	}
+	req.writeXML(http.StatusOK, result)
+	return nil
+}
+
+// listObjectsV1 serves the original ListObjects, paged with markers
+func listObjectsV1(req *s3Request) error {
+	query := req.r.URL.Query()
+	maxKeys, err := parseMaxKeys(query)
+	if err != nil {
+		return err
+	}
+
+	l, err := listObjects(req, query.Get("prefix"), query.Get("delimiter"), query.Get("marker"), maxKeys, true)
+	if err != nil {
+		return err
+	}
+
+	encode := encoder(query)
+	result := listObjectsV1Result{
+		Name:           req.bucket,
+		Prefix:         encode(l.prefix),
+		Marker:         encode(query.Get("marker")),
+		Delimiter:      encode(l.delimiter),
+		MaxKeys:        maxKeys,
+		IsTruncated:    l.truncated,
+		EncodingType:   query.Get("encoding-type"),
+		Contents:       l.entries(encode),
+		CommonPrefixes: l.commonPrefixes(encode),
+	}
+	if l.truncated {
+		result.NextMarker = encode(l.last)
+	}
+	req.writeXML(http.StatusOK, result)
+	return nil
+}
+
+func parseMaxKeys(query url.Values) (int, error) {
+	if query.Get("max-keys") == "" {
+		return maxListKeys, nil
+	}
+	maxKeys, err := strconv.Atoi(query.Get("max-keys"))
+	if err != nil || maxKeys < 0 {
+		return 0, errInvalidArgument("max-keys must be a non-negative integer")
+	}
+	return min(maxKeys, maxListKeys), nil
+}
+
+// encoder returns how keys are written in a listing: URL-encoded if the client asked for it
+func encoder(query url.Values) func(string) string {
+	if query.Get("encoding-type") == "url" {
+		return url.QueryEscape
+	}
+	return func(s string) string { return s }
+}
+
+// errListFull stops the walk once a page is full
+var errListFull = errors.New("listing page is full")
+
+// listing is one page of a bucket listing. Keys are produced in S3's (byte) order by walking the
+// tree depth-first with each folder's entries sorted by key, so only the folders a page touches are
+// listed (and generated).
+type listing struct {
+	req         *s3Request
+	prefix      string
+	delimiter   string
+	after       string // keys up to this one were returned on earlier pages
+	afterPrefix bool   // after is a common prefix, everything below it was returned too
+	maxKeys     int
+	withOwner   bool
+
+	objects    []dbTypes.Node
+	prefixes   []string
+	lastPrefix string
+	last       string
+	truncated  bool
+}
+
+func listObjects(req *s3Request, prefix, delimiter, after string, maxKeys int, withOwner bool) (*listing, error) {
+	l := &listing{req: req, prefix: prefix, delimiter: delimiter, after: after, maxKeys: maxKeys, withOwner: withOwner}
+	// A common prefix is only returned as such, so a page can't end on a key containing the delimiter
+	l.afterPrefix = after != "" && delimiter != "" && strings.HasPrefix(after, prefix) && strings.Contains(after[len(prefix):], delimiter)
+	if maxKeys == 0 {
+		return l, nil
+	}
+
+	root, err := items.GetRoot(req.tableManager, req.database, items.GetRootRequest{TableID: req.tableID})
+	if err != nil {
+		return nil, err
+	}
+	if err := l.walk(root.Root.ID); err != nil && !errors.Is(err, errListFull) {
+		return nil, err
+	}
+	return l, nil
+}
+
+type listEntry struct {
+	key  string
+	node dbTypes.Node
+}
+
+// walk adds the objects and common prefixes below a folder to the page
+func (l *listing) walk(folderID string) error {
+	resp, err := items.ListItems(l.req.tableManager, l.req.database, l.req.generator, items.ListItemsRequest{TableID: l.req.tableID, FolderID: folderID})
+	if err != nil {
+		return err
+	}
+	entries := make([]listEntry, 0, len(resp.Items))
+	for _, child := range resp.Items {
+		if isObject(child) {
+			entries = append(entries, listEntry{key: objectKey(child), node: child})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+
+	for _, entry := range entries {
+		key, folder := entry.key, entry.node.Type == tables.NodeTypeFolder
+		inPrefix := strings.HasPrefix(key, l.prefix)
+		if !inPrefix && !(folder && strings.HasPrefix(l.prefix, key)) {
+			continue
+		}
+
+		// Keys with the delimiter after the prefix roll up into a common prefix (a folder's
+		// whole subtree does once its own key does)
+		if l.delimiter != "" && inPrefix {
+			if i := strings.Index(key[len(l.prefix):], l.delimiter); i >= 0 {
+				common := key[:len(l.prefix)+i+len(l.delimiter)]
+				if common != l.lastPrefix && common > l.after {
+					if err := l.add(common, nil); err != nil {
+						return err
+					}
+				}
+				l.lastPrefix = common
+				continue
+			}
+		}
+
+		if inPrefix && !l.returned(key) {
+			if err := l.add(key, &entry.node); err != nil {
+				return err
+			}
+		}
+		if folder && !l.subtreeReturned(key) {
+			if err := l.walk(entry.node.ID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// returned reports whether a key was on an earlier page
+func (l *listing) returned(key string) bool {
+	return key <= l.after || (l.afterPrefix && strings.HasPrefix(key, l.after))
+}
+
+// subtreeReturned reports whether all keys below a folder were on earlier pages
+func (l *listing) subtreeReturned(folderKey string) bool {
+	if l.after == "" {
+		return false
+	}
+	return (folderKey < l.after && !strings.HasPrefix(l.after, folderKey)) || (l.afterPrefix && strings.HasPrefix(folderKey, l.after))
+}
+
+// add puts an object (or a common prefix if node is nil) on the page
+func (l *listing) add(key string, node *dbTypes.Node) error {
+	if len(l.objects)+len(l.prefixes) == l.maxKeys {
+		l.truncated = true
+		return errListFull
+	}
+	if node == nil {
+		l.prefixes = append(l.prefixes, key)
+	} else {
+		l.objects = append(l.objects, *node)
+	}
+	l.last = key
+	return nil
+}
+
+func (l *listing) entries(encode func(string) string) []objectEntry {
+	entries := make([]objectEntry, len(l.objects))
+	for i, node := range l.objects {
+		entries[i] = objectEntry{
+			Key:          encode(objectKey(node)),
+			LastModified: formatTime(node.UpdatedAt),
+			ETag:         etag(l.req.generator, node),
+			Size:         node.Size,
+			StorageClass: "STANDARD",
+		}
+		if node.Type == tables.NodeTypeFolder {
+			entries[i].Size = 0
+		}
+		if l.withOwner {
+			entries[i].Owner = &owner{ID: "ghostfs", DisplayName: "ghostfs"}
+		}
+	}
+	return entries
+}
+
+func (l *listing) commonPrefixes(encode func(string) string) []commonPrefix {
+	prefixes := make([]commonPrefix, len(l.prefixes))
+	for i, prefix := range l.prefixes {
+		prefixes[i] = commonPrefix{Prefix: encode(prefix)}
+	}
+	return prefixes
+}
@@ -0,0 +1,276 @@
+package s3
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	maxPartNumber = 10000
+	minPartSize   = 5 << 20 // every part but the last, like S3
+)
+
+// multipartUpload is an upload in progress. Parts are counted, not kept, like PutObject payloads.
+type multipartUpload struct {
+	id        string
+	tableID   string
+	key       string
+	initiated time.Time
+	parts     map[int]uploadedPart
+}
+
+type uploadedPart struct {
+	size         int64
+	etag         string
+	lastModified time.Time
+}
+
+// uploadStore keeps the multipart uploads in progress. Uploads don't survive a restart.
+type uploadStore struct {
+	mu      sync.Mutex
+	uploads map[string]*multipartUpload
+}
+
+func newUploadStore() *uploadStore {
+	return &uploadStore{uploads: make(map[string]*multipartUpload)}
+}
+
+// get returns an upload of the request's object
+func (s *uploadStore) get(req *s3Request) (*multipartUpload, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	upload, ok := s.uploads[req.r.URL.Query().Get("uploadId")]
+	if !ok || upload.tableID != req.tableID || upload.key != req.key {
+		return nil, &s3Error{Status: http.StatusNotFound, Code: "NoSuchUpload", Message: "The specified upload does not exist. The upload ID may be invalid, or the upload may have been aborted or completed."}
+	}
+	return upload, nil
+}
+
+type initiateMultipartUploadResult struct {
+	XMLName  xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ InitiateMultipartUploadResult"`
+	Bucket   string   `xml:"Bucket"`
+	Key      string   `xml:"Key"`
+	UploadID string   `xml:"UploadId"`
+}
+
+// createMultipartUpload serves CreateMultipartUpload
+func (h *handler) createMultipartUpload(req *s3Request) error {
+	if _, folder, ok := keyPath(req.key); !ok || folder {
+		return errInvalidArgument(fmt.Sprintf("invalid key %q", req.key))
+	}
+
+	upload := &multipartUpload{
+		id:        strings.ReplaceAll(uuid.New().String(), "-", ""),
+		tableID:   req.tableID,
+		key:       req.key,
+		initiated: time.Now().UTC(),
+		parts:     make(map[int]uploadedPart),
+	}
+	h.uploads.mu.Lock()
+	h.uploads.uploads[upload.id] = upload
+	h.uploads.mu.Unlock()
+
+	req.writeXML(http.StatusOK, initiateMultipartUploadResult{Bucket: req.bucket, Key: req.key, UploadID: upload.id})
+	return nil
+}
+
+// uploadPart serves UploadPart. The part's ETag is the MD5 of the bytes sent, as in S3.
+func (h *handler) uploadPart(req *s3Request) error {
+	upload, err := h.uploads.get(req)
+	if err != nil {
+		return err
+	}
+	if req.r.Header.Get("x-amz-copy-source") != "" {
+		return errNotImplemented("UploadPartCopy is not supported")
+	}
+	partNumber, err := strconv.Atoi(req.r.URL.Query().Get("partNumber"))
+	if err != nil || partNumber < 1 || partNumber > maxPartNumber {
+		return errInvalidArgument("Part number must be an integer between 1 and 10000, inclusive")
+	}
+
+	hash := md5.New()
+	size, err := io.Copy(hash, req.body)
+	if err != nil {
+		return err
+	}
+	part := uploadedPart{size: size, etag: `"` + hex.EncodeToString(hash.Sum(nil)) + `"`, lastModified: time.Now().UTC()}
+
+	h.uploads.mu.Lock()
+	upload.parts[partNumber] = part
+	h.uploads.mu.Unlock()
+
+	req.w.Header().Set("ETag", part.etag)
+	req.w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+type completeMultipartUpload struct {
+	Parts []struct {
+		PartNumber int    `xml:"PartNumber"`
+		ETag       string `xml:"ETag"`
+	} `xml:"Part"`
+}
+
+type completeMultipartUploadResult struct {
+	XMLName  xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ CompleteMultipartUploadResult"`
+	Location string   `xml:"Location"`
+	Bucket   string   `xml:"Bucket"`
+	Key      string   `xml:"Key"`
+	ETag     string   `xml:"ETag"`
+}
+
+// completeMultipartUpload serves CompleteMultipartUpload: the object gets the total size of the
+// listed parts
+func (h *handler) completeMultipartUpload(req *s3Request) error {
+	upload, err := h.uploads.get(req)
+	if err != nil {
+		return err
+	}
+
+	var body completeMultipartUpload
+	if err := xml.NewDecoder(io.LimitReader(req.body, 2<<20)).Decode(&body); err != nil {
+		var s3Err *s3Error
+		if errors.As(err, &s3Err) {
+			return s3Err
+		}
+		return errMalformedXML()
+	}
+	if len(body.Parts) == 0 {
+		return errMalformedXML()
+	}
+
+	h.uploads.mu.Lock()
+	var size int64
+	var partErr *s3Error
+	for i, listed := range body.Parts {
+		part, ok := upload.parts[listed.PartNumber]
+		switch {
+		case i > 0 && listed.PartNumber <= body.Parts[i-1].PartNumber:
+			partErr = &s3Error{Status: http.StatusBadRequest, Code: "InvalidPartOrder", Message: "The list of parts was not in ascending order. Parts must be ordered by part number."}
+		case !ok || strings.Trim(listed.ETag, `"`) != strings.Trim(part.etag, `"`):
+			partErr = &s3Error{Status: http.StatusBadRequest, Code: "InvalidPart", Message: fmt.Sprintf("Part %d could not be found or its ETag doesn't match.", listed.PartNumber)}
+		case i < len(body.Parts)-1 && part.size < minPartSize:
+			partErr = &s3Error{Status: http.StatusBadRequest, Code: "EntityTooSmall", Message: "Your proposed upload is smaller than the minimum allowed object size."}
+		}
+		if partErr != nil {
+			break
+		}
+		size += part.size
+	}
+	h.uploads.mu.Unlock()
+	if partErr != nil {
+		return partErr
+	}
+
+	node, err := writeObject(req, req.key, size, 0)
+	if err != nil {
+		return err
+	}
+	h.uploads.mu.Lock()
+	delete(h.uploads.uploads, upload.id)
+	h.uploads.mu.Unlock()
+
+	req.writeXML(http.StatusOK, completeMultipartUploadResult{
+		Location: req.r.URL.Path,
+		Bucket:   req.bucket,
+		Key:      req.key,
+		ETag:     etag(req.generator, *node),
+	})
+	return nil
+}
+
+// abortMultipartUpload serves AbortMultipartUpload
+func (h *handler) abortMultipartUpload(req *s3Request) error {
+	upload, err := h.uploads.get(req)
+	if err != nil {
+		return err
+	}
+	h.uploads.mu.Lock()
+	delete(h.uploads.uploads, upload.id)
+	h.uploads.mu.Unlock()
+
+	req.w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+type partEntry struct {
+	PartNumber   int    `xml:"PartNumber"`
+	LastModified string `xml:"LastModified"`
+	ETag         string `xml:"ETag"`
+	Size         int64  `xml:"Size"`
+}
+
+type listPartsResult struct {
+	XMLName     xml.Name    `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListPartsResult"`
+	Bucket      string      `xml:"Bucket"`
+	Key         string      `xml:"Key"`
+	UploadID    string      `xml:"UploadId"`
+	IsTruncated bool        `xml:"IsTruncated"`
+	Parts       []partEntry `xml:"Part"`
+}
+
+// listParts serves ListParts (all parts on one page)
+func (h *handler) listParts(req *s3Request) error {
+	upload, err := h.uploads.get(req)
+	if err != nil {
+		return err
+	}
+
+	result := listPartsResult{Bucket: req.bucket, Key: req.key, UploadID: upload.id}
+	h.uploads.mu.Lock()
+	for number, part := range upload.parts {
+		result.Parts = append(result.Parts, partEntry{PartNumber: number, LastModified: formatTime(part.lastModified), ETag: part.etag, Size: part.size})
+	}
+	h.uploads.mu.Unlock()
+	sort.Slice(result.Parts, func(i, j int) bool { return result.Parts[i].PartNumber < result.Parts[j].PartNumber })
+
+	req.writeXML(http.StatusOK, result)
+	return nil
+}
+
+type uploadEntry struct {
+	Key          string `xml:"Key"`
+	UploadID     string `xml:"UploadId"`
+	Initiated    string `xml:"Initiated"`
+	StorageClass string `xml:"StorageClass"`
+}
+
+type listMultipartUploadsResult struct {
+	XMLName     xml.Name      `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListMultipartUploadsResult"`
+	Bucket      string        `xml:"Bucket"`
+	IsTruncated bool          `xml:"IsTruncated"`
+	Uploads     []uploadEntry `xml:"Upload"`
+}
+
+// listMultipartUploads serves ListMultipartUploads (all uploads of the bucket on one page)
+func (h *handler) listMultipartUploads(req *s3Request) error {
+	result := listMultipartUploadsResult{Bucket: req.bucket}
+	h.uploads.mu.Lock()
+	for _, upload := range h.uploads.uploads {
+		if upload.tableID == req.tableID {
+			result.Uploads = append(result.Uploads, uploadEntry{Key: upload.key, UploadID: upload.id, Initiated: formatTime(upload.initiated), StorageClass: "STANDARD"})
+		}
+	}
+	h.uploads.mu.Unlock()
+	sort.Slice(result.Uploads, func(i, j int) bool {
+		if result.Uploads[i].Key != result.Uploads[j].Key {
+			return result.Uploads[i].Key < result.Uploads[j].Key
+		}
+		return result.Uploads[i].Initiated < result.Uploads[j].Initiated
+	})
+
+	req.writeXML(http.StatusOK, result)
+	return nil
+}
@@ -0,0 +1,392 @@
+package s3
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Voltaic314/GhostFS/code/core/items"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+)
+
+// emptyETag is the ETag of empty objects (the MD5 of no bytes), folder markers included
+const emptyETag = `"d41d8cd98f00b204e9800998ecf8427e"`
+
+// Objects are the files of a table, keyed by their path without the leading slash. Folders are
+// zero-byte "folder/" marker objects, like the ones S3 consoles create, so empty folders are
+// listed too. Links other than hard links aren't objects.
+
+// objectKey returns the key of a node
+func objectKey(node dbTypes.Node) string {
+	key := strings.TrimPrefix(node.Path, "/")
+	if node.Type == tables.NodeTypeFolder {
+		key += "/"
+	}
+	return key
+}
+
+// isObject reports whether a node is served as an object
+func isObject(node dbTypes.Node) bool {
+	return node.Type == tables.NodeTypeFile || node.Type == tables.NodeTypeHardlink || node.Type == tables.NodeTypeFolder
+}
+
+// etag returns a node's ETag. Content isn't hashed (that would mean generating all of it), the ETag
+// is derived from the content seed and size, so it changes with the content and copies share it.
+func etag(generator *tables.DeterministicGenerator, node dbTypes.Node) string {
+	if node.Type == tables.NodeTypeFolder || node.Size == 0 {
+		return emptyETag
+	}
+	sum := md5.Sum([]byte(fmt.Sprintf("%d:%d", generator.ContentSeed(node), node.Size)))
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+// keyPath maps a key to a node path and whether it names a folder marker. Keys that can't name a
+// node (empty segments, "." or "..") aren't valid.
+func keyPath(key string) (string, bool, bool) {
+	folder := strings.HasSuffix(key, "/")
+	trimmed := strings.TrimSuffix(key, "/")
+	if trimmed == "" {
+		return "", false, false
+	}
+	for _, name := range strings.Split(trimmed, "/") {
+		if name == "" || name == "." || name == ".." {
+			return "", false, false
+		}
+	}
+	return "/" + trimmed, folder, true
+}
+
+// getNode returns the node an object key names, or nil if there is no such object
+func getNode(req *s3Request, tableID string, key string) (*dbTypes.Node, error) {
+	nodePath, folder, ok := keyPath(key)
+	if !ok {
+		return nil, nil
+	}
+	resp, err := items.GetItemByPath(req.tableManager, req.database, req.generator, items.GetItemByPathRequest{TableID: tableID, Path: nodePath})
+	var itemErr *items.ItemError
+	if errors.As(err, &itemErr) && itemErr.Code == items.ErrCodeNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	node := resp.Item
+	if !isObject(node) || folder != (node.Type == tables.NodeTypeFolder) {
+		return nil, nil
+	}
+	return &node, nil
+}
+
+// getObject serves GetObject and HeadObject, with Range and conditional requests
+func getObject(req *s3Request) error {
+	node, err := getNode(req, req.tableID, req.key)
+	if err != nil {
+		return err
+	}
+	if node == nil {
+		return errNoSuchKey()
+	}
+
+	header := req.w.Header()
+	header.Set("ETag", etag(req.generator, *node))
+	header.Set("Content-Type", contentType(*node))
+	header.Set("x-amz-storage-class", "STANDARD")
+	query := req.r.URL.Query()
+	for param, name := range responseOverrides {
+		if value := query.Get(param); value != "" {
+			header.Set(name, value)
+		}
+	}
+
+	var content io.ReadSeeker = strings.NewReader("")
+	if node.Type != tables.NodeTypeFolder {
+		content = req.generator.OpenContent(*node, node.Size)
+	}
+	http.ServeContent(req.w, req.r, node.Name, node.UpdatedAt, content)
+	return nil
+}
+
+// responseOverrides are the GetObject parameters that replace response headers
+var responseOverrides = map[string]string{
+	"response-content-type":        "Content-Type",
+	"response-content-language":    "Content-Language",
+	"response-expires":             "Expires",
+	"response-cache-control":       "Cache-Control",
+	"response-content-disposition": "Content-Disposition",
+	"response-content-encoding":    "Content-Encoding",
+}
+
+// contentType guesses an object's type from its extension
+func contentType(node dbTypes.Node) string {
+	if node.Type == tables.NodeTypeFolder {
+		return "application/x-directory"
+	}
+	if guessed := mime.TypeByExtension(path.Ext(node.Name)); guessed != "" {
+		return guessed
+	}
+	return "binary/octet-stream"
+}
+
+// putObject serves PutObject. Only the size of the payload is kept, the object reads back as seeded
+// content. Keys ending in "/" create folders.
+func putObject(req *s3Request) error {
+	size, err := io.Copy(io.Discard, req.body)
+	if err != nil {
+		return err
+	}
+
+	node, err := writeObject(req, req.key, size, 0)
+	if err != nil {
+		return err
+	}
+	req.w.Header().Set("ETag", etag(req.generator, *node))
+	req.w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// writeObject stores an object of size bytes under key, creating the folders on the way. An
+// existing file gets the new content as a new revision. A zero content seed means new content.
+func writeObject(req *s3Request, key string, size int64, contentSeed int64) (*dbTypes.Node, error) {
+	nodePath, folder, ok := keyPath(key)
+	if !ok {
+		return nil, errInvalidArgument(fmt.Sprintf("invalid key %q", key))
+	}
+	if folder {
+		if size > 0 {
+			return nil, errInvalidArgument("folder markers (keys ending in /) must be empty")
+		}
+		return ensureFolder(req, nodePath)
+	}
+
+	parent, err := ensureFolder(req, path.Dir(nodePath))
+	if err != nil {
+		return nil, err
+	}
+	name := path.Base(nodePath)
+	existing, err := findChild(req, parent, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing != nil {
+		if existing.Type != tables.NodeTypeFile {
+			return nil, &s3Error{Status: http.StatusBadRequest, Code: "InvalidRequest", Message: fmt.Sprintf("%s is a %s, it can't be overwritten with an object", existing.Path, existing.Type)}
+		}
+		resp, err := items.UpdateFile(req.tableManager, req.database, req.generator, items.UpdateFileRequest{
+			TableID:     req.tableID,
+			ItemID:      existing.ID,
+			Size:        size,
+			ContentSeed: contentSeed,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &resp.Item, nil
+	}
+
+	return createItem(req, parent, items.NewItem{Name: name, Type: tables.NodeTypeFile, Size: size, ContentSeed: contentSeed})
+}
+
+// ensureFolder returns the folder at folderPath, creating it and its missing parents
+func ensureFolder(req *s3Request, folderPath string) (*dbTypes.Node, error) {
+	root, err := items.GetRoot(req.tableManager, req.database, items.GetRootRequest{TableID: req.tableID})
+	if err != nil {
+		return nil, err
+	}
+	folder := &root.Root
+
+	for _, name := range strings.Split(strings.Trim(folderPath, "/"), "/") {
+		if name == "" {
+			continue
+		}
+		child, err := findChild(req, folder, name)
+		if err != nil {
+			return nil, err
+		}
+		if child == nil {
+			child, err = createItem(req, folder, items.NewItem{Name: name, Type: tables.NodeTypeFolder})
+			if err != nil {
+				return nil, err
+			}
+		}
+		if child.Type != tables.NodeTypeFolder {
+			return nil, &s3Error{Status: http.StatusBadRequest, Code: "InvalidRequest", Message: fmt.Sprintf("%s is a %s, it can't hold objects", child.Path, child.Type)}
+		}
+		folder = child
+	}
+	return folder, nil
+}
+
+// findChild returns the child of a folder with the given name, or nil
+func findChild(req *s3Request, folder *dbTypes.Node, name string) (*dbTypes.Node, error) {
+	resp, err := items.ListItems(req.tableManager, req.database, req.generator, items.ListItemsRequest{TableID: req.tableID, FolderID: folder.ID})
+	if err != nil {
+		return nil, err
+	}
+	for _, child := range resp.Items {
+		if child.Name == name {
+			return &child, nil
+		}
+	}
+	return nil, nil
+}
+
+// createItem creates one file or folder
+func createItem(req *s3Request, parent *dbTypes.Node, item items.NewItem) (*dbTypes.Node, error) {
+	resp, err := items.CreateItems(req.tableManager, req.database, req.generator, items.CreateItemsRequest{
+		TableID:  req.tableID,
+		ParentID: parent.ID,
+		Items:    []items.NewItem{item},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result := resp.Results[0]; result.Error != nil {
+		return nil, result.Error
+	}
+	return resp.Results[0].Item, nil
+}
+
+type copyObjectResult struct {
+	XMLName      xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ CopyObjectResult"`
+	ETag         string   `xml:"ETag"`
+	LastModified string   `xml:"LastModified"`
+}
+
+// copyObject serves CopyObject, within a bucket or across buckets. The copy shares the source's content.
+func copyObject(req *s3Request) error {
+	source, err := url.PathUnescape(strings.SplitN(req.r.Header.Get("x-amz-copy-source"), "?", 2)[0])
+	if err != nil {
+		return errInvalidArgument("invalid x-amz-copy-source")
+	}
+	sourceBucket, sourceKey, _ := strings.Cut(strings.TrimPrefix(source, "/"), "/")
+	sourceTableID, err := resolveBucket(req.database, sourceBucket)
+	if err != nil {
+		return err
+	}
+
+	node, err := getNode(req, sourceTableID, sourceKey)
+	if err != nil {
+		return err
+	}
+	if node == nil {
+		return errNoSuchKey()
+	}
+
+	if sourceTableID == req.tableID && sourceKey == req.key {
+		if !strings.EqualFold(req.r.Header.Get("x-amz-metadata-directive"), "REPLACE") {
+			return &s3Error{Status: http.StatusBadRequest, Code: "InvalidRequest", Message: "This copy request is illegal because it is trying to copy an object to itself without changing the object's metadata, storage class, website redirect location or encryption attributes."}
+		}
+	} else {
+		copied, err := writeObject(req, req.key, node.Size, req.generator.ContentSeed(*node))
+		if err != nil {
+			return err
+		}
+		node = copied
+	}
+
+	req.writeXML(http.StatusOK, copyObjectResult{ETag: etag(req.generator, *node), LastModified: formatTime(node.UpdatedAt)})
+	return nil
+}
+
+// deleteObject deletes the object under key. Like S3, deleting a key that doesn't exist succeeds.
+// Folder markers are only deleted with their folder once it is empty, its objects keep the prefix
+// alive otherwise. Tables with a trash keep deleted objects there.
+func deleteObject(req *s3Request, key string) error {
+	node, err := getNode(req, req.tableID, key)
+	if err != nil || node == nil {
+		return err
+	}
+
+	if node.Type == tables.NodeTypeFolder {
+		resp, err := items.ListItems(req.tableManager, req.database, req.generator, items.ListItemsRequest{TableID: req.tableID, FolderID: node.ID})
+		if err != nil {
+			return err
+		}
+		if len(resp.Items) > 0 {
+			return nil
+		}
+	}
+
+	_, err = items.DeleteItem(req.tableManager, req.database, req.generator, items.DeleteItemRequest{TableID: req.tableID, ItemID: node.ID})
+	return err
+}
+
+// maxDeleteObjects is the number of keys DeleteObjects takes at once
+const maxDeleteObjects = 1000
+
+type deleteRequest struct {
+	Quiet   bool `xml:"Quiet"`
+	Objects []struct {
+		Key string `xml:"Key"`
+	} `xml:"Object"`
+}
+
+type deletedObject struct {
+	Key string `xml:"Key"`
+}
+
+type deleteError struct {
+	Key     string `xml:"Key"`
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+type deleteResult struct {
+	XMLName xml.Name        `xml:"http://s3.amazonaws.com/doc/2006-03-01/ DeleteResult"`
+	Deleted []deletedObject `xml:"Deleted"`
+	Errors  []deleteError   `xml:"Error"`
+}
+
+// deleteObjects serves DeleteObjects: up to 1000 keys, with per-key results
+func deleteObjects(req *s3Request) error {
+	var body deleteRequest
+	if err := xml.NewDecoder(io.LimitReader(req.body, 2<<20)).Decode(&body); err != nil {
+		var s3Err *s3Error
+		if errors.As(err, &s3Err) {
+			return s3Err
+		}
+		return errMalformedXML()
+	}
+	if len(body.Objects) == 0 || len(body.Objects) > maxDeleteObjects {
+		return errMalformedXML()
+	}
+
+	var result deleteResult
+	for _, object := range body.Objects {
+		if err := deleteObject(req, object.Key); err != nil {
+			var s3Err *s3Error
+			var itemErr *items.ItemError
+			switch {
+			case errors.As(err, &s3Err):
+			case errors.As(err, &itemErr):
+				s3Err = itemError(itemErr)
+			default:
+				return err
+			}
+			result.Errors = append(result.Errors, deleteError{Key: object.Key, Code: s3Err.Code, Message: s3Err.Message})
+			continue
+		}
+		if !body.Quiet {
+			result.Deleted = append(result.Deleted, deletedObject{Key: object.Key})
+		}
+	}
+
+	req.writeXML(http.StatusOK, result)
+	return nil
+}
+
+// formatTime formats timestamps in XML documents
+func formatTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000Z")
+}
@@ -0,0 +1,110 @@
+package rpc
+
+import (
+	"time"
+
+	"github.com/Voltaic314/GhostFS/code/api/rpc/ghostfspb"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var itemTypes = map[string]ghostfspb.ItemType{
+	tables.NodeTypeFile:     ghostfspb.ItemType_ITEM_TYPE_FILE,
+	tables.NodeTypeFolder:   ghostfspb.ItemType_ITEM_TYPE_FOLDER,
+	tables.NodeTypeSymlink:  ghostfspb.ItemType_ITEM_TYPE_SYMLINK,
+	tables.NodeTypeHardlink: ghostfspb.ItemType_ITEM_TYPE_HARDLINK,
+	tables.NodeTypeShortcut: ghostfspb.ItemType_ITEM_TYPE_SHORTCUT,
+}
+
+// itemOf converts a node to an Item message
+func itemOf(node dbTypes.Node) *ghostfspb.Item {
+	item := &ghostfspb.Item{
+		Id:          node.ID,
+		ParentId:    node.ParentID,
+		Name:        node.Name,
+		Path:        node.Path,
+		Type:        itemTypes[node.Type],
+		Target:      node.Target,
+		Size:        node.Size,
+		ContentSeed: node.ContentSeed,
+		Level:       int32(node.Level),
+		CreatedAt:   timestamppb.New(node.CreatedAt),
+		UpdatedAt:   timestamppb.New(node.UpdatedAt),
+		Shared:      node.Sharing != nil,
+		UserCreated: node.UserCreated,
+	}
+	if lock := node.Lock; lock != nil {
+		item.Lock = &ghostfspb.Lock{
+			Owner:      lock.Owner,
+			AcquiredAt: timestamppb.New(lock.AcquiredAt),
+			ExpiresAt:  timestampOf(lock.ExpiresAt),
+		}
+	}
+	return item
+}
+
+// itemsOf converts nodes to Item messages
+func itemsOf(nodes []dbTypes.Node) []*ghostfspb.Item {
+	list := make([]*ghostfspb.Item, len(nodes))
+	for i, node := range nodes {
+		list[i] = itemOf(node)
+	}
+	return list
+}
+
+// trashItemOf converts an item in the trash to a TrashItem message
+func trashItemOf(trashed dbTypes.TrashItem) *ghostfspb.TrashItem {
+	return &ghostfspb.TrashItem{
+		Item:      itemOf(trashed.Node),
+		DeletedAt: timestamppb.New(trashed.DeletedAt),
+		PurgeAt:   timestampOf(trashed.PurgeAt),
+		Items:     int64(trashed.Items),
+	}
+}
+
+// timestampOf converts an optional time (nil stays unset)
+func timestampOf(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+// revisionOf converts a revision to a Revision message
+func revisionOf(revision dbTypes.Revision) *ghostfspb.Revision {
+	return &ghostfspb.Revision{
+		Id:          revision.ID,
+		Number:      int32(revision.Number),
+		Size:        revision.Size,
+		ContentSeed: revision.ContentSeed,
+		ModifiedAt:  timestamppb.New(revision.ModifiedAt),
+		Author:      revision.Author,
+		Current:     revision.Current,
+	}
+}
+
+// sharingOf converts an item's sharing to a Sharing message
+func sharingOf(sharing dbTypes.Sharing) *ghostfspb.Sharing {
+	message := &ghostfspb.Sharing{Owner: sharing.Owner}
+	for _, member := range sharing.Members {
+		message.Members = append(message.Members, &ghostfspb.ShareMember{Email: member.Email, Role: member.Role})
+	}
+	for _, link := range sharing.Links {
+		message.Links = append(message.Links, sharedLinkOf(link))
+	}
+	return message
+}
+
+// sharedLinkOf converts a shared link to a SharedLink message
+func sharedLinkOf(link dbTypes.SharedLink) *ghostfspb.SharedLink {
+	return &ghostfspb.SharedLink{
+		Id:                link.ID,
+		Url:               link.URL,
+		Scope:             link.Scope,
+		Role:              link.Role,
+		PasswordProtected: link.PasswordProtected,
+		CreatedAt:         timestamppb.New(link.CreatedAt),
+		ExpiresAt:         timestampOf(link.ExpiresAt),
+	}
+}
@@ -0,0 +1,90 @@
+package rpc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Voltaic314/GhostFS/code/core/items"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errorDomain is the domain of the ErrorInfo details. Their reason is the REST API's error code.
+const errorDomain = "ghostfs"
+
+// itemCodes maps the REST API's item error codes to gRPC status codes
+var itemCodes = map[string]codes.Code{
+	items.ErrCodeNotFound:            codes.NotFound,
+	items.ErrCodeNameConflict:        codes.AlreadyExists,
+	items.ErrCodeNotAFolder:          codes.InvalidArgument,
+	items.ErrCodeNotAFile:            codes.InvalidArgument,
+	items.ErrCodeInvalidName:         codes.InvalidArgument,
+	items.ErrCodeInvalidSize:         codes.InvalidArgument,
+	items.ErrCodeInvalidType:         codes.InvalidArgument,
+	items.ErrCodeInvalidDest:         codes.InvalidArgument,
+	items.ErrCodeInvalidLock:         codes.InvalidArgument,
+	items.ErrCodeInvalidShare:        codes.InvalidArgument,
+	tables.ViolationPathTooLong:      codes.InvalidArgument,
+	tables.ViolationNameTooLong:      codes.InvalidArgument,
+	tables.ViolationInvalidCharacter: codes.InvalidArgument,
+	tables.ViolationReservedName:     codes.InvalidArgument,
+	tables.ViolationMaxDepth:         codes.InvalidArgument,
+	items.ErrCodeTooManyItems:        codes.ResourceExhausted,
+	items.ErrCodeNotAllowed:          codes.PermissionDenied,
+	items.ErrCodeLocked:              codes.FailedPrecondition,
+	items.ErrCodeNotLocked:           codes.FailedPrecondition,
+}
+
+// statusOf converts an error of the core packages to a gRPC status. Item errors keep their code as
+// the reason of an ErrorInfo detail, so clients can tell e.g. a locked item from a missing lock.
+func statusOf(method string, err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		err = &items.ItemError{Code: items.ErrCodeNotFound, Message: "item not found"}
+	}
+
+	var itemErr *items.ItemError
+	if !errors.As(err, &itemErr) {
+		fmt.Printf("❌ gRPC %s failed: %v\n", method, err)
+		return status.Error(codes.Internal, err.Error())
+	}
+	code, ok := itemCodes[itemErr.Code]
+	if !ok {
+		code = codes.FailedPrecondition
+	}
+	st := status.New(code, itemErr.Message)
+	if detailed, err := st.WithDetails(&errdetails.ErrorInfo{Reason: itemErr.Code, Domain: errorDomain}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+func unaryErrors(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	resp, err := handler(ctx, req)
+	if err != nil {
+		return nil, statusOf(info.FullMethod, err)
+	}
+	return resp, nil
+}
+
+func streamErrors(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := handler(srv, stream); err != nil {
+		return statusOf(info.FullMethod, err)
+	}
+	return nil
+}
+
+// invalidArgument is a request the service rejects before calling the core packages
+func invalidArgument(format string, args ...any) error {
+	return status.Errorf(codes.InvalidArgument, format, args...)
+}
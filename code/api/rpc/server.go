@@ -0,0 +1,52 @@
+package rpc
+
+import (
+	"fmt"
+	"log"
+	"net"
+
+	"github.com/Voltaic314/GhostFS/code/api/rpc/ghostfspb"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	"google.golang.org/grpc"
+)
+
+// Server is the gRPC API. It serves the same tables and operations as the REST API, with
+// server-streaming listings and downloads and client-streaming uploads.
+type Server struct {
+	address    string
+	grpcServer *grpc.Server
+}
+
+// NewServer creates the gRPC server for an API server. It listens on the configured address, or on
+// defaultAddress (the HTTP server's) if none is configured.
+func NewServer(server interface{}, config *tables.GRPCConfig, defaultAddress string) *Server {
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unaryErrors),
+		grpc.ChainStreamInterceptor(streamErrors),
+	)
+	ghostfspb.RegisterGhostFSServer(grpcServer, newService(server))
+
+	address := config.Address
+	if address == "" {
+		address = defaultAddress
+	}
+	return &Server{
+		address:    fmt.Sprintf("%s:%d", address, config.GetPort()),
+		grpcServer: grpcServer,
+	}
+}
+
+// Start listens and serves gRPC calls until the server is stopped
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.address)
+	if err != nil {
+		return err
+	}
+	log.Printf("📡 gRPC server starting on %s", s.address)
+	return s.grpcServer.Serve(listener)
+}
+
+// Stop stops the server. Calls in progress, streams included, are cancelled.
+func (s *Server) Stop() {
+	s.grpcServer.Stop()
+}
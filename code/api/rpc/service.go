@@ -0,0 +1,334 @@
+package rpc
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Voltaic314/GhostFS/code/api/rpc/ghostfspb"
+	"github.com/Voltaic314/GhostFS/code/core/items"
+	coreTables "github.com/Voltaic314/GhostFS/code/core/tables"
+	"github.com/Voltaic314/GhostFS/code/db"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// service implements the GhostFS gRPC service on top of the same core packages as the REST routes
+type service struct {
+	ghostfspb.UnimplementedGhostFSServer
+	tableManager *tables.TableManager
+	database     *db.DB
+	generator    *tables.DeterministicGenerator
+}
+
+func newService(server interface{}) *service {
+	// Cast server to get access to DB and TableManager
+	s := server.(interface {
+		GetTableManager() *tables.TableManager
+		GetDB() *db.DB
+		GetDeterministicGenerator() *tables.DeterministicGenerator
+	})
+	return &service{
+		tableManager: s.GetTableManager(),
+		database:     s.GetDB(),
+		generator:    s.GetDeterministicGenerator(),
+	}
+}
+
+// checkTable returns NotFound for unknown tables, which the core packages report as plain errors
+func (s *service) checkTable(tableID string) error {
+	if tableID == "" {
+		return invalidArgument("table_id is required")
+	}
+	if _, exists := s.tableManager.GetTableNameByID(tableID); exists {
+		return nil
+	}
+	if _, err := tables.GetTableName(s.database, tableID); err != nil {
+		return status.Errorf(codes.NotFound, "table not found: %s", tableID)
+	}
+	return nil
+}
+
+func (s *service) ListTables(ctx context.Context, req *ghostfspb.ListTablesRequest) (*ghostfspb.ListTablesResponse, error) {
+	resp, err := coreTables.ListTables(s.database)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(resp.Tables, func(i, j int) bool { return resp.Tables[i].TableName < resp.Tables[j].TableName })
+	list := make([]*ghostfspb.Table, len(resp.Tables))
+	for i, info := range resp.Tables {
+		list[i] = &ghostfspb.Table{TableId: info.TableID, TableName: info.TableName, Type: info.Type}
+	}
+	return &ghostfspb.ListTablesResponse{Tables: list}, nil
+}
+
+func (s *service) GetRoot(ctx context.Context, req *ghostfspb.GetRootRequest) (*ghostfspb.Item, error) {
+	if err := s.checkTable(req.TableId); err != nil {
+		return nil, err
+	}
+	resp, err := items.GetRoot(s.tableManager, s.database, items.GetRootRequest{TableID: req.TableId})
+	if err != nil {
+		return nil, err
+	}
+	return itemOf(resp.Root), nil
+}
+
+func (s *service) GetItem(ctx context.Context, req *ghostfspb.GetItemRequest) (*ghostfspb.Item, error) {
+	if err := s.checkTable(req.TableId); err != nil {
+		return nil, err
+	}
+	if req.Path != "" {
+		resp, err := items.GetItemByPath(s.tableManager, s.database, s.generator, items.GetItemByPathRequest{TableID: req.TableId, Path: req.Path})
+		if err != nil {
+			return nil, err
+		}
+		return itemOf(resp.Item), nil
+	}
+	if req.ItemId == "" {
+		return nil, invalidArgument("item_id or path is required")
+	}
+	resp, err := items.GetItem(s.tableManager, s.database, items.GetItemRequest{TableID: req.TableId, ItemID: req.ItemId})
+	if err != nil {
+		return nil, err
+	}
+	return itemOf(resp.Item), nil
+}
+
+func (s *service) ListItems(ctx context.Context, req *ghostfspb.ListItemsRequest) (*ghostfspb.ListItemsResponse, error) {
+	if err := s.checkTable(req.TableId); err != nil {
+		return nil, err
+	}
+	resp, err := items.ListItems(s.tableManager, s.database, s.generator, items.ListItemsRequest{TableID: req.TableId, FolderID: req.FolderId, FoldersOnly: req.FoldersOnly})
+	if err != nil {
+		return nil, err
+	}
+	return &ghostfspb.ListItemsResponse{Items: itemsOf(resp.Items)}, nil
+}
+
+func (s *service) CreateItems(ctx context.Context, req *ghostfspb.CreateItemsRequest) (*ghostfspb.CreateItemsResponse, error) {
+	if err := s.checkTable(req.TableId); err != nil {
+		return nil, err
+	}
+	newItems := make([]items.NewItem, len(req.Items))
+	for i, item := range req.Items {
+		var itemType string
+		switch item.Type {
+		case ghostfspb.ItemType_ITEM_TYPE_FILE:
+			itemType = tables.NodeTypeFile
+		case ghostfspb.ItemType_ITEM_TYPE_FOLDER:
+			itemType = tables.NodeTypeFolder
+		default:
+			return nil, invalidArgument("item %d: only files and folders can be created", i)
+		}
+		newItems[i] = items.NewItem{Name: item.Name, Type: itemType, Size: item.Size, ContentSeed: item.ContentSeed}
+	}
+
+	resp, err := items.CreateItems(s.tableManager, s.database, s.generator, items.CreateItemsRequest{TableID: req.TableId, ParentID: req.ParentId, Items: newItems})
+	if err != nil {
+		return nil, err
+	}
+	results := make([]*ghostfspb.CreateItemResult, len(resp.Results))
+	for i, result := range resp.Results {
+		results[i] = &ghostfspb.CreateItemResult{}
+		if result.Item != nil {
+			results[i].Item = itemOf(*result.Item)
+		}
+		if result.Error != nil {
+			results[i].Error = &ghostfspb.ItemError{Code: result.Error.Code, Message: result.Error.Message}
+		}
+	}
+	return &ghostfspb.CreateItemsResponse{Results: results}, nil
+}
+
+func (s *service) MoveItem(ctx context.Context, req *ghostfspb.MoveItemRequest) (*ghostfspb.Item, error) {
+	if err := s.checkTable(req.TableId); err != nil {
+		return nil, err
+	}
+	resp, err := items.MoveItem(s.tableManager, s.database, s.generator, items.MoveItemRequest{TableID: req.TableId, ItemID: req.ItemId, ParentID: req.ParentId, Name: req.Name})
+	if err != nil {
+		return nil, err
+	}
+	return itemOf(resp.Item), nil
+}
+
+func (s *service) CopyItem(ctx context.Context, req *ghostfspb.CopyItemRequest) (*ghostfspb.CopyItemResponse, error) {
+	if err := s.checkTable(req.TableId); err != nil {
+		return nil, err
+	}
+	resp, err := items.CopyItem(s.tableManager, s.database, s.generator, items.CopyItemRequest{TableID: req.TableId, ItemID: req.ItemId, ParentID: req.ParentId, Name: req.Name})
+	if err != nil {
+		return nil, err
+	}
+	return &ghostfspb.CopyItemResponse{Item: itemOf(resp.Item), Copied: int64(resp.Copied)}, nil
+}
+
+func (s *service) DeleteItem(ctx context.Context, req *ghostfspb.DeleteItemRequest) (*ghostfspb.DeleteItemResponse, error) {
+	if err := s.checkTable(req.TableId); err != nil {
+		return nil, err
+	}
+	resp, err := items.DeleteItem(s.tableManager, s.database, s.generator, items.DeleteItemRequest{TableID: req.TableId, ItemID: req.ItemId, Permanent: req.Permanent})
+	if err != nil {
+		return nil, err
+	}
+	return &ghostfspb.DeleteItemResponse{Item: itemOf(resp.Item), Trashed: resp.Trashed}, nil
+}
+
+func (s *service) ListTrash(ctx context.Context, req *ghostfspb.ListTrashRequest) (*ghostfspb.ListTrashResponse, error) {
+	if err := s.checkTable(req.TableId); err != nil {
+		return nil, err
+	}
+	resp, err := items.ListTrash(s.tableManager, s.database, items.ListTrashRequest{TableID: req.TableId})
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*ghostfspb.TrashItem, len(resp.Items))
+	for i, trashed := range resp.Items {
+		list[i] = trashItemOf(trashed)
+	}
+	return &ghostfspb.ListTrashResponse{Items: list}, nil
+}
+
+func (s *service) RestoreTrashItem(ctx context.Context, req *ghostfspb.RestoreTrashItemRequest) (*ghostfspb.Item, error) {
+	if err := s.checkTable(req.TableId); err != nil {
+		return nil, err
+	}
+	resp, err := items.RestoreTrashItem(s.tableManager, s.database, s.generator, items.RestoreTrashItemRequest{TableID: req.TableId, ItemID: req.ItemId, ParentID: req.ParentId, Name: req.Name})
+	if err != nil {
+		return nil, err
+	}
+	return itemOf(resp.Item), nil
+}
+
+func (s *service) PurgeTrash(ctx context.Context, req *ghostfspb.PurgeTrashRequest) (*ghostfspb.PurgeTrashResponse, error) {
+	if err := s.checkTable(req.TableId); err != nil {
+		return nil, err
+	}
+	resp, err := items.PurgeTrash(s.tableManager, s.database, items.PurgeTrashRequest{TableID: req.TableId, ItemID: req.ItemId})
+	if err != nil {
+		return nil, err
+	}
+	return &ghostfspb.PurgeTrashResponse{Purged: resp.Purged}, nil
+}
+
+func (s *service) LockItem(ctx context.Context, req *ghostfspb.LockItemRequest) (*ghostfspb.Item, error) {
+	if err := s.checkTable(req.TableId); err != nil {
+		return nil, err
+	}
+	if req.DurationSeconds < 0 {
+		return nil, invalidArgument("duration_seconds can't be negative")
+	}
+	resp, err := items.LockItem(s.tableManager, s.database, s.generator, items.LockItemRequest{
+		TableID:  req.TableId,
+		ItemID:   req.ItemId,
+		Owner:    req.Owner,
+		Duration: time.Duration(req.DurationSeconds) * time.Second,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return itemOf(resp.Item), nil
+}
+
+func (s *service) UnlockItem(ctx context.Context, req *ghostfspb.UnlockItemRequest) (*ghostfspb.Item, error) {
+	if err := s.checkTable(req.TableId); err != nil {
+		return nil, err
+	}
+	resp, err := items.UnlockItem(s.tableManager, s.database, s.generator, items.UnlockItemRequest{TableID: req.TableId, ItemID: req.ItemId, Owner: req.Owner, Force: req.Force})
+	if err != nil {
+		return nil, err
+	}
+	return itemOf(resp.Item), nil
+}
+
+func (s *service) ListRevisions(ctx context.Context, req *ghostfspb.ListRevisionsRequest) (*ghostfspb.ListRevisionsResponse, error) {
+	if err := s.checkTable(req.TableId); err != nil {
+		return nil, err
+	}
+	resp, err := items.ListRevisions(s.tableManager, s.database, s.generator, items.ListRevisionsRequest{TableID: req.TableId, ItemID: req.ItemId})
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*ghostfspb.Revision, len(resp.Revisions))
+	for i, revision := range resp.Revisions {
+		list[i] = revisionOf(revision)
+	}
+	return &ghostfspb.ListRevisionsResponse{Item: itemOf(resp.Item), Revisions: list}, nil
+}
+
+func (s *service) RestoreRevision(ctx context.Context, req *ghostfspb.RestoreRevisionRequest) (*ghostfspb.RestoreRevisionResponse, error) {
+	if err := s.checkTable(req.TableId); err != nil {
+		return nil, err
+	}
+	resp, err := items.RestoreRevision(s.tableManager, s.database, s.generator, items.RestoreRevisionRequest{TableID: req.TableId, ItemID: req.ItemId, RevisionID: req.RevisionId, Author: req.Author})
+	if err != nil {
+		return nil, err
+	}
+	return &ghostfspb.RestoreRevisionResponse{Item: itemOf(resp.Item), Revision: revisionOf(resp.Revision)}, nil
+}
+
+func (s *service) GetSharing(ctx context.Context, req *ghostfspb.GetSharingRequest) (*ghostfspb.SharingResponse, error) {
+	if err := s.checkTable(req.TableId); err != nil {
+		return nil, err
+	}
+	resp, err := items.GetSharing(s.tableManager, s.database, s.generator, items.GetSharingRequest{TableID: req.TableId, ItemID: req.ItemId})
+	if err != nil {
+		return nil, err
+	}
+	return sharingResponseOf(resp), nil
+}
+
+func (s *service) UpdateMembers(ctx context.Context, req *ghostfspb.UpdateMembersRequest) (*ghostfspb.SharingResponse, error) {
+	if err := s.checkTable(req.TableId); err != nil {
+		return nil, err
+	}
+	add := make([]dbTypes.ShareMember, len(req.Add))
+	for i, member := range req.Add {
+		add[i] = dbTypes.ShareMember{Email: member.Email, Role: member.Role}
+	}
+	resp, err := items.UpdateMembers(s.tableManager, s.database, s.generator, items.UpdateMembersRequest{TableID: req.TableId, ItemID: req.ItemId, Add: add, Remove: req.Remove})
+	if err != nil {
+		return nil, err
+	}
+	return sharingResponseOf(resp), nil
+}
+
+func (s *service) CreateLink(ctx context.Context, req *ghostfspb.CreateLinkRequest) (*ghostfspb.CreateLinkResponse, error) {
+	if err := s.checkTable(req.TableId); err != nil {
+		return nil, err
+	}
+	var expiresAt *time.Time
+	if req.ExpiresAt != nil {
+		t := req.ExpiresAt.AsTime()
+		expiresAt = &t
+	}
+	resp, err := items.CreateLink(s.tableManager, s.database, s.generator, items.CreateLinkRequest{
+		TableID:   req.TableId,
+		ItemID:    req.ItemId,
+		Scope:     req.Scope,
+		Role:      req.Role,
+		ExpiresAt: expiresAt,
+		Password:  req.Password,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ghostfspb.CreateLinkResponse{Item: itemOf(resp.Item), Sharing: sharingOf(resp.Sharing), Link: sharedLinkOf(resp.Link)}, nil
+}
+
+func (s *service) DeleteLink(ctx context.Context, req *ghostfspb.DeleteLinkRequest) (*ghostfspb.SharingResponse, error) {
+	if err := s.checkTable(req.TableId); err != nil {
+		return nil, err
+	}
+	resp, err := items.DeleteLink(s.tableManager, s.database, s.generator, items.DeleteLinkRequest{TableID: req.TableId, ItemID: req.ItemId, LinkID: req.LinkId})
+	if err != nil {
+		return nil, err
+	}
+	return sharingResponseOf(resp), nil
+}
+
+// sharingResponseOf converts the outcome of a sharing operation to a SharingResponse message
+func sharingResponseOf(resp *items.SharingResponse) *ghostfspb.SharingResponse {
+	return &ghostfspb.SharingResponse{Item: itemOf(resp.Item), Sharing: sharingOf(resp.Sharing)}
+}
@@ -0,0 +1,201 @@
+package rpc
+
+import (
+	"io"
+
+	"github.com/Voltaic314/GhostFS/code/api/rpc/ghostfspb"
+	"github.com/Voltaic314/GhostFS/code/core/items"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultWalkBatch = 1000
+	maxWalkBatch     = 10000
+
+	defaultChunkSize = 256 << 10
+	maxChunkSize     = 1 << 20 // well under gRPC's default 4 MiB message limit
+)
+
+// Walk lists a folder's subtree breadth first, like a crawler would, sending the items in batches.
+// Symlinks and shortcuts are listed but not followed.
+func (s *service) Walk(req *ghostfspb.WalkRequest, stream grpc.ServerStreamingServer[ghostfspb.WalkResponse]) error {
+	if err := s.checkTable(req.TableId); err != nil {
+		return err
+	}
+	if req.MaxDepth < 0 {
+		return invalidArgument("max_depth can't be negative")
+	}
+	batchSize := int(req.BatchSize)
+	switch {
+	case batchSize < 0 || batchSize > maxWalkBatch:
+		return invalidArgument("batch_size must be between 1 and %d", maxWalkBatch)
+	case batchSize == 0:
+		batchSize = defaultWalkBatch
+	}
+
+	folderID := req.FolderId
+	if folderID == "" {
+		root, err := items.GetRoot(s.tableManager, s.database, items.GetRootRequest{TableID: req.TableId})
+		if err != nil {
+			return err
+		}
+		folderID = root.Root.ID
+	}
+
+	type folder struct {
+		id    string
+		depth int32
+	}
+	queue := []folder{{id: folderID}}
+	batch := make([]*ghostfspb.Item, 0, batchSize)
+	for len(queue) > 0 {
+		if err := stream.Context().Err(); err != nil {
+			return err
+		}
+		current := queue[0]
+		queue = queue[1:]
+		resp, err := items.ListItems(s.tableManager, s.database, s.generator, items.ListItemsRequest{TableID: req.TableId, FolderID: current.id, FoldersOnly: req.FoldersOnly})
+		if err != nil {
+			return err
+		}
+		for _, node := range resp.Items {
+			if node.Type == tables.NodeTypeFolder && (req.MaxDepth == 0 || current.depth+1 < req.MaxDepth) {
+				queue = append(queue, folder{id: node.ID, depth: current.depth + 1})
+			}
+			batch = append(batch, itemOf(node))
+			if len(batch) == batchSize {
+				if err := stream.Send(&ghostfspb.WalkResponse{Items: batch}); err != nil {
+					return err
+				}
+				batch = make([]*ghostfspb.Item, 0, batchSize)
+			}
+		}
+	}
+	if len(batch) > 0 {
+		return stream.Send(&ghostfspb.WalkResponse{Items: batch})
+	}
+	return nil
+}
+
+// Download streams a file's deterministic content (or a version's), or a range of it, in chunks
+func (s *service) Download(req *ghostfspb.DownloadRequest, stream grpc.ServerStreamingServer[ghostfspb.DownloadResponse]) error {
+	if err := s.checkTable(req.TableId); err != nil {
+		return err
+	}
+	chunkSize := int(req.ChunkSize)
+	switch {
+	case chunkSize < 0 || chunkSize > maxChunkSize:
+		return invalidArgument("chunk_size must be between 1 and %d", maxChunkSize)
+	case chunkSize == 0:
+		chunkSize = defaultChunkSize
+	}
+	node, content, err := s.openContent(req)
+	if err != nil {
+		return err
+	}
+	size := content.Size()
+	if req.Offset < 0 || req.Offset > size || req.Length < 0 {
+		return status.Errorf(codes.OutOfRange, "invalid range: offset %d, length %d of %d bytes", req.Offset, req.Length, size)
+	}
+	end := size
+	if req.Length > 0 && req.Offset+req.Length < end {
+		end = req.Offset + req.Length
+	}
+
+	// The first chunk carries the item, so even an empty file gets one
+	buf := make([]byte, chunkSize)
+	for offset, first := req.Offset, true; offset < end || first; first = false {
+		n := min(int64(chunkSize), end-offset)
+		if _, err := content.ReadAt(buf[:n], offset); err != nil && err != io.EOF {
+			return err
+		}
+		chunk := &ghostfspb.DownloadResponse{Offset: offset, Data: buf[:n]}
+		if first {
+			chunk.Item = itemOf(node)
+		}
+		if err := stream.Send(chunk); err != nil {
+			return err
+		}
+		offset += n
+	}
+	return nil
+}
+
+// openContent returns the item a download is for and the content to send: the requested version's,
+// or the current one
+func (s *service) openContent(req *ghostfspb.DownloadRequest) (dbTypes.Node, *tables.ContentReader, error) {
+	if req.RevisionId != "" {
+		resp, err := items.OpenRevision(s.tableManager, s.database, s.generator, items.OpenRevisionRequest{TableID: req.TableId, ItemID: req.ItemId, RevisionID: req.RevisionId})
+		if err != nil {
+			return dbTypes.Node{}, nil, err
+		}
+		return resp.Item, resp.Content, nil
+	}
+	// Like the REST downloads, symlinks serve their target's content
+	resp, err := items.OpenFile(s.tableManager, s.database, s.generator, items.OpenFileRequest{TableID: req.TableId, ItemID: req.ItemId})
+	if err != nil {
+		return dbTypes.Node{}, nil, err
+	}
+	return resp.Item, resp.Content, nil
+}
+
+// Upload writes a file from a header and its content. Like the REST uploads, the content is read
+// and counted, not stored: the file reads back as seeded content of the uploaded size.
+func (s *service) Upload(stream grpc.ClientStreamingServer[ghostfspb.UploadRequest, ghostfspb.Item]) error {
+	msg, err := stream.Recv()
+	if err == io.EOF {
+		return invalidArgument("the upload has no header")
+	}
+	if err != nil {
+		return err
+	}
+	header := msg.GetHeader()
+	if header == nil {
+		return invalidArgument("the first message of an upload must be its header")
+	}
+	if err := s.checkTable(header.TableId); err != nil {
+		return err
+	}
+	if header.ItemId == "" && (header.ParentId == "" || header.Name == "") {
+		return invalidArgument("the header needs an item_id, or a parent_id and a name")
+	}
+
+	var size int64
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if msg.GetHeader() != nil {
+			return invalidArgument("an upload has a single header")
+		}
+		size += int64(len(msg.GetData()))
+	}
+
+	if header.ItemId != "" {
+		resp, err := items.UpdateFile(s.tableManager, s.database, s.generator, items.UpdateFileRequest{TableID: header.TableId, ItemID: header.ItemId, Size: size})
+		if err != nil {
+			return err
+		}
+		return stream.SendAndClose(itemOf(resp.Item))
+	}
+	resp, err := items.CreateItems(s.tableManager, s.database, s.generator, items.CreateItemsRequest{
+		TableID:  header.TableId,
+		ParentID: header.ParentId,
+		Items:    []items.NewItem{{Name: header.Name, Type: tables.NodeTypeFile, Size: size}},
+	})
+	if err != nil {
+		return err
+	}
+	if result := resp.Results[0]; result.Error != nil {
+		return result.Error
+	}
+	return stream.SendAndClose(itemOf(*resp.Results[0].Item))
+}
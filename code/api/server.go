@@ -13,8 +13,11 @@ import (
 	"time"
 
 	"github.com/Voltaic314/GhostFS/code/api/routes"
+	"github.com/Voltaic314/GhostFS/code/api/rpc"
+	"github.com/Voltaic314/GhostFS/code/api/sftp"
 	"github.com/Voltaic314/GhostFS/code/db"
 	"github.com/Voltaic314/GhostFS/code/db/tables"
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
 	"github.com/go-chi/chi/v5"
 )
 
@@ -26,6 +29,8 @@ type GhostFSServer struct {
 	tableManager           *tables.TableManager
 	deterministicGenerator *tables.DeterministicGenerator
 	server                 *http.Server
+	sftpServer             *sftp.Server // nil unless SFTP is enabled
+	grpcServer             *rpc.Server  // nil unless gRPC is enabled
 }
 
 // NewGhostFSServer creates a new GhostFS server instance
@@ -73,6 +78,11 @@ func NewGhostFSServer(configPath string) (*GhostFSServer, error) {
 		tableManager,
 	)
 
+	// Databases seeded by older versions have nodes tables without the newer columns
+	if err := tables.MigrateNodeTables(database, tableManager.GetTableNames()); err != nil {
+		return nil, fmt.Errorf("migrate nodes tables: %w", err)
+	}
+
 	// Load existing seeds from all tables into memory
 	tableNames := tableManager.GetTableNames()
 	for _, tableName := range tableNames {
@@ -82,6 +92,28 @@ func NewGhostFSServer(configPath string) (*GhostFSServer, error) {
 		}
 	}
 
+	// Set up write queues for tables (generated children are stored through them)
+	for _, tableName := range tableNames {
+		database.InitWriteQueue(tableName, dbTypes.NodeWriteQueue, 1000, 100*time.Millisecond)
+	}
+
+	// Databases seeded before revisions, trash, locks, sharing and changes existed don't have their tables yet
+	if err := (&tables.RevisionsTable{}).Init(database); err != nil {
+		return nil, fmt.Errorf("create revisions table: %w", err)
+	}
+	if err := (&tables.TrashTable{}).Init(database); err != nil {
+		return nil, fmt.Errorf("create trash table: %w", err)
+	}
+	if err := (&tables.LocksTable{}).Init(database); err != nil {
+		return nil, fmt.Errorf("create locks table: %w", err)
+	}
+	if err := (&tables.SharingTable{}).Init(database); err != nil {
+		return nil, fmt.Errorf("create sharing table: %w", err)
+	}
+	if err := (&tables.ChangesTable{}).Init(database); err != nil {
+		return nil, fmt.Errorf("create changes table: %w", err)
+	}
+
 	// Create router
 	router := chi.NewRouter()
 
@@ -96,6 +128,19 @@ func NewGhostFSServer(configPath string) (*GhostFSServer, error) {
 	// Setup routes with server instance
 	routes.RegisterAllRoutes(router, server)
 
+	// The SFTP server serves the same tables next to the HTTP API
+	if sftpConfig := tableManager.GetSFTPConfig(); sftpConfig != nil && sftpConfig.Enabled {
+		server.sftpServer, err = sftp.NewServer(server, sftpConfig, cfg.Network.Address)
+		if err != nil {
+			return nil, fmt.Errorf("create sftp server: %w", err)
+		}
+	}
+
+	// So does the gRPC server
+	if grpcConfig := tableManager.GetGRPCConfig(); grpcConfig != nil && grpcConfig.Enabled {
+		server.grpcServer = rpc.NewServer(server, grpcConfig, cfg.Network.Address)
+	}
+
 	return server, nil
 }
 
@@ -107,12 +152,35 @@ func (s *GhostFSServer) Start() error {
 		Handler: s.router,
 	}
 
+	if s.sftpServer != nil {
+		go func() {
+			if err := s.sftpServer.Start(); err != nil {
+				log.Printf("SFTP server error: %v", err)
+			}
+		}()
+	}
+	if s.grpcServer != nil {
+		go func() {
+			if err := s.grpcServer.Start(); err != nil {
+				log.Printf("gRPC server error: %v", err)
+			}
+		}()
+	}
+
 	log.Printf("🚀 GhostFS server starting on %s", addr)
 	return s.server.ListenAndServe()
 }
 
 // Stop gracefully stops the GhostFS server
 func (s *GhostFSServer) Stop(ctx context.Context) error {
+	if s.sftpServer != nil {
+		if err := s.sftpServer.Stop(); err != nil {
+			log.Printf("Error stopping SFTP server: %v", err)
+		}
+	}
+	if s.grpcServer != nil {
+		s.grpcServer.Stop()
+	}
 	if s.server != nil {
 		return s.server.Shutdown(ctx)
 	}
@@ -0,0 +1,663 @@
+package sftp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Voltaic314/GhostFS/code/core/items"
+	coreTables "github.com/Voltaic314/GhostFS/code/core/tables"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+)
+
+// The file system is "/" with one directory per table, named after the table. Files read back as
+// their seeded content; writes only keep the size of what was written, like the HTTP API's uploads.
+// Symlinks are followed, shortcuts have no SFTP equivalent and aren't served.
+
+const (
+	maxReadLength   = 256 * 1024 // the largest read served at once
+	readdirBatch    = 100        // names per READDIR response
+	maxSymlinkHops  = 8          // symlinks followed before giving up, like a loop
+	directoryMode   = 0o040755
+	fileMode        = 0o100644
+	symlinkMode     = 0o120777
+	permissionsMask = 0o777
+)
+
+// location is what a path names: the root ("/"), a table's directory or a node of a table
+type location struct {
+	path     string // the cleaned path
+	table    string // table name, empty for the root
+	tableID  string
+	nodePath string // node path in the table, "/" for the table's directory
+}
+
+// isRoot reports whether the location is "/" or a table's directory, which can't be changed
+func (l location) isRoot() bool {
+	return l.table == "" || l.nodePath == "/"
+}
+
+// handle is an open file or directory
+type handle struct {
+	path    string
+	tableID string
+	attrs   fileAttrs
+
+	// Directories
+	dir     bool
+	entries []entry
+	next    int
+
+	// Files. node is nil for a new file, created in parent when it's closed.
+	node     *dbTypes.Node
+	content  *tables.ContentReader
+	writable bool
+	append   bool
+	parent   *dbTypes.Node
+	name     string
+	size     int64
+	dirty    bool
+}
+
+// entry is a name of a directory listing
+type entry struct {
+	name  string
+	attrs fileAttrs
+}
+
+// resolve maps a path to its location. Relative paths are relative to "/".
+func (s *session) resolve(p string) (location, error) {
+	loc := location{path: path.Clean("/" + p), nodePath: "/"}
+	if loc.path == "/" {
+		return loc, nil
+	}
+	rest := strings.TrimPrefix(loc.path, "/")
+	table, nodePath, _ := strings.Cut(rest, "/")
+	loc.table, loc.nodePath = table, "/"+nodePath
+
+	resp, err := coreTables.ListTables(s.database)
+	if err != nil {
+		return loc, err
+	}
+	for _, info := range resp.Tables {
+		if info.TableName == table {
+			loc.tableID = info.TableID
+			return loc, nil
+		}
+	}
+	return loc, errNoSuchFile(loc.path)
+}
+
+// child returns the location of a name in a directory of the same table
+func (l location) child(nodePath string) location {
+	nodePath = path.Clean(nodePath)
+	return location{path: path.Join("/", l.table, nodePath), table: l.table, tableID: l.tableID, nodePath: nodePath}
+}
+
+// lookup returns the node at a location of a table, or nil if there is none
+func (s *session) lookup(loc location) (*dbTypes.Node, error) {
+	resp, err := items.GetItemByPath(s.tableManager, s.database, s.generator, items.GetItemByPathRequest{TableID: loc.tableID, Path: loc.nodePath})
+	var itemErr *items.ItemError
+	if errors.As(err, &itemErr) && itemErr.Code == items.ErrCodeNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if resp.Item.Type == tables.NodeTypeShortcut {
+		return nil, nil
+	}
+	return &resp.Item, nil
+}
+
+// follow resolves symlinks until it reaches a node that isn't one (or nothing). Targets are relative
+// to the link's folder; absolute targets start at the table's directory.
+func (s *session) follow(loc location, node *dbTypes.Node) (location, *dbTypes.Node, error) {
+	for hops := 0; node != nil && node.Type == tables.NodeTypeSymlink; hops++ {
+		if hops == maxSymlinkHops {
+			return loc, nil, errFailure("%s: too many levels of symbolic links", loc.path)
+		}
+		target := node.Target
+		if !strings.HasPrefix(target, "/") {
+			target = path.Join(path.Dir(loc.nodePath), target)
+		}
+		loc = loc.child(target)
+		var err error
+		if node, err = s.lookup(loc); err != nil {
+			return loc, nil, err
+		}
+	}
+	return loc, node, nil
+}
+
+// node returns the node at a path, following symlinks if asked. Paths without a node fail with
+// NO_SUCH_FILE.
+func (s *session) node(p string, followLinks bool) (location, *dbTypes.Node, error) {
+	loc, err := s.resolve(p)
+	if err != nil {
+		return loc, nil, err
+	}
+	if loc.table == "" {
+		return loc, nil, errPermissionDenied("%s is not in a table", loc.path)
+	}
+	node, err := s.lookup(loc)
+	if err == nil && followLinks {
+		loc, node, err = s.follow(loc, node)
+	}
+	if err != nil {
+		return loc, nil, err
+	}
+	if node == nil {
+		return loc, nil, errNoSuchFile(loc.path)
+	}
+	return loc, node, nil
+}
+
+// parentFolder returns the folder a new item at a location goes into. Missing parents aren't created.
+func (s *session) parentFolder(loc location) (*dbTypes.Node, error) {
+	parentLoc := loc.child(path.Dir(loc.nodePath))
+	parent, err := s.lookup(parentLoc)
+	if err == nil {
+		parentLoc, parent, err = s.follow(parentLoc, parent)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if parent == nil || parent.Type != tables.NodeTypeFolder {
+		return nil, errNoSuchFile(parentLoc.path)
+	}
+	return parent, nil
+}
+
+// createItem creates one file or folder
+func (s *session) createItem(tableID string, parent *dbTypes.Node, item items.NewItem) error {
+	resp, err := items.CreateItems(s.tableManager, s.database, s.generator, items.CreateItemsRequest{
+		TableID:  tableID,
+		ParentID: parent.ID,
+		Items:    []items.NewItem{item},
+	})
+	if err != nil {
+		return err
+	}
+	if result := resp.Results[0]; result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// nodeAttrs returns the attributes of a node
+func nodeAttrs(node dbTypes.Node) fileAttrs {
+	attrs := fileAttrs{
+		flags: attrSize | attrPermissions | attrACModTime,
+		size:  uint64(node.Size),
+		atime: uint32(node.UpdatedAt.Unix()),
+		mtime: uint32(node.UpdatedAt.Unix()),
+	}
+	switch node.Type {
+	case tables.NodeTypeFolder:
+		attrs.permissions, attrs.size = directoryMode, 0
+	case tables.NodeTypeSymlink:
+		attrs.permissions, attrs.size = symlinkMode, uint64(len(node.Target))
+	default:
+		attrs.permissions = fileMode
+	}
+	return attrs
+}
+
+func directoryAttrs(modTime time.Time) fileAttrs {
+	return fileAttrs{
+		flags:       attrSize | attrPermissions | attrACModTime,
+		permissions: directoryMode,
+		atime:       uint32(modTime.Unix()),
+		mtime:       uint32(modTime.Unix()),
+	}
+}
+
+// longname formats a listing entry like "ls -l", which is what clients show
+func longname(name string, attrs fileAttrs) string {
+	mode := os.FileMode(attrs.permissions & permissionsMask)
+	switch attrs.permissions &^ permissionsMask {
+	case directoryMode &^ permissionsMask:
+		mode |= os.ModeDir
+	case symlinkMode &^ permissionsMask:
+		mode |= os.ModeSymlink
+	}
+	modTime := time.Unix(int64(attrs.mtime), 0).UTC()
+	date := modTime.Format("Jan _2 15:04")
+	if time.Since(modTime) > 180*24*time.Hour {
+		date = modTime.Format("Jan _2  2006")
+	}
+	modeString := strings.Replace(mode.String(), "L", "l", 1)
+	return fmt.Sprintf("%s    1 ghostfs  ghostfs  %12d %s %s", modeString, attrs.size, date, name)
+}
+
+func names(id uint32, entries []entry) *encoder {
+	response := newPacket(fxpName).uint32(id).uint32(uint32(len(entries)))
+	for _, e := range entries {
+		response.string(e.name).string(longname(e.name, e.attrs)).attrs(e.attrs)
+	}
+	return response
+}
+
+// open opens a file for reading or writing. New files need CREAT and are created when they're
+// closed; locked files can't be opened for writing.
+func (s *session) open(id uint32, p string, pflags uint32, _ fileAttrs) (*encoder, error) {
+	writing := pflags&(fxfWrite|fxfAppend|fxfCreat|fxfTrunc) != 0
+	loc, err := s.resolve(p)
+	if err != nil {
+		return nil, err
+	}
+	if loc.isRoot() {
+		return nil, errFailure("%s is a directory", loc.path)
+	}
+	node, err := s.lookup(loc)
+	if err == nil {
+		loc, node, err = s.follow(loc, node)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if node != nil && node.Type == tables.NodeTypeFolder {
+		return nil, errFailure("%s is a directory", loc.path)
+	}
+
+	h := &handle{path: loc.path, tableID: loc.tableID, node: node, writable: writing, append: pflags&fxfAppend != 0}
+	switch {
+	case node == nil && (!writing || pflags&fxfCreat == 0):
+		return nil, errNoSuchFile(loc.path)
+	case node == nil:
+		if h.parent, err = s.parentFolder(loc); err != nil {
+			return nil, err
+		}
+		h.name = path.Base(loc.nodePath)
+		h.attrs = fileAttrs{flags: attrSize | attrPermissions | attrACModTime, permissions: fileMode}
+		h.attrs.atime = uint32(time.Now().Unix())
+		h.attrs.mtime = h.attrs.atime
+		h.dirty = true
+	default:
+		if writing {
+			if pflags&fxfCreat != 0 && pflags&fxfExcl != 0 {
+				return nil, errFailure("%s already exists", loc.path)
+			}
+			if node.Lock != nil {
+				return nil, errPermissionDenied("%s is locked by %s", loc.path, node.Lock.Owner)
+			}
+			if node.Type != tables.NodeTypeFile {
+				return nil, errPermissionDenied("%s is a %s, it can't be written", loc.path, node.Type)
+			}
+		}
+		h.attrs = nodeAttrs(*node)
+		h.size = node.Size
+		h.content = s.generator.OpenContent(*node, node.Size)
+		if writing && pflags&fxfTrunc != 0 {
+			h.size, h.dirty = 0, true
+		}
+	}
+	return newPacket(fxpHandle).uint32(id).string(s.addHandle(h)), nil
+}
+
+// close closes a handle. Files that were written are created or updated now.
+func (s *session) close(id uint32, name string) (*encoder, error) {
+	h, err := s.getHandle(name)
+	if err != nil {
+		return nil, err
+	}
+	delete(s.handles, name)
+	if err := s.commit(h); err != nil {
+		return nil, err
+	}
+	return ok(id), nil
+}
+
+// commit saves what was written to a file: a new file with the written size, or a new revision
+func (s *session) commit(h *handle) error {
+	if !h.writable || !h.dirty {
+		return nil
+	}
+	if h.node == nil {
+		return s.createItem(h.tableID, h.parent, items.NewItem{Name: h.name, Type: tables.NodeTypeFile, Size: h.size})
+	}
+	_, err := items.UpdateFile(s.tableManager, s.database, s.generator, items.UpdateFileRequest{
+		TableID: h.tableID,
+		ItemID:  h.node.ID,
+		Size:    h.size,
+		Author:  s.user,
+	})
+	return err
+}
+
+// read serves a file's content. Files open for writing read back their content before the writes.
+func (s *session) read(id uint32, name string, offset uint64, length uint32) (*encoder, error) {
+	h, err := s.getHandle(name)
+	if err != nil {
+		return nil, err
+	}
+	if h.dir {
+		return nil, errFailure("%s is a directory", h.path)
+	}
+	if h.content == nil || offset >= uint64(h.content.Size()) {
+		return statusPacket(id, fxEOF, "EOF"), nil
+	}
+	n := min(uint64(length), maxReadLength, uint64(h.content.Size())-offset)
+	buf := make([]byte, n)
+	if _, err := h.content.ReadAt(buf, int64(offset)); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return newPacket(fxpData).uint32(id).bytes(buf), nil
+}
+
+// write records a write. Only the file's size is tracked: the furthest byte written.
+func (s *session) write(id uint32, name string, offset uint64, data []byte) (*encoder, error) {
+	h, err := s.getHandle(name)
+	if err != nil {
+		return nil, err
+	}
+	if !h.writable {
+		return nil, errPermissionDenied("%s is not open for writing", h.path)
+	}
+	if h.append {
+		offset = uint64(h.size)
+	}
+	if offset > math.MaxInt64-uint64(len(data)) {
+		return nil, errFailure("offset out of range")
+	}
+	h.size = max(h.size, int64(offset)+int64(len(data)))
+	h.dirty = true
+	return ok(id), nil
+}
+
+// stat serves STAT (following symlinks) and LSTAT
+func (s *session) stat(id uint32, p string, followLinks bool) (*encoder, error) {
+	attrs, err := s.attrs(p, followLinks)
+	if err != nil {
+		return nil, err
+	}
+	return newPacket(fxpAttrs).uint32(id).attrs(attrs), nil
+}
+
+// attrs returns the attributes of a path
+func (s *session) attrs(p string, followLinks bool) (fileAttrs, error) {
+	loc, err := s.resolve(p)
+	if err != nil {
+		return fileAttrs{}, err
+	}
+	if loc.table == "" {
+		return directoryAttrs(time.Now()), nil
+	}
+	_, node, err := s.node(p, followLinks)
+	if err != nil {
+		return fileAttrs{}, err
+	}
+	return nodeAttrs(*node), nil
+}
+
+func (s *session) fstat(id uint32, name string) (*encoder, error) {
+	h, err := s.getHandle(name)
+	if err != nil {
+		return nil, err
+	}
+	attrs := h.attrs
+	if !h.dir {
+		attrs.size = uint64(h.size)
+	}
+	return newPacket(fxpAttrs).uint32(id).attrs(attrs), nil
+}
+
+// setstat changes a file's size. Permissions, owners and times aren't stored, changing them succeeds
+// so that clients preserving them (e.g. "put -p") don't fail.
+func (s *session) setstat(id uint32, p string, attrs fileAttrs) (*encoder, error) {
+	if path.Clean("/"+p) == "/" {
+		return ok(id), nil
+	}
+	loc, node, err := s.node(p, true)
+	if err != nil {
+		return nil, err
+	}
+	if attrs.flags&attrSize == 0 || int64(attrs.size) == node.Size {
+		return ok(id), nil
+	}
+	if node.Type != tables.NodeTypeFile {
+		return nil, errPermissionDenied("%s is a %s, it can't be truncated", loc.path, node.Type)
+	}
+	if attrs.size > math.MaxInt64 {
+		return nil, errFailure("size out of range")
+	}
+	_, err = items.UpdateFile(s.tableManager, s.database, s.generator, items.UpdateFileRequest{
+		TableID: loc.tableID,
+		ItemID:  node.ID,
+		Size:    int64(attrs.size),
+		Author:  s.user,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ok(id), nil
+}
+
+// fsetstat changes the size of an open file, saved when it's closed
+func (s *session) fsetstat(id uint32, name string, attrs fileAttrs) (*encoder, error) {
+	h, err := s.getHandle(name)
+	if err != nil {
+		return nil, err
+	}
+	if attrs.flags&attrSize == 0 || h.dir || int64(attrs.size) == h.size {
+		return ok(id), nil
+	}
+	if !h.writable {
+		return nil, errPermissionDenied("%s is not open for writing", h.path)
+	}
+	if attrs.size > math.MaxInt64 {
+		return nil, errFailure("size out of range")
+	}
+	h.size, h.dirty = int64(attrs.size), true
+	return ok(id), nil
+}
+
+// opendir lists a directory. The listing is taken when it's opened and served in batches.
+func (s *session) opendir(id uint32, p string) (*encoder, error) {
+	loc, err := s.resolve(p)
+	if err != nil {
+		return nil, err
+	}
+	h := &handle{path: loc.path, dir: true}
+
+	if loc.table == "" {
+		h.attrs = directoryAttrs(time.Now())
+		resp, err := coreTables.ListTables(s.database)
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range resp.Tables {
+			h.entries = append(h.entries, entry{name: info.TableName, attrs: directoryAttrs(time.Now())})
+		}
+		return newPacket(fxpHandle).uint32(id).string(s.addHandle(h)), nil
+	}
+
+	loc, node, err := s.node(p, true)
+	if err != nil {
+		return nil, err
+	}
+	if node.Type != tables.NodeTypeFolder {
+		return nil, errFailure("%s is not a directory", loc.path)
+	}
+	resp, err := items.ListItems(s.tableManager, s.database, s.generator, items.ListItemsRequest{TableID: loc.tableID, FolderID: node.ID})
+	if err != nil {
+		return nil, err
+	}
+	h.attrs = nodeAttrs(*node)
+	for _, child := range resp.Items {
+		if child.Type != tables.NodeTypeShortcut {
+			h.entries = append(h.entries, entry{name: child.Name, attrs: nodeAttrs(child)})
+		}
+	}
+	return newPacket(fxpHandle).uint32(id).string(s.addHandle(h)), nil
+}
+
+func (s *session) readdir(id uint32, name string) (*encoder, error) {
+	h, err := s.getHandle(name)
+	if err != nil {
+		return nil, err
+	}
+	if !h.dir {
+		return nil, errFailure("%s is not a directory", h.path)
+	}
+	if h.next >= len(h.entries) {
+		return statusPacket(id, fxEOF, "EOF"), nil
+	}
+	batch := h.entries[h.next:min(h.next+readdirBatch, len(h.entries))]
+	h.next += len(batch)
+	return names(id, batch), nil
+}
+
+// remove deletes a file (or a link). Tables with a trash keep it there.
+func (s *session) remove(id uint32, p string) (*encoder, error) {
+	loc, node, err := s.node(p, false)
+	if err != nil {
+		return nil, err
+	}
+	if node.Type == tables.NodeTypeFolder {
+		return nil, errFailure("%s is a directory", loc.path)
+	}
+	if _, err := items.DeleteItem(s.tableManager, s.database, s.generator, items.DeleteItemRequest{TableID: loc.tableID, ItemID: node.ID}); err != nil {
+		return nil, err
+	}
+	return ok(id), nil
+}
+
+func (s *session) mkdir(id uint32, p string) (*encoder, error) {
+	loc, err := s.resolve(p)
+	if err != nil {
+		return nil, err
+	}
+	if loc.isRoot() {
+		return nil, errFailure("%s already exists", loc.path)
+	}
+	node, err := s.lookup(loc)
+	if err != nil {
+		return nil, err
+	}
+	if node != nil {
+		return nil, errFailure("%s already exists", loc.path)
+	}
+	parent, err := s.parentFolder(loc)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.createItem(loc.tableID, parent, items.NewItem{Name: path.Base(loc.nodePath), Type: tables.NodeTypeFolder}); err != nil {
+		return nil, err
+	}
+	return ok(id), nil
+}
+
+// rmdir deletes an empty directory. The root and the tables' directories can't be deleted.
+func (s *session) rmdir(id uint32, p string) (*encoder, error) {
+	loc, err := s.resolve(p)
+	if err != nil {
+		return nil, err
+	}
+	if loc.isRoot() {
+		return nil, errPermissionDenied("%s can't be deleted", loc.path)
+	}
+	_, node, err := s.node(p, false)
+	if err != nil {
+		return nil, err
+	}
+	if node.Type != tables.NodeTypeFolder {
+		return nil, errFailure("%s is not a directory", loc.path)
+	}
+	if err := s.checkEmpty(loc, node); err != nil {
+		return nil, err
+	}
+	if _, err := items.DeleteItem(s.tableManager, s.database, s.generator, items.DeleteItemRequest{TableID: loc.tableID, ItemID: node.ID}); err != nil {
+		return nil, err
+	}
+	return ok(id), nil
+}
+
+// checkEmpty fails if a folder has children
+func (s *session) checkEmpty(loc location, folder *dbTypes.Node) error {
+	resp, err := items.ListItems(s.tableManager, s.database, s.generator, items.ListItemsRequest{TableID: loc.tableID, FolderID: folder.ID})
+	if err != nil {
+		return err
+	}
+	if len(resp.Items) > 0 {
+		return errFailure("%s is not empty", loc.path)
+	}
+	return nil
+}
+
+// realpath canonicalizes a path. The session starts in "/".
+func (s *session) realpath(id uint32, p string) (*encoder, error) {
+	clean := path.Clean("/" + p)
+	return names(id, []entry{{name: clean}}), nil
+}
+
+// rename moves an item within its table. The new path must not exist, except with the
+// posix-rename extension, which replaces files and empty directories.
+func (s *session) rename(id uint32, oldPath string, newPath string, overwrite bool) (*encoder, error) {
+	from, node, err := s.node(oldPath, false)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.resolve(newPath)
+	if err != nil {
+		return nil, err
+	}
+	if to.tableID != from.tableID {
+		return nil, errFailure("items can only be moved within their table")
+	}
+	if to.isRoot() {
+		return nil, errFailure("%s already exists", to.path)
+	}
+	if to.nodePath == from.nodePath {
+		return ok(id), nil
+	}
+	if strings.HasPrefix(to.nodePath, from.nodePath+"/") {
+		return nil, errFailure("%s can't be moved into itself", from.path)
+	}
+
+	existing, err := s.lookup(to)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		if !overwrite {
+			return nil, errFailure("%s already exists", to.path)
+		}
+		if existing.Type == tables.NodeTypeFolder {
+			if err := s.checkEmpty(to, existing); err != nil {
+				return nil, err
+			}
+		}
+	}
+	parent, err := s.parentFolder(to)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		if _, err := items.DeleteItem(s.tableManager, s.database, s.generator, items.DeleteItemRequest{TableID: to.tableID, ItemID: existing.ID}); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := items.MoveItem(s.tableManager, s.database, s.generator, items.MoveItemRequest{TableID: from.tableID, ItemID: node.ID, ParentID: parent.ID, Name: path.Base(to.nodePath)}); err != nil {
+		return nil, err
+	}
+	return ok(id), nil
+}
+
+func (s *session) readlink(id uint32, p string) (*encoder, error) {
+	loc, node, err := s.node(p, false)
+	if err != nil {
+		return nil, err
+	}
+	if node.Type != tables.NodeTypeSymlink {
+		return nil, errFailure("%s is not a symbolic link", loc.path)
+	}
+	return names(id, []entry{{name: node.Target}}), nil
+}
@@ -0,0 +1,222 @@
+package sftp
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// The server speaks SFTP version 3 (draft-ietf-secsh-filexfer-02), the version of OpenSSH and the
+// version every client supports
+
+const sftpVersion = 3
+
+// Packet types
+const (
+	fxpInit          = 1
+	fxpVersion       = 2
+	fxpOpen          = 3
+	fxpClose         = 4
+	fxpRead          = 5
+	fxpWrite         = 6
+	fxpLstat         = 7
+	fxpFstat         = 8
+	fxpSetstat       = 9
+	fxpFsetstat      = 10
+	fxpOpendir       = 11
+	fxpReaddir       = 12
+	fxpRemove        = 13
+	fxpMkdir         = 14
+	fxpRmdir         = 15
+	fxpRealpath      = 16
+	fxpStat          = 17
+	fxpRename        = 18
+	fxpReadlink      = 19
+	fxpSymlink       = 20
+	fxpStatus        = 101
+	fxpHandle        = 102
+	fxpData          = 103
+	fxpName          = 104
+	fxpAttrs         = 105
+	fxpExtended      = 200
+	fxpExtendedReply = 201
+)
+
+// Status codes
+const (
+	fxOK               = 0
+	fxEOF              = 1
+	fxNoSuchFile       = 2
+	fxPermissionDenied = 3
+	fxFailure          = 4
+	fxBadMessage       = 5
+	fxOpUnsupported    = 8
+)
+
+// Open flags
+const (
+	fxfRead   = 0x01
+	fxfWrite  = 0x02
+	fxfAppend = 0x04
+	fxfCreat  = 0x08
+	fxfTrunc  = 0x10
+	fxfExcl   = 0x20
+)
+
+// Attribute flags
+const (
+	attrSize        = 0x00000001
+	attrUIDGID      = 0x00000002
+	attrPermissions = 0x00000004
+	attrACModTime   = 0x00000008
+	attrExtended    = 0x80000000
+)
+
+// maxPacketLength caps incoming packets: writes of up to 256 KiB and their header, like OpenSSH
+const maxPacketLength = 256*1024 + 1024
+
+// errBadMessage is a packet that is shorter than its fields
+var errBadMessage = errors.New("bad message")
+
+// fileAttrs are the attributes of a file. Only the fields in flags are set.
+type fileAttrs struct {
+	flags       uint32
+	size        uint64
+	uid, gid    uint32
+	permissions uint32
+	atime       uint32
+	mtime       uint32
+}
+
+// readPacket reads one packet: its type and payload
+func readPacket(r io.Reader) (byte, []byte, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, nil, err
+	}
+	length := binary.BigEndian.Uint32(header[:])
+	if length == 0 || length > maxPacketLength {
+		return 0, nil, errBadMessage
+	}
+	packet := make([]byte, length)
+	if _, err := io.ReadFull(r, packet); err != nil {
+		return 0, nil, err
+	}
+	return packet[0], packet[1:], nil
+}
+
+// decoder reads the fields of a packet. The first short read sets err, later reads return zeros.
+type decoder struct {
+	data []byte
+	err  error
+}
+
+func (d *decoder) take(n int) []byte {
+	if d.err != nil || len(d.data) < n {
+		d.err = errBadMessage
+		return nil
+	}
+	field := d.data[:n]
+	d.data = d.data[n:]
+	return field
+}
+
+func (d *decoder) uint32() uint32 {
+	if field := d.take(4); field != nil {
+		return binary.BigEndian.Uint32(field)
+	}
+	return 0
+}
+
+func (d *decoder) uint64() uint64 {
+	if field := d.take(8); field != nil {
+		return binary.BigEndian.Uint64(field)
+	}
+	return 0
+}
+
+func (d *decoder) bytes() []byte {
+	return d.take(int(d.uint32()))
+}
+
+func (d *decoder) string() string {
+	return string(d.bytes())
+}
+
+func (d *decoder) attrs() fileAttrs {
+	attrs := fileAttrs{flags: d.uint32()}
+	if attrs.flags&attrSize != 0 {
+		attrs.size = d.uint64()
+	}
+	if attrs.flags&attrUIDGID != 0 {
+		attrs.uid, attrs.gid = d.uint32(), d.uint32()
+	}
+	if attrs.flags&attrPermissions != 0 {
+		attrs.permissions = d.uint32()
+	}
+	if attrs.flags&attrACModTime != 0 {
+		attrs.atime, attrs.mtime = d.uint32(), d.uint32()
+	}
+	if attrs.flags&attrExtended != 0 {
+		for count := d.uint32(); count > 0 && d.err == nil; count-- {
+			d.string()
+			d.string()
+		}
+	}
+	return attrs
+}
+
+// encoder builds a packet
+type encoder struct {
+	buf []byte
+}
+
+// newPacket starts a packet of the given type. Every response starts with the request ID.
+func newPacket(packetType byte) *encoder {
+	return &encoder{buf: []byte{0, 0, 0, 0, packetType}}
+}
+
+func (e *encoder) uint32(v uint32) *encoder {
+	e.buf = binary.BigEndian.AppendUint32(e.buf, v)
+	return e
+}
+
+func (e *encoder) uint64(v uint64) *encoder {
+	e.buf = binary.BigEndian.AppendUint64(e.buf, v)
+	return e
+}
+
+func (e *encoder) string(s string) *encoder {
+	e.uint32(uint32(len(s)))
+	e.buf = append(e.buf, s...)
+	return e
+}
+
+func (e *encoder) bytes(b []byte) *encoder {
+	e.uint32(uint32(len(b)))
+	e.buf = append(e.buf, b...)
+	return e
+}
+
+func (e *encoder) attrs(attrs fileAttrs) *encoder {
+	e.uint32(attrs.flags)
+	if attrs.flags&attrSize != 0 {
+		e.uint64(attrs.size)
+	}
+	if attrs.flags&attrUIDGID != 0 {
+		e.uint32(attrs.uid).uint32(attrs.gid)
+	}
+	if attrs.flags&attrPermissions != 0 {
+		e.uint32(attrs.permissions)
+	}
+	if attrs.flags&attrACModTime != 0 {
+		e.uint32(attrs.atime).uint32(attrs.mtime)
+	}
+	return e
+}
+
+// packet returns the finished packet with its length
+func (e *encoder) packet() []byte {
+	binary.BigEndian.PutUint32(e.buf, uint32(len(e.buf)-4))
+	return e.buf
+}
@@ -0,0 +1,180 @@
+package sftp
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	"golang.org/x/crypto/ssh"
+)
+
+// Server is the embedded SFTP server. It serves the same tables as the HTTP API: the root directory
+// holds one directory per table.
+type Server struct {
+	server    interface{}
+	address   string
+	sshConfig *ssh.ServerConfig
+
+	mu       sync.Mutex
+	listener net.Listener
+	closed   bool
+}
+
+// NewServer creates the SFTP server for an API server. It listens on the configured address, or on
+// defaultAddress (the HTTP server's) if none is configured.
+func NewServer(server interface{}, config *tables.SFTPConfig, defaultAddress string) (*Server, error) {
+	hostKey, err := loadHostKey(config.HostKey)
+	if err != nil {
+		return nil, fmt.Errorf("host key: %w", err)
+	}
+
+	users := make(map[string]tables.SFTPUser)
+	keys := make(map[string][]ssh.PublicKey)
+	for _, user := range config.Users {
+		users[user.Username] = user
+		for _, line := range user.AuthorizedKeys {
+			key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(line))
+			if err != nil {
+				return nil, fmt.Errorf("authorized key of %s: %w", user.Username, err)
+			}
+			keys[user.Username] = append(keys[user.Username], key)
+		}
+	}
+
+	sshConfig := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			user, ok := users[conn.User()]
+			if ok && user.Password != "" && subtle.ConstantTimeCompare([]byte(user.Password), password) == 1 {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("wrong password for %s", conn.User())
+		},
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			for _, authorized := range keys[conn.User()] {
+				if bytes.Equal(authorized.Marshal(), key.Marshal()) {
+					return nil, nil
+				}
+			}
+			return nil, fmt.Errorf("unknown public key for %s", conn.User())
+		},
+	}
+	sshConfig.AddHostKey(hostKey)
+
+	address := config.Address
+	if address == "" {
+		address = defaultAddress
+	}
+	log.Printf("🔑 SFTP host key %s", ssh.FingerprintSHA256(hostKey.PublicKey()))
+	return &Server{
+		server:    server,
+		address:   fmt.Sprintf("%s:%d", address, config.GetPort()),
+		sshConfig: sshConfig,
+	}, nil
+}
+
+// loadHostKey reads the host key from a PEM file, or generates a new one if path is empty
+func loadHostKey(path string) (ssh.Signer, error) {
+	if path == "" {
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		return ssh.NewSignerFromKey(key)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ssh.ParsePrivateKey(data)
+}
+
+// Start listens and serves SFTP connections until the server is stopped
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.address)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.listener = listener
+	s.mu.Unlock()
+
+	log.Printf("📂 SFTP server starting on %s", s.address)
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return nil
+			}
+			return err
+		}
+		go s.handleConn(conn)
+	}
+}
+
+// Stop stops accepting connections. Sessions in progress end with their connection.
+func (s *Server) Stop() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if s.listener != nil {
+		return s.listener.Close()
+	}
+	return nil
+}
+
+// handleConn runs the SSH handshake and serves the "sftp" subsystem of the connection's sessions
+func (s *Server) handleConn(conn net.Conn) {
+	sshConn, channels, requests, err := ssh.NewServerConn(conn, s.sshConfig)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	defer sshConn.Close()
+	go ssh.DiscardRequests(requests)
+
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "only session channels are supported")
+			continue
+		}
+		channel, channelRequests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go s.handleSession(sshConn.User(), channel, channelRequests)
+	}
+}
+
+// handleSession waits for the "sftp" subsystem request of a session channel and serves it. Shells
+// and commands are refused.
+func (s *Server) handleSession(user string, channel ssh.Channel, requests <-chan *ssh.Request) {
+	for req := range requests {
+		payload := decoder{data: req.Payload}
+		ok := req.Type == "subsystem" && payload.string() == "sftp" && payload.err == nil
+		if req.WantReply {
+			req.Reply(ok, nil)
+		}
+		if !ok {
+			continue
+		}
+
+		go ssh.DiscardRequests(requests)
+		if err := newSession(s.server, user, channel).serve(); err != nil {
+			fmt.Printf("❌ SFTP session of %s failed: %v\n", user, err)
+		}
+		channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{0}))
+		channel.Close()
+		return
+	}
+	channel.Close()
+}
@@ -0,0 +1,212 @@
+package sftp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/Voltaic314/GhostFS/code/core/items"
+	"github.com/Voltaic314/GhostFS/code/db"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+)
+
+// session serves the SFTP requests of one subsystem channel. Requests are handled in order.
+type session struct {
+	tableManager *tables.TableManager
+	database     *db.DB
+	generator    *tables.DeterministicGenerator
+	user         string
+	channel      io.ReadWriter
+	handles      map[string]*handle
+	nextHandle   uint64
+}
+
+func newSession(server interface{}, user string, channel io.ReadWriter) *session {
+	// Cast server to get access to DB and TableManager
+	s := server.(interface {
+		GetTableManager() *tables.TableManager
+		GetDB() *db.DB
+		GetDeterministicGenerator() *tables.DeterministicGenerator
+	})
+	return &session{
+		tableManager: s.GetTableManager(),
+		database:     s.GetDB(),
+		generator:    s.GetDeterministicGenerator(),
+		user:         user,
+		channel:      channel,
+		handles:      make(map[string]*handle),
+	}
+}
+
+// statusError is a failure reported with an SFTP status code
+type statusError struct {
+	code    uint32
+	message string
+}
+
+func (e *statusError) Error() string {
+	return e.message
+}
+
+func errNoSuchFile(path string) *statusError {
+	return &statusError{code: fxNoSuchFile, message: fmt.Sprintf("%s: no such file or directory", path)}
+}
+
+func errFailure(format string, args ...any) *statusError {
+	return &statusError{code: fxFailure, message: fmt.Sprintf(format, args...)}
+}
+
+func errPermissionDenied(format string, args ...any) *statusError {
+	return &statusError{code: fxPermissionDenied, message: fmt.Sprintf(format, args...)}
+}
+
+// serve reads requests until the client closes the channel. Files being written are committed
+// when the session ends without closing them.
+func (s *session) serve() error {
+	defer s.closeAll()
+
+	packetType, payload, err := readPacket(s.channel)
+	if err != nil {
+		return err
+	}
+	if packetType != fxpInit {
+		return fmt.Errorf("expected init, got packet type %d", packetType)
+	}
+	version := newPacket(fxpVersion).uint32(sftpVersion).
+		string("posix-rename@openssh.com").string("1")
+	if err := s.send(version); err != nil {
+		return err
+	}
+
+	for {
+		packetType, payload, err = readPacket(s.channel)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		d := &decoder{data: payload}
+		id := d.uint32()
+		response, err := s.handle(packetType, id, d)
+		if err == nil && d.err != nil {
+			err = &statusError{code: fxBadMessage, message: "malformed request"}
+		}
+		if err != nil {
+			response = s.status(id, err)
+		}
+		if err := s.send(response); err != nil {
+			return err
+		}
+	}
+}
+
+// handle runs one request and returns its response
+func (s *session) handle(packetType byte, id uint32, d *decoder) (*encoder, error) {
+	switch packetType {
+	case fxpOpen:
+		return s.open(id, d.string(), d.uint32(), d.attrs())
+	case fxpClose:
+		return s.close(id, d.string())
+	case fxpRead:
+		return s.read(id, d.string(), d.uint64(), d.uint32())
+	case fxpWrite:
+		return s.write(id, d.string(), d.uint64(), d.bytes())
+	case fxpLstat, fxpStat:
+		return s.stat(id, d.string(), packetType == fxpStat)
+	case fxpFstat:
+		return s.fstat(id, d.string())
+	case fxpSetstat:
+		return s.setstat(id, d.string(), d.attrs())
+	case fxpFsetstat:
+		return s.fsetstat(id, d.string(), d.attrs())
+	case fxpOpendir:
+		return s.opendir(id, d.string())
+	case fxpReaddir:
+		return s.readdir(id, d.string())
+	case fxpRemove:
+		return s.remove(id, d.string())
+	case fxpMkdir:
+		return s.mkdir(id, d.string())
+	case fxpRmdir:
+		return s.rmdir(id, d.string())
+	case fxpRealpath:
+		return s.realpath(id, d.string())
+	case fxpRename:
+		return s.rename(id, d.string(), d.string(), false)
+	case fxpReadlink:
+		return s.readlink(id, d.string())
+	case fxpExtended:
+		if d.string() == "posix-rename@openssh.com" {
+			return s.rename(id, d.string(), d.string(), true)
+		}
+	}
+	return nil, &statusError{code: fxOpUnsupported, message: fmt.Sprintf("request type %d is not supported", packetType)}
+}
+
+// status builds the status response of a failed request
+func (s *session) status(id uint32, err error) *encoder {
+	var statusErr *statusError
+	var itemErr *items.ItemError
+	switch {
+	case errors.As(err, &statusErr):
+	case errors.As(err, &itemErr):
+		statusErr = itemError(itemErr)
+	default:
+		fmt.Printf("❌ SFTP request of %s failed: %v\n", s.user, err)
+		statusErr = errFailure("%v", err)
+	}
+	return statusPacket(id, statusErr.code, statusErr.message)
+}
+
+// itemError translates a failed item operation into the closest SFTP status
+func itemError(err *items.ItemError) *statusError {
+	switch err.Code {
+	case items.ErrCodeNotFound:
+		return &statusError{code: fxNoSuchFile, message: err.Message}
+	case items.ErrCodeLocked, items.ErrCodeNotAllowed, tables.ViolationNameTooLong, tables.ViolationPathTooLong,
+		tables.ViolationInvalidCharacter, tables.ViolationReservedName, tables.ViolationMaxDepth:
+		return &statusError{code: fxPermissionDenied, message: err.Message}
+	}
+	return &statusError{code: fxFailure, message: err.Message}
+}
+
+func statusPacket(id uint32, code uint32, message string) *encoder {
+	return newPacket(fxpStatus).uint32(id).uint32(code).string(message).string("")
+}
+
+func ok(id uint32) *encoder {
+	return statusPacket(id, fxOK, "")
+}
+
+func (s *session) send(response *encoder) error {
+	_, err := s.channel.Write(response.packet())
+	return err
+}
+
+// addHandle registers an open file or directory and returns its handle string
+func (s *session) addHandle(h *handle) string {
+	s.nextHandle++
+	name := strconv.FormatUint(s.nextHandle, 10)
+	s.handles[name] = h
+	return name
+}
+
+func (s *session) getHandle(name string) (*handle, error) {
+	h, ok := s.handles[name]
+	if !ok {
+		return nil, errFailure("invalid handle")
+	}
+	return h, nil
+}
+
+// closeAll commits the files a client didn't close before disconnecting
+func (s *session) closeAll() {
+	for name, h := range s.handles {
+		if err := s.commit(h); err != nil {
+			fmt.Printf("❌ SFTP upload of %s by %s failed: %v\n", h.path, s.user, err)
+		}
+		delete(s.handles, name)
+	}
+}
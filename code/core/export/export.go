@@ -0,0 +1,245 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/Voltaic314/GhostFS/code/core/items"
+	"github.com/Voltaic314/GhostFS/code/db"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+)
+
+// Archive formats accepted by ExportToArchive
+const (
+	FormatTar = "tar"
+	FormatZip = "zip"
+)
+
+// ExportRequest represents the input for exporting a table or a subtree of one
+type ExportRequest struct {
+	TableID     string
+	FolderID    string // folder to export (empty = the table's root)
+	MaxFileSize int64  // write at most this many bytes per file (0 = full size)
+	MaxDepth    int    // levels below the exported folder to include (0 = all, tables without max_depth never end)
+}
+
+// ExportResponse summarizes an export
+type ExportResponse struct {
+	Folders     int64 `json:"folders"`
+	Files       int64 `json:"files"`
+	Bytes       int64 `json:"bytes"`
+	CappedFiles int64 `json:"capped_files"` // files truncated to MaxFileSize
+	Links       int64 `json:"links"`        // symlinks, hard links and shortcuts written as links
+	Skipped     int64 `json:"skipped"`      // shortcuts whose target isn't part of the export
+	Renamed     int64 `json:"renamed"`      // items renamed "name (N).ext" so they don't overwrite a sibling
+}
+
+// sink receives the exported tree. Folders are opened before their children and closed after them,
+// so a sink can apply folder timestamps once nothing else will touch the folder.
+type sink interface {
+	openFolder(relPath string, node dbTypes.Node) error
+	closeFolder(relPath string, node dbTypes.Node) error
+	file(relPath string, node dbTypes.Node, content io.Reader, size int64) error
+	symlink(relPath string, node dbTypes.Node, target string) error
+	// hardlink links relPath to the already written targetPath; sinks without hard links write content instead
+	hardlink(relPath string, node dbTypes.Node, targetPath string, content io.Reader, size int64) error
+	// foldsNames reports whether names that only differ by case or Unicode normalization are the same
+	// entry, so such siblings have to be renamed
+	foldsNames() bool
+	close() error
+}
+
+// ExportToDirectory writes the tree (with deterministic content and seeded timestamps) under dir
+func ExportToDirectory(tableManager *tables.TableManager, database *db.DB, generator *tables.DeterministicGenerator, req ExportRequest, dir string) (*ExportResponse, error) {
+	s, err := newDirSink(dir)
+	if err != nil {
+		return nil, err
+	}
+	return export(tableManager, database, generator, req, s)
+}
+
+// ExportToArchive streams the tree to w as a tar or zip archive
+func ExportToArchive(tableManager *tables.TableManager, database *db.DB, generator *tables.DeterministicGenerator, req ExportRequest, w io.Writer, format string) (*ExportResponse, error) {
+	var s sink
+	switch format {
+	case FormatTar:
+		s = newTarSink(w)
+	case FormatZip:
+		s = newZipSink(w)
+	default:
+		return nil, fmt.Errorf("unknown archive format: %q", format)
+	}
+	return export(tableManager, database, generator, req, s)
+}
+
+// export walks the subtree depth-first, generating folders as it goes, and feeds it to the sink
+func export(tableManager *tables.TableManager, database *db.DB, generator *tables.DeterministicGenerator, req ExportRequest, s sink) (*ExportResponse, error) {
+	if req.MaxFileSize < 0 || req.MaxDepth < 0 {
+		return nil, fmt.Errorf("max_file_size and max_depth must not be negative")
+	}
+
+	var start dbTypes.Node
+	if req.FolderID == "" {
+		rootResp, err := items.GetRoot(tableManager, database, items.GetRootRequest{TableID: req.TableID})
+		if err != nil {
+			return nil, err
+		}
+		start = rootResp.Root
+	} else {
+		itemResp, err := items.GetItem(tableManager, database, items.GetItemRequest{TableID: req.TableID, ItemID: req.FolderID})
+		if err != nil {
+			return nil, err
+		}
+		start = itemResp.Item
+	}
+	if start.Type != "folder" {
+		return nil, fmt.Errorf("%s is not a folder", start.Path)
+	}
+
+	resp := &ExportResponse{}
+	written := make(map[string]string) // node ID -> exported path, for hard links and shortcuts
+
+	var walk func(folder dbTypes.Node, relPath string) error
+	walk = func(folder dbTypes.Node, relPath string) error {
+		if err := s.openFolder(relPath, folder); err != nil {
+			return err
+		}
+
+		listResp, err := items.ListItems(tableManager, database, generator, items.ListItemsRequest{TableID: req.TableID, FolderID: folder.ID})
+		if err != nil {
+			return fmt.Errorf("list %s: %w", folder.Path, err)
+		}
+
+		taken := make(map[string]struct{}, len(listResp.Items))
+		for _, child := range listResp.Items {
+			if !safeName(child.Name) {
+				return fmt.Errorf("refusing to export %q: name is not a single path element", child.Path)
+			}
+			name := child.Name
+			if s.foldsNames() {
+				if name = foldedUniqueName(child.Name, taken); name != child.Name {
+					resp.Renamed++
+				}
+			}
+			childPath := path.Join(relPath, name)
+			written[child.ID] = childPath
+
+			switch child.Type {
+			case tables.NodeTypeSymlink:
+				if err := s.symlink(childPath, child, child.Target); err != nil {
+					return fmt.Errorf("write %s: %w", child.Path, err)
+				}
+				resp.Links++
+				continue
+			case tables.NodeTypeShortcut:
+				// Exported as a symlink when the shortcut's target is part of the export
+				targetPath, ok := written[child.Target]
+				if !ok {
+					resp.Skipped++
+					continue
+				}
+				if err := s.symlink(childPath, child, relativePath(relPath, targetPath)); err != nil {
+					return fmt.Errorf("write %s: %w", child.Path, err)
+				}
+				resp.Links++
+				continue
+			}
+
+			if child.Type == "folder" {
+				resp.Folders++
+				if req.MaxDepth > 0 && child.Level-start.Level >= req.MaxDepth {
+					// Keep the folder itself but don't descend (or generate) any further
+					if err := s.openFolder(childPath, child); err != nil {
+						return err
+					}
+					if err := s.closeFolder(childPath, child); err != nil {
+						return err
+					}
+					continue
+				}
+				if err := walk(child, childPath); err != nil {
+					return err
+				}
+				continue
+			}
+
+			size := child.Size
+			if req.MaxFileSize > 0 && size > req.MaxFileSize {
+				size = req.MaxFileSize
+				resp.CappedFiles++
+			}
+			content := generator.OpenContent(child, size)
+			if targetPath, ok := written[child.Target]; ok && child.Type == tables.NodeTypeHardlink {
+				if err := s.hardlink(childPath, child, targetPath, content, size); err != nil {
+					return fmt.Errorf("write %s: %w", child.Path, err)
+				}
+				resp.Links++
+			} else if err := s.file(childPath, child, content, size); err != nil {
+				return fmt.Errorf("write %s: %w", child.Path, err)
+			}
+			resp.Files++
+			resp.Bytes += size
+		}
+
+		return s.closeFolder(relPath, folder)
+	}
+
+	if err := walk(start, ""); err != nil {
+		s.close()
+		return nil, err
+	}
+	if err := s.close(); err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("📦 Exported %d folders and %d files (%d bytes) from %s\n", resp.Folders, resp.Files, resp.Bytes, start.Path)
+	return resp, nil
+}
+
+// relativePath returns target (relative to the export root) as seen from the folder at fromDir
+func relativePath(fromDir, target string) string {
+	from := strings.Split(fromDir, "/")
+	to := strings.Split(target, "/")
+	if fromDir == "" {
+		from = nil
+	}
+	common := 0
+	for common < len(from) && common < len(to)-1 && from[common] == to[common] {
+		common++
+	}
+	parts := make([]string, 0, len(from)-common+len(to)-common)
+	for range from[common:] {
+		parts = append(parts, "..")
+	}
+	return path.Join(append(parts, to[common:]...)...)
+}
+
+// foldedNames compares names the way case- and normalization-insensitive file systems do
+var foldedNames = tables.NamePolicy{CaseInsensitive: true, NormalizationInsensitive: true}
+
+// foldedUniqueName returns name, or "name (N).ext" if a sibling already took it up to case and
+// normalization. taken holds the folded names of the siblings so far.
+func foldedUniqueName(name string, taken map[string]struct{}) string {
+	candidate := name
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	if base == "" {
+		base, ext = name, ""
+	}
+	for n := 2; ; n++ {
+		key := foldedNames.NameKey(candidate)
+		if _, ok := taken[key]; !ok {
+			taken[key] = struct{}{}
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s (%d)%s", base, n, ext)
+	}
+}
+
+// safeName rejects names that would escape or collapse the exported path
+func safeName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, "/\x00")
+}
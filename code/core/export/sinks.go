@@ -0,0 +1,244 @@
+package export
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+)
+
+// dirSink writes the tree to a local directory. Exporting into a previous export replaces its files
+// and links; anything else already in the directory is left alone.
+type dirSink struct {
+	root string
+}
+
+func newDirSink(dir string) (*dirSink, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create export directory: %w", err)
+	}
+	return &dirSink{root: dir}, nil
+}
+
+func (s *dirSink) openFolder(relPath string, node dbTypes.Node) error {
+	if relPath == "" {
+		return nil
+	}
+	// A link in the way would send the folder's children somewhere else
+	if err := removeNonFolder(s.path(relPath)); err != nil {
+		return fmt.Errorf("create folder %s: %w", relPath, err)
+	}
+	if err := os.Mkdir(s.path(relPath), 0o755); err != nil && !os.IsExist(err) {
+		return fmt.Errorf("create folder %s: %w", relPath, err)
+	}
+	return nil
+}
+
+func (s *dirSink) closeFolder(relPath string, node dbTypes.Node) error {
+	// Writing children bumps the folder's mtime, so its own times go on last
+	if err := os.Chtimes(s.path(relPath), node.UpdatedAt, node.UpdatedAt); err != nil {
+		return fmt.Errorf("set times on %s: %w", relPath, err)
+	}
+	return nil
+}
+
+func (s *dirSink) file(relPath string, node dbTypes.Node, content io.Reader, size int64) error {
+	target := s.path(relPath)
+	// Not os.Create on the old entry: it would write through a symlink or into a hard link's other names
+	if err := removeNonFolder(target); err != nil {
+		return err
+	}
+	f, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, content); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Chtimes(target, node.UpdatedAt, node.UpdatedAt)
+}
+
+func (s *dirSink) symlink(relPath string, node dbTypes.Node, target string) error {
+	if err := removeNonFolder(s.path(relPath)); err != nil {
+		return err
+	}
+	return os.Symlink(filepath.FromSlash(target), s.path(relPath))
+}
+
+func (s *dirSink) hardlink(relPath string, node dbTypes.Node, targetPath string, content io.Reader, size int64) error {
+	if err := removeNonFolder(s.path(relPath)); err != nil {
+		return err
+	}
+	return os.Link(s.path(targetPath), s.path(relPath))
+}
+
+// Local file systems are often case- or normalization-insensitive (Windows, macOS)
+func (s *dirSink) foldsNames() bool {
+	return true
+}
+
+func (s *dirSink) close() error {
+	return nil
+}
+
+func (s *dirSink) path(relPath string) string {
+	return filepath.Join(s.root, filepath.FromSlash(relPath))
+}
+
+// removeNonFolder removes whatever a previous export left at target unless it's a folder
+func removeNonFolder(target string) error {
+	info, err := os.Lstat(target)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if info.IsDir() {
+		return nil
+	}
+	return os.Remove(target)
+}
+
+// tarSink streams the tree as a tar archive
+type tarSink struct {
+	w *tar.Writer
+}
+
+func newTarSink(w io.Writer) *tarSink {
+	return &tarSink{w: tar.NewWriter(w)}
+}
+
+func (s *tarSink) openFolder(relPath string, node dbTypes.Node) error {
+	if relPath == "" {
+		return nil
+	}
+	return s.w.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeDir,
+		Name:     relPath + "/",
+		Mode:     0o755,
+		ModTime:  node.UpdatedAt,
+	})
+}
+
+func (s *tarSink) closeFolder(relPath string, node dbTypes.Node) error {
+	return nil
+}
+
+func (s *tarSink) file(relPath string, node dbTypes.Node, content io.Reader, size int64) error {
+	err := s.w.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     relPath,
+		Mode:     0o644,
+		Size:     size,
+		ModTime:  node.UpdatedAt,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(s.w, content)
+	return err
+}
+
+func (s *tarSink) symlink(relPath string, node dbTypes.Node, target string) error {
+	return s.w.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeSymlink,
+		Name:     relPath,
+		Linkname: target,
+		Mode:     0o777,
+		ModTime:  node.UpdatedAt,
+	})
+}
+
+func (s *tarSink) hardlink(relPath string, node dbTypes.Node, targetPath string, content io.Reader, size int64) error {
+	return s.w.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeLink,
+		Name:     relPath,
+		Linkname: targetPath,
+		Mode:     0o644,
+		ModTime:  node.UpdatedAt,
+	})
+}
+
+func (s *tarSink) foldsNames() bool {
+	return false
+}
+
+func (s *tarSink) close() error {
+	return s.w.Close()
+}
+
+// zipSink streams the tree as a zip archive. Content is random, so entries are stored uncompressed.
+type zipSink struct {
+	w *zip.Writer
+}
+
+func newZipSink(w io.Writer) *zipSink {
+	return &zipSink{w: zip.NewWriter(w)}
+}
+
+func (s *zipSink) openFolder(relPath string, node dbTypes.Node) error {
+	if relPath == "" {
+		return nil
+	}
+	_, err := s.w.CreateHeader(&zip.FileHeader{
+		Name:     relPath + "/",
+		Method:   zip.Store,
+		Modified: node.UpdatedAt,
+	})
+	return err
+}
+
+func (s *zipSink) closeFolder(relPath string, node dbTypes.Node) error {
+	return nil
+}
+
+func (s *zipSink) file(relPath string, node dbTypes.Node, content io.Reader, size int64) error {
+	w, err := s.w.CreateHeader(&zip.FileHeader{
+		Name:     relPath,
+		Method:   zip.Store,
+		Modified: node.UpdatedAt,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, content)
+	return err
+}
+
+func (s *zipSink) symlink(relPath string, node dbTypes.Node, target string) error {
+	// Zip stores symlinks as entries with the symlink mode bit and the target as content
+	header := &zip.FileHeader{
+		Name:     relPath,
+		Method:   zip.Store,
+		Modified: node.UpdatedAt,
+	}
+	header.SetMode(os.ModeSymlink | 0o777)
+	w, err := s.w.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, target)
+	return err
+}
+
+func (s *zipSink) hardlink(relPath string, node dbTypes.Node, targetPath string, content io.Reader, size int64) error {
+	// Zip has no hard links, write a copy of the content
+	return s.file(relPath, node, content, size)
+}
+
+func (s *zipSink) foldsNames() bool {
+	return false
+}
+
+func (s *zipSink) close() error {
+	return s.w.Close()
+}
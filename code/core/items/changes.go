@@ -0,0 +1,123 @@
+package items
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Voltaic314/GhostFS/code/db"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+)
+
+// maxChangesPage caps the changes returned at once
+const maxChangesPage = 1000
+
+// GetChangeTokenRequest represents the input for getting a table's current change position
+type GetChangeTokenRequest struct {
+	TableID string
+}
+
+// GetChangeTokenResponse represents the output for getting a table's current change position
+type GetChangeTokenResponse struct {
+	Token int64 // listing changes after it returns the changes made from now on
+}
+
+// GetChangeToken returns the position of a table's latest change, to sync from
+func GetChangeToken(tableManager *tables.TableManager, database *db.DB, req GetChangeTokenRequest) (*GetChangeTokenResponse, error) {
+	tableName, err := resolveTableName(tableManager, database, req.TableID)
+	if err != nil {
+		return nil, err
+	}
+	token, err := tables.LatestChange(database, tableName)
+	if err != nil {
+		return nil, err
+	}
+	return &GetChangeTokenResponse{Token: token}, nil
+}
+
+// ListChangesRequest represents the input for listing a table's changes
+type ListChangesRequest struct {
+	TableID string
+	After   int64 // token of the last change seen
+	Limit   int   // 0 = the maximum page
+}
+
+// Change is an item changed since the requested token, in its current state. Removed items are
+// gone for good; trashed ones are in the trash (Item is the trashed item).
+type Change struct {
+	Token     int64
+	ItemID    string
+	Removed   bool
+	Item      *dbTypes.Node      // nil if removed
+	Trashed   *dbTypes.TrashItem // set if the item is in the trash
+	ChangedAt time.Time
+}
+
+// ListChangesResponse represents the output for listing a table's changes
+type ListChangesResponse struct {
+	Changes []Change
+	Next    int64 // token to continue from
+	HasMore bool
+}
+
+// ListChanges lists the items changed through the API after a token, oldest first. Items changed
+// more than once are listed at their last change.
+func ListChanges(tableManager *tables.TableManager, database *db.DB, req ListChangesRequest) (*ListChangesResponse, error) {
+	tableName, err := resolveTableName(tableManager, database, req.TableID)
+	if err != nil {
+		return nil, err
+	}
+	limit := req.Limit
+	if limit <= 0 || limit > maxChangesPage {
+		limit = maxChangesPage
+	}
+
+	// One more than the page tells whether there are more
+	journal, err := tables.GetChanges(database, tableName, req.After, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	resp := &ListChangesResponse{Changes: []Change{}, Next: req.After}
+	if len(journal) > limit {
+		journal, resp.HasMore = journal[:limit], true
+	}
+
+	for _, entry := range journal {
+		change := Change{Token: entry.Seq, ItemID: entry.NodeID, Removed: entry.Removed, ChangedAt: entry.ChangedAt}
+		if !change.Removed {
+			if err := resolveChange(database, tableName, &change); err != nil {
+				return nil, err
+			}
+		}
+		resp.Changes = append(resp.Changes, change)
+		resp.Next = entry.Seq
+	}
+	return resp, nil
+}
+
+// resolveChange looks up a changed item: in the table, else in the trash, else it's gone (e.g. deleted
+// with a trashed folder, or purged)
+func resolveChange(database *db.DB, tableName string, change *Change) error {
+	node, err := tables.GetNode(database, tableName, change.ItemID)
+	if err == nil {
+		change.Item = node
+		return nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to get changed item: %w", err)
+	}
+
+	trashed, err := tables.GetTrashItem(database, tableName, change.ItemID)
+	if err != nil {
+		return fmt.Errorf("failed to get trashed item: %w", err)
+	}
+	if trashed == nil {
+		change.Removed = true
+		return nil
+	}
+	change.Trashed = trashed
+	change.Item = &trashed.Node
+	return nil
+}
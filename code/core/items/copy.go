@@ -0,0 +1,175 @@
+package items
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Voltaic314/GhostFS/code/db"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+	"github.com/google/uuid"
+)
+
+// maxCopyItems caps the nodes a single copy writes. Generated trees can be unbounded, and providers
+// refuse large server-side copies too (e.g. Dropbox's too_many_files).
+const maxCopyItems = 10000
+
+// CopyItemRequest represents the input for copying an item
+type CopyItemRequest struct {
+	TableID  string
+	ItemID   string
+	ParentID string // destination folder (empty = the item's own folder)
+	Name     string // name of the copy (empty = keep the name)
+}
+
+// CopyItemResponse represents the output for copying an item
+type CopyItemResponse struct {
+	Item   dbTypes.Node // the copy of the requested item
+	Copied int          // nodes written, including descendants
+}
+
+// CopyItem copies an item, with its subtree for folders. Copies keep the content (content seed), size and
+// modified time of the originals and are stored in the database, so they don't depend on the generator.
+// Failures a provider would report (conflicts, constraint violations, ...) are returned as *ItemError.
+func CopyItem(tableManager *tables.TableManager, database *db.DB, generator *tables.DeterministicGenerator, req CopyItemRequest) (*CopyItemResponse, error) {
+	tableName, err := resolveTableName(tableManager, database, req.TableID)
+	if err != nil {
+		return nil, err
+	}
+
+	node, dest, err := getSourceAndDestination(database, tableName, req.ItemID, req.ParentID)
+	if err != nil {
+		return nil, err
+	}
+	name := req.Name
+	if name == "" {
+		name = node.Name
+	}
+	if itemErr := validateName(name); itemErr != nil {
+		return nil, itemErr
+	}
+
+	if err := checkUnlocked(tableManager, database, generator, tableName, dest); err != nil {
+		return nil, err
+	}
+
+	siblings, err := materializeFolder(tableManager, database, generator, tableName, dest)
+	if err != nil {
+		return nil, err
+	}
+	if itemErr := newSiblingNames(tableManager.GetNamePolicy(tableName), siblings, "").check(name); itemErr != nil {
+		return nil, itemErr
+	}
+
+	c := &subtreeCopy{
+		tableManager: tableManager,
+		database:     database,
+		generator:    generator,
+		tableName:    tableName,
+		now:          time.Now().UTC(),
+		newIDs:       make(map[string]string),
+	}
+	root := c.copyNode(*node, dest, name)
+	if itemErr := checkConstraints(tableManager, tableName, root); itemErr != nil {
+		return nil, itemErr
+	}
+	c.nodes = append(c.nodes, root)
+	if node.Type == tables.NodeTypeFolder {
+		if err := c.copyDescendants(*node, root); err != nil {
+			return nil, err
+		}
+	}
+	c.remapLinkTargets()
+
+	if err := insertNodes(database, tableName, c.nodes); err != nil {
+		return nil, fmt.Errorf("failed to copy %s: %w", node.Path, err)
+	}
+	copiedIDs := make([]string, len(c.nodes))
+	for i, copied := range c.nodes {
+		copiedIDs[i] = copied.ID
+	}
+	if err := tables.RecordChanges(database, tableName, false, copiedIDs...); err != nil {
+		return nil, err
+	}
+
+	return &CopyItemResponse{Item: c.nodes[0], Copied: len(c.nodes)}, nil
+}
+
+// subtreeCopy collects the copies of a subtree before they are written in one batch
+type subtreeCopy struct {
+	tableManager *tables.TableManager
+	database     *db.DB
+	generator    *tables.DeterministicGenerator
+	tableName    string
+	now          time.Time
+	nodes        []dbTypes.Node
+	newIDs       map[string]string // original ID -> copy ID
+}
+
+// copyNode builds the copy of a node under parent
+func (c *subtreeCopy) copyNode(original dbTypes.Node, parent *dbTypes.Node, name string) dbTypes.Node {
+	copied := original
+	copied.ID = uuid.New().String()
+	copied.ParentID = parent.ID
+	copied.Name = name
+	copied.Path = childPath(parent.Path, name)
+	copied.Level = parent.Level + 1
+	copied.Checked = false
+	copied.ChildSeed = nil
+	copied.SecondaryExistenceMap = ""
+	copied.Lock = nil
+	copied.Sharing = nil
+	copied.UserCreated = true
+	copied.CreatedAt = c.now
+	if original.Type == tables.NodeTypeFolder {
+		copied.Materialized = true
+	} else if original.Type == tables.NodeTypeFile || original.Type == tables.NodeTypeHardlink {
+		copied.ContentSeed = c.generator.ContentSeed(original)
+	}
+	c.newIDs[original.ID] = copied.ID
+	return copied
+}
+
+// copyDescendants copies the subtree of original (listing or generating it) under its copy. The walk is
+// breadth first, so the item cap stops it early even in unbounded generated trees.
+func (c *subtreeCopy) copyDescendants(original dbTypes.Node, copied dbTypes.Node) error {
+	type pendingFolder struct{ original, copied dbTypes.Node }
+	queue := []pendingFolder{{original, copied}}
+
+	for len(queue) > 0 {
+		folder := queue[0]
+		queue = queue[1:]
+
+		children, err := listChildren(c.tableManager, c.database, c.generator, c.tableName, &folder.original, false)
+		if err != nil {
+			return err
+		}
+		if len(c.nodes)+len(children) > maxCopyItems {
+			return &ItemError{Code: ErrCodeTooManyItems, Message: fmt.Sprintf("copy of %s exceeds %d items", original.Path, maxCopyItems)}
+		}
+
+		for _, child := range children {
+			childCopy := c.copyNode(child, &folder.copied, child.Name)
+			if itemErr := checkConstraints(c.tableManager, c.tableName, childCopy); itemErr != nil {
+				return itemErr
+			}
+			c.nodes = append(c.nodes, childCopy)
+			if child.Type == tables.NodeTypeFolder {
+				queue = append(queue, pendingFolder{child, childCopy})
+			}
+		}
+	}
+	return nil
+}
+
+// remapLinkTargets points hard links and shortcuts at the copies of their targets when those were copied too
+func (c *subtreeCopy) remapLinkTargets() {
+	for i, node := range c.nodes {
+		if node.Type != tables.NodeTypeHardlink && node.Type != tables.NodeTypeShortcut {
+			continue
+		}
+		if newID, ok := c.newIDs[node.Target]; ok {
+			c.nodes[i].Target = newID
+		}
+	}
+}
@@ -0,0 +1,298 @@
+package items
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Voltaic314/GhostFS/code/db"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+	"github.com/google/uuid"
+)
+
+// Error codes reported by item write operations, modelled on what cloud providers return
+const (
+	ErrCodeNotFound     = "not_found"
+	ErrCodeNotAFolder   = "not_a_folder"
+	ErrCodeNotAFile     = "not_a_file"
+	ErrCodeInvalidName  = "invalid_name"
+	ErrCodeInvalidSize  = "invalid_size"
+	ErrCodeInvalidType  = "invalid_type"
+	ErrCodeNameConflict = "name_conflict"
+	ErrCodeInvalidDest  = "invalid_destination"
+	ErrCodeTooManyItems = "too_many_items"
+	ErrCodeNotAllowed   = "not_allowed"
+	ErrCodeLocked       = "locked"
+	ErrCodeNotLocked    = "not_locked"
+	ErrCodeInvalidLock  = "invalid_lock"
+	ErrCodeInvalidShare = "invalid_sharing"
+)
+
+// ItemError is a write operation failure with a provider-like error code
+type ItemError struct {
+	Code    string
+	Message string
+}
+
+func (e *ItemError) Error() string {
+	return e.Message
+}
+
+// NewItem describes an item to create
+type NewItem struct {
+	Name string
+	Type string // "file" or "folder"
+	Size int64  // Only for files
+
+	// ContentSeed gives a file the content of another one (0 = its own content, derived from the ID)
+	ContentSeed int64
+}
+
+// CreateItemsRequest represents the input for creating items
+type CreateItemsRequest struct {
+	TableID  string
+	ParentID string
+	Items    []NewItem
+}
+
+// CreateItemResult is the outcome of creating a single item. Either Item or Error is set.
+type CreateItemResult struct {
+	Item  *dbTypes.Node
+	Error *ItemError
+}
+
+// CreateItemsResponse represents the output for creating items
+type CreateItemsResponse struct {
+	Results []CreateItemResult
+}
+
+// CreateItems creates files and folders in a folder. Names are checked against the existing siblings
+// using the table's name policy, so creates that collide case-insensitively or after Unicode
+// normalization fail on tables configured like Windows, OneDrive or macOS.
+// Returns an *ItemError when the parent can't take children, per-item failures are in the results.
+func CreateItems(tableManager *tables.TableManager, database *db.DB, generator *tables.DeterministicGenerator, req CreateItemsRequest) (*CreateItemsResponse, error) {
+	tableName, err := resolveTableName(tableManager, database, req.TableID)
+	if err != nil {
+		return nil, err
+	}
+
+	parent, err := getParentFolder(database, tableName, req.ParentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkUnlocked(tableManager, database, generator, tableName, parent); err != nil {
+		return nil, err
+	}
+
+	siblings, err := materializeFolder(tableManager, database, generator, tableName, parent)
+	if err != nil {
+		return nil, err
+	}
+
+	names := newSiblingNames(tableManager.GetNamePolicy(tableName), siblings, "")
+	results := make([]CreateItemResult, 0, len(req.Items))
+	for _, item := range req.Items {
+		node := newItemNode(parent, item)
+		itemErr := validateNewItem(item)
+		if itemErr == nil {
+			itemErr = names.check(item.Name)
+		}
+		if itemErr == nil {
+			itemErr = checkConstraints(tableManager, tableName, node)
+		}
+		if itemErr != nil {
+			results = append(results, CreateItemResult{Error: itemErr})
+			continue
+		}
+
+		if err := insertNodes(database, tableName, []dbTypes.Node{node}); err != nil {
+			return nil, fmt.Errorf("failed to create %s: %w", item.Name, err)
+		}
+		if err := tables.RecordChanges(database, tableName, false, node.ID); err != nil {
+			return nil, err
+		}
+		names.add(item.Name)
+		results = append(results, CreateItemResult{Item: &node})
+	}
+
+	return &CreateItemsResponse{Results: results}, nil
+}
+
+// getItem loads a node by ID
+func getItem(database *db.DB, tableName string, itemID string) (*dbTypes.Node, error) {
+	node, err := tables.GetNode(database, tableName, itemID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &ItemError{Code: ErrCodeNotFound, Message: fmt.Sprintf("item %s not found", itemID)}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get item: %w", err)
+	}
+	return node, nil
+}
+
+// getParentFolder loads the folder new items go into
+func getParentFolder(database *db.DB, tableName string, parentID string) (*dbTypes.Node, error) {
+	parent, err := tables.GetNode(database, tableName, parentID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &ItemError{Code: ErrCodeNotFound, Message: fmt.Sprintf("parent folder %s not found", parentID)}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get parent folder: %w", err)
+	}
+	if parent.Type != tables.NodeTypeFolder {
+		return nil, &ItemError{Code: ErrCodeNotAFolder, Message: fmt.Sprintf("%s is not a folder", parent.Path)}
+	}
+	return parent, nil
+}
+
+// materializeFolder makes a folder's children live in the database so they can be changed, generating
+// them first if needed. Returns the folder's current children.
+func materializeFolder(tableManager *tables.TableManager, database *db.DB, generator *tables.DeterministicGenerator, tableName string, folder *dbTypes.Node) ([]dbTypes.Node, error) {
+	if tableManager.IsGeneratedTable(tableName) && !folder.Materialized {
+		if _, err := generator.GenerateChildren(folder, false, tableName); err != nil {
+			return nil, fmt.Errorf("failed to generate children: %w", err)
+		}
+		database.ForceFlushTable(tableName)
+		query := fmt.Sprintf("UPDATE %s SET materialized = TRUE WHERE id = ?", tableName)
+		if _, err := database.Exec(query, folder.ID); err != nil {
+			return nil, fmt.Errorf("failed to materialize folder: %w", err)
+		}
+		folder.Materialized = true
+	}
+
+	children, err := tables.GetChildren(database, tableName, folder.ID, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list children: %w", err)
+	}
+	return children, nil
+}
+
+// validateNewItem checks the parts of a new item that don't depend on the table
+func validateNewItem(item NewItem) *ItemError {
+	if item.Type != tables.NodeTypeFile && item.Type != tables.NodeTypeFolder {
+		return &ItemError{Code: ErrCodeInvalidType, Message: fmt.Sprintf("invalid type %q for %q (must be file or folder)", item.Type, item.Name)}
+	}
+	if item.Size < 0 {
+		return &ItemError{Code: ErrCodeInvalidSize, Message: fmt.Sprintf("invalid size %d for %q", item.Size, item.Name)}
+	}
+	return validateName(item.Name)
+}
+
+// validateName rejects names that can't be stored in any table
+func validateName(name string) *ItemError {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, "/\x00") {
+		return &ItemError{Code: ErrCodeInvalidName, Message: fmt.Sprintf("invalid name %q", name)}
+	}
+	return nil
+}
+
+// checkConstraints applies the table's constraint profile (if any) to a node
+func checkConstraints(tableManager *tables.TableManager, tableName string, node dbTypes.Node) *ItemError {
+	profile := tableManager.GetConstraints(tableName)
+	if profile == nil {
+		return nil
+	}
+	if violation := profile.Check(node.Name, node.Path, node.Level); violation != nil {
+		return &ItemError{Code: violation.Code, Message: violation.Message}
+	}
+	return nil
+}
+
+// siblingNames detects name conflicts in a folder under a table's name policy
+type siblingNames struct {
+	policy tables.NamePolicy
+	taken  map[string]string // name key -> existing name
+}
+
+// newSiblingNames indexes a folder's children, leaving out the nodes with the skipped IDs (e.g. the
+// one being renamed)
+func newSiblingNames(policy tables.NamePolicy, siblings []dbTypes.Node, skipIDs ...string) *siblingNames {
+	names := &siblingNames{policy: policy, taken: make(map[string]string, len(siblings))}
+	for _, sibling := range siblings {
+		if !slices.Contains(skipIDs, sibling.ID) {
+			names.add(sibling.Name)
+		}
+	}
+	return names
+}
+
+func (n *siblingNames) add(name string) {
+	n.taken[n.policy.NameKey(name)] = name
+}
+
+func (n *siblingNames) check(name string) *ItemError {
+	if existing, exists := n.taken[n.policy.NameKey(name)]; exists {
+		return &ItemError{
+			Code:    ErrCodeNameConflict,
+			Message: fmt.Sprintf("an item named %q already exists in this folder (conflicts with %q)", name, existing),
+		}
+	}
+	return nil
+}
+
+// newItemNode builds the node of a created item. Created folders are empty, so they are materialized right away.
+func newItemNode(parent *dbTypes.Node, item NewItem) dbTypes.Node {
+	now := time.Now().UTC()
+	node := dbTypes.Node{
+		ID:           uuid.New().String(),
+		ParentID:     parent.ID,
+		Name:         item.Name,
+		Path:         childPath(parent.Path, item.Name),
+		Type:         item.Type,
+		Level:        parent.Level + 1,
+		Materialized: item.Type == tables.NodeTypeFolder,
+		UserCreated:  true,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if item.Type == tables.NodeTypeFile {
+		node.Size = item.Size
+		node.ContentSeed = item.ContentSeed
+	}
+	return node
+}
+
+// insertNodes stores new nodes in a single transaction
+func insertNodes(database *db.DB, tableName string, nodes []dbTypes.Node) error {
+	query := fmt.Sprintf("INSERT INTO %s (id, parent_id, name, path, type, target, content_seed, size, level, checked, materialized, user_created, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", tableName)
+	queries := make([]string, len(nodes))
+	params := make([][]any, len(nodes))
+	for i, node := range nodes {
+		queries[i] = query
+		params[i] = []any{node.ID, node.ParentID, node.Name, node.Path, node.Type, nullableString(node.Target), nullableInt(node.ContentSeed),
+			node.Size, node.Level, false, node.Materialized, node.UserCreated, node.CreatedAt, node.UpdatedAt}
+	}
+
+	// Imported tables have no write queue, write directly after anything queued for the table
+	database.ForceFlushTable(tableName)
+	return database.WriteBatch(map[string][]string{tableName: queries}, map[string][][]any{tableName: params})
+}
+
+// nullableString stores empty strings as NULL
+func nullableString(value string) any {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+// nullableInt stores zero as NULL
+func nullableInt(value int64) any {
+	if value == 0 {
+		return nil
+	}
+	return value
+}
+
+// childPath builds the path of a child from its parent's path
+func childPath(parentPath, name string) string {
+	if parentPath == "/" {
+		return "/" + name
+	}
+	return parentPath + "/" + name
+}
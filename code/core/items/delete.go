@@ -0,0 +1,285 @@
+package items
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Voltaic314/GhostFS/code/db"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+)
+
+// DeleteItemRequest represents the input for deleting an item
+type DeleteItemRequest struct {
+	TableID   string
+	ItemID    string
+	Permanent bool // skip the trash even if the table has one
+}
+
+// DeleteItemResponse represents the output for deleting an item
+type DeleteItemResponse struct {
+	Item    dbTypes.Node
+	Trashed bool // false if the item was deleted permanently
+}
+
+// DeleteItem deletes an item, with its subtree for folders. Tables with a trash keep it there until
+// it is restored or purged, other deletes are permanent.
+// Failures a provider would report (unknown item, root folder) are returned as *ItemError.
+func DeleteItem(tableManager *tables.TableManager, database *db.DB, generator *tables.DeterministicGenerator, req DeleteItemRequest) (*DeleteItemResponse, error) {
+	tableName, err := resolveTableName(tableManager, database, req.TableID)
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := getItem(database, tableName, req.ItemID)
+	if err != nil {
+		return nil, err
+	}
+	if node.Level == 0 {
+		return nil, &ItemError{Code: ErrCodeNotAllowed, Message: "the root folder can't be deleted"}
+	}
+
+	// The folder must stop generating the item
+	parent, err := tables.GetNode(database, tableName, node.ParentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get parent folder: %w", err)
+	}
+	if err := checkUnlocked(tableManager, database, generator, tableName, node, parent); err != nil {
+		return nil, err
+	}
+	if err := checkSubtreeUnlocked(tableManager, database, generator, tableName, *node); err != nil {
+		return nil, err
+	}
+	if _, err := materializeFolder(tableManager, database, generator, tableName, parent); err != nil {
+		return nil, err
+	}
+
+	trash := tableManager.GetTrashConfig(tableName)
+	if trash != nil && trash.Enabled && !req.Permanent {
+		if err := purgeExpiredTrash(database, tableName, trash); err != nil {
+			return nil, err
+		}
+		queries, params := tables.TrashQueries(tableName, *node, time.Now().UTC())
+		database.ForceFlushTable(tableName)
+		if err := database.WriteBatch(map[string][]string{tableName: queries}, map[string][][]any{tableName: params}); err != nil {
+			return nil, fmt.Errorf("failed to move %s to the trash: %w", node.Path, err)
+		}
+		if err := tables.RecordChanges(database, tableName, false, node.ID); err != nil {
+			return nil, err
+		}
+		return &DeleteItemResponse{Item: *node, Trashed: true}, nil
+	}
+
+	subtree := "id = ? OR starts_with(path, ?)"
+	database.ForceFlushTable(tableName)
+	for _, related := range tables.NodeStateTables {
+		query := fmt.Sprintf("DELETE FROM %s WHERE table_name = ? AND node_id IN (SELECT id FROM %s WHERE %s)", related, tableName, subtree)
+		if _, err := database.Exec(query, tableName, node.ID, node.Path+"/"); err != nil {
+			return nil, fmt.Errorf("failed to delete %s of %s: %w", related, node.Path, err)
+		}
+	}
+	if _, err := database.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s", tableName, subtree), node.ID, node.Path+"/"); err != nil {
+		return nil, fmt.Errorf("failed to delete %s: %w", node.Path, err)
+	}
+	if err := tables.RecordChanges(database, tableName, true, node.ID); err != nil {
+		return nil, err
+	}
+	return &DeleteItemResponse{Item: *node}, nil
+}
+
+// ListTrashRequest represents the input for listing a table's trash
+type ListTrashRequest struct {
+	TableID string
+}
+
+// ListTrashResponse represents the output for listing a table's trash
+type ListTrashResponse struct {
+	Items []dbTypes.TrashItem // most recently deleted first
+}
+
+// ListTrash lists the items in a table's trash. Items past the retention period are purged first.
+func ListTrash(tableManager *tables.TableManager, database *db.DB, req ListTrashRequest) (*ListTrashResponse, error) {
+	tableName, err := resolveTableName(tableManager, database, req.TableID)
+	if err != nil {
+		return nil, err
+	}
+
+	trash := tableManager.GetTrashConfig(tableName)
+	if err := purgeExpiredTrash(database, tableName, trash); err != nil {
+		return nil, err
+	}
+
+	items, err := tables.GetTrash(database, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trash: %w", err)
+	}
+	if trash != nil && trash.RetentionDays > 0 {
+		for i := range items {
+			purgeAt := items[i].DeletedAt.Add(trash.Retention())
+			items[i].PurgeAt = &purgeAt
+		}
+	}
+
+	return &ListTrashResponse{Items: items}, nil
+}
+
+// GetTrashItemRequest represents the input for getting an item in a table's trash
+type GetTrashItemRequest struct {
+	TableID string
+	ItemID  string
+}
+
+// GetTrashItemResponse represents the output for getting an item in a table's trash
+type GetTrashItemResponse struct {
+	Item dbTypes.TrashItem
+}
+
+// GetTrashItem gets an item deleted to the trash (not one of its descendants). Items that aren't
+// in the trash return a not_found *ItemError.
+func GetTrashItem(tableManager *tables.TableManager, database *db.DB, req GetTrashItemRequest) (*GetTrashItemResponse, error) {
+	tableName, err := resolveTableName(tableManager, database, req.TableID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := purgeExpiredTrash(database, tableName, tableManager.GetTrashConfig(tableName)); err != nil {
+		return nil, err
+	}
+	item, err := tables.GetTrashItem(database, tableName, req.ItemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trashed item: %w", err)
+	}
+	if item == nil {
+		return nil, &ItemError{Code: ErrCodeNotFound, Message: fmt.Sprintf("item %s is not in the trash", req.ItemID)}
+	}
+	return &GetTrashItemResponse{Item: *item}, nil
+}
+
+// RestoreTrashItemRequest represents the input for restoring an item from the trash
+type RestoreTrashItemRequest struct {
+	TableID  string
+	ItemID   string
+	ParentID string // folder to restore into (empty = the folder it was deleted from)
+	Name     string // name to restore under (empty = its original name)
+}
+
+// RestoreTrashItemResponse represents the output for restoring an item from the trash
+type RestoreTrashItemResponse struct {
+	Item dbTypes.Node
+}
+
+// RestoreTrashItem moves an item (with its subtree) out of the trash. Like providers, the restore
+// fails if the folder is gone or a sibling took the name; pass ParentID or Name to put it elsewhere.
+// Failures are returned as *ItemError.
+func RestoreTrashItem(tableManager *tables.TableManager, database *db.DB, generator *tables.DeterministicGenerator, req RestoreTrashItemRequest) (*RestoreTrashItemResponse, error) {
+	tableName, err := resolveTableName(tableManager, database, req.TableID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := purgeExpiredTrash(database, tableName, tableManager.GetTrashConfig(tableName)); err != nil {
+		return nil, err
+	}
+	item, err := tables.GetTrashItem(database, tableName, req.ItemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trashed item: %w", err)
+	}
+	if item == nil {
+		return nil, &ItemError{Code: ErrCodeNotFound, Message: fmt.Sprintf("item %s is not in the trash", req.ItemID)}
+	}
+
+	parentID, name := req.ParentID, req.Name
+	if parentID == "" {
+		parentID = item.ParentID
+	}
+	if name == "" {
+		name = item.Name
+	}
+	if itemErr := validateName(name); itemErr != nil {
+		return nil, itemErr
+	}
+	parent, err := getParentFolder(database, tableName, parentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkUnlocked(tableManager, database, generator, tableName, parent); err != nil {
+		return nil, err
+	}
+
+	siblings, err := materializeFolder(tableManager, database, generator, tableName, parent)
+	if err != nil {
+		return nil, err
+	}
+	if itemErr := newSiblingNames(tableManager.GetNamePolicy(tableName), siblings, "").check(name); itemErr != nil {
+		return nil, itemErr
+	}
+
+	restored := item.Node
+	restored.ParentID, restored.Name, restored.Path, restored.Level = parent.ID, name, childPath(parent.Path, name), parent.Level+1
+	if itemErr := checkConstraints(tableManager, tableName, restored); itemErr != nil {
+		return nil, itemErr
+	}
+
+	queries, params := tables.RestoreQueries(tableName, *item, *parent, name)
+	database.ForceFlushTable(tableName)
+	if err := database.WriteBatch(map[string][]string{tableName: queries}, map[string][][]any{tableName: params}); err != nil {
+		return nil, fmt.Errorf("failed to restore %s: %w", item.Path, err)
+	}
+	if err := tables.RecordChanges(database, tableName, false, restored.ID); err != nil {
+		return nil, err
+	}
+
+	return &RestoreTrashItemResponse{Item: restored}, nil
+}
+
+// PurgeTrashRequest represents the input for permanently deleting trashed items
+type PurgeTrashRequest struct {
+	TableID string
+	ItemID  string // item to purge (empty = empty the whole trash)
+}
+
+// PurgeTrashResponse represents the output for permanently deleting trashed items
+type PurgeTrashResponse struct {
+	Purged int64
+}
+
+// PurgeTrash permanently deletes one item from a table's trash, or all of them
+func PurgeTrash(tableManager *tables.TableManager, database *db.DB, req PurgeTrashRequest) (*PurgeTrashResponse, error) {
+	tableName, err := resolveTableName(tableManager, database, req.TableID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.ItemID != "" {
+		item, err := tables.GetTrashItem(database, tableName, req.ItemID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get trashed item: %w", err)
+		}
+		if item == nil {
+			return nil, &ItemError{Code: ErrCodeNotFound, Message: fmt.Sprintf("item %s is not in the trash", req.ItemID)}
+		}
+	}
+
+	purged, err := tables.PurgeTrash(database, tableName, req.ItemID, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	return &PurgeTrashResponse{Purged: purged}, nil
+}
+
+// purgeExpiredTrash applies a table's retention period. Expired items are purged lazily, whenever
+// the trash is used, so the results don't depend on a background timer.
+func purgeExpiredTrash(database *db.DB, tableName string, trash *tables.TrashConfig) error {
+	if trash == nil || trash.RetentionDays == 0 {
+		return nil
+	}
+	purged, err := tables.PurgeTrash(database, tableName, "", time.Now().UTC().Add(-trash.Retention()))
+	if err != nil {
+		return err
+	}
+	if purged > 0 {
+		fmt.Printf("🗑️  Purged %d expired items from the %s trash\n", purged, tableName)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package items
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/Voltaic314/GhostFS/code/db"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+)
+
+// maxSymlinkHops is how many symlinks a download follows before giving up, like ELOOP
+const maxSymlinkHops = 8
+
+// OpenFileRequest represents the input for reading the content of a file
+type OpenFileRequest struct {
+	TableID string
+	ItemID  string
+}
+
+// OpenFileResponse represents the output for reading the content of a file
+type OpenFileResponse struct {
+	Item    dbTypes.Node // the requested item
+	File    dbTypes.Node // the file whose content is served (the item, or a symlink's target)
+	Content *tables.ContentReader
+}
+
+// OpenFile returns a reader over the current content of a file. Hard links read as the file they
+// share content with and symlinks are followed (targets are relative to the link's folder).
+// Folders and shortcuts aren't files, broken symlinks fail with not_found.
+func OpenFile(tableManager *tables.TableManager, database *db.DB, generator *tables.DeterministicGenerator, req OpenFileRequest) (*OpenFileResponse, error) {
+	tableName, err := resolveTableName(tableManager, database, req.TableID)
+	if err != nil {
+		return nil, err
+	}
+	item, err := getItem(database, tableName, req.ItemID)
+	if err != nil {
+		return nil, err
+	}
+
+	file := item
+	for hops := 0; file.Type == tables.NodeTypeSymlink; hops++ {
+		if hops == maxSymlinkHops {
+			return nil, &ItemError{Code: ErrCodeNotAFile, Message: fmt.Sprintf("%s: too many levels of symbolic links", item.Path)}
+		}
+		target := file.Target
+		if !strings.HasPrefix(target, "/") {
+			target = path.Join(path.Dir(file.Path), target)
+		}
+		resp, err := GetItemByPath(tableManager, database, generator, GetItemByPathRequest{TableID: req.TableID, Path: target})
+		if err != nil {
+			var itemErr *ItemError
+			if errors.As(err, &itemErr) && itemErr.Code == ErrCodeNotFound {
+				return nil, &ItemError{Code: ErrCodeNotFound, Message: fmt.Sprintf("%s: symlink target %s not found", item.Path, file.Target)}
+			}
+			return nil, err
+		}
+		file = &resp.Item
+	}
+
+	switch file.Type {
+	case tables.NodeTypeFile, tables.NodeTypeHardlink:
+	case tables.NodeTypeShortcut:
+		return nil, &ItemError{Code: ErrCodeNotAFile, Message: fmt.Sprintf("%s is a shortcut, download its target instead", file.Path)}
+	default:
+		return nil, &ItemError{Code: ErrCodeNotAFile, Message: fmt.Sprintf("%s is not a file", file.Path)}
+	}
+
+	return &OpenFileResponse{
+		Item:    *item,
+		File:    *file,
+		Content: generator.OpenContent(*file, file.Size),
+	}, nil
+}
@@ -0,0 +1,116 @@
+package items
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Voltaic314/GhostFS/code/db"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+)
+
+// GetItemRequest represents the input for getting a single item
+type GetItemRequest struct {
+	TableID string
+	ItemID  string
+}
+
+// GetItemResponse represents the output for getting a single item
+type GetItemResponse struct {
+	Item dbTypes.Node
+}
+
+// GetItem gets a single item (file or folder) by ID. Items only exist once their parent folder was listed.
+func GetItem(tableManager *tables.TableManager, database *db.DB, req GetItemRequest) (*GetItemResponse, error) {
+	tableName, err := resolveTableName(tableManager, database, req.TableID)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := tables.GetNode(database, tableName, req.ItemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get item: %w", err)
+	}
+
+	return &GetItemResponse{Item: *item}, nil
+}
+
+// GetItemByPathRequest represents the input for getting a single item by path
+type GetItemByPathRequest struct {
+	TableID string
+	Path    string // e.g. "/folder/file.txt" ("/" = the root)
+}
+
+// GetItemByPath gets a single item by path. Folders on the way are listed (generated if needed), so
+// any path of a generated tree can be looked up directly. The item comes with its lock and sharing,
+// like in a listing. Unknown paths return a not_found *ItemError.
+func GetItemByPath(tableManager *tables.TableManager, database *db.DB, generator *tables.DeterministicGenerator, req GetItemByPathRequest) (*GetItemResponse, error) {
+	tableName, err := resolveTableName(tableManager, database, req.TableID)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := GetRoot(tableManager, database, GetRootRequest{TableID: req.TableID})
+	if err != nil {
+		return nil, err
+	}
+	node, err := tables.GetNode(database, tableName, root.Root.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get root: %w", err)
+	}
+
+	generated := false
+	for _, name := range strings.Split(strings.Trim(req.Path, "/"), "/") {
+		if name == "" {
+			continue
+		}
+		if node.Type != tables.NodeTypeFolder {
+			return nil, &ItemError{Code: ErrCodeNotFound, Message: fmt.Sprintf("path %s not found", req.Path)}
+		}
+		generated = generated || (tableManager.IsGeneratedTable(tableName) && !node.Materialized)
+		children, err := listChildren(tableManager, database, generator, tableName, node, false)
+		if err != nil {
+			return nil, err
+		}
+		var child *dbTypes.Node
+		for i := range children {
+			if children[i].Name == name {
+				child = &children[i]
+				break
+			}
+		}
+		if child == nil {
+			return nil, &ItemError{Code: ErrCodeNotFound, Message: fmt.Sprintf("path %s not found", req.Path)}
+		}
+		node = child
+	}
+
+	// Generated children are stored through the write queue, the item must be in the database for
+	// operations that look it up by ID
+	if generated {
+		database.ForceFlushTable(tableName)
+	}
+	if node.ParentID != "" {
+		listed := []dbTypes.Node{*node}
+		if err := setLocks(tableManager, database, generator, tableName, node.ParentID, listed); err != nil {
+			return nil, fmt.Errorf("failed to get lock: %w", err)
+		}
+		if err := setSharing(tableManager, database, generator, tableName, node.ParentID, listed); err != nil {
+			return nil, fmt.Errorf("failed to get sharing: %w", err)
+		}
+		node = &listed[0]
+	}
+	return &GetItemResponse{Item: *node}, nil
+}
+
+// resolveTableName maps a table ID to its table name (checks the cache first, then the lookup table)
+func resolveTableName(tableManager *tables.TableManager, database *db.DB, tableID string) (string, error) {
+	if tableName, exists := tableManager.GetTableNameByID(tableID); exists {
+		return tableName, nil
+	}
+	tableName, err := tables.GetTableName(database, tableID)
+	if err != nil {
+		return "", fmt.Errorf("invalid table_id: %s", tableID)
+	}
+	return tableName, nil
+}
@@ -20,19 +20,13 @@ type GetRootResponse struct {
 
 // GetRoot gets the root node for a table
 func GetRoot(tableManager *tables.TableManager, database *db.DB, req GetRootRequest) (*GetRootResponse, error) {
-	// Get table name from table ID (check cache first)
-	tableName, exists := tableManager.GetTableNameByID(req.TableID)
-	if !exists {
-		// Not in cache, try to load from lookup table
-		var err error
-		tableName, err = tables.GetTableName(database, req.TableID)
-		if err != nil {
-			return nil, fmt.Errorf("invalid table_id: %s", req.TableID)
-		}
+	tableName, err := resolveTableName(tableManager, database, req.TableID)
+	if err != nil {
+		return nil, err
 	}
 
 	// Build SQL query to get the root node (level = 0)
-	query := fmt.Sprintf("SELECT id, name, path, type, size, level, checked FROM %s WHERE level = 0 LIMIT 1", tableName)
+	query := fmt.Sprintf("SELECT id, name, path, type, size, level, checked, created_at, updated_at FROM %s WHERE level = 0 LIMIT 1", tableName)
 
 	// Execute query
 	rows, err := database.Query(tableName, query)
@@ -44,7 +38,7 @@ func GetRoot(tableManager *tables.TableManager, database *db.DB, req GetRootRequ
 	// Parse result - should only be one root node
 	var rootNode dbTypes.Node
 	if rows.Next() {
-		err := rows.Scan(&rootNode.ID, &rootNode.Name, &rootNode.Path, &rootNode.Type, &rootNode.Size, &rootNode.Level, &rootNode.Checked)
+		err := rows.Scan(&rootNode.ID, &rootNode.Name, &rootNode.Path, &rootNode.Type, &rootNode.Size, &rootNode.Level, &rootNode.Checked, &rootNode.CreatedAt, &rootNode.UpdatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse database results: %w", err)
 		}
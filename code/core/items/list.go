@@ -22,31 +22,52 @@ type ListItemsResponse struct {
 
 // ListItems lists all items (files and folders) in a folder
 func ListItems(tableManager *tables.TableManager, database *db.DB, generator *tables.DeterministicGenerator, req ListItemsRequest) (*ListItemsResponse, error) {
-	// Get table name from table ID (check cache first)
-	tableName, exists := tableManager.GetTableNameByID(req.TableID)
-	if !exists {
-		// Not in cache, try to load from lookup table
-		var err error
-		tableName, err = tables.GetTableName(database, req.TableID)
-		if err != nil {
-			return nil, fmt.Errorf("invalid table_id: %s", req.TableID)
-		}
+	tableName, err := resolveTableName(tableManager, database, req.TableID)
+	if err != nil {
+		return nil, err
 	}
 
 	// Get folder information from database (we need path and level for generation)
-	folderInfo, err := generator.GetFolderInfo(req.FolderID, tableName)
+	folderInfo, err := tables.GetNode(database, tableName, req.FolderID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get folder info: %w", err)
 	}
 
+	items, err := listChildren(tableManager, database, generator, tableName, folderInfo, req.FoldersOnly)
+	if err != nil {
+		return nil, err
+	}
+	if err := setLocks(tableManager, database, generator, tableName, folderInfo.ID, items); err != nil {
+		return nil, fmt.Errorf("failed to get locks: %w", err)
+	}
+	if err := setSharing(tableManager, database, generator, tableName, folderInfo.ID, items); err != nil {
+		return nil, fmt.Errorf("failed to get sharing: %w", err)
+	}
+
+	return &ListItemsResponse{Items: items}, nil
+}
+
+// listChildren returns a folder's children, generating them unless they live in the database
+func listChildren(tableManager *tables.TableManager, database *db.DB, generator *tables.DeterministicGenerator, tableName string, folder *dbTypes.Node, foldersOnly bool) ([]dbTypes.Node, error) {
+	// Imported tables aren't generated and folders changed through the API are materialized,
+	// their contents live in the database
+	if !tableManager.IsGeneratedTable(tableName) || folder.Materialized {
+		items, err := tables.GetChildren(database, tableName, folder.ID, foldersOnly)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list children: %w", err)
+		}
+		generator.MarkFolderAccessed(folder.ID, tableName)
+		return items, nil
+	}
+
 	// Use deterministic generator instead of database query
-	items, err := generator.GenerateChildren(req.FolderID, folderInfo.Path, folderInfo.Level, req.FoldersOnly, tableName)
+	items, err := generator.GenerateChildren(folder, foldersOnly, tableName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate children: %w", err)
 	}
 
 	// Mark the parent folder as accessed (async)
-	generator.MarkFolderAccessed(req.FolderID, tableName)
+	generator.MarkFolderAccessed(folder.ID, tableName)
 
-	return &ListItemsResponse{Items: items}, nil
+	return items, nil
 }
@@ -0,0 +1,217 @@
+package items
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Voltaic314/GhostFS/code/db"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+)
+
+// LockItemRequest represents the input for locking an item
+type LockItemRequest struct {
+	TableID  string
+	ItemID   string
+	Owner    string
+	Duration time.Duration // 0 = held until released
+}
+
+// LockItemResponse represents the output for locking an item
+type LockItemResponse struct {
+	Item dbTypes.Node // with Lock set
+}
+
+// LockItem acquires an exclusive lock on an item. Locking an item the owner already holds refreshes
+// the expiry. While a file is locked it can't be changed, moved or deleted; while a folder is locked
+// its contents can't be added to or removed either.
+func LockItem(tableManager *tables.TableManager, database *db.DB, generator *tables.DeterministicGenerator, req LockItemRequest) (*LockItemResponse, error) {
+	tableName, err := resolveTableName(tableManager, database, req.TableID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Owner == "" {
+		return nil, &ItemError{Code: ErrCodeInvalidLock, Message: "a lock needs an owner"}
+	}
+	if req.Duration < 0 {
+		return nil, &ItemError{Code: ErrCodeInvalidLock, Message: fmt.Sprintf("invalid lock duration %s", req.Duration)}
+	}
+
+	node, err := getItem(database, tableName, req.ItemID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	current, err := getLock(tableManager, database, generator, tableName, *node, now)
+	if err != nil {
+		return nil, err
+	}
+	if current != nil && current.Owner != req.Owner {
+		return nil, lockedError(node, current)
+	}
+
+	lock := &dbTypes.Lock{Owner: req.Owner, AcquiredAt: now}
+	if current != nil {
+		lock.AcquiredAt = current.AcquiredAt
+	}
+	if req.Duration > 0 {
+		expiresAt := now.Add(req.Duration)
+		lock.ExpiresAt = &expiresAt
+	}
+
+	query, params := tables.LockQuery(tableName, node.ID, lock, now)
+	if _, err := database.Exec(query, params...); err != nil {
+		return nil, fmt.Errorf("failed to lock %s: %w", node.Path, err)
+	}
+
+	node.Lock = lock
+	return &LockItemResponse{Item: *node}, nil
+}
+
+// UnlockItemRequest represents the input for releasing a lock
+type UnlockItemRequest struct {
+	TableID string
+	ItemID  string
+	Owner   string
+	Force   bool // release someone else's lock (like an admin discarding a checkout)
+}
+
+// UnlockItemResponse represents the output for releasing a lock
+type UnlockItemResponse struct {
+	Item dbTypes.Node
+}
+
+// UnlockItem releases the lock on an item. Only the owner can release it unless Force is set.
+func UnlockItem(tableManager *tables.TableManager, database *db.DB, generator *tables.DeterministicGenerator, req UnlockItemRequest) (*UnlockItemResponse, error) {
+	tableName, err := resolveTableName(tableManager, database, req.TableID)
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := getItem(database, tableName, req.ItemID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	current, err := getLock(tableManager, database, generator, tableName, *node, now)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		return nil, &ItemError{Code: ErrCodeNotLocked, Message: fmt.Sprintf("%s is not locked", node.Path)}
+	}
+	if current.Owner != req.Owner && !req.Force {
+		return nil, lockedError(node, current)
+	}
+
+	query, params := tables.LockQuery(tableName, node.ID, nil, now)
+	if _, err := database.Exec(query, params...); err != nil {
+		return nil, fmt.Errorf("failed to unlock %s: %w", node.Path, err)
+	}
+
+	return &UnlockItemResponse{Item: *node}, nil
+}
+
+// getLock returns the active lock on a node: the stored one if the lock was acquired or released
+// through the API, otherwise the seeded one (primary table only)
+func getLock(tableManager *tables.TableManager, database *db.DB, generator *tables.DeterministicGenerator, tableName string, node dbTypes.Node, now time.Time) (*dbTypes.Lock, error) {
+	stored, err := tables.GetStoredLocks(database, tableName, "node_id = ?", node.ID)
+	if err != nil {
+		return nil, err
+	}
+	if lock, ok := stored[node.ID]; ok {
+		return tables.ActiveLock(lock, now), nil
+	}
+	if tableName == tableManager.GetPrimaryTableName() {
+		return tables.ActiveLock(generator.GenerateLock(node), now), nil
+	}
+	return nil, nil
+}
+
+// setLocks fills in the Lock of a folder's listed children
+func setLocks(tableManager *tables.TableManager, database *db.DB, generator *tables.DeterministicGenerator, tableName string, folderID string, children []dbTypes.Node) error {
+	stored, err := tables.GetStoredLocks(database, tableName, fmt.Sprintf("node_id IN (SELECT id FROM %s WHERE parent_id = ?)", tableName), folderID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	seeded := tableName == tableManager.GetPrimaryTableName()
+	for i, child := range children {
+		if lock, ok := stored[child.ID]; ok {
+			children[i].Lock = tables.ActiveLock(lock, now)
+		} else if seeded {
+			children[i].Lock = tables.ActiveLock(generator.GenerateLock(child), now)
+		}
+	}
+	return nil
+}
+
+// checkUnlocked fails with a locked *ItemError if any of the nodes is locked
+func checkUnlocked(tableManager *tables.TableManager, database *db.DB, generator *tables.DeterministicGenerator, tableName string, nodes ...*dbTypes.Node) error {
+	return checkUnlockedFor(tableManager, database, generator, tableName, "", nodes...)
+}
+
+// checkUnlockedFor is checkUnlocked for a write made by owner: locks owner holds don't block it
+// (empty owner = locks block everyone)
+func checkUnlockedFor(tableManager *tables.TableManager, database *db.DB, generator *tables.DeterministicGenerator, tableName string, owner string, nodes ...*dbTypes.Node) error {
+	now := time.Now().UTC()
+	for _, node := range nodes {
+		lock, err := getLock(tableManager, database, generator, tableName, *node, now)
+		if err != nil {
+			return err
+		}
+		if lock != nil && (owner == "" || lock.Owner != owner) {
+			return lockedError(node, lock)
+		}
+	}
+	return nil
+}
+
+// checkSubtreeUnlocked fails with a locked *ItemError if a stored descendant of a folder is locked.
+// Descendants that were never listed aren't checked.
+func checkSubtreeUnlocked(tableManager *tables.TableManager, database *db.DB, generator *tables.DeterministicGenerator, tableName string, folder dbTypes.Node) error {
+	if folder.Type != tables.NodeTypeFolder {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	stored, err := tables.GetStoredLocks(database, tableName, fmt.Sprintf("node_id IN (SELECT id FROM %s WHERE starts_with(path, ?))", tableName), folder.Path+"/")
+	if err != nil {
+		return err
+	}
+	for nodeID, lock := range stored {
+		if lock = tables.ActiveLock(lock, now); lock != nil {
+			node, err := getItem(database, tableName, nodeID)
+			if err != nil {
+				return err
+			}
+			return lockedError(node, lock)
+		}
+	}
+
+	// Seeded locks of the stored files without a lock row
+	if tableName != tableManager.GetPrimaryTableName() || tableManager.GetPrimaryConfig().Locks == nil {
+		return nil
+	}
+	files, err := tables.GetDescendants(database, tableName, folder.Path, tables.NodeTypeFile)
+	if err != nil {
+		return fmt.Errorf("failed to check locks in %s: %w", folder.Path, err)
+	}
+	for _, file := range files {
+		if _, ok := stored[file.ID]; ok {
+			continue
+		}
+		if lock := generator.GenerateLock(file); lock != nil {
+			return lockedError(&file, lock)
+		}
+	}
+	return nil
+}
+
+// lockedError reports a write blocked by a lock
+func lockedError(node *dbTypes.Node, lock *dbTypes.Lock) *ItemError {
+	return &ItemError{Code: ErrCodeLocked, Message: fmt.Sprintf("%s is locked by %s", node.Path, lock.Owner)}
+}
@@ -0,0 +1,189 @@
+package items
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Voltaic314/GhostFS/code/db"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+)
+
+// MoveItemRequest represents the input for moving and/or renaming an item
+type MoveItemRequest struct {
+	TableID  string
+	ItemID   string
+	ParentID string // destination folder (empty = stay in the same folder)
+	Name     string // new name (empty = keep the name)
+
+	// ReplaceID is an item in the destination folder that's deleted (the way DeleteItem would) in the
+	// same move, once everything else is checked: the item the moved one replaces. Empty = none.
+	ReplaceID string
+}
+
+// MoveItemResponse represents the output for moving an item
+type MoveItemResponse struct {
+	Item dbTypes.Node
+}
+
+// MoveItem moves an item to another folder and/or renames it. Folders take their subtree with them.
+// Failures a provider would report (conflicts, constraint violations, ...) are returned as *ItemError.
+func MoveItem(tableManager *tables.TableManager, database *db.DB, generator *tables.DeterministicGenerator, req MoveItemRequest) (*MoveItemResponse, error) {
+	tableName, err := resolveTableName(tableManager, database, req.TableID)
+	if err != nil {
+		return nil, err
+	}
+
+	node, dest, err := getSourceAndDestination(database, tableName, req.ItemID, req.ParentID)
+	if err != nil {
+		return nil, err
+	}
+	name := req.Name
+	if name == "" {
+		name = node.Name
+	}
+	if itemErr := validateName(name); itemErr != nil {
+		return nil, itemErr
+	}
+
+	source, err := tables.GetNode(database, tableName, node.ParentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get source folder: %w", err)
+	}
+	if err := checkUnlocked(tableManager, database, generator, tableName, node, source, dest); err != nil {
+		return nil, err
+	}
+	if err := checkSubtreeUnlocked(tableManager, database, generator, tableName, *node); err != nil {
+		return nil, err
+	}
+
+	// The old folder must stop generating the item, the new one must list it next to its siblings
+	if dest.ID != node.ParentID {
+		if _, err := materializeFolder(tableManager, database, generator, tableName, source); err != nil {
+			return nil, err
+		}
+	}
+	siblings, err := materializeFolder(tableManager, database, generator, tableName, dest)
+	if err != nil {
+		return nil, err
+	}
+	var replaced *dbTypes.Node
+	if req.ReplaceID != "" {
+		if replaced = findReplaced(siblings, req.ReplaceID, node.ID); replaced == nil {
+			return nil, &ItemError{Code: ErrCodeNotFound, Message: fmt.Sprintf("item %s to replace not found in the destination folder", req.ReplaceID)}
+		}
+		if err := checkUnlocked(tableManager, database, generator, tableName, replaced); err != nil {
+			return nil, err
+		}
+		if err := checkSubtreeUnlocked(tableManager, database, generator, tableName, *replaced); err != nil {
+			return nil, err
+		}
+	}
+	if itemErr := newSiblingNames(tableManager.GetNamePolicy(tableName), siblings, node.ID, req.ReplaceID).check(name); itemErr != nil {
+		return nil, itemErr
+	}
+
+	moved := *node
+	moved.ParentID, moved.Name, moved.Path, moved.Level = dest.ID, name, childPath(dest.Path, name), dest.Level+1
+	if itemErr := checkConstraints(tableManager, tableName, moved); itemErr != nil {
+		return nil, itemErr
+	}
+
+	if node.Type == tables.NodeTypeFolder {
+		if err := checkMovedSubtree(tableManager, database, tableName, *node, moved); err != nil {
+			return nil, err
+		}
+	}
+
+	if replaced != nil {
+		if _, err := DeleteItem(tableManager, database, generator, DeleteItemRequest{TableID: req.TableID, ItemID: replaced.ID}); err != nil {
+			return nil, err
+		}
+	}
+
+	queries := []string{fmt.Sprintf("UPDATE %s SET parent_id = ?, name = ?, path = ?, level = ? WHERE id = ?", tableName)}
+	params := [][]any{{moved.ParentID, moved.Name, moved.Path, moved.Level, moved.ID}}
+	if node.Type == tables.NodeTypeFolder {
+		// Stored descendants follow; ones generated later derive their paths from the moved folder
+		oldPrefix := node.Path + "/"
+		queries = append(queries, fmt.Sprintf("UPDATE %s SET path = ? || substr(path, ?), level = level + ? WHERE starts_with(path, ?)", tableName))
+		params = append(params, []any{moved.Path + "/", utf8.RuneCountInString(oldPrefix) + 1, moved.Level - node.Level, oldPrefix})
+	}
+
+	database.ForceFlushTable(tableName)
+	if err := database.WriteBatch(map[string][]string{tableName: queries}, map[string][][]any{tableName: params}); err != nil {
+		return nil, fmt.Errorf("failed to move %s: %w", node.Path, err)
+	}
+	if err := tables.RecordChanges(database, tableName, false, moved.ID); err != nil {
+		return nil, err
+	}
+
+	return &MoveItemResponse{Item: moved}, nil
+}
+
+// findReplaced returns the sibling with ID replaceID, unless it's the moved item itself
+func findReplaced(siblings []dbTypes.Node, replaceID string, movedID string) *dbTypes.Node {
+	for i := range siblings {
+		if siblings[i].ID == replaceID && replaceID != movedID {
+			return &siblings[i]
+		}
+	}
+	return nil
+}
+
+// getSourceAndDestination loads the item to move or copy and its destination folder (default: the
+// item's own folder). A folder can't go into itself or one of its descendants.
+func getSourceAndDestination(database *db.DB, tableName string, itemID string, parentID string) (*dbTypes.Node, *dbTypes.Node, error) {
+	node, err := getItem(database, tableName, itemID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if node.Level == 0 {
+		return nil, nil, &ItemError{Code: ErrCodeInvalidDest, Message: "the root folder can't be moved or copied"}
+	}
+
+	if parentID == "" {
+		parentID = node.ParentID
+	}
+	dest, err := getParentFolder(database, tableName, parentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if node.Type == tables.NodeTypeFolder && (dest.ID == node.ID || strings.HasPrefix(dest.Path, node.Path+"/")) {
+		return nil, nil, &ItemError{Code: ErrCodeInvalidDest, Message: fmt.Sprintf("%s can't be placed inside itself", node.Path)}
+	}
+	return node, dest, nil
+}
+
+// checkMovedSubtree applies the depth and path length limits to the stored descendants of a moved folder.
+// Violations are returned as *ItemError.
+func checkMovedSubtree(tableManager *tables.TableManager, database *db.DB, tableName string, node dbTypes.Node, moved dbTypes.Node) error {
+	profile := tableManager.GetConstraints(tableName)
+	if profile == nil || (profile.MaxDepth == 0 && profile.MaxPathLength == 0) {
+		return nil
+	}
+
+	query := fmt.Sprintf("SELECT COALESCE(MAX(level), 0), COALESCE(MAX(length(path)), 0) FROM %s WHERE starts_with(path, ?)", tableName)
+	rows, err := database.Query(tableName, query, node.Path+"/")
+	if err != nil {
+		return fmt.Errorf("failed to check subtree of %s: %w", node.Path, err)
+	}
+	defer rows.Close()
+
+	var deepest, longest int
+	if rows.Next() {
+		if err := rows.Scan(&deepest, &longest); err != nil {
+			return fmt.Errorf("failed to check subtree of %s: %w", node.Path, err)
+		}
+	}
+
+	if deepest > 0 && profile.MaxDepth > 0 && deepest+moved.Level-node.Level > profile.MaxDepth {
+		return &ItemError{Code: tables.ViolationMaxDepth, Message: fmt.Sprintf("moving %s would put its contents %d levels deep, the limit is %d", node.Path, deepest+moved.Level-node.Level, profile.MaxDepth)}
+	}
+	growth := utf8.RuneCountInString(moved.Path) - utf8.RuneCountInString(node.Path)
+	if longest > 0 && profile.MaxPathLength > 0 && longest+growth > profile.MaxPathLength {
+		return &ItemError{Code: tables.ViolationPathTooLong, Message: fmt.Sprintf("moving %s would make paths inside it longer than %d characters", node.Path, profile.MaxPathLength)}
+	}
+	return nil
+}
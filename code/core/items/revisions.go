@@ -0,0 +1,265 @@
+package items
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Voltaic314/GhostFS/code/db"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+)
+
+// ListRevisionsRequest represents the input for listing a file's versions
+type ListRevisionsRequest struct {
+	TableID string
+	ItemID  string
+}
+
+// ListRevisionsResponse represents the output for listing a file's versions
+type ListRevisionsResponse struct {
+	Item      dbTypes.Node
+	Revisions []dbTypes.Revision // oldest first, the last one is current
+}
+
+// ListRevisions lists the versions of a file. Files of generated tables may have a seeded history
+// (see the revisions config), it is stored once a revision is restored.
+func ListRevisions(tableManager *tables.TableManager, database *db.DB, generator *tables.DeterministicGenerator, req ListRevisionsRequest) (*ListRevisionsResponse, error) {
+	tableName, err := resolveTableName(tableManager, database, req.TableID)
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := getFile(database, tableName, req.ItemID)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions, _, err := getRevisions(tableManager, database, generator, tableName, *node)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListRevisionsResponse{Item: *node, Revisions: revisions}, nil
+}
+
+// OpenRevisionRequest represents the input for reading one version of a file
+type OpenRevisionRequest struct {
+	TableID    string
+	ItemID     string
+	RevisionID string
+}
+
+// OpenRevisionResponse represents the output for reading one version of a file
+type OpenRevisionResponse struct {
+	Item     dbTypes.Node
+	Revision dbTypes.Revision
+	Content  *tables.ContentReader
+}
+
+// OpenRevision returns a reader over the content of one version of a file
+func OpenRevision(tableManager *tables.TableManager, database *db.DB, generator *tables.DeterministicGenerator, req OpenRevisionRequest) (*OpenRevisionResponse, error) {
+	tableName, err := resolveTableName(tableManager, database, req.TableID)
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := getFile(database, tableName, req.ItemID)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions, _, err := getRevisions(tableManager, database, generator, tableName, *node)
+	if err != nil {
+		return nil, err
+	}
+	revision, err := findRevision(revisions, node, req.RevisionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OpenRevisionResponse{
+		Item:     *node,
+		Revision: revision,
+		Content:  tables.NewContentReader(revision.ContentSeed, revision.Size),
+	}, nil
+}
+
+// RestoreRevisionRequest represents the input for restoring a version of a file
+type RestoreRevisionRequest struct {
+	TableID    string
+	ItemID     string
+	RevisionID string
+	Author     string // recorded on the new revision (optional)
+}
+
+// RestoreRevisionResponse represents the output for restoring a version of a file
+type RestoreRevisionResponse struct {
+	Item     dbTypes.Node
+	Revision dbTypes.Revision // the new current revision
+}
+
+// RestoreRevision makes an older version of a file current again. Like providers do, the restore
+// is appended to the history as a new revision, the older ones are kept.
+func RestoreRevision(tableManager *tables.TableManager, database *db.DB, generator *tables.DeterministicGenerator, req RestoreRevisionRequest) (*RestoreRevisionResponse, error) {
+	tableName, err := resolveTableName(tableManager, database, req.TableID)
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := getFile(database, tableName, req.ItemID)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions, _, err := getRevisions(tableManager, database, generator, tableName, *node)
+	if err != nil {
+		return nil, err
+	}
+	restored, err := findRevision(revisions, node, req.RevisionID)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := addRevision(tableManager, database, generator, tableName, node, restored.Size, restored.ContentSeed, req.Author, "")
+	if err != nil {
+		return nil, err
+	}
+	return &RestoreRevisionResponse{Item: *node, Revision: current}, nil
+}
+
+// UpdateFileRequest represents the input for replacing the content of a file
+type UpdateFileRequest struct {
+	TableID     string
+	ItemID      string
+	Size        int64
+	ContentSeed int64  // content to take over from another file (0 = new content)
+	Author      string // recorded on the new revision (optional)
+	LockOwner   string // the file may be locked by this owner, like a checkout being saved (optional)
+}
+
+// UpdateFileResponse represents the output for replacing the content of a file
+type UpdateFileResponse struct {
+	Item     dbTypes.Node
+	Revision dbTypes.Revision // the new current revision
+}
+
+// UpdateFile replaces the content of a file, like an upload over an existing file. Only the size of
+// the new content is kept, it reads back as seeded content. The old content stays in the history.
+func UpdateFile(tableManager *tables.TableManager, database *db.DB, generator *tables.DeterministicGenerator, req UpdateFileRequest) (*UpdateFileResponse, error) {
+	tableName, err := resolveTableName(tableManager, database, req.TableID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Size < 0 {
+		return nil, &ItemError{Code: ErrCodeInvalidSize, Message: fmt.Sprintf("invalid size %d", req.Size)}
+	}
+
+	node, err := getFile(database, tableName, req.ItemID)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := addRevision(tableManager, database, generator, tableName, node, req.Size, req.ContentSeed, req.Author, req.LockOwner)
+	if err != nil {
+		return nil, err
+	}
+	return &UpdateFileResponse{Item: *node, Revision: current}, nil
+}
+
+// addRevision makes new content current for a file: it is appended to the file's history and the
+// node takes its size and content seed. A zero seed gets new content. Files locked by lockOwner can
+// be written (empty = only unlocked files).
+func addRevision(tableManager *tables.TableManager, database *db.DB, generator *tables.DeterministicGenerator, tableName string, node *dbTypes.Node, size int64, contentSeed int64, author string, lockOwner string) (dbTypes.Revision, error) {
+	if err := checkUnlockedFor(tableManager, database, generator, tableName, lockOwner, node); err != nil {
+		return dbTypes.Revision{}, err
+	}
+
+	// The file's new size and content live in the database, its folder must stop generating it
+	parent, err := tables.GetNode(database, tableName, node.ParentID)
+	if err != nil {
+		return dbTypes.Revision{}, fmt.Errorf("failed to get parent folder: %w", err)
+	}
+	if _, err := materializeFolder(tableManager, database, generator, tableName, parent); err != nil {
+		return dbTypes.Revision{}, err
+	}
+
+	revisions, stored, err := getRevisions(tableManager, database, generator, tableName, *node)
+	if err != nil {
+		return dbTypes.Revision{}, err
+	}
+
+	now := time.Now().UTC()
+	number := len(revisions) + 1
+	if contentSeed == 0 {
+		contentSeed = generator.RevisionContentSeed(node.ID, number)
+	}
+	current := dbTypes.Revision{
+		ID:          fmt.Sprintf("%d", number),
+		NodeID:      node.ID,
+		Number:      number,
+		Size:        size,
+		ContentSeed: contentSeed,
+		ModifiedAt:  now,
+		Author:      author,
+		Current:     true,
+	}
+
+	// The first change stores the generated history, so it survives next to the new revision
+	newRevisions := []dbTypes.Revision{current}
+	if !stored {
+		newRevisions = append(revisions, current)
+	}
+	queries, params := tables.RevisionInsertQueries(tableName, newRevisions)
+	if err := database.WriteBatch(map[string][]string{"revisions": queries}, map[string][][]any{"revisions": params}); err != nil {
+		return dbTypes.Revision{}, fmt.Errorf("failed to store revisions of %s: %w", node.Path, err)
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET size = ?, content_seed = ?, updated_at = ? WHERE id = ?", tableName)
+	if _, err := database.Exec(query, current.Size, current.ContentSeed, now, node.ID); err != nil {
+		return dbTypes.Revision{}, fmt.Errorf("failed to update %s: %w", node.Path, err)
+	}
+	if err := tables.RecordChanges(database, tableName, false, node.ID); err != nil {
+		return dbTypes.Revision{}, err
+	}
+
+	node.Size, node.ContentSeed, node.UpdatedAt = current.Size, current.ContentSeed, now
+	return current, nil
+}
+
+// getFile loads a node that must be a file
+func getFile(database *db.DB, tableName string, itemID string) (*dbTypes.Node, error) {
+	node, err := getItem(database, tableName, itemID)
+	if err != nil {
+		return nil, err
+	}
+	if node.Type != tables.NodeTypeFile {
+		return nil, &ItemError{Code: ErrCodeNotAFile, Message: fmt.Sprintf("%s is not a file", node.Path)}
+	}
+	return node, nil
+}
+
+// getRevisions returns a file's history and whether it is stored. Unchanged files of generated
+// tables get their seeded history, other files only have their current content.
+func getRevisions(tableManager *tables.TableManager, database *db.DB, generator *tables.DeterministicGenerator, tableName string, node dbTypes.Node) ([]dbTypes.Revision, bool, error) {
+	revisions, err := tables.GetStoredRevisions(database, tableName, node.ID)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(revisions) > 0 {
+		return revisions, true, nil
+	}
+	if tableManager.IsGeneratedTable(tableName) {
+		return generator.GenerateRevisions(node), false, nil
+	}
+	return generator.SingleRevision(node, ""), false, nil
+}
+
+// findRevision picks a revision by ID
+func findRevision(revisions []dbTypes.Revision, node *dbTypes.Node, revisionID string) (dbTypes.Revision, error) {
+	for _, revision := range revisions {
+		if revision.ID == revisionID {
+			return revision, nil
+		}
+	}
+	return dbTypes.Revision{}, &ItemError{Code: ErrCodeNotFound, Message: fmt.Sprintf("revision %s of %s not found", revisionID, node.Path)}
+}
@@ -0,0 +1,280 @@
+package items
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Voltaic314/GhostFS/code/db"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+)
+
+// GetSharingRequest represents the input for reading an item's sharing
+type GetSharingRequest struct {
+	TableID string
+	ItemID  string
+}
+
+// SharingResponse represents the output of the sharing operations
+type SharingResponse struct {
+	Item    dbTypes.Node    // with Sharing set if the item is shared
+	Sharing dbTypes.Sharing // the item's sharing after the operation
+}
+
+// GetSharing returns who an item is shared with and its shared links. Items of the primary table
+// may be seeded as shared (see the sharing config), the sharing is stored once it is changed.
+func GetSharing(tableManager *tables.TableManager, database *db.DB, generator *tables.DeterministicGenerator, req GetSharingRequest) (*SharingResponse, error) {
+	tableName, err := resolveTableName(tableManager, database, req.TableID)
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := getItem(database, tableName, req.ItemID)
+	if err != nil {
+		return nil, err
+	}
+
+	sharing, err := getSharing(tableManager, database, generator, tableName, *node)
+	if err != nil {
+		return nil, err
+	}
+	return sharingResponse(node, sharing), nil
+}
+
+// UpdateMembersRequest represents the input for sharing an item with people or unsharing it
+type UpdateMembersRequest struct {
+	TableID string
+	ItemID  string
+	Add     []dbTypes.ShareMember // new members, or new roles for existing ones
+	Remove  []string              // emails of members to remove
+}
+
+// UpdateMembers shares an item with people, changes their roles or removes them
+func UpdateMembers(tableManager *tables.TableManager, database *db.DB, generator *tables.DeterministicGenerator, req UpdateMembersRequest) (*SharingResponse, error) {
+	tableName, err := resolveTableName(tableManager, database, req.TableID)
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := getItem(database, tableName, req.ItemID)
+	if err != nil {
+		return nil, err
+	}
+	sharing, err := getSharing(tableManager, database, generator, tableName, *node)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, member := range req.Add {
+		email := strings.TrimSpace(member.Email)
+		if email == "" {
+			return nil, &ItemError{Code: ErrCodeInvalidShare, Message: "a member needs an email"}
+		}
+		if !tables.ValidRole(member.Role, false) {
+			return nil, &ItemError{Code: ErrCodeInvalidShare, Message: fmt.Sprintf("invalid role %q for %s", member.Role, email)}
+		}
+		if strings.EqualFold(email, sharing.Owner) {
+			return nil, &ItemError{Code: ErrCodeInvalidShare, Message: fmt.Sprintf("%s owns %s", email, node.Path)}
+		}
+		if i := findMember(sharing.Members, email); i >= 0 {
+			sharing.Members[i].Role = member.Role
+		} else {
+			sharing.Members = append(sharing.Members, dbTypes.ShareMember{Email: email, Role: member.Role})
+		}
+	}
+	for _, email := range req.Remove {
+		i := findMember(sharing.Members, email)
+		if i < 0 {
+			return nil, &ItemError{Code: ErrCodeNotFound, Message: fmt.Sprintf("%s is not shared with %s", node.Path, email)}
+		}
+		sharing.Members = append(sharing.Members[:i], sharing.Members[i+1:]...)
+	}
+
+	if err := storeSharing(database, tableName, node, sharing); err != nil {
+		return nil, err
+	}
+	return sharingResponse(node, sharing), nil
+}
+
+// CreateLinkRequest represents the input for creating a shared link
+type CreateLinkRequest struct {
+	TableID   string
+	ItemID    string
+	Scope     string     // "anyone" (default) or "organization"
+	Role      string     // "viewer" (default) or "editor"
+	ExpiresAt *time.Time // nil = never expires
+	Password  bool       // the link asks for a password
+}
+
+// CreateLinkResponse represents the output for creating a shared link
+type CreateLinkResponse struct {
+	SharingResponse
+	Link dbTypes.SharedLink
+}
+
+// CreateLink adds a shared link to an item
+func CreateLink(tableManager *tables.TableManager, database *db.DB, generator *tables.DeterministicGenerator, req CreateLinkRequest) (*CreateLinkResponse, error) {
+	tableName, err := resolveTableName(tableManager, database, req.TableID)
+	if err != nil {
+		return nil, err
+	}
+
+	scope, role := req.Scope, req.Role
+	if scope == "" {
+		scope = tables.LinkScopeAnyone
+	}
+	if role == "" {
+		role = tables.RoleViewer
+	}
+	if scope != tables.LinkScopeAnyone && scope != tables.LinkScopeOrganization {
+		return nil, &ItemError{Code: ErrCodeInvalidShare, Message: fmt.Sprintf("invalid link scope %q", scope)}
+	}
+	if !tables.ValidRole(role, true) {
+		return nil, &ItemError{Code: ErrCodeInvalidShare, Message: fmt.Sprintf("invalid link role %q", role)}
+	}
+	now := time.Now().UTC()
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(now) {
+		return nil, &ItemError{Code: ErrCodeInvalidShare, Message: "a link must expire in the future"}
+	}
+
+	node, err := getItem(database, tableName, req.ItemID)
+	if err != nil {
+		return nil, err
+	}
+	sharing, err := getSharing(tableManager, database, generator, tableName, *node)
+	if err != nil {
+		return nil, err
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed to generate link ID: %w", err)
+	}
+	link := dbTypes.SharedLink{
+		ID:                hex.EncodeToString(id),
+		Scope:             scope,
+		Role:              role,
+		PasswordProtected: req.Password,
+		CreatedAt:         now,
+		ExpiresAt:         req.ExpiresAt,
+	}
+	link.URL = tables.SharedLinkURL(link.ID)
+	sharing.Links = append(sharing.Links, link)
+
+	if err := storeSharing(database, tableName, node, sharing); err != nil {
+		return nil, err
+	}
+	return &CreateLinkResponse{SharingResponse: *sharingResponse(node, sharing), Link: link}, nil
+}
+
+// DeleteLinkRequest represents the input for removing a shared link
+type DeleteLinkRequest struct {
+	TableID string
+	ItemID  string
+	LinkID  string
+}
+
+// DeleteLink removes a shared link from an item
+func DeleteLink(tableManager *tables.TableManager, database *db.DB, generator *tables.DeterministicGenerator, req DeleteLinkRequest) (*SharingResponse, error) {
+	tableName, err := resolveTableName(tableManager, database, req.TableID)
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := getItem(database, tableName, req.ItemID)
+	if err != nil {
+		return nil, err
+	}
+	sharing, err := getSharing(tableManager, database, generator, tableName, *node)
+	if err != nil {
+		return nil, err
+	}
+
+	removed := false
+	for i, link := range sharing.Links {
+		if link.ID == req.LinkID {
+			sharing.Links = append(sharing.Links[:i], sharing.Links[i+1:]...)
+			removed = true
+			break
+		}
+	}
+	if !removed {
+		return nil, &ItemError{Code: ErrCodeNotFound, Message: fmt.Sprintf("link %s of %s not found", req.LinkID, node.Path)}
+	}
+
+	if err := storeSharing(database, tableName, node, sharing); err != nil {
+		return nil, err
+	}
+	return sharingResponse(node, sharing), nil
+}
+
+// getSharing returns a node's sharing: the stored one if it was changed through the API, otherwise
+// the seeded one (primary table only). Unshared items get an empty sharing.
+func getSharing(tableManager *tables.TableManager, database *db.DB, generator *tables.DeterministicGenerator, tableName string, node dbTypes.Node) (dbTypes.Sharing, error) {
+	stored, err := tables.GetStoredSharing(database, tableName, "node_id = ?", node.ID)
+	if err != nil {
+		return dbTypes.Sharing{}, err
+	}
+	if sharing, ok := stored[node.ID]; ok {
+		return *sharing, nil
+	}
+	if tableName == tableManager.GetPrimaryTableName() {
+		if sharing := generator.GenerateSharing(node); sharing != nil {
+			return *sharing, nil
+		}
+	}
+	return dbTypes.Sharing{}, nil
+}
+
+// setSharing fills in the Sharing of a folder's listed children
+func setSharing(tableManager *tables.TableManager, database *db.DB, generator *tables.DeterministicGenerator, tableName string, folderID string, children []dbTypes.Node) error {
+	stored, err := tables.GetStoredSharing(database, tableName, fmt.Sprintf("node_id IN (SELECT id FROM %s WHERE parent_id = ?)", tableName), folderID)
+	if err != nil {
+		return err
+	}
+
+	seeded := tableName == tableManager.GetPrimaryTableName()
+	for i, child := range children {
+		if sharing, ok := stored[child.ID]; ok {
+			if !sharing.IsEmpty() {
+				children[i].Sharing = sharing
+			}
+		} else if seeded {
+			children[i].Sharing = generator.GenerateSharing(child)
+		}
+	}
+	return nil
+}
+
+// storeSharing saves the whole sharing of a node, which replaces its seeded sharing from then on
+func storeSharing(database *db.DB, tableName string, node *dbTypes.Node, sharing dbTypes.Sharing) error {
+	query, params, err := tables.SharingQuery(tableName, node.ID, sharing)
+	if err != nil {
+		return err
+	}
+	if _, err := database.Exec(query, params...); err != nil {
+		return fmt.Errorf("failed to update sharing of %s: %w", node.Path, err)
+	}
+	return nil
+}
+
+// sharingResponse sets a node's Sharing for the response
+func sharingResponse(node *dbTypes.Node, sharing dbTypes.Sharing) *SharingResponse {
+	if !sharing.IsEmpty() {
+		node.Sharing = &sharing
+	}
+	return &SharingResponse{Item: *node, Sharing: sharing}
+}
+
+// findMember returns the index of a member by email, or -1
+func findMember(members []dbTypes.ShareMember, email string) int {
+	for i, member := range members {
+		if strings.EqualFold(member.Email, strings.TrimSpace(email)) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,61 @@
+package items
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Voltaic314/GhostFS/code/db"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+)
+
+// SetModTimeRequest represents the input for changing an item's modified time
+type SetModTimeRequest struct {
+	TableID string
+	ItemID  string
+	ModTime time.Time
+}
+
+// SetModTimeResponse represents the output for changing an item's modified time
+type SetModTimeResponse struct {
+	Item dbTypes.Node
+}
+
+// SetModTime sets the modified time of an item, like a sync tool preserving the source's times.
+// The content and history are unchanged. Locked items can't be changed.
+func SetModTime(tableManager *tables.TableManager, database *db.DB, generator *tables.DeterministicGenerator, req SetModTimeRequest) (*SetModTimeResponse, error) {
+	tableName, err := resolveTableName(tableManager, database, req.TableID)
+	if err != nil {
+		return nil, err
+	}
+	node, err := getItem(database, tableName, req.ItemID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkUnlockedFor(tableManager, database, generator, tableName, "", node); err != nil {
+		return nil, err
+	}
+
+	// The item's time lives in the database, its folder must stop generating it
+	if node.ParentID != "" {
+		parent, err := tables.GetNode(database, tableName, node.ParentID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get parent folder: %w", err)
+		}
+		if _, err := materializeFolder(tableManager, database, generator, tableName, parent); err != nil {
+			return nil, err
+		}
+	}
+
+	modTime := req.ModTime.UTC()
+	query := fmt.Sprintf("UPDATE %s SET updated_at = ? WHERE id = ?", tableName)
+	if _, err := database.Exec(query, modTime, node.ID); err != nil {
+		return nil, fmt.Errorf("failed to update %s: %w", node.Path, err)
+	}
+	if err := tables.RecordChanges(database, tableName, false, node.ID); err != nil {
+		return nil, err
+	}
+
+	node.UpdatedAt = modTime
+	return &SetModTimeResponse{Item: *node}, nil
+}
@@ -82,6 +82,11 @@ func (db *DB) Exec(query string, params ...any) (sql.Result, error) {
 }
 
 func (db *DB) flushWriteQueue(wq *WriteQueue, tableName string, force bool) {
+	// Readers force a flush before querying, so they must wait for a flush that is already
+	// running (e.g. the timer's) to commit instead of finding an empty queue
+	wq.flushMu.Lock()
+	defer wq.flushMu.Unlock()
+
 	batches := wq.Flush(force)
 	for _, b := range batches {
 		qs := make([]string, len(b.Ops))
@@ -159,6 +164,9 @@ func (db *DB) GetWriteQueue(table string) typesdb.WriteQueueInterface {
 // ForceFlushTable forces a flush of the write queue for a specific table
 func (db *DB) ForceFlushTable(tableName string) {
 	if wq, ok := db.wqMap[tableName]; ok {
+		wq.flushMu.Lock()
+		defer wq.flushMu.Unlock()
+
 		// Keep trying until we successfully flush or there's nothing to flush
 		for {
 			batches := wq.Flush(true)
@@ -208,9 +216,11 @@ func batchExecute(conn *sql.DB, tableQueries map[string][]string, tableParams ma
 	for table, queries := range tableQueries {
 		params := tableParams[table]
 		for i, query := range queries {
-			result, err := tx.Exec(query, params[i]...)
-			if err != nil {
-				return fmt.Errorf("failed to execute query for table %s: %w", table, err)
+			result, execErr := tx.Exec(query, params[i]...)
+			if execErr != nil {
+				// Assigned to err so the deferred rollback runs
+				err = fmt.Errorf("failed to execute query for table %s: %w", table, execErr)
+				return err
 			}
 
 			// Check if UPDATE/DELETE affected any rows
@@ -0,0 +1,315 @@
+package importer
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Voltaic314/GhostFS/code/db"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	"github.com/google/uuid"
+)
+
+// TableTypeImported is the table_id_lookup type of imported tables
+const TableTypeImported = "imported"
+
+var validTableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Options configures an import
+type Options struct {
+	Replace bool // drop the table first if it already exists
+}
+
+// Result summarizes an import
+type Result struct {
+	TableID   string `json:"table_id"`
+	TableName string `json:"table_name"`
+	Folders   int64  `json:"folders"`
+	Files     int64  `json:"files"`
+}
+
+// importNode is a node waiting to be written
+type importNode struct {
+	id       string
+	parentID string
+	name     string
+	path     string
+	nodeType string
+	size     int64
+	level    int
+	modTime  time.Time
+	ownTime  bool // the timestamp came from the listing rather than from the node's children
+	line     int  // manifest line that listed (or implied) the node, 0 for scanned directories
+}
+
+// Import writes the entries as a new node table. The table keeps names, sizes and timestamps
+// (no content) and is served straight from the database instead of the deterministic generator.
+func Import(database *db.DB, tableName string, entries []Entry, opts Options) (*Result, error) {
+	if !validTableName.MatchString(tableName) {
+		return nil, fmt.Errorf("invalid table name %q (letters, digits and underscores only)", tableName)
+	}
+
+	if tables.IsInternalTable(tableName) {
+		return nil, fmt.Errorf("table name %s is reserved", tableName)
+	}
+
+	lookupTable := &tables.TableLookup{}
+	if err := database.CreateTable(lookupTable.Name(), lookupTable.Schema()); err != nil {
+		return nil, fmt.Errorf("create table %s: %w", lookupTable.Name(), err)
+	}
+
+	exists, err := tableExists(database, tableName)
+	if err != nil {
+		return nil, err
+	}
+	if exists && !opts.Replace {
+		return nil, fmt.Errorf("table %s already exists", tableName)
+	}
+	if exists {
+		// Only a previous import may be replaced, not a generated table or one GhostFS doesn't know
+		tableType, err := registeredType(database, tableName)
+		if err != nil {
+			return nil, err
+		}
+		if tableType != TableTypeImported {
+			return nil, fmt.Errorf("table %s isn't an imported table and can't be replaced", tableName)
+		}
+	}
+
+	// The table is dropped and written in one transaction, so a failed import keeps the previous one
+	nodesTable := tables.NewNodesTable(tableName)
+	queries := []string{
+		"DROP TABLE IF EXISTS " + tableName,
+		fmt.Sprintf("CREATE TABLE %s (%s)", nodesTable.Name(), nodesTable.Schema()),
+	}
+	params := [][]any{nil, nil}
+
+	result := &Result{TableName: tableName}
+	query := fmt.Sprintf("INSERT INTO %s (id, parent_id, name, path, type, size, level, checked, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", tableName)
+	nodes, err := buildNodes(tableName, entries)
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range nodes {
+		queries = append(queries, query)
+		params = append(params, []any{node.id, node.parentID, node.name, node.path, node.nodeType, node.size, node.level, false, node.modTime, node.modTime})
+		if node.level == 0 {
+			continue
+		}
+		if node.nodeType == "folder" {
+			result.Folders++
+		} else {
+			result.Files++
+		}
+	}
+	if err := database.WriteBatch(map[string][]string{tableName: queries}, map[string][][]any{tableName: params}); err != nil {
+		return nil, fmt.Errorf("write table %s: %w", tableName, err)
+	}
+	if exists {
+		if err := clearNodeState(database, tableName); err != nil {
+			return nil, err
+		}
+	}
+
+	// Replace any previous registration of this table name
+	if _, err := database.Exec("DELETE FROM table_id_lookup WHERE table_name = ?", tableName); err != nil {
+		return nil, fmt.Errorf("clear table mapping for %s: %w", tableName, err)
+	}
+	result.TableID = tables.GenerateTableID()
+	if err := tables.SetTableName(database, result.TableID, tableName, TableTypeImported); err != nil {
+		return nil, fmt.Errorf("save table mapping %s->%s: %w", result.TableID, tableName, err)
+	}
+
+	fmt.Printf("📥 Imported %d folders and %d files into %s\n", result.Folders, result.Files, tableName)
+	return result, nil
+}
+
+// buildNodes normalizes entry paths, adds any missing parent folders and a root, and orders
+// nodes parents-first. Folders without a timestamp get the newest timestamp found below them.
+// Fails if a path is listed as a file and as a folder (or the parent of another entry).
+func buildNodes(tableName string, entries []Entry) ([]*importNode, error) {
+	byPath := make(map[string]*importNode)
+
+	root := &importNode{id: nodeID(tableName, "/"), name: "root", path: "/", nodeType: "folder"}
+	byPath["/"] = root
+
+	var ensureFolder func(p string, entry Entry) (*importNode, error)
+	ensureFolder = func(p string, entry Entry) (*importNode, error) {
+		if node, ok := byPath[p]; ok {
+			if node.nodeType != "folder" {
+				return nil, fileConflict(p, node, entry)
+			}
+			return node, nil
+		}
+		parent, err := ensureFolder(path.Dir(p), entry)
+		if err != nil {
+			return nil, err
+		}
+		node := &importNode{
+			id:       nodeID(tableName, p),
+			parentID: parent.id,
+			name:     path.Base(p),
+			path:     p,
+			nodeType: "folder",
+			level:    parent.level + 1,
+			line:     entry.Line,
+		}
+		byPath[p] = node
+		return node, nil
+	}
+
+	for _, entry := range entries {
+		p := normalizePath(entry.Path)
+		if p == "/" {
+			continue
+		}
+
+		if entry.Type == "folder" {
+			node, err := ensureFolder(p, entry)
+			if err != nil {
+				return nil, err
+			}
+			node.modTime = entry.ModTime
+			node.ownTime = !entry.ModTime.IsZero()
+			node.line = entry.Line
+			continue
+		}
+
+		// Later entries for the same path win, but a file can't replace a folder
+		if existing, ok := byPath[p]; ok && existing.nodeType == "folder" {
+			return nil, folderConflict(p, existing, entry)
+		}
+		parent, err := ensureFolder(path.Dir(p), entry)
+		if err != nil {
+			return nil, err
+		}
+		byPath[p] = &importNode{
+			id:       nodeID(tableName, p),
+			parentID: parent.id,
+			name:     path.Base(p),
+			path:     p,
+			nodeType: "file",
+			size:     entry.Size,
+			level:    parent.level + 1,
+			modTime:  entry.ModTime,
+			ownTime:  true,
+			line:     entry.Line,
+		}
+	}
+
+	nodes := make([]*importNode, 0, len(byPath))
+	for _, node := range byPath {
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].level != nodes[j].level {
+			return nodes[i].level < nodes[j].level
+		}
+		return nodes[i].path < nodes[j].path
+	})
+
+	// Fill in missing folder timestamps bottom-up (deepest nodes come last)
+	for i := len(nodes) - 1; i > 0; i-- {
+		node := nodes[i]
+		parent := byPath[path.Dir(node.path)]
+		if !parent.ownTime && node.modTime.After(parent.modTime) {
+			parent.modTime = node.modTime
+		}
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	for _, node := range nodes {
+		if node.modTime.IsZero() {
+			node.modTime = now
+		}
+	}
+
+	return nodes, nil
+}
+
+// fileConflict reports an entry that needs a folder where a file is listed
+func fileConflict(p string, file *importNode, entry Entry) error {
+	if p == normalizePath(entry.Path) {
+		return fmt.Errorf("%s%s is listed as a folder and as a file%s", lineOf(entry.Line), p, atLine(file.line))
+	}
+	return fmt.Errorf("%s%s is listed as a file%s but %s needs it to be a folder", lineOf(entry.Line), p, atLine(file.line), normalizePath(entry.Path))
+}
+
+// folderConflict reports a file entry at the path of a folder
+func folderConflict(p string, folder *importNode, entry Entry) error {
+	return fmt.Errorf("%s%s is listed as a file and as a folder%s", lineOf(entry.Line), p, atLine(folder.line))
+}
+
+// lineOf prefixes errors about a manifest entry with its line
+func lineOf(line int) string {
+	if line == 0 {
+		return ""
+	}
+	return fmt.Sprintf("manifest line %d: ", line)
+}
+
+// atLine points at the line of an earlier entry
+func atLine(line int) string {
+	if line == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (line %d)", line)
+}
+
+// normalizePath turns manifest paths ("./a/b", "a/b/", "/a/b") into "/a/b"
+func normalizePath(p string) string {
+	p = strings.ReplaceAll(p, "\\", "/")
+	p = strings.TrimPrefix(p, "./")
+	return path.Clean("/" + p)
+}
+
+// nodeID derives a stable node ID from the table name and path, so re-imports keep their IDs
+func nodeID(tableName, p string) string {
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte("ghostfs:"+tableName+":"+p)).String()
+}
+
+// tableExists checks whether a table with this name exists in the database
+func tableExists(database *db.DB, tableName string) (bool, error) {
+	var count int
+	query := "SELECT COUNT(*) FROM information_schema.tables WHERE table_name = ?"
+	if err := database.QueryRow(query, tableName).Scan(&count); err != nil {
+		return false, fmt.Errorf("check table %s: %w", tableName, err)
+	}
+	return count > 0, nil
+}
+
+// clearNodeState removes what the trash, changes and node state tables keep about a replaced
+// table. Re-imports keep their node IDs, so it would otherwise attach to the new nodes.
+func clearNodeState(database *db.DB, tableName string) error {
+	related := append([]string{(&tables.TrashTable{}).Name(), (&tables.ChangesTable{}).Name()}, tables.NodeStateTables...)
+	for _, name := range related {
+		exists, err := tableExists(database, name)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			continue
+		}
+		if _, err := database.Exec(fmt.Sprintf("DELETE FROM %s WHERE table_name = ?", name), tableName); err != nil {
+			return fmt.Errorf("clear %s of %s: %w", name, tableName, err)
+		}
+	}
+	return nil
+}
+
+// registeredType returns the table_id_lookup type of a table, empty if it isn't registered
+func registeredType(database *db.DB, tableName string) (string, error) {
+	var tableType string
+	err := database.QueryRow("SELECT type FROM table_id_lookup WHERE table_name = ? LIMIT 1", tableName).Scan(&tableType)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("check table %s: %w", tableName, err)
+	}
+	return tableType, nil
+}
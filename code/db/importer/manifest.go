@@ -0,0 +1,270 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Manifest formats accepted by ReadManifest
+const (
+	FormatFind  = "find"  // find . -printf '%y\t%s\t%T@\t%P\n'
+	FormatCSV   = "csv"   // path,size,mtime[,type] with a header row
+	FormatJSONL = "jsonl" // {"path": ..., "size": ..., "mtime": ..., "type": ...} per line
+)
+
+// Entry is a single file or folder from a scanned directory or manifest
+type Entry struct {
+	Path    string    `json:"path"`           // slash separated, relative to the imported root
+	Type    string    `json:"type,omitempty"` // "file" or "folder" (defaults to "file")
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"-"`
+	Line    int       `json:"-"` // line of the manifest the entry was read from (0 = scanned)
+}
+
+// ScanDirectory walks a local directory and returns its shape (names, sizes and modification times).
+// Symlinks aren't followed or imported.
+func ScanDirectory(root string) ([]Entry, error) {
+	var entries []Entry
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		if !d.IsDir() && !d.Type().IsRegular() {
+			return nil // symlinks, sockets, devices... like the find manifests, only files and folders
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		entry := Entry{
+			Path:    filepath.ToSlash(rel),
+			Type:    "file",
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		}
+		if d.IsDir() {
+			entry.Type = "folder"
+			entry.Size = 0
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scan %s: %w", root, err)
+	}
+	return entries, nil
+}
+
+// ReadManifestFile reads a manifest file, guessing the format from its extension if format is empty
+func ReadManifestFile(manifestPath string, format string) ([]Entry, error) {
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(manifestPath)) {
+		case ".csv":
+			format = FormatCSV
+		case ".jsonl", ".ndjson":
+			format = FormatJSONL
+		default:
+			format = FormatFind
+		}
+	}
+
+	f, err := os.Open(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("open manifest: %w", err)
+	}
+	defer f.Close()
+
+	return ReadManifest(f, format)
+}
+
+// ReadManifest parses a file listing in one of the supported formats
+func ReadManifest(r io.Reader, format string) ([]Entry, error) {
+	switch format {
+	case FormatFind:
+		return readFindManifest(r)
+	case FormatCSV:
+		return readCSVManifest(r)
+	case FormatJSONL:
+		return readJSONLManifest(r)
+	default:
+		return nil, fmt.Errorf("unknown manifest format: %q", format)
+	}
+}
+
+// readFindManifest parses `find . -printf '%y\t%s\t%T@\t%P\n'` output
+func readFindManifest(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := strings.SplitN(line, "\t", 4)
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("find manifest line %d: expected type, size, mtime and path separated by tabs", lineNum)
+		}
+
+		size, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("find manifest line %d: invalid size: %w", lineNum, err)
+		}
+		if size < 0 {
+			return nil, fmt.Errorf("find manifest line %d: invalid size %d", lineNum, size)
+		}
+		modTime, err := parseTime(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("find manifest line %d: %w", lineNum, err)
+		}
+
+		entryType := "file"
+		switch fields[0] {
+		case "d":
+			entryType = "folder"
+			size = 0
+		case "f":
+		default:
+			continue // symlinks, sockets, devices... only files and folders are imported
+		}
+
+		entries = append(entries, Entry{Path: fields[3], Type: entryType, Size: size, ModTime: modTime, Line: lineNum})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read find manifest: %w", err)
+	}
+	return entries, nil
+}
+
+// readCSVManifest parses a CSV with a header row naming at least the path column
+func readCSVManifest(r io.Reader) ([]Entry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read csv header: %w", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["path"]; !ok {
+		return nil, fmt.Errorf("csv manifest needs a path column")
+	}
+
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var entries []Entry
+	for lineNum := 2; ; lineNum++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("csv manifest line %d: %w", lineNum, err)
+		}
+
+		entry := Entry{Path: record[columns["path"]], Type: normalizeType(field(record, "type")), Line: lineNum}
+		if size := field(record, "size"); size != "" {
+			if entry.Size, err = strconv.ParseInt(size, 10, 64); err != nil {
+				return nil, fmt.Errorf("csv manifest line %d: invalid size: %w", lineNum, err)
+			}
+			if entry.Size < 0 {
+				return nil, fmt.Errorf("csv manifest line %d: invalid size %d", lineNum, entry.Size)
+			}
+		}
+		if mtime := field(record, "mtime"); mtime != "" {
+			if entry.ModTime, err = parseTime(mtime); err != nil {
+				return nil, fmt.Errorf("csv manifest line %d: %w", lineNum, err)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// readJSONLManifest parses one JSON object per line
+func readJSONLManifest(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var raw struct {
+			Path  string          `json:"path"`
+			Type  string          `json:"type"`
+			Size  int64           `json:"size"`
+			MTime json.RawMessage `json:"mtime"`
+		}
+		if err := json.Unmarshal([]byte(line), &raw); err != nil {
+			return nil, fmt.Errorf("jsonl manifest line %d: %w", lineNum, err)
+		}
+
+		if raw.Size < 0 {
+			return nil, fmt.Errorf("jsonl manifest line %d: invalid size %d", lineNum, raw.Size)
+		}
+		entry := Entry{Path: raw.Path, Type: normalizeType(raw.Type), Size: raw.Size, Line: lineNum}
+		if len(raw.MTime) > 0 && string(raw.MTime) != "null" {
+			var err error
+			if entry.ModTime, err = parseTime(strings.Trim(string(raw.MTime), `"`)); err != nil {
+				return nil, fmt.Errorf("jsonl manifest line %d: %w", lineNum, err)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read jsonl manifest: %w", err)
+	}
+	return entries, nil
+}
+
+// normalizeType maps the type spellings found in listings to "file" / "folder"
+func normalizeType(t string) string {
+	switch strings.ToLower(t) {
+	case "folder", "dir", "directory", "d":
+		return "folder"
+	default:
+		return "file"
+	}
+}
+
+// parseTime accepts RFC3339 timestamps or (fractional) Unix epoch seconds
+func parseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t.UTC(), nil
+	}
+	epoch, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid mtime %q (expected RFC3339 or Unix seconds)", value)
+	}
+	sec := int64(epoch)
+	return time.Unix(sec, int64((epoch-float64(sec))*1e9)).UTC(), nil
+}
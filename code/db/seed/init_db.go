@@ -123,18 +123,21 @@ func createRootNodes(db *db.DB, tableManager *tables.TableManager, masterSeed in
 	// Generate root's child seed
 	rootChildSeed := generateDeterministicSeed(masterSeed, rootID)
 
+	// Root timestamps bound every seeded timestamp below it
+	createdAt, updatedAt := tables.NewTimestampGenerator(tableManager.GetPrimaryConfig().Timestamps).RootTimes()
+
 	// Insert root node into primary table
 	primaryTableName := tableManager.GetPrimaryTableName()
-	primaryQuery := fmt.Sprintf("INSERT INTO %s (id, parent_id, name, path, type, size, level, checked, secondary_existence_map, child_seed) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", primaryTableName)
-	if err := db.Write(primaryQuery, rootID, "", "root", rootPath, "folder", 0, 0, false, existenceMapJSON, rootChildSeed); err != nil {
+	primaryQuery := fmt.Sprintf("INSERT INTO %s (id, parent_id, name, path, type, size, level, checked, secondary_existence_map, child_seed, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", primaryTableName)
+	if err := db.Write(primaryQuery, rootID, "", "root", rootPath, "folder", 0, 0, false, existenceMapJSON, rootChildSeed, createdAt, updatedAt); err != nil {
 		return fmt.Errorf("insert root into primary table: %w", err)
 	}
 	fmt.Printf("🌱 Created root in primary table: %s\n", primaryTableName)
 
 	// Insert root node into all secondary tables
 	for _, tableName := range secondaryTableNames {
-		secondaryQuery := fmt.Sprintf("INSERT INTO %s (id, parent_id, name, path, type, size, level, checked, child_seed) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)", tableName)
-		if err := db.Write(secondaryQuery, rootID, "", "root", rootPath, "folder", 0, 0, false, rootChildSeed); err != nil {
+		secondaryQuery := fmt.Sprintf("INSERT INTO %s (id, parent_id, name, path, type, size, level, checked, child_seed, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", tableName)
+		if err := db.Write(secondaryQuery, rootID, "", "root", rootPath, "folder", 0, 0, false, rootChildSeed, createdAt, updatedAt); err != nil {
 			return fmt.Errorf("insert root into secondary table %s: %w", tableName, err)
 		}
 		fmt.Printf("🌱 Created root in secondary table: %s\n", tableName)
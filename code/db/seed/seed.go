@@ -26,6 +26,8 @@ type ParentNodeWithExistence struct {
 	ID           string
 	Path         string
 	ExistenceMap tables.SecondaryExistenceMap
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
 }
 
 // Node struct removed - using direct DB inserts instead of in-memory accumulation
@@ -190,6 +192,46 @@ func createTables(db *db.DB, tableManager *tables.TableManager) error {
 	}
 	fmt.Printf("📜 Created table: %s\n", seedInfoTable.Name())
 
+	// Create revisions table (file version histories that changed)
+	revisionsTable := &tables.RevisionsTable{}
+	ddl = fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", revisionsTable.Name(), revisionsTable.Schema())
+	if err := db.Write(ddl); err != nil {
+		return fmt.Errorf("creating table %q: %w", revisionsTable.Name(), err)
+	}
+	fmt.Printf("📜 Created table: %s\n", revisionsTable.Name())
+
+	// Create trash table (soft-deleted nodes of all tables)
+	trashTable := &tables.TrashTable{}
+	ddl = fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", trashTable.Name(), trashTable.Schema())
+	if err := db.Write(ddl); err != nil {
+		return fmt.Errorf("creating table %q: %w", trashTable.Name(), err)
+	}
+	fmt.Printf("📜 Created table: %s\n", trashTable.Name())
+
+	// Create locks table (locks acquired or released through the API)
+	locksTable := &tables.LocksTable{}
+	ddl = fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", locksTable.Name(), locksTable.Schema())
+	if err := db.Write(ddl); err != nil {
+		return fmt.Errorf("creating table %q: %w", locksTable.Name(), err)
+	}
+	fmt.Printf("📜 Created table: %s\n", locksTable.Name())
+
+	// Create sharing table (sharing changed through the API)
+	sharingTable := &tables.SharingTable{}
+	ddl = fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", sharingTable.Name(), sharingTable.Schema())
+	if err := db.Write(ddl); err != nil {
+		return fmt.Errorf("creating table %q: %w", sharingTable.Name(), err)
+	}
+	fmt.Printf("📜 Created table: %s\n", sharingTable.Name())
+
+	// Create changes table (the change journal of items changed through the API)
+	changesTable := &tables.ChangesTable{}
+	ddl = fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", changesTable.Name(), changesTable.Schema())
+	if err := db.Write(ddl); err != nil {
+		return fmt.Errorf("creating table %q: %w", changesTable.Name(), err)
+	}
+	fmt.Printf("📜 Created table: %s\n", changesTable.Name())
+
 	// Create nodes tables
 	tableNames := tableManager.GetTableNames()
 	for _, tableName := range tableNames {
@@ -238,7 +280,7 @@ func generateTreeLevelByLevel(rng *rand.Rand, db *db.DB, tableManager *tables.Ta
 		fmt.Printf("📁 Processing level %d...\n", currentLevel)
 
 		// Query database for parent nodes at level (currentLevel-1) to generate children at currentLevel
-		nodeCount, err := generateChildrenForLevelFromDB(rng, db, tableManager, currentLevel)
+		nodeCount, err := generateChildrenForLevelFromDB(rng, db, tableManager, seed, currentLevel)
 		if err != nil {
 			return 0, 0, fmt.Errorf("generate children for level %d: %w", currentLevel, err)
 		}
@@ -288,20 +330,23 @@ func insertRootNode(db *db.DB, tableManager *tables.TableManager, rootID string)
 		return fmt.Errorf("convert root existence map to JSON: %w", err)
 	}
 
+	// Root timestamps bound every seeded timestamp below it
+	createdAt, updatedAt := tables.NewTimestampGenerator(tableManager.GetPrimaryConfig().Timestamps).RootTimes()
+
 	// Insert root node into primary table
-	primaryQuery := fmt.Sprintf("INSERT INTO %s (id, parent_id, name, path, type, size, level, checked, secondary_existence_map) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)", tableManager.GetPrimaryTableName())
-	db.QueueWrite(tableManager.GetPrimaryTableName(), primaryQuery, rootID, "", "root", rootPath, "folder", 0, 0, false, existenceMapJSON)
+	primaryQuery := fmt.Sprintf("INSERT INTO %s (id, parent_id, name, path, type, size, level, checked, secondary_existence_map, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", tableManager.GetPrimaryTableName())
+	db.QueueWrite(tableManager.GetPrimaryTableName(), primaryQuery, rootID, "", "root", rootPath, "folder", 0, 0, false, existenceMapJSON, createdAt, updatedAt)
 
 	// Insert root node into all secondary tables
 	for _, tableName := range secondaryTableNames {
-		secondaryQuery := fmt.Sprintf("INSERT INTO %s (id, parent_id, name, path, type, size, level, checked) VALUES (?, ?, ?, ?, ?, ?, ?, ?)", tableName)
-		db.QueueWrite(tableName, secondaryQuery, rootID, "", "root", rootPath, "folder", 0, 0, false)
+		secondaryQuery := fmt.Sprintf("INSERT INTO %s (id, parent_id, name, path, type, size, level, checked, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", tableName)
+		db.QueueWrite(tableName, secondaryQuery, rootID, "", "root", rootPath, "folder", 0, 0, false, createdAt, updatedAt)
 	}
 
 	return nil
 }
 
-func generateChildrenForLevelFromDB(rng *rand.Rand, db *db.DB, tableManager *tables.TableManager, targetLevel int) (int64, error) {
+func generateChildrenForLevelFromDB(rng *rand.Rand, db *db.DB, tableManager *tables.TableManager, masterSeed int64, targetLevel int) (int64, error) {
 	var totalNodeCount int64
 	const batchSize = 1000       // Process 1000 parents at a time
 	var lastSeenRowID int64 = -1 // Start with -1 so we include rowid 0
@@ -315,7 +360,7 @@ func generateChildrenForLevelFromDB(rng *rand.Rand, db *db.DB, tableManager *tab
 	for {
 		// Query ONLY the primary table for folder nodes at the parent level
 		// Use rowid-based pagination for O(1) performance (rowid is monotonic)
-		query := `SELECT rowid, id, path, secondary_existence_map FROM ` + tableManager.GetPrimaryTableName() + `
+		query := `SELECT rowid, id, path, secondary_existence_map, created_at, updated_at FROM ` + tableManager.GetPrimaryTableName() + `
 			WHERE level = ? AND type = 'folder' AND rowid > ? 
 			ORDER BY rowid LIMIT ?`
 
@@ -330,7 +375,8 @@ func generateChildrenForLevelFromDB(rng *rand.Rand, db *db.DB, tableManager *tab
 		for rows.Next() {
 			var rowID int64
 			var ID, parentPath, existenceMapJSON string
-			if err := rows.Scan(&rowID, &ID, &parentPath, &existenceMapJSON); err != nil {
+			var createdAt, updatedAt time.Time
+			if err := rows.Scan(&rowID, &ID, &parentPath, &existenceMapJSON, &createdAt, &updatedAt); err != nil {
 				rows.Close()
 				return 0, fmt.Errorf("scan parent row: %w", err)
 			}
@@ -346,6 +392,8 @@ func generateChildrenForLevelFromDB(rng *rand.Rand, db *db.DB, tableManager *tab
 				ID:           ID,
 				Path:         parentPath,
 				ExistenceMap: existenceMap,
+				CreatedAt:    createdAt,
+				UpdatedAt:    updatedAt,
 			})
 			if rowID > maxRowID {
 				maxRowID = rowID
@@ -363,7 +411,7 @@ func generateChildrenForLevelFromDB(rng *rand.Rand, db *db.DB, tableManager *tab
 		lastSeenRowID = maxRowID
 
 		// Generate children for this batch of parents
-		nodeCount, err := generateChildrenForBatch(rng, db, tableManager, parents, targetLevel)
+		nodeCount, err := generateChildrenForBatch(rng, db, tableManager, masterSeed, parents, targetLevel)
 		if err != nil {
 			return 0, fmt.Errorf("generate children for batch: %w", err)
 		}
@@ -382,19 +430,43 @@ func generateChildrenForLevelFromDB(rng *rand.Rand, db *db.DB, tableManager *tab
 	return totalNodeCount, nil
 }
 
-func generateChildrenForBatch(rng *rand.Rand, db *db.DB, tableManager *tables.TableManager, parents []ParentNodeWithExistence, targetLevel int) (int64, error) {
+func generateChildrenForBatch(rng *rand.Rand, db *db.DB, tableManager *tables.TableManager, masterSeed int64, parents []ParentNodeWithExistence, targetLevel int) (int64, error) {
 	var nodeCount int64
 	primaryConfig := tableManager.GetPrimaryConfig()
 	secondaryConfigs := tableManager.GetSecondaryTableConfigs()
 	secondaryTableNames := tableManager.GetSecondaryTableNames()
+	names, err := tables.NewNameGenerator(primaryConfig.NamingProfiles)
+	if err != nil {
+		return 0, fmt.Errorf("create name generator: %w", err)
+	}
+	sizes, err := tables.NewSizeSampler(primaryConfig.FileSizes)
+	if err != nil {
+		return 0, fmt.Errorf("create size sampler: %w", err)
+	}
+	timestamps := tables.NewTimestampGenerator(primaryConfig.Timestamps)
+	links := tables.NewLinkGenerator(primaryConfig.Links)
+	duplicates := tables.NewDuplicateGenerator(primaryConfig.Duplicates, masterSeed, sizes)
 
 	// Process each parent in this batch
 	for _, parent := range parents {
+		usedNames := make(map[string]struct{})
+		siblings := make([]typesdb.Node, 0)
+
+		// Per-subtree overrides may change the fan-out for this parent
+		parentConfig := tableManager.GetGenerationConfigForFolder(tableManager.GetPrimaryTableName(), parent.Path, targetLevel-1)
+		if leafProb := tableManager.GetLeafProb(targetLevel - 1); leafProb > 0 && rng.Float64() < leafProb {
+			parentConfig.MinChildFolders, parentConfig.MaxChildFolders = 0, 0
+		}
+
 		// Generate random number of folders for this parent
-		numFolders := primaryConfig.MinChildFolders + rng.Intn(primaryConfig.MaxChildFolders-primaryConfig.MinChildFolders+1)
+		numFolders := parentConfig.MinChildFolders + rng.Intn(parentConfig.MaxChildFolders-parentConfig.MinChildFolders+1)
 		for i := 0; i < numFolders; i++ {
 			folderID := generateUUID()
-			folderName := fmt.Sprintf("folder_%d", i)
+			folderName := names.FolderName(rng, i)
+			if violating, ok := primaryConfig.Violations.Violate(rng, folderName, parent.Path); ok {
+				folderName = violating
+			}
+			folderName = tables.UniqueName(folderName, usedNames)
 			folderPath := buildPath(parent.Path, folderName)
 
 			// Determine which secondary tables this folder should exist in
@@ -410,24 +482,33 @@ func generateChildrenForBatch(rng *rand.Rand, db *db.DB, tableManager *tables.Ta
 			}
 
 			// Insert folder into primary table
-			primaryQuery := fmt.Sprintf("INSERT INTO %s (id, parent_id, name, path, type, size, level, checked, secondary_existence_map) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)", tableManager.GetPrimaryTableName())
-			db.QueueWrite(tableManager.GetPrimaryTableName(), primaryQuery, folderID, parent.ID, folderName, folderPath, "folder", 0, targetLevel, false, existenceMapJSON)
+			createdAt, updatedAt := timestamps.ChildTimes(rng, parent.CreatedAt, parent.UpdatedAt, false)
+			primaryQuery := fmt.Sprintf("INSERT INTO %s (id, parent_id, name, path, type, size, level, checked, secondary_existence_map, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", tableManager.GetPrimaryTableName())
+			db.QueueWrite(tableManager.GetPrimaryTableName(), primaryQuery, folderID, parent.ID, folderName, folderPath, "folder", 0, targetLevel, false, existenceMapJSON, createdAt, updatedAt)
+			siblings = append(siblings, typesdb.Node{ID: folderID, Name: folderName, Type: "folder"})
 			nodeCount++
 
 			// Insert into secondary tables where it should exist
 			for tableName, exists := range childExistenceMap {
 				if exists {
-					secondaryQuery := fmt.Sprintf("INSERT INTO %s (id, parent_id, name, path, type, size, level, checked) VALUES (?, ?, ?, ?, ?, ?, ?, ?)", tableName)
-					db.QueueWrite(tableName, secondaryQuery, folderID, parent.ID, folderName, folderPath, "folder", 0, targetLevel, false)
+					secondaryQuery := fmt.Sprintf("INSERT INTO %s (id, parent_id, name, path, type, size, level, checked, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", tableName)
+					db.QueueWrite(tableName, secondaryQuery, folderID, parent.ID, folderName, folderPath, "folder", 0, targetLevel, false, createdAt, updatedAt)
 				}
 			}
 		}
 
 		// Generate random number of files for this parent
-		numFiles := primaryConfig.MinChildFiles + rng.Intn(primaryConfig.MaxChildFiles-primaryConfig.MinChildFiles+1)
+		numFiles := parentConfig.MinChildFiles + rng.Intn(parentConfig.MaxChildFiles-parentConfig.MinChildFiles+1)
 		for i := 0; i < numFiles; i++ {
 			fileID := generateUUID()
-			fileName := fmt.Sprintf("file_%d.txt", i)
+			fileName := names.FileName(rng, i)
+			if violating, ok := primaryConfig.Violations.Violate(rng, fileName, parent.Path); ok {
+				fileName = violating
+			}
+			fileName = tables.UniqueName(fileName, usedNames)
+			if variant, ok := primaryConfig.NameCollisions.CollidingName(rng, siblings, usedNames); ok {
+				fileName = variant
+			}
 			filePath := buildPath(parent.Path, fileName)
 
 			// Determine which secondary tables this file should exist in
@@ -443,16 +524,36 @@ func generateChildrenForBatch(rng *rand.Rand, db *db.DB, tableManager *tables.Ta
 			}
 
 			// Insert file into primary table
-			primaryQuery := fmt.Sprintf("INSERT INTO %s (id, parent_id, name, path, type, size, level, checked, secondary_existence_map) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)", tableManager.GetPrimaryTableName())
-			fileSize := int64(100 + rng.Intn(900)) // Random size 100-999 bytes
-			db.QueueWrite(tableManager.GetPrimaryTableName(), primaryQuery, fileID, parent.ID, fileName, filePath, "file", fileSize, targetLevel, false, existenceMapJSON)
+			primaryQuery := fmt.Sprintf("INSERT INTO %s (id, parent_id, name, path, type, target, content_seed, size, level, checked, secondary_existence_map, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", tableManager.GetPrimaryTableName())
+			fileSize := sizes.Sample(rng, fileName)
+			createdAt, updatedAt := timestamps.ChildTimes(rng, parent.CreatedAt, parent.UpdatedAt, true)
+			var target, contentSeed any
+			nodeType, seedValue := "file", tables.DeriveContentSeed(masterSeed, fileID)
+			if linkType, linkTarget, linkSize, ok := links.Link(rng, targetLevel-1, siblings); ok {
+				nodeType, target, fileSize = linkType, linkTarget, linkSize
+				seedValue = 0
+				if nodeType == tables.NodeTypeHardlink {
+					for _, sibling := range siblings {
+						if sibling.ID == linkTarget {
+							seedValue = sibling.ContentSeed
+						}
+					}
+				}
+			} else if dupSeed, dupSize, ok := duplicates.Duplicate(rng, siblings); ok {
+				seedValue, fileSize = dupSeed, dupSize
+			}
+			if seedValue != 0 {
+				contentSeed = seedValue
+			}
+			db.QueueWrite(tableManager.GetPrimaryTableName(), primaryQuery, fileID, parent.ID, fileName, filePath, nodeType, target, contentSeed, fileSize, targetLevel, false, existenceMapJSON, createdAt, updatedAt)
+			siblings = append(siblings, typesdb.Node{ID: fileID, Name: fileName, Type: nodeType, Size: fileSize, ContentSeed: seedValue})
 			nodeCount++
 
 			// Insert into secondary tables where it should exist
 			for tableName, exists := range childExistenceMap {
 				if exists {
-					secondaryQuery := fmt.Sprintf("INSERT INTO %s (id, parent_id, name, path, type, size, level, checked) VALUES (?, ?, ?, ?, ?, ?, ?, ?)", tableName)
-					db.QueueWrite(tableName, secondaryQuery, fileID, parent.ID, fileName, filePath, "file", fileSize, targetLevel, false)
+					secondaryQuery := fmt.Sprintf("INSERT INTO %s (id, parent_id, name, path, type, target, content_seed, size, level, checked, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", tableName)
+					db.QueueWrite(tableName, secondaryQuery, fileID, parent.ID, fileName, filePath, nodeType, target, contentSeed, fileSize, targetLevel, false, createdAt, updatedAt)
 				}
 			}
 		}
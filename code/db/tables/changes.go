@@ -0,0 +1,102 @@
+package tables
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Voltaic314/GhostFS/code/db"
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+)
+
+// ChangesTable is the change journal of all tables: every item changed through the API, in order,
+// so clients can sync from a position like with Drive's or Graph's change feeds. Generated items
+// aren't changes, the journal starts empty.
+type ChangesTable struct{}
+
+func (t *ChangesTable) Name() string {
+	return "changes"
+}
+
+func (t *ChangesTable) Schema() string {
+	return `
+		table_name VARCHAR NOT NULL,
+		seq BIGINT NOT NULL,
+		node_id VARCHAR NOT NULL,
+		removed BOOLEAN NOT NULL DEFAULT FALSE,
+		changed_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (table_name, seq)
+	`
+}
+
+// Init creates the changes table asynchronously.
+func (t *ChangesTable) Init(db *db.DB) error {
+	done := make(chan error)
+	go func() {
+		done <- db.CreateTable(t.Name(), t.Schema())
+	}()
+	return <-done
+}
+
+// changesMu serializes journal writes, so sequence numbers are handed out once
+var changesMu sync.Mutex
+
+// RecordChanges appends nodes to a table's change journal. removed marks nodes that are gone for good.
+func RecordChanges(database *db.DB, tableName string, removed bool, nodeIDs ...string) error {
+	if len(nodeIDs) == 0 {
+		return nil
+	}
+	changesMu.Lock()
+	defer changesMu.Unlock()
+
+	last, err := LatestChange(database, tableName)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	values := make([]string, len(nodeIDs))
+	params := make([]any, 0, 5*len(nodeIDs))
+	for i, nodeID := range nodeIDs {
+		values[i] = "(?, ?, ?, ?, ?)"
+		params = append(params, tableName, last+int64(i)+1, nodeID, removed, now)
+	}
+	query := "INSERT INTO changes (table_name, seq, node_id, removed, changed_at) VALUES " + strings.Join(values, ", ")
+	if _, err := database.Exec(query, params...); err != nil {
+		return fmt.Errorf("record changes of %s: %w", tableName, err)
+	}
+	return nil
+}
+
+// LatestChange returns the sequence number of a table's last change (0 = none yet)
+func LatestChange(database *db.DB, tableName string) (int64, error) {
+	var seq int64
+	if err := database.QueryRow("SELECT COALESCE(MAX(seq), 0) FROM changes WHERE table_name = ?", tableName).Scan(&seq); err != nil {
+		return 0, fmt.Errorf("get latest change of %s: %w", tableName, err)
+	}
+	return seq, nil
+}
+
+// GetChanges returns up to limit changes of a table after a sequence number, oldest first. Nodes
+// changed more than once only appear at their last change.
+func GetChanges(database *db.DB, tableName string, after int64, limit int) ([]dbTypes.Change, error) {
+	query := `SELECT seq, node_id, removed, changed_at FROM changes c
+		WHERE table_name = ? AND seq > ?
+		AND seq = (SELECT MAX(seq) FROM changes WHERE table_name = c.table_name AND node_id = c.node_id)
+		ORDER BY seq LIMIT ?`
+	rows, err := database.Query("changes", query, tableName, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query changes of %s: %w", tableName, err)
+	}
+	defer rows.Close()
+
+	var changes []dbTypes.Change
+	for rows.Next() {
+		var change dbTypes.Change
+		if err := rows.Scan(&change.Seq, &change.NodeID, &change.Removed, &change.ChangedAt); err != nil {
+			return nil, fmt.Errorf("scan change of %s: %w", tableName, err)
+		}
+		changes = append(changes, change)
+	}
+	return changes, rows.Err()
+}
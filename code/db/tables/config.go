@@ -10,12 +10,67 @@ type PrimaryTableConfig struct {
 	MaxChildFiles   int    `json:"max_child_files,omitempty"`
 	MinDepth        int    `json:"min_depth,omitempty"`
 	MaxDepth        int    `json:"max_depth,omitempty"`
+
+	// NamingProfiles maps naming profile names (see naming.go) to their relative weights.
+	// Empty means the default folder_N / file_N.txt naming.
+	NamingProfiles map[string]float64 `json:"naming_profiles,omitempty"`
+
+	// FileSizes configures the file size distribution (see sizes.go).
+	// Nil keeps the legacy 100-999 byte uniform sizes.
+	FileSizes *SizeDistribution `json:"file_sizes,omitempty"`
+
+	// Timestamps configures seeded created/modified times (see timestamps.go).
+	// Nil keeps time.Now() timestamps.
+	Timestamps *TimestampConfig `json:"timestamps,omitempty"`
+
+	// Overrides replace the child folder/file ranges for matching subtrees (see overrides.go).
+	// Later overrides win over earlier ones.
+	Overrides []GenerationOverride `json:"overrides,omitempty"`
+
+	// FanOut makes the child ranges depth dependent and can calibrate them towards a target
+	// tree size (see fanout.go). Overrides are applied after the fan-out curves.
+	FanOut *FanOutConfig `json:"fan_out,omitempty"`
+
+	// Links turns a fraction of generated files into symlinks, hard links and shortcuts (see links.go).
+	Links *LinkConfig `json:"links,omitempty"`
+
+	// Duplicates makes a fraction of files share content with other files (see duplicates.go).
+	Duplicates *DuplicateConfig `json:"duplicates,omitempty"`
+
+	// NameCollisions gives some files names that only differ from a sibling's by case or
+	// Unicode normalization form (see name_collisions.go).
+	NameCollisions *NameCollisionConfig `json:"name_collisions,omitempty"`
+
+	// Violations makes a fraction of names break a constraint profile (see constraints.go).
+	Violations *ViolationConfig `json:"violations,omitempty"`
+
+	// Revisions gives a fraction of files a seeded version history (see revisions.go).
+	Revisions *RevisionConfig `json:"revisions,omitempty"`
+
+	// Trash makes deletes in this table soft (see trash.go).
+	Trash *TrashConfig `json:"trash,omitempty"`
+
+	// Locks starts a seeded fraction of files locked (see locks.go).
+	Locks *LockConfig `json:"locks,omitempty"`
+
+	// Sharing shares a seeded fraction of items with members and shared links (see sharing.go).
+	Sharing *SharingConfig `json:"sharing,omitempty"`
 }
 
 // SecondaryTableConfig represents configuration for a secondary table
 type SecondaryTableConfig struct {
 	TableName string  `json:"table_name"`
 	DstProb   float64 `json:"dst_prob"` // Probability of placing node in this table (0.0-1.0)
+
+	// NamePolicy makes creates in this table reject names that collide case-insensitively
+	// and/or after Unicode normalization, like Windows, OneDrive or macOS do.
+	NamePolicy
+
+	// Constraints limits names, paths and depth on create, move and copy (see constraints.go).
+	Constraints *ConstraintProfile `json:"constraints,omitempty"`
+
+	// Trash makes deletes in this table soft (see trash.go).
+	Trash *TrashConfig `json:"trash,omitempty"`
 }
 
 // TestConfig represents the configuration for test harness
@@ -31,4 +86,16 @@ type TestConfig struct {
 		Address string `json:"address"`
 		Port    int    `json:"port"`
 	} `json:"network"`
+
+	// S3 serves every table as a bucket of an S3-compatible API under /s3.
+	S3 *S3Config `json:"s3,omitempty"`
+
+	// SFTP serves every table as a directory of an embedded SFTP server.
+	SFTP *SFTPConfig `json:"sftp,omitempty"`
+
+	// Graph throttles the Microsoft Graph API under /graph (see graph.go).
+	Graph *GraphConfig `json:"graph,omitempty"`
+
+	// GRPC serves the REST operations, with streaming listings and transfers, over gRPC.
+	GRPC *GRPCConfig `json:"grpc,omitempty"`
 }
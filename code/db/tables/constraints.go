@@ -0,0 +1,239 @@
+package tables
+
+import (
+	"fmt"
+	"math/rand"
+	"path"
+	"strings"
+	"unicode/utf8"
+)
+
+// Constraint violation codes, shared by write operations and the violation generator
+const (
+	ViolationPathTooLong      = "path_too_long"
+	ViolationNameTooLong      = "name_too_long"
+	ViolationInvalidCharacter = "invalid_character"
+	ViolationReservedName     = "reserved_name"
+	ViolationMaxDepth         = "max_depth_exceeded"
+)
+
+// ConstraintProfile limits the names and paths a table accepts, like a destination provider does.
+// Preset picks a built-in profile, the other fields override it (0 / empty = keep the preset's value).
+type ConstraintProfile struct {
+	Preset         string   `json:"preset,omitempty"`          // "windows", "sharepoint", "onedrive" or "macos"
+	MaxPathLength  int      `json:"max_path_length,omitempty"` // in characters, measured on the node path
+	MaxNameLength  int      `json:"max_name_length,omitempty"` // in characters
+	ForbiddenChars string   `json:"forbidden_chars,omitempty"` // characters that may not appear in a name
+	ReservedNames  []string `json:"reserved_names,omitempty"`  // case-insensitive; "CON" also blocks "CON.txt"
+	MaxDepth       int      `json:"max_depth,omitempty"`       // deepest allowed level (root = 0)
+}
+
+var windowsDeviceNames = []string{
+	"CON", "PRN", "AUX", "NUL",
+	"COM0", "COM1", "COM2", "COM3", "COM4", "COM5", "COM6", "COM7", "COM8", "COM9",
+	"LPT0", "LPT1", "LPT2", "LPT3", "LPT4", "LPT5", "LPT6", "LPT7", "LPT8", "LPT9",
+}
+
+// constraintPresets are the built-in profiles
+var constraintPresets = map[string]ConstraintProfile{
+	// Legacy Win32 MAX_PATH
+	"windows": {
+		MaxPathLength:  260,
+		MaxNameLength:  255,
+		ForbiddenChars: `<>:"\|?*`,
+		ReservedNames:  windowsDeviceNames,
+	},
+	"sharepoint": {
+		MaxPathLength:  400,
+		MaxNameLength:  255,
+		ForbiddenChars: `"*:<>?\|`,
+		ReservedNames:  append([]string{".lock", "_vti_", "desktop.ini"}, windowsDeviceNames...),
+	},
+	"onedrive": {
+		MaxPathLength:  400,
+		MaxNameLength:  255,
+		ForbiddenChars: `"*:<>?\|`,
+		ReservedNames:  append([]string{".lock", "_vti_", "desktop.ini"}, windowsDeviceNames...),
+	},
+	"macos": {
+		MaxPathLength:  1024,
+		MaxNameLength:  255,
+		ForbiddenChars: ":",
+	},
+}
+
+// Validate checks the profile
+func (p *ConstraintProfile) Validate() error {
+	if p.Preset != "" {
+		if _, ok := constraintPresets[p.Preset]; !ok {
+			return fmt.Errorf("unknown preset %q", p.Preset)
+		}
+	}
+	if p.MaxPathLength < 0 || p.MaxNameLength < 0 || p.MaxDepth < 0 {
+		return fmt.Errorf("max_path_length, max_name_length and max_depth must not be negative")
+	}
+	return nil
+}
+
+// Resolved returns the profile with its preset's values filled in
+func (p *ConstraintProfile) Resolved() ConstraintProfile {
+	resolved := constraintPresets[p.Preset]
+	resolved.Preset = p.Preset
+	if p.MaxPathLength > 0 {
+		resolved.MaxPathLength = p.MaxPathLength
+	}
+	if p.MaxNameLength > 0 {
+		resolved.MaxNameLength = p.MaxNameLength
+	}
+	if p.ForbiddenChars != "" {
+		resolved.ForbiddenChars = p.ForbiddenChars
+	}
+	if len(p.ReservedNames) > 0 {
+		resolved.ReservedNames = p.ReservedNames
+	}
+	if p.MaxDepth > 0 {
+		resolved.MaxDepth = p.MaxDepth
+	}
+	return resolved
+}
+
+// ConstraintViolation describes why a node breaks a profile
+type ConstraintViolation struct {
+	Code    string
+	Message string
+}
+
+func (v *ConstraintViolation) Error() string {
+	return v.Message
+}
+
+// Check returns the first rule a node with this name, path and level breaks, or nil.
+// The profile must be resolved.
+func (p ConstraintProfile) Check(name, nodePath string, level int) *ConstraintViolation {
+	if p.MaxNameLength > 0 && utf8.RuneCountInString(name) > p.MaxNameLength {
+		return &ConstraintViolation{ViolationNameTooLong, fmt.Sprintf("name %q is longer than %d characters", name, p.MaxNameLength)}
+	}
+	if i := strings.IndexAny(name, p.ForbiddenChars); p.ForbiddenChars != "" && i >= 0 {
+		r, _ := utf8.DecodeRuneInString(name[i:])
+		return &ConstraintViolation{ViolationInvalidCharacter, fmt.Sprintf("name %q contains the forbidden character %q", name, r)}
+	}
+	if reserved, ok := p.reservedName(name); ok {
+		return &ConstraintViolation{ViolationReservedName, fmt.Sprintf("name %q is reserved (%s)", name, reserved)}
+	}
+	if p.MaxPathLength > 0 && utf8.RuneCountInString(nodePath) > p.MaxPathLength {
+		return &ConstraintViolation{ViolationPathTooLong, fmt.Sprintf("path %q is longer than %d characters", nodePath, p.MaxPathLength)}
+	}
+	if p.MaxDepth > 0 && level > p.MaxDepth {
+		return &ConstraintViolation{ViolationMaxDepth, fmt.Sprintf("%q is %d levels deep, the limit is %d", nodePath, level, p.MaxDepth)}
+	}
+	return nil
+}
+
+// reservedName matches a whole name, or the part before the first dot ("CON.txt")
+func (p ConstraintProfile) reservedName(name string) (string, bool) {
+	base := name
+	if i := strings.Index(name, "."); i > 0 {
+		base = name[:i]
+	}
+	for _, reserved := range p.ReservedNames {
+		if strings.EqualFold(name, reserved) || strings.EqualFold(base, reserved) {
+			return reserved, true
+		}
+	}
+	return "", false
+}
+
+// ViolationConfig makes a fraction of generated names break a constraint profile, so pre-flight
+// checkers can be tested against a source that doesn't fit the destination. Depth violations come
+// from the tree itself (generate deeper than the profile's max_depth).
+type ViolationConfig struct {
+	Prob    float64           `json:"prob"`
+	Profile ConstraintProfile `json:"profile"`
+	Kinds   []string          `json:"kinds,omitempty"` // subset of the name violation codes (default: all the profile has rules for)
+}
+
+// Validate checks the violation configuration
+func (c *ViolationConfig) Validate() error {
+	if c.Prob < 0 || c.Prob > 1 {
+		return fmt.Errorf("prob must be between 0.0 and 1.0")
+	}
+	if err := c.Profile.Validate(); err != nil {
+		return fmt.Errorf("profile: %w", err)
+	}
+	for _, kind := range c.Kinds {
+		switch kind {
+		case ViolationPathTooLong, ViolationNameTooLong, ViolationInvalidCharacter, ViolationReservedName:
+		default:
+			return fmt.Errorf("unknown violation kind %q", kind)
+		}
+	}
+	return nil
+}
+
+// Violate rolls whether a new node's name breaks the profile. Returns the violating name, or ok=false.
+func (c *ViolationConfig) Violate(rng *rand.Rand, name string, parentPath string) (string, bool) {
+	if c == nil || rng.Float64() >= c.Prob {
+		return "", false
+	}
+
+	profile := c.Profile.Resolved()
+	kinds := c.applicableKinds(profile)
+	if len(kinds) == 0 {
+		return "", false
+	}
+
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	switch kinds[rng.Intn(len(kinds))] {
+	case ViolationNameTooLong:
+		return padName(base, ext, profile.MaxNameLength+1+rng.Intn(20)), true
+	case ViolationPathTooLong:
+		parentLength := utf8.RuneCountInString(buildPath(parentPath, ""))
+		return padName(base, ext, profile.MaxPathLength-parentLength+1+rng.Intn(20)), true
+	case ViolationInvalidCharacter:
+		chars := []rune(strings.ReplaceAll(profile.ForbiddenChars, "/", ""))
+		runes := []rune(base)
+		at := rng.Intn(len(runes) + 1)
+		return string(runes[:at]) + string(chars[rng.Intn(len(chars))]) + string(runes[at:]) + ext, true
+	default:
+		reserved := profile.ReservedNames[rng.Intn(len(profile.ReservedNames))]
+		if strings.Contains(reserved, ".") || ext == "" {
+			return reserved, true
+		}
+		return reserved + ext, true
+	}
+}
+
+// applicableKinds lists the violations the profile has rules for
+func (c *ViolationConfig) applicableKinds(profile ConstraintProfile) []string {
+	available := map[string]bool{
+		ViolationNameTooLong:      profile.MaxNameLength > 0,
+		ViolationPathTooLong:      profile.MaxPathLength > 0,
+		ViolationInvalidCharacter: strings.ReplaceAll(profile.ForbiddenChars, "/", "") != "",
+		ViolationReservedName:     len(profile.ReservedNames) > 0,
+	}
+	kinds := c.Kinds
+	if len(kinds) == 0 {
+		kinds = []string{ViolationNameTooLong, ViolationPathTooLong, ViolationInvalidCharacter, ViolationReservedName}
+	}
+
+	applicable := make([]string, 0, len(kinds))
+	for _, kind := range kinds {
+		if available[kind] {
+			applicable = append(applicable, kind)
+		}
+	}
+	return applicable
+}
+
+// padName repeats the base name until the whole name is at least length characters
+func padName(base, ext string, length int) string {
+	if base == "" {
+		base = "x"
+	}
+	padded := base
+	for utf8.RuneCountInString(padded+ext) < length {
+		padded += " " + base
+	}
+	return padded + ext
+}
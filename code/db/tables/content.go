@@ -0,0 +1,106 @@
+package tables
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+)
+
+// ContentReader streams a file's deterministic content. Every 8-byte word is a pure function of
+// (seed, word index), so reads can start anywhere without generating the bytes before them.
+type ContentReader struct {
+	seed   uint64
+	size   int64
+	offset int64
+}
+
+// NewContentReader returns a reader over size bytes of content derived from seed
+func NewContentReader(seed int64, size int64) *ContentReader {
+	return &ContentReader{seed: uint64(seed), size: size}
+}
+
+// ContentSeed returns the seed of a node's content. Duplicates and hard links store the seed they
+// share, everything else derives it from the master seed and the node ID, so the same file has
+// the same bytes everywhere it is served or exported.
+func (dg *DeterministicGenerator) ContentSeed(node dbTypes.Node) int64 {
+	if node.ContentSeed != 0 {
+		return node.ContentSeed
+	}
+	if node.Type == NodeTypeHardlink && node.Target != "" {
+		return DeriveContentSeed(dg.masterSeed, node.Target)
+	}
+	return DeriveContentSeed(dg.masterSeed, node.ID)
+}
+
+// OpenContent returns a reader over the first size bytes of a file node's content
+func (dg *DeterministicGenerator) OpenContent(node dbTypes.Node, size int64) *ContentReader {
+	return NewContentReader(dg.ContentSeed(node), size)
+}
+
+// Size returns the total content length
+func (r *ContentReader) Size() int64 {
+	return r.size
+}
+
+// Read implements io.Reader
+func (r *ContentReader) Read(p []byte) (int, error) {
+	n, err := r.ReadAt(p, r.offset)
+	r.offset += int64(n)
+	return n, err
+}
+
+// ReadAt implements io.ReaderAt
+func (r *ContentReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("content: negative offset")
+	}
+	if off >= r.size {
+		return 0, io.EOF
+	}
+
+	n := len(p)
+	if remaining := r.size - off; int64(n) > remaining {
+		n = int(remaining)
+	}
+
+	var word [8]byte
+	for i := 0; i < n; {
+		pos := off + int64(i)
+		binary.LittleEndian.PutUint64(word[:], splitmix64(r.seed+uint64(pos/8+1)*0x9E3779B97F4A7C15))
+		i += copy(p[i:n], word[pos%8:])
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Seek implements io.Seeker
+func (r *ContentReader) Seek(offset int64, whence int) (int64, error) {
+	var abs int64
+	switch whence {
+	case io.SeekStart:
+		abs = offset
+	case io.SeekCurrent:
+		abs = r.offset + offset
+	case io.SeekEnd:
+		abs = r.size + offset
+	default:
+		return 0, errors.New("content: invalid whence")
+	}
+	if abs < 0 {
+		return 0, errors.New("content: negative position")
+	}
+	r.offset = abs
+	return abs, nil
+}
+
+// splitmix64 is the SplitMix64 finalizer, a cheap and well mixed 64-bit hash
+func splitmix64(x uint64) uint64 {
+	x = (x ^ (x >> 30)) * 0xBF58476D1CE4E5B9
+	x = (x ^ (x >> 27)) * 0x94D049BB133111EB
+	return x ^ (x >> 31)
+}
@@ -2,11 +2,11 @@ package tables
 
 import (
 	"crypto/sha256"
+	"database/sql"
 	"encoding/binary"
 	"fmt"
 	"math/rand"
 	"sync"
-	"time"
 
 	"github.com/Voltaic314/GhostFS/code/db"
 	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
@@ -28,10 +28,28 @@ type DeterministicGenerator struct {
 	cacheMutex       sync.RWMutex
 	masterSeed       int64
 	tableManager     *TableManager
+	names            *NameGenerator
+	sizes            *SizeSampler
+	timestamps       *TimestampGenerator
+	links            *LinkGenerator
+	duplicates       *DuplicateGenerator
 }
 
 // NewDeterministicGenerator creates a new deterministic generator
 func NewDeterministicGenerator(database *db.DB, config PrimaryTableConfig, secondaryConfigs map[string]SecondaryTableConfig, masterSeed int64, tableManager *TableManager) *DeterministicGenerator {
+	// Naming profiles and size distributions are validated by TableManager.ValidateConfig,
+	// fall back to the defaults if they still can't be prepared (e.g. unreadable histogram file)
+	names, err := NewNameGenerator(config.NamingProfiles)
+	if err != nil {
+		fmt.Printf("⚠️  Warning: Could not prepare naming profiles, using defaults: %v\n", err)
+		names, _ = NewNameGenerator(nil)
+	}
+	sizes, err := NewSizeSampler(config.FileSizes)
+	if err != nil {
+		fmt.Printf("⚠️  Warning: Could not prepare file size distribution, using defaults: %v\n", err)
+		sizes, _ = NewSizeSampler(nil)
+	}
+
 	return &DeterministicGenerator{
 		db:               database,
 		config:           config,
@@ -39,6 +57,11 @@ func NewDeterministicGenerator(database *db.DB, config PrimaryTableConfig, secon
 		nodeCache:        make(map[string]CachedNodeData),
 		masterSeed:       masterSeed,
 		tableManager:     tableManager,
+		names:            names,
+		sizes:            sizes,
+		timestamps:       NewTimestampGenerator(config.Timestamps),
+		links:            NewLinkGenerator(config.Links),
+		duplicates:       NewDuplicateGenerator(config.Duplicates, masterSeed, sizes),
 	}
 }
 
@@ -108,7 +131,9 @@ func (dg *DeterministicGenerator) LoadSeedsFromDatabase(tableName string) error
 }
 
 // GenerateChildren generates children for a folder deterministically
-func (dg *DeterministicGenerator) GenerateChildren(folderID string, folderPath string, level int, foldersOnly bool, tableName string) ([]dbTypes.Node, error) {
+func (dg *DeterministicGenerator) GenerateChildren(folder *dbTypes.Node, foldersOnly bool, tableName string) ([]dbTypes.Node, error) {
+	folderID, folderPath, level := folder.ID, folder.Path, folder.Level
+
 	// Get or create child seed for this folder
 	childSeed, err := dg.getOrCreateChildSeed(folderID, tableName)
 	if err != nil {
@@ -124,43 +149,78 @@ func (dg *DeterministicGenerator) GenerateChildren(folderID string, folderPath s
 		return nil, fmt.Errorf("get parent existence map: %w", err)
 	}
 
+	// Per-subtree overrides may change the fan-out for this folder
+	config := dg.tableManager.GetGenerationConfigForFolder(tableName, folderPath, level)
+	if leafProb := dg.tableManager.GetLeafProb(level); leafProb > 0 && slotRNG(childSeed, "leaf").Float64() < leafProb {
+		config.MinChildFolders, config.MaxChildFolders = 0, 0
+	}
+
 	// Generate children deterministically
 	children := make([]dbTypes.Node, 0)
+	usedNames := make(map[string]struct{})
 
 	// Generate folders
-	numFolders := dg.config.MinChildFolders + rng.Intn(dg.config.MaxChildFolders-dg.config.MinChildFolders+1)
+	numFolders := config.MinChildFolders + rng.Intn(config.MaxChildFolders-config.MinChildFolders+1)
 	for i := 0; i < numFolders; i++ {
+		// IDs are keyed by slot so they stay stable no matter which naming profile is used
+		slot := fmt.Sprintf("folder_%d", i)
+		name := dg.names.FolderName(slotRNG(childSeed, slot), i)
+		if violating, ok := dg.config.Violations.Violate(slotRNG(childSeed, slot+":violation"), name, folderPath); ok {
+			name = violating
+		}
+		name = UniqueName(name, usedNames)
+		createdAt, updatedAt := dg.timestamps.ChildTimes(slotRNG(childSeed, slot+":timestamps"), folder.CreatedAt, folder.UpdatedAt, false)
 		folderChild := dbTypes.Node{
-			ID:        generateDeterministicUUID(childSeed, fmt.Sprintf("folder_%d", i)),
+			ID:        generateDeterministicUUID(childSeed, slot),
 			ParentID:  folderID,
-			Name:      fmt.Sprintf("folder_%d", i),
-			Path:      buildPath(folderPath, fmt.Sprintf("folder_%d", i)),
+			Name:      name,
+			Path:      buildPath(folderPath, name),
 			Type:      "folder",
 			Size:      0,
 			Level:     level + 1,
 			Checked:   false,
-			UpdatedAt: time.Now(),
-			CreatedAt: time.Now(),
+			UpdatedAt: updatedAt,
+			CreatedAt: createdAt,
 		}
 		children = append(children, folderChild)
 	}
 
 	// Generate files (unless foldersOnly is true)
 	if !foldersOnly {
-		numFiles := dg.config.MinChildFiles + rng.Intn(dg.config.MaxChildFiles-dg.config.MinChildFiles+1)
+		numFiles := config.MinChildFiles + rng.Intn(config.MaxChildFiles-config.MinChildFiles+1)
 		for i := 0; i < numFiles; i++ {
-			fileSize := int64(100 + rng.Intn(900)) // Random size 100-999 bytes
+			slot := fmt.Sprintf("file_%d.txt", i)
+			name := dg.names.FileName(slotRNG(childSeed, slot), i)
+			if violating, ok := dg.config.Violations.Violate(slotRNG(childSeed, slot+":violation"), name, folderPath); ok {
+				name = violating
+			}
+			name = UniqueName(name, usedNames)
+			if variant, ok := dg.config.NameCollisions.CollidingName(slotRNG(childSeed, slot+":collision"), children, usedNames); ok {
+				name = variant
+			}
+			fileSize := dg.sizes.Sample(rng, name)
+			createdAt, updatedAt := dg.timestamps.ChildTimes(slotRNG(childSeed, slot+":timestamps"), folder.CreatedAt, folder.UpdatedAt, true)
+			id := generateDeterministicUUID(childSeed, slot)
+			nodeType, target, contentSeed := "file", "", DeriveContentSeed(dg.masterSeed, id)
+			if linkType, linkTarget, linkSize, ok := dg.links.Link(slotRNG(childSeed, slot+":link"), level, children); ok {
+				nodeType, target, fileSize = linkType, linkTarget, linkSize
+				contentSeed = linkedContentSeed(nodeType, target, children)
+			} else if dupSeed, dupSize, ok := dg.duplicates.Duplicate(slotRNG(childSeed, slot+":duplicate"), children); ok {
+				contentSeed, fileSize = dupSeed, dupSize
+			}
 			fileChild := dbTypes.Node{
-				ID:        generateDeterministicUUID(childSeed, fmt.Sprintf("file_%d.txt", i)),
-				ParentID:  folderID,
-				Name:      fmt.Sprintf("file_%d.txt", i),
-				Path:      buildPath(folderPath, fmt.Sprintf("file_%d.txt", i)),
-				Type:      "file",
-				Size:      fileSize,
-				Level:     level + 1,
-				Checked:   false,
-				UpdatedAt: time.Now(),
-				CreatedAt: time.Now(),
+				ID:          id,
+				ParentID:    folderID,
+				Name:        name,
+				Path:        buildPath(folderPath, name),
+				Type:        nodeType,
+				Target:      target,
+				ContentSeed: contentSeed,
+				Size:        fileSize,
+				Level:       level + 1,
+				Checked:     false,
+				UpdatedAt:   updatedAt,
+				CreatedAt:   createdAt,
 			}
 			children = append(children, fileChild)
 		}
@@ -294,6 +354,13 @@ func (dg *DeterministicGenerator) getOrCreateParentExistenceMap(folderID string,
 func (dg *DeterministicGenerator) storeChildrenWithSeeds(children []dbTypes.Node, parentExistenceMap SecondaryExistenceMap, tableName string) error {
 	secondaryTableNames := dg.tableManager.GetSecondaryTableNames()
 
+	// Skip children stored by an earlier listing. Besides saving writes, DuckDB trips an internal
+	// error when a batch of fully ignored INSERT OR IGNOREs is followed by an UPDATE (MarkFolderAccessed).
+	stored, err := dg.getStoredChildIDs(children, tableName)
+	if err != nil {
+		return err
+	}
+
 	for _, child := range children {
 		// Generate child's own seed
 		childSeed := generateDeterministicSeed(dg.masterSeed, child.ID)
@@ -310,9 +377,16 @@ func (dg *DeterministicGenerator) storeChildrenWithSeeds(children []dbTypes.Node
 			return fmt.Errorf("convert existence map to JSON for child %s: %w", child.ID, err)
 		}
 
+		if _, ok := stored[child.ID]; ok {
+			dg.cacheMutex.Lock()
+			dg.nodeCache[child.ID] = CachedNodeData{ChildSeed: childSeed, ExistenceMap: childExistenceMap}
+			dg.cacheMutex.Unlock()
+			continue
+		}
+
 		// Insert child into primary table with seed
-		primaryQuery := fmt.Sprintf("INSERT OR IGNORE INTO %s (id, parent_id, name, path, type, size, level, checked, secondary_existence_map, child_seed, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", tableName)
-		dg.db.QueueWrite(tableName, primaryQuery, child.ID, child.ParentID, child.Name, child.Path, child.Type, child.Size, child.Level, child.Checked, existenceMapJSON, childSeed, child.CreatedAt, child.UpdatedAt)
+		primaryQuery := fmt.Sprintf("INSERT OR IGNORE INTO %s (id, parent_id, name, path, type, target, content_seed, size, level, checked, secondary_existence_map, child_seed, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", tableName)
+		dg.db.QueueWrite(tableName, primaryQuery, child.ID, child.ParentID, child.Name, child.Path, child.Type, nullableTarget(child.Target), nullableSeed(child.ContentSeed), child.Size, child.Level, child.Checked, existenceMapJSON, childSeed, child.CreatedAt, child.UpdatedAt)
 
 		// Cache the child's existence map and seed
 		dg.cacheMutex.Lock()
@@ -325,8 +399,8 @@ func (dg *DeterministicGenerator) storeChildrenWithSeeds(children []dbTypes.Node
 		// Insert into secondary tables where it should exist
 		for _, secondaryTableName := range secondaryTableNames {
 			if childExistenceMap[secondaryTableName] {
-				secondaryQuery := fmt.Sprintf("INSERT OR IGNORE INTO %s (id, parent_id, name, path, type, size, level, checked, child_seed, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", secondaryTableName)
-				dg.db.QueueWrite(secondaryTableName, secondaryQuery, child.ID, child.ParentID, child.Name, child.Path, child.Type, child.Size, child.Level, child.Checked, childSeed, child.CreatedAt, child.UpdatedAt)
+				secondaryQuery := fmt.Sprintf("INSERT OR IGNORE INTO %s (id, parent_id, name, path, type, target, content_seed, size, level, checked, child_seed, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", secondaryTableName)
+				dg.db.QueueWrite(secondaryTableName, secondaryQuery, child.ID, child.ParentID, child.Name, child.Path, child.Type, nullableTarget(child.Target), nullableSeed(child.ContentSeed), child.Size, child.Level, child.Checked, childSeed, child.CreatedAt, child.UpdatedAt)
 			}
 		}
 	}
@@ -334,6 +408,30 @@ func (dg *DeterministicGenerator) storeChildrenWithSeeds(children []dbTypes.Node
 	return nil
 }
 
+// getStoredChildIDs returns the IDs of the given children's parent that are already in the table
+func (dg *DeterministicGenerator) getStoredChildIDs(children []dbTypes.Node, tableName string) (map[string]struct{}, error) {
+	stored := make(map[string]struct{})
+	if len(children) == 0 {
+		return stored, nil
+	}
+
+	query := fmt.Sprintf("SELECT id FROM %s WHERE parent_id = ?", tableName)
+	rows, err := dg.db.Query(tableName, query, children[0].ParentID)
+	if err != nil {
+		return nil, fmt.Errorf("query stored children of %s: %w", children[0].ParentID, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan stored child of %s: %w", children[0].ParentID, err)
+		}
+		stored[id] = struct{}{}
+	}
+	return stored, rows.Err()
+}
+
 // determineSecondaryExistence determines which secondary tables a node should exist in based on probability
 func (dg *DeterministicGenerator) determineSecondaryExistence(childSeed int64) SecondaryExistenceMap {
 	existenceMap := make(SecondaryExistenceMap)
@@ -363,15 +461,26 @@ func (dg *DeterministicGenerator) checkParentDependencies(parentExistenceMap, ch
 	return result
 }
 
-// GetFolderInfo gets folder information from database (for path, level, etc.)
+// GetFolderInfo gets folder information from database (for path, level, timestamps, etc.)
 func (dg *DeterministicGenerator) GetFolderInfo(folderID string, tableName string) (*dbTypes.Node, error) {
-	query := fmt.Sprintf("SELECT id, parent_id, name, path, type, size, level, checked FROM %s WHERE id = ? LIMIT 1", tableName)
+	query := fmt.Sprintf("SELECT id, parent_id, name, path, type, size, level, checked, created_at, updated_at FROM %s WHERE id = ? LIMIT 1", tableName)
+
+	// Query (rather than QueryRow) flushes queued writes first, so freshly generated folders are visible
+	rows, err := dg.db.Query(tableName, query, folderID)
+	if err != nil {
+		return nil, fmt.Errorf("get folder info for %s: %w", folderID, err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, fmt.Errorf("get folder info for %s: %w", folderID, sql.ErrNoRows)
+	}
 
 	var folder dbTypes.Node
-	err := dg.db.QueryRow(query, folderID).Scan(
+	err = rows.Scan(
 		&folder.ID, &folder.ParentID, &folder.Name, &folder.Path,
-		&folder.Type, &folder.Size, &folder.Level, &folder.Checked)
-
+		&folder.Type, &folder.Size, &folder.Level, &folder.Checked,
+		&folder.CreatedAt, &folder.UpdatedAt)
 	if err != nil {
 		return nil, fmt.Errorf("get folder info for %s: %w", folderID, err)
 	}
@@ -386,6 +495,11 @@ func (dg *DeterministicGenerator) MarkFolderAccessed(folderID string, tableName
 	dg.db.QueueWrite(tableName, updateQuery, folderID)
 }
 
+// GetTimestampGenerator returns the generator used for seeded node timestamps
+func (dg *DeterministicGenerator) GetTimestampGenerator() *TimestampGenerator {
+	return dg.timestamps
+}
+
 // ClearCache clears the node cache (useful for testing or memory management)
 func (dg *DeterministicGenerator) ClearCache() {
 	dg.cacheMutex.Lock()
@@ -436,6 +550,11 @@ func generateDeterministicUUID(seed int64, name string) string {
 	return uuid.String()
 }
 
+// slotRNG creates an RNG for a single child slot, independent of the folder's main RNG sequence
+func slotRNG(childSeed int64, slot string) *rand.Rand {
+	return rand.New(rand.NewSource(generateDeterministicSeed(childSeed, slot)))
+}
+
 // buildPath constructs the full path for a node based on its parent's path and name
 func buildPath(parentPath, name string) string {
 	if parentPath == "/" {
@@ -0,0 +1,83 @@
+package tables
+
+import (
+	"fmt"
+	"math/rand"
+
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+)
+
+// DuplicateConfig makes a fraction of files share their content (and size) with other files.
+// Duplicates either copy a sibling file in the same folder or draw from a shared pool of contents.
+// The pool is keyed by the master seed only, so pooled duplicates match across folders, across
+// tables of the database and across databases generated from the same seed.
+type DuplicateConfig struct {
+	Prob        float64 `json:"prob"`                   // fraction of regular files that are duplicates
+	SameFolder  float64 `json:"same_folder,omitempty"`  // relative weight of sibling duplicates
+	CrossFolder float64 `json:"cross_folder,omitempty"` // relative weight of pooled duplicates
+	PoolSize    int     `json:"pool_size,omitempty"`    // distinct contents in the pool (default 100)
+}
+
+const defaultDuplicatePoolSize = 100
+
+// Validate checks the duplicate configuration
+func (c *DuplicateConfig) Validate() error {
+	if c.Prob < 0 || c.Prob > 1 {
+		return fmt.Errorf("prob must be between 0.0 and 1.0")
+	}
+	if c.SameFolder < 0 || c.CrossFolder < 0 {
+		return fmt.Errorf("same_folder and cross_folder weights must not be negative")
+	}
+	if c.PoolSize < 0 {
+		return fmt.Errorf("pool_size must not be negative")
+	}
+	return nil
+}
+
+// DeriveContentSeed returns the content seed of a node that doesn't share its content
+func DeriveContentSeed(masterSeed int64, nodeID string) int64 {
+	return generateDeterministicSeed(masterSeed, "content:"+nodeID)
+}
+
+// DuplicateGenerator decides which files duplicate another file's content
+type DuplicateGenerator struct {
+	config     *DuplicateConfig
+	masterSeed int64
+	sizes      *SizeSampler
+}
+
+// NewDuplicateGenerator creates a duplicate generator. A nil config never produces duplicates.
+func NewDuplicateGenerator(config *DuplicateConfig, masterSeed int64, sizes *SizeSampler) *DuplicateGenerator {
+	return &DuplicateGenerator{config: config, masterSeed: masterSeed, sizes: sizes}
+}
+
+// Duplicate rolls whether a file duplicates another one. siblings are the folder's children generated
+// so far (with their content seeds). Returns the shared content seed and size, or ok=false.
+func (g *DuplicateGenerator) Duplicate(rng *rand.Rand, siblings []dbTypes.Node) (contentSeed int64, size int64, ok bool) {
+	if g.config == nil || rng.Float64() >= g.config.Prob {
+		return 0, 0, false
+	}
+
+	sameFolder, crossFolder := g.config.SameFolder, g.config.CrossFolder
+	if sameFolder == 0 && crossFolder == 0 {
+		sameFolder, crossFolder = 1, 1
+	}
+
+	files := siblingsOfType(siblings, NodeTypeFile)
+	if len(files) > 0 && rng.Float64()*(sameFolder+crossFolder) < sameFolder {
+		original := files[rng.Intn(len(files))]
+		return original.ContentSeed, original.Size, true
+	}
+	if crossFolder == 0 {
+		return 0, 0, false
+	}
+
+	poolSize := g.config.PoolSize
+	if poolSize == 0 {
+		poolSize = defaultDuplicatePoolSize
+	}
+	entry := fmt.Sprintf("duplicate_pool:%d", rng.Intn(poolSize))
+	// Pool entries get their size from the configured distribution, independent of any file name
+	size = g.sizes.Sample(rand.New(rand.NewSource(generateDeterministicSeed(g.masterSeed, entry+":size"))), "")
+	return generateDeterministicSeed(g.masterSeed, entry), size, true
+}
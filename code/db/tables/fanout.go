@@ -0,0 +1,138 @@
+package tables
+
+import (
+	"fmt"
+	"math"
+)
+
+// FanOutConfig turns the flat min/max child ranges into functions of depth.
+// At folder level L the folder range is scaled by folder_decay^L and the file range by file_growth^L.
+// When fan_out is set, folders at max_depth get no children so the tree is bounded.
+type FanOutConfig struct {
+	FolderDecay float64 `json:"folder_decay,omitempty"` // per-level multiplier for the folder range (default 1)
+	FileGrowth  float64 `json:"file_growth,omitempty"`  // per-level multiplier for the file range (default 1)
+	LeafProb    float64 `json:"leaf_prob,omitempty"`    // probability that a folder at or below min_depth has no subfolders
+	TargetNodes int64   `json:"target_nodes,omitempty"` // calibrate both ranges so the expected tree size is close to this
+}
+
+// Validate checks the fan-out configuration against the primary table config it belongs to
+func (c *FanOutConfig) Validate(primary PrimaryTableConfig) error {
+	if c.FolderDecay < 0 || c.FileGrowth < 0 {
+		return fmt.Errorf("folder_decay and file_growth must not be negative")
+	}
+	if c.LeafProb < 0 || c.LeafProb > 1 {
+		return fmt.Errorf("leaf_prob must be between 0.0 and 1.0")
+	}
+	if c.TargetNodes < 0 {
+		return fmt.Errorf("target_nodes must not be negative")
+	}
+	if primary.MaxDepth <= 0 {
+		return fmt.Errorf("fan_out needs max_depth to bound the tree")
+	}
+	return nil
+}
+
+// FanOut applies a FanOutConfig to per-level generation configs
+type FanOut struct {
+	base  PrimaryTableConfig
+	scale float64
+}
+
+// NewFanOut prepares the fan-out curves for a primary table config and, if target_nodes is set,
+// calibrates the scale factor. Returns nil if the config has no fan_out section.
+func NewFanOut(base PrimaryTableConfig) *FanOut {
+	if base.FanOut == nil {
+		return nil
+	}
+
+	f := &FanOut{base: base, scale: 1}
+	if base.FanOut.TargetNodes > 0 {
+		f.calibrate(float64(base.FanOut.TargetNodes))
+	}
+	return f
+}
+
+// Scale returns the calibrated scale factor (1 without target_nodes)
+func (f *FanOut) Scale() float64 {
+	return f.scale
+}
+
+// Apply scales config's child ranges for the children of a folder at level
+func (f *FanOut) Apply(config *PrimaryTableConfig, level int) {
+	config.MinChildFolders, config.MaxChildFolders, config.MinChildFiles, config.MaxChildFiles = f.ranges(level, f.scale)
+}
+
+// LeafProb returns the probability that a folder at level gets no subfolders
+func (f *FanOut) LeafProb(level int) float64 {
+	if level < f.base.MinDepth {
+		return 0
+	}
+	return f.base.FanOut.LeafProb
+}
+
+// ExpectedNodes returns the expected total number of nodes (including the root)
+func (f *FanOut) ExpectedNodes() float64 {
+	return f.expectedNodes(f.scale)
+}
+
+// ranges returns the scaled folder and file ranges at level
+func (f *FanOut) ranges(level int, scale float64) (int, int, int, int) {
+	if level >= f.base.MaxDepth {
+		return 0, 0, 0, 0
+	}
+
+	folderFactor := scale * math.Pow(defaultOne(f.base.FanOut.FolderDecay), float64(level))
+	fileFactor := scale * math.Pow(defaultOne(f.base.FanOut.FileGrowth), float64(level))
+
+	minFolders, maxFolders := scaleRange(f.base.MinChildFolders, f.base.MaxChildFolders, folderFactor)
+	minFiles, maxFiles := scaleRange(f.base.MinChildFiles, f.base.MaxChildFiles, fileFactor)
+	return minFolders, maxFolders, minFiles, maxFiles
+}
+
+// expectedNodes walks the levels computing the expected number of folders and files at each
+func (f *FanOut) expectedNodes(scale float64) float64 {
+	total := 1.0   // root
+	folders := 1.0 // expected folders at the current level
+	for level := 0; level < f.base.MaxDepth && folders > 0; level++ {
+		minFolders, maxFolders, minFiles, maxFiles := f.ranges(level, scale)
+		subfolders := folders * (1 - f.LeafProb(level)) * float64(minFolders+maxFolders) / 2
+		files := folders * float64(minFiles+maxFiles) / 2
+		total += subfolders + files
+		folders = subfolders
+		if total > 1e15 {
+			break // far past any sane target, no need to keep going
+		}
+	}
+	return total
+}
+
+// calibrate binary searches (on a log scale) for the scale whose expected size is closest to target
+func (f *FanOut) calibrate(target float64) {
+	low, high := math.Log(1e-6), math.Log(1e6)
+	for i := 0; i < 100; i++ {
+		mid := (low + high) / 2
+		if f.expectedNodes(math.Exp(mid)) < target {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	f.scale = math.Exp(high)
+}
+
+// scaleRange multiplies a min/max range by factor, keeping it a valid integer range
+func scaleRange(minCount, maxCount int, factor float64) (int, int) {
+	scaledMin := int(math.Round(float64(minCount) * factor))
+	scaledMax := int(math.Round(float64(maxCount) * factor))
+	if scaledMax < scaledMin {
+		scaledMax = scaledMin
+	}
+	return scaledMin, scaledMax
+}
+
+func defaultOne(value float64) float64 {
+	if value == 0 {
+		return 1
+	}
+	return value
+}
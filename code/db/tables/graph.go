@@ -0,0 +1,17 @@
+package tables
+
+import "fmt"
+
+// GraphConfig configures the Microsoft Graph (OneDrive) API. The API is always served; the
+// configuration throttles it like Graph does, with 429 activityLimitReached errors.
+type GraphConfig struct {
+	RequestsPerMinute int `json:"requests_per_minute,omitempty"` // 0 = no throttling
+}
+
+// Validate checks the Graph configuration
+func (c *GraphConfig) Validate() error {
+	if c.RequestsPerMinute < 0 {
+		return fmt.Errorf("requests_per_minute must not be negative")
+	}
+	return nil
+}
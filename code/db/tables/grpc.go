@@ -0,0 +1,29 @@
+package tables
+
+import "fmt"
+
+// defaultGRPCPort is the port of the gRPC server unless configured
+const defaultGRPCPort = 50051
+
+// GRPCConfig enables the gRPC API, which serves the same tables and operations as the REST API
+type GRPCConfig struct {
+	Enabled bool   `json:"enabled"`
+	Address string `json:"address,omitempty"` // default: the HTTP server's address
+	Port    int    `json:"port,omitempty"`    // default 50051
+}
+
+// Validate checks the gRPC configuration
+func (c *GRPCConfig) Validate() error {
+	if c.Port < 0 || c.Port > 65535 {
+		return fmt.Errorf("invalid port %d", c.Port)
+	}
+	return nil
+}
+
+// GetPort returns the port the gRPC server listens on
+func (c *GRPCConfig) GetPort() int {
+	if c.Port == 0 {
+		return defaultGRPCPort
+	}
+	return c.Port
+}
@@ -0,0 +1,173 @@
+package tables
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+	"github.com/google/uuid"
+)
+
+// Node types stored in the type column
+const (
+	NodeTypeFile     = "file"
+	NodeTypeFolder   = "folder"
+	NodeTypeSymlink  = "symlink"  // target is a path relative to the link's folder
+	NodeTypeHardlink = "hardlink" // target is the ID of the file whose content it shares
+	NodeTypeShortcut = "shortcut" // cloud-style shortcut, target is the ID of the node it points at
+)
+
+// LinkConfig turns a fraction of generated files into links. The three type probabilities are
+// per file slot and must add up to at most 1.
+type LinkConfig struct {
+	SymlinkProb  float64 `json:"symlink_prob,omitempty"`
+	HardlinkProb float64 `json:"hardlink_prob,omitempty"`
+	ShortcutProb float64 `json:"shortcut_prob,omitempty"`
+	BrokenProb   float64 `json:"broken_prob,omitempty"` // fraction of symlinks and shortcuts whose target doesn't exist
+	CycleProb    float64 `json:"cycle_prob,omitempty"`  // fraction of symlinks pointing at their own folder or an ancestor
+}
+
+// Validate checks the link probabilities
+func (c *LinkConfig) Validate() error {
+	for name, p := range map[string]float64{
+		"symlink_prob": c.SymlinkProb, "hardlink_prob": c.HardlinkProb, "shortcut_prob": c.ShortcutProb,
+		"broken_prob": c.BrokenProb, "cycle_prob": c.CycleProb,
+	} {
+		if p < 0 || p > 1 {
+			return fmt.Errorf("%s must be between 0.0 and 1.0", name)
+		}
+	}
+	if c.SymlinkProb+c.HardlinkProb+c.ShortcutProb > 1 {
+		return fmt.Errorf("symlink_prob + hardlink_prob + shortcut_prob must not exceed 1.0")
+	}
+	if c.BrokenProb+c.CycleProb > 1 {
+		return fmt.Errorf("broken_prob + cycle_prob must not exceed 1.0")
+	}
+	return nil
+}
+
+// IsLinkType returns true for the link node types
+func IsLinkType(nodeType string) bool {
+	return nodeType == NodeTypeSymlink || nodeType == NodeTypeHardlink || nodeType == NodeTypeShortcut
+}
+
+// LinkGenerator decides which file slots become links and what they point at
+type LinkGenerator struct {
+	config *LinkConfig
+}
+
+// NewLinkGenerator creates a link generator. A nil config never produces links.
+func NewLinkGenerator(config *LinkConfig) *LinkGenerator {
+	return &LinkGenerator{config: config}
+}
+
+// Link rolls whether a file slot becomes a link. siblings are the folder's children generated so far,
+// level is the level of the folder the link lives in. Returns ok=false for a regular file, which is
+// also the fallback when the chosen link type has nothing to point at.
+func (g *LinkGenerator) Link(rng *rand.Rand, level int, siblings []dbTypes.Node) (nodeType string, target string, size int64, ok bool) {
+	if g.config == nil {
+		return "", "", 0, false
+	}
+
+	roll := rng.Float64()
+	switch {
+	case roll < g.config.SymlinkProb:
+		target, ok = g.symlinkTarget(rng, level, siblings)
+		return NodeTypeSymlink, target, int64(len(target)), ok
+	case roll < g.config.SymlinkProb+g.config.HardlinkProb:
+		// Hard links need a regular file to share content with
+		files := siblingsOfType(siblings, NodeTypeFile)
+		if len(files) == 0 {
+			return "", "", 0, false
+		}
+		file := files[rng.Intn(len(files))]
+		return NodeTypeHardlink, file.ID, file.Size, true
+	case roll < g.config.SymlinkProb+g.config.HardlinkProb+g.config.ShortcutProb:
+		if rng.Float64() < g.config.BrokenProb {
+			return NodeTypeShortcut, randomUUID(rng), 0, true
+		}
+		targets := append(siblingsOfType(siblings, NodeTypeFolder), siblingsOfType(siblings, NodeTypeFile)...)
+		if len(targets) == 0 {
+			return "", "", 0, false
+		}
+		return NodeTypeShortcut, targets[rng.Intn(len(targets))].ID, 0, true
+	default:
+		return "", "", 0, false
+	}
+}
+
+// symlinkTarget picks a relative target: a sibling, a missing path, or "." / "../.." style cycles
+func (g *LinkGenerator) symlinkTarget(rng *rand.Rand, level int, siblings []dbTypes.Node) (string, bool) {
+	roll := rng.Float64()
+	switch {
+	case roll < g.config.BrokenProb:
+		return fmt.Sprintf("missing_%d/%s", rng.Intn(1000), pick(rng, []string{"file.txt", "data.bin", "gone"})), true
+	case roll < g.config.BrokenProb+g.config.CycleProb:
+		// Up to the table root: the link's own folder (".") or one of its ancestors
+		up := rng.Intn(level + 1)
+		if up == 0 {
+			return ".", true
+		}
+		return strings.TrimSuffix(strings.Repeat("../", up), "/"), true
+	default:
+		targets := make([]dbTypes.Node, 0, len(siblings))
+		for _, sibling := range siblings {
+			if sibling.Type == NodeTypeFile || sibling.Type == NodeTypeFolder {
+				targets = append(targets, sibling)
+			}
+		}
+		if len(targets) == 0 {
+			return "", false
+		}
+		return targets[rng.Intn(len(targets))].Name, true
+	}
+}
+
+// nullableTarget stores an empty target as NULL
+func nullableTarget(target string) any {
+	if target == "" {
+		return nil
+	}
+	return target
+}
+
+// nullableSeed stores an unset content seed as NULL
+func nullableSeed(seed int64) any {
+	if seed == 0 {
+		return nil
+	}
+	return seed
+}
+
+// linkedContentSeed returns the content seed a new link node stores: hard links share their
+// target sibling's content, other links have none
+func linkedContentSeed(nodeType string, target string, siblings []dbTypes.Node) int64 {
+	if nodeType != NodeTypeHardlink {
+		return 0
+	}
+	for _, sibling := range siblings {
+		if sibling.ID == target {
+			return sibling.ContentSeed
+		}
+	}
+	return 0
+}
+
+func siblingsOfType(siblings []dbTypes.Node, nodeType string) []dbTypes.Node {
+	matches := make([]dbTypes.Node, 0, len(siblings))
+	for _, sibling := range siblings {
+		if sibling.Type == nodeType {
+			matches = append(matches, sibling)
+		}
+	}
+	return matches
+}
+
+// randomUUID draws a UUID from rng (used for shortcut targets that don't exist)
+func randomUUID(rng *rand.Rand) string {
+	var b [16]byte
+	rng.Read(b[:])
+	id, _ := uuid.FromBytes(b[:])
+	return id.String()
+}
@@ -0,0 +1,125 @@
+package tables
+
+import (
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/Voltaic314/GhostFS/code/db"
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+)
+
+// LockConfig starts a seeded fraction of the primary table's files locked, like checked-out
+// SharePoint files or Office documents someone has open. Seeded locks are held until released.
+type LockConfig struct {
+	Prob   float64  `json:"prob"`
+	Owners []string `json:"owners,omitempty"` // lock holders to pick from
+}
+
+// Validate checks the lock configuration
+func (c *LockConfig) Validate() error {
+	if c.Prob < 0 || c.Prob > 1 {
+		return fmt.Errorf("prob must be between 0.0 and 1.0")
+	}
+	return nil
+}
+
+// LocksTable stores the locks acquired or released through the API. A row overrides the node's
+// seeded lock; released locks are kept (with released set) so seeded ones stay released.
+type LocksTable struct{}
+
+func (t *LocksTable) Name() string {
+	return "locks"
+}
+
+func (t *LocksTable) Schema() string {
+	return `
+		table_name VARCHAR NOT NULL,
+		node_id VARCHAR NOT NULL,
+		owner VARCHAR NOT NULL,
+		acquired_at TIMESTAMP NOT NULL,
+		expires_at TIMESTAMP,
+		released BOOLEAN NOT NULL DEFAULT FALSE,
+		PRIMARY KEY (table_name, node_id)
+	`
+}
+
+// Init creates the locks table asynchronously.
+func (t *LocksTable) Init(db *db.DB) error {
+	done := make(chan error)
+	go func() {
+		done <- db.CreateTable(t.Name(), t.Schema())
+	}()
+	return <-done
+}
+
+// GenerateLock returns the seeded lock of a primary table file, or nil (always for files created through the API)
+func (dg *DeterministicGenerator) GenerateLock(node dbTypes.Node) *dbTypes.Lock {
+	config := dg.config.Locks
+	if config == nil || node.Type != NodeTypeFile || node.UserCreated {
+		return nil
+	}
+	rng := rand.New(rand.NewSource(generateDeterministicSeed(dg.masterSeed, "lock:"+node.ID)))
+	if rng.Float64() >= config.Prob {
+		return nil
+	}
+
+	owners := config.Owners
+	if len(owners) == 0 {
+		owners = defaultUsers
+	}
+	return &dbTypes.Lock{Owner: owners[rng.Intn(len(owners))], AcquiredAt: node.UpdatedAt}
+}
+
+// GetStoredLocks returns the lock rows of a table's nodes matching where (a condition on node_id),
+// by node ID. Released locks map to nil, nodes without a row aren't in the map.
+func GetStoredLocks(database *db.DB, tableName string, where string, params ...any) (map[string]*dbTypes.Lock, error) {
+	query := "SELECT node_id, owner, acquired_at, expires_at, released FROM locks WHERE table_name = ? AND " + where
+	rows, err := database.Query("locks", query, append([]any{tableName}, params...)...)
+	if err != nil {
+		return nil, fmt.Errorf("query locks of %s: %w", tableName, err)
+	}
+	defer rows.Close()
+
+	locks := make(map[string]*dbTypes.Lock)
+	for rows.Next() {
+		var nodeID string
+		var lock dbTypes.Lock
+		var expiresAt sql.NullTime
+		var released bool
+		if err := rows.Scan(&nodeID, &lock.Owner, &lock.AcquiredAt, &expiresAt, &released); err != nil {
+			return nil, fmt.Errorf("scan lock of %s: %w", tableName, err)
+		}
+		if released {
+			locks[nodeID] = nil
+			continue
+		}
+		if expiresAt.Valid {
+			lock.ExpiresAt = &expiresAt.Time
+		}
+		locks[nodeID] = &lock
+	}
+	return locks, rows.Err()
+}
+
+// LockQuery returns the statement that stores a node's lock (nil = released)
+func LockQuery(tableName string, nodeID string, lock *dbTypes.Lock, now time.Time) (string, []any) {
+	query := "INSERT OR REPLACE INTO locks (table_name, node_id, owner, acquired_at, expires_at, released) VALUES (?, ?, ?, ?, ?, ?)"
+	if lock == nil {
+		return query, []any{tableName, nodeID, "", now, nil, true}
+	}
+	var expiresAt any
+	if lock.ExpiresAt != nil {
+		expiresAt = *lock.ExpiresAt
+	}
+	return query, []any{tableName, nodeID, lock.Owner, lock.AcquiredAt, expiresAt, false}
+}
+
+// ActiveLock returns the lock unless it expired
+func ActiveLock(lock *dbTypes.Lock, now time.Time) *dbTypes.Lock {
+	if lock == nil || (lock.ExpiresAt != nil && !lock.ExpiresAt.After(now)) {
+		return nil
+	}
+	return lock
+}
@@ -0,0 +1,104 @@
+package tables
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"unicode"
+
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// NameCollisionConfig makes some file names variants of an earlier sibling's name that only differ
+// by case (Report.pdf / report.PDF) or by Unicode normalization form (NFC / NFD). They are distinct
+// names on a case-sensitive, normalization-sensitive source but collide on Windows, OneDrive or macOS.
+type NameCollisionConfig struct {
+	CaseProb          float64 `json:"case_prob,omitempty"`
+	NormalizationProb float64 `json:"normalization_prob,omitempty"` // needs names with accented characters (e.g. the unicode naming profile)
+}
+
+// Validate checks the collision probabilities
+func (c *NameCollisionConfig) Validate() error {
+	if c.CaseProb < 0 || c.NormalizationProb < 0 || c.CaseProb+c.NormalizationProb > 1 {
+		return fmt.Errorf("case_prob and normalization_prob must be between 0.0 and 1.0 and add up to at most 1.0")
+	}
+	return nil
+}
+
+// CollidingName rolls whether a new file gets a variant of an earlier sibling file's name. The variant
+// is reserved in used. Returns ok=false to keep the regular name.
+func (c *NameCollisionConfig) CollidingName(rng *rand.Rand, siblings []dbTypes.Node, used map[string]struct{}) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	files := siblingsOfType(siblings, NodeTypeFile)
+	if len(files) == 0 {
+		return "", false
+	}
+
+	roll := rng.Float64()
+	original := files[rng.Intn(len(files))].Name
+	variant := ""
+	switch {
+	case roll < c.CaseProb:
+		variant = caseVariant(rng, original)
+	case roll < c.CaseProb+c.NormalizationProb:
+		// Names that are already NFC get decomposed, decomposed names get composed
+		if variant = norm.NFD.String(original); variant == original {
+			variant = norm.NFC.String(original)
+		}
+	default:
+		return "", false
+	}
+
+	if _, taken := used[variant]; taken || variant == original {
+		return "", false
+	}
+	used[variant] = struct{}{}
+	return variant, true
+}
+
+// caseVariant changes the case of a name: all upper, all lower or every letter flipped
+func caseVariant(rng *rand.Rand, name string) string {
+	switch rng.Intn(3) {
+	case 0:
+		if upper := strings.ToUpper(name); upper != name {
+			return upper
+		}
+		return strings.ToLower(name)
+	case 1:
+		if lower := strings.ToLower(name); lower != name {
+			return lower
+		}
+		return strings.ToUpper(name)
+	default:
+		// Flip the case of every letter, e.g. "Report.pdf" -> "rEPORT.PDF"
+		return strings.Map(func(r rune) rune {
+			if unicode.IsUpper(r) {
+				return unicode.ToLower(r)
+			}
+			return unicode.ToUpper(r)
+		}, name)
+	}
+}
+
+// NamePolicy describes how a table compares sibling names
+type NamePolicy struct {
+	CaseInsensitive          bool `json:"case_insensitive,omitempty"`          // Windows, OneDrive, SharePoint, default macOS
+	NormalizationInsensitive bool `json:"normalization_insensitive,omitempty"` // macOS (APFS/HFS+)
+}
+
+var caseFolder = cases.Fold()
+
+// NameKey returns the form of a name used to detect sibling collisions under this policy
+func (p NamePolicy) NameKey(name string) string {
+	if p.NormalizationInsensitive {
+		name = norm.NFC.String(name)
+	}
+	if p.CaseInsensitive {
+		name = caseFolder.String(name)
+	}
+	return name
+}
@@ -0,0 +1,302 @@
+package tables
+
+import (
+	"fmt"
+	"math/rand"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Naming profile identifiers used as keys in PrimaryTableConfig.NamingProfiles
+const (
+	NamingDefault = "default" // folder_N / file_N.txt
+	NamingOffice  = "office"  // office documents and department folders
+	NamingSource  = "source"  // source trees (src/, main.go, package.json, ...)
+	NamingPhotos  = "photos"  // photo libraries with camera / EXIF-style names
+	NamingUnicode = "unicode" // accented, CJK, RTL and emoji names
+	NamingPadded  = "padded"  // leading/trailing spaces and dots
+	NamingLong    = "long"    // very long names (100-255 characters)
+	NamingIllegal = "illegal" // names illegal on Windows or SharePoint
+)
+
+// namingProfile produces folder and file names for a single profile
+type namingProfile struct {
+	folderName func(rng *rand.Rand, index int) string
+	fileName   func(rng *rand.Rand, index int) string
+}
+
+var namingProfiles = map[string]namingProfile{
+	NamingDefault: {
+		folderName: func(rng *rand.Rand, index int) string { return fmt.Sprintf("folder_%d", index) },
+		fileName:   func(rng *rand.Rand, index int) string { return fmt.Sprintf("file_%d.txt", index) },
+	},
+	NamingOffice: {
+		folderName: func(rng *rand.Rand, index int) string {
+			return pick(rng, officeFolders) + pick(rng, []string{"", "", " " + year(rng), " (old)", " - Copy"})
+		},
+		fileName: func(rng *rand.Rand, index int) string {
+			name := pick(rng, officeWords) + " " + pick(rng, officeWords)
+			switch rng.Intn(4) {
+			case 0:
+				name += " " + year(rng)
+			case 1:
+				name += fmt.Sprintf(" v%d", 1+rng.Intn(9))
+			case 2:
+				name += " - " + date(rng)
+			}
+			return name + pick(rng, officeExtensions)
+		},
+	},
+	NamingSource: {
+		folderName: func(rng *rand.Rand, index int) string { return pick(rng, sourceFolders) },
+		fileName: func(rng *rand.Rand, index int) string {
+			if rng.Intn(4) == 0 {
+				return pick(rng, sourceWellKnownFiles)
+			}
+			return pick(rng, sourceWords) + pick(rng, []string{"", "_" + pick(rng, sourceWords), "_test", fmt.Sprintf("%d", rng.Intn(100))}) + pick(rng, sourceExtensions)
+		},
+	},
+	NamingPhotos: {
+		folderName: func(rng *rand.Rand, index int) string {
+			switch rng.Intn(4) {
+			case 0:
+				return year(rng)
+			case 1:
+				return fmt.Sprintf("%s-%02d %s", year(rng), 1+rng.Intn(12), pick(rng, photoEvents))
+			case 2:
+				return fmt.Sprintf("%d%s", 100+rng.Intn(900), pick(rng, []string{"CANON", "NIKON", "APPLE", "_FUJI"}))
+			default:
+				return pick(rng, []string{"DCIM", "Camera Roll", "Screenshots", "WhatsApp Images", "Exports"})
+			}
+		},
+		fileName: func(rng *rand.Rand, index int) string {
+			switch rng.Intn(5) {
+			case 0:
+				return fmt.Sprintf("IMG_%04d%s", rng.Intn(10000), pick(rng, []string{".JPG", ".HEIC", ".jpg", ".PNG"}))
+			case 1:
+				return fmt.Sprintf("DSC%05d%s", rng.Intn(100000), pick(rng, []string{".JPG", ".ARW", ".NEF"}))
+			case 2:
+				return fmt.Sprintf("PXL_%s_%s%03d.jpg", compactDate(rng), compactTime(rng), rng.Intn(1000))
+			case 3:
+				return fmt.Sprintf("%s_%s%s", compactDate(rng), compactTime(rng), pick(rng, []string{".jpg", ".heic", ".mp4"}))
+			default:
+				return fmt.Sprintf("Screenshot %s at %02d.%02d.%02d.png", date(rng), rng.Intn(24), rng.Intn(60), rng.Intn(60))
+			}
+		},
+	},
+	NamingUnicode: {
+		folderName: func(rng *rand.Rand, index int) string { return pick(rng, unicodeFolders) },
+		fileName: func(rng *rand.Rand, index int) string {
+			return pick(rng, unicodeWords) + pick(rng, []string{"", " " + pick(rng, unicodeWords), " " + pick(rng, emoji)}) + pick(rng, []string{".txt", ".docx", ".pdf", ".jpg"})
+		},
+	},
+	NamingPadded: {
+		folderName: func(rng *rand.Rand, index int) string {
+			return pad(rng, fmt.Sprintf("folder_%d", index))
+		},
+		fileName: func(rng *rand.Rand, index int) string {
+			base := fmt.Sprintf("file_%d", index)
+			switch rng.Intn(3) {
+			case 0:
+				return pad(rng, base+".txt")
+			case 1:
+				return base + pick(rng, []string{" .txt", ". .txt", "..txt"})
+			default:
+				return pick(rng, []string{".", "..."}) + base
+			}
+		},
+	},
+	NamingLong: {
+		folderName: func(rng *rand.Rand, index int) string { return longName(rng, "") },
+		fileName:   func(rng *rand.Rand, index int) string { return longName(rng, pick(rng, officeExtensions)) },
+	},
+	NamingIllegal: {
+		folderName: func(rng *rand.Rand, index int) string {
+			if rng.Intn(2) == 0 {
+				return pick(rng, reservedNames)
+			}
+			return fmt.Sprintf("folder%s%d", pick(rng, illegalChars), index)
+		},
+		fileName: func(rng *rand.Rand, index int) string {
+			switch rng.Intn(4) {
+			case 0:
+				return pick(rng, reservedNames) + pick(rng, []string{"", ".txt"})
+			case 1:
+				return pick(rng, illegalFiles)
+			case 2:
+				return fmt.Sprintf("~$file_%d.docx", index)
+			default:
+				return fmt.Sprintf("file%s%d.txt", pick(rng, illegalChars), index)
+			}
+		},
+	},
+}
+
+var (
+	officeFolders        = []string{"Finance", "HR", "Legal", "Marketing", "Projects", "Shared", "Clients", "Archive", "Meeting Notes", "Templates", "Contracts", "Invoices", "Board", "Sales", "Operations"}
+	officeWords          = []string{"Budget", "Report", "Invoice", "Proposal", "Minutes", "Forecast", "Roadmap", "Contract", "Summary", "Presentation", "Plan", "Review", "Draft", "Final", "Quarterly"}
+	officeExtensions     = []string{".docx", ".xlsx", ".pptx", ".pdf", ".doc", ".xls", ".csv", ".txt", ".msg", ".vsdx"}
+	sourceFolders        = []string{"src", "lib", "pkg", "cmd", "internal", "test", "tests", "docs", "scripts", "build", "dist", "vendor", "node_modules", ".git", ".github", "assets", "config", "api"}
+	sourceWords          = []string{"main", "index", "util", "handler", "server", "client", "config", "model", "service", "router", "types", "helpers", "db", "auth"}
+	sourceExtensions     = []string{".go", ".js", ".ts", ".py", ".rs", ".java", ".c", ".h", ".cpp", ".json", ".yaml", ".md", ".sh", ".css", ".html"}
+	sourceWellKnownFiles = []string{"README.md", "LICENSE", "Makefile", "Dockerfile", ".gitignore", ".editorconfig", "package.json", "go.mod", "go.sum", "Cargo.toml", "requirements.txt", "setup.py", ".env.example"}
+	photoEvents          = []string{"Vacation", "Birthday", "Wedding", "Hiking", "Christmas", "Graduation", "Beach", "Family"}
+	unicodeFolders       = []string{"Résumés", "Données", "日本語フォルダ", "文件夹", "Ελληνικά", "Русский", "مستندات", "עברית", "📁 Projects", "Ünïcödé", "Straße", "한국어", "Café ☕"}
+	unicodeWords         = []string{"résumé", "naïve", "café", "façade", "日本語", "报告", "Ωmega", "Привет", "مرحبا", "שלום", "emoji", "São Paulo", "Zürich", "Kraków", "dođi"}
+	emoji                = []string{"🎉", "📄", "🚀", "👻", "✅", "🔥", "❤️", "👍🏽", "🇺🇸", "👨‍👩‍👧"}
+	reservedNames        = []string{"CON", "PRN", "AUX", "NUL", "COM1", "COM9", "LPT1", "LPT9", "desktop.ini", "Thumbs.db", ".lock", "_vti_test", "forms"}
+	illegalChars         = []string{"<", ">", ":", "\"", "\\", "|", "?", "*", "#", "%", "\t"}
+	illegalFiles         = []string{"desktop.ini", "Thumbs.db", ".DS_Store", "file.tmp", "~lock.file#", "report.", "report ", "CON.txt", "aux.log", "name\\with\\backslash.txt"}
+)
+
+// NameGenerator picks names for generated children according to weighted naming profiles
+type NameGenerator struct {
+	profiles   []string
+	cumulative []float64
+	total      float64
+}
+
+// NewNameGenerator creates a name generator from a profile -> weight map.
+// An empty map selects the default folder_N / file_N.txt naming.
+func NewNameGenerator(weights map[string]float64) (*NameGenerator, error) {
+	if err := ValidateNamingProfiles(weights); err != nil {
+		return nil, err
+	}
+
+	ng := &NameGenerator{}
+	if len(weights) == 0 {
+		weights = map[string]float64{NamingDefault: 1}
+	}
+
+	// Sort profile names so the weighted pick doesn't depend on map iteration order
+	names := make([]string, 0, len(weights))
+	for name, weight := range weights {
+		if weight > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		ng.total += weights[name]
+		ng.profiles = append(ng.profiles, name)
+		ng.cumulative = append(ng.cumulative, ng.total)
+	}
+
+	return ng, nil
+}
+
+// ValidateNamingProfiles checks that every configured profile exists and has a sane weight
+func ValidateNamingProfiles(weights map[string]float64) error {
+	var positive bool
+	for name, weight := range weights {
+		if _, ok := namingProfiles[name]; !ok {
+			return fmt.Errorf("unknown naming profile: %s", name)
+		}
+		if weight < 0 {
+			return fmt.Errorf("naming profile %s weight must not be negative", name)
+		}
+		if weight > 0 {
+			positive = true
+		}
+	}
+	if len(weights) > 0 && !positive {
+		return fmt.Errorf("at least one naming profile must have a positive weight")
+	}
+	return nil
+}
+
+// FolderName returns the name for the index-th child folder
+func (ng *NameGenerator) FolderName(rng *rand.Rand, index int) string {
+	return namingProfiles[ng.pickProfile(rng)].folderName(rng, index)
+}
+
+// FileName returns the name for the index-th child file
+func (ng *NameGenerator) FileName(rng *rand.Rand, index int) string {
+	return namingProfiles[ng.pickProfile(rng)].fileName(rng, index)
+}
+
+// pickProfile selects a profile by weight. With a single profile no randomness is consumed,
+// so the default configuration keeps the exact same RNG sequence as before profiles existed.
+func (ng *NameGenerator) pickProfile(rng *rand.Rand) string {
+	if len(ng.profiles) == 1 {
+		return ng.profiles[0]
+	}
+	roll := rng.Float64() * ng.total
+	for i, cumulative := range ng.cumulative {
+		if roll < cumulative {
+			return ng.profiles[i]
+		}
+	}
+	return ng.profiles[len(ng.profiles)-1]
+}
+
+// UniqueName makes name unique among its siblings by appending " (N)" before the extension
+func UniqueName(name string, used map[string]struct{}) string {
+	if _, taken := used[name]; !taken {
+		used[name] = struct{}{}
+		return name
+	}
+
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	if base == "" {
+		// Names like ".gitignore" have no base, keep the suffix after the dot
+		base, ext = name, ""
+	}
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s (%d)%s", base, n, ext)
+		if _, taken := used[candidate]; !taken {
+			used[candidate] = struct{}{}
+			return candidate
+		}
+	}
+}
+
+func pick(rng *rand.Rand, options []string) string {
+	return options[rng.Intn(len(options))]
+}
+
+func year(rng *rand.Rand) string {
+	return fmt.Sprintf("%d", 2005+rng.Intn(20))
+}
+
+func date(rng *rand.Rand) string {
+	return fmt.Sprintf("%s-%02d-%02d", year(rng), 1+rng.Intn(12), 1+rng.Intn(28))
+}
+
+func compactDate(rng *rand.Rand) string {
+	return fmt.Sprintf("%s%02d%02d", year(rng), 1+rng.Intn(12), 1+rng.Intn(28))
+}
+
+func compactTime(rng *rand.Rand) string {
+	return fmt.Sprintf("%02d%02d%02d", rng.Intn(24), rng.Intn(60), rng.Intn(60))
+}
+
+// pad surrounds a name with leading/trailing spaces and dots
+func pad(rng *rand.Rand, name string) string {
+	switch rng.Intn(4) {
+	case 0:
+		return " " + name
+	case 1:
+		return name + " "
+	case 2:
+		return name + "."
+	default:
+		return " " + name + " ."
+	}
+}
+
+// longName builds a name between 100 and 255 characters long (including the extension)
+func longName(rng *rand.Rand, ext string) string {
+	target := 100 + rng.Intn(156) - len(ext)
+	var sb strings.Builder
+	for sb.Len() < target {
+		if sb.Len() > 0 {
+			sb.WriteString(pick(rng, []string{" ", "_", "-"}))
+		}
+		sb.WriteString(pick(rng, officeWords))
+	}
+	return sb.String()[:target] + ext
+}
@@ -0,0 +1,122 @@
+package tables
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Voltaic314/GhostFS/code/db"
+)
+
+// addedNodeColumns are the columns nodes tables gained after the first release, as ALTER TABLE can
+// add them (DuckDB can't add NOT NULL columns, their DEFAULT fills in the existing rows)
+var addedNodeColumns = []string{
+	"target VARCHAR",
+	"content_seed BIGINT",
+	"materialized BOOLEAN DEFAULT FALSE",
+	"user_created BOOLEAN DEFAULT FALSE",
+}
+
+// MigrateNodeTables brings the nodes tables of a database seeded by an older version up to the
+// current schema: the given tables and any registered in table_id_lookup (e.g. imported tables).
+// Tables that don't exist yet are skipped.
+func MigrateNodeTables(database *db.DB, tableNames []string) error {
+	names := append([]string{}, tableNames...)
+	// The lookup table doesn't exist before the database is seeded
+	if mappings, err := GetAllTableMappings(database); err == nil {
+		for _, tableName := range mappings {
+			names = append(names, tableName)
+		}
+	}
+
+	migrated := make(map[string]bool)
+	for _, tableName := range names {
+		if migrated[tableName] {
+			continue
+		}
+		migrated[tableName] = true
+		if err := migrateNodesTable(database, tableName); err != nil {
+			return fmt.Errorf("migrate table %s: %w", tableName, err)
+		}
+	}
+	return nil
+}
+
+func migrateNodesTable(database *db.DB, tableName string) error {
+	columns, err := tableColumns(database, tableName)
+	if err != nil || len(columns) == 0 {
+		return err
+	}
+
+	// The type CHECK can't be altered, tables from before link nodes are rebuilt
+	var checks string
+	query := "SELECT COALESCE(string_agg(expression, ' '), '') FROM duckdb_constraints() WHERE table_name = ? AND constraint_type = 'CHECK'"
+	if err := database.QueryRow(query, tableName).Scan(&checks); err != nil {
+		return fmt.Errorf("query constraints: %w", err)
+	}
+	if !strings.Contains(checks, "'"+NodeTypeSymlink+"'") {
+		if err := rebuildNodesTable(database, tableName, columns); err != nil {
+			return err
+		}
+		fmt.Printf("🔧 Rebuilt table %s with the current node types\n", tableName)
+	}
+
+	for _, column := range addedNodeColumns {
+		if _, err := database.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s", tableName, column)); err != nil {
+			return fmt.Errorf("add column %s: %w", column, err)
+		}
+	}
+	return nil
+}
+
+// rebuildNodesTable recreates a nodes table with the current schema, keeping the rows and the
+// columns it already had
+func rebuildNodesTable(database *db.DB, tableName string, columns map[string]bool) error {
+	rebuilt := tableName + "_migrated"
+	if err := database.DropTable(rebuilt); err != nil {
+		return fmt.Errorf("drop table %s: %w", rebuilt, err)
+	}
+	if err := database.CreateTable(rebuilt, NewNodesTable(rebuilt).Schema()); err != nil {
+		return fmt.Errorf("create table %s: %w", rebuilt, err)
+	}
+	newColumns, err := tableColumns(database, rebuilt)
+	if err != nil {
+		return err
+	}
+
+	var kept []string
+	for column := range columns {
+		if newColumns[column] {
+			kept = append(kept, column)
+		}
+	}
+	columnList := strings.Join(kept, ", ")
+	queries := []string{
+		fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s", rebuilt, columnList, columnList, tableName),
+		fmt.Sprintf("DROP TABLE %s", tableName),
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s", rebuilt, tableName),
+	}
+	params := make([][]any, len(queries))
+	if err := database.WriteBatch(map[string][]string{tableName: queries}, map[string][][]any{tableName: params}); err != nil {
+		return fmt.Errorf("copy rows: %w", err)
+	}
+	return nil
+}
+
+// tableColumns returns the column names of a table, none if it doesn't exist
+func tableColumns(database *db.DB, tableName string) (map[string]bool, error) {
+	rows, err := database.Query("", "SELECT column_name FROM duckdb_columns() WHERE table_name = ?", tableName)
+	if err != nil {
+		return nil, fmt.Errorf("query columns of %s: %w", tableName, err)
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return nil, fmt.Errorf("scan column of %s: %w", tableName, err)
+		}
+		columns[column] = true
+	}
+	return columns, rows.Err()
+}
@@ -0,0 +1,104 @@
+package tables
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/Voltaic314/GhostFS/code/db"
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+)
+
+// nodeColumns is the column list scanned by scanNode
+const nodeColumns = "id, parent_id, name, path, type, COALESCE(target, ''), COALESCE(content_seed, 0), size, level, checked, materialized, user_created, created_at, updated_at"
+
+// GetChildren returns the children of a folder as stored in the database, ordered by type and name.
+// Used for tables whose contents are materialized rather than generated (e.g. imported tables).
+func GetChildren(database *db.DB, tableName string, parentID string, foldersOnly bool) ([]dbTypes.Node, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE parent_id = ?", nodeColumns, tableName)
+	if foldersOnly {
+		query += " AND type = 'folder'"
+	}
+	query += " ORDER BY type DESC, name"
+
+	rows, err := database.Query(tableName, query, parentID)
+	if err != nil {
+		return nil, fmt.Errorf("query children of %s: %w", parentID, err)
+	}
+	defer rows.Close()
+
+	children := make([]dbTypes.Node, 0)
+	for rows.Next() {
+		node, err := scanNode(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan child of %s: %w", parentID, err)
+		}
+		children = append(children, *node)
+	}
+
+	return children, rows.Err()
+}
+
+// GetDescendants returns the stored descendants of a folder, optionally only those of one type
+func GetDescendants(database *db.DB, tableName string, folderPath string, nodeType string) ([]dbTypes.Node, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE starts_with(path, ?)", nodeColumns, tableName)
+	params := []any{buildPath(folderPath, "")}
+	if nodeType != "" {
+		query += " AND type = ?"
+		params = append(params, nodeType)
+	}
+
+	rows, err := database.Query(tableName, query, params...)
+	if err != nil {
+		return nil, fmt.Errorf("query descendants of %s: %w", folderPath, err)
+	}
+	defer rows.Close()
+
+	descendants := make([]dbTypes.Node, 0)
+	for rows.Next() {
+		node, err := scanNode(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan descendant of %s: %w", folderPath, err)
+		}
+		descendants = append(descendants, *node)
+	}
+
+	return descendants, rows.Err()
+}
+
+// GetNode returns a single node by ID
+func GetNode(database *db.DB, tableName string, nodeID string) (*dbTypes.Node, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE id = ? LIMIT 1", nodeColumns, tableName)
+
+	// Query (rather than QueryRow) flushes queued writes first, so freshly generated nodes are visible
+	rows, err := database.Query(tableName, query, nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("query node %s: %w", nodeID, err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("query node %s: %w", nodeID, err)
+		}
+		return nil, fmt.Errorf("node %s: %w", nodeID, sql.ErrNoRows)
+	}
+
+	node, err := scanNode(rows)
+	if err != nil {
+		return nil, fmt.Errorf("scan node %s: %w", nodeID, err)
+	}
+	return node, nil
+}
+
+// scanNode scans a row selected with nodeColumns, followed by any extra columns into extra
+func scanNode(rows *sql.Rows, extra ...any) (*dbTypes.Node, error) {
+	var node dbTypes.Node
+	dest := []any{
+		&node.ID, &node.ParentID, &node.Name, &node.Path, &node.Type, &node.Target, &node.ContentSeed,
+		&node.Size, &node.Level, &node.Checked, &node.Materialized, &node.UserCreated, &node.CreatedAt, &node.UpdatedAt}
+	err := rows.Scan(append(dest, extra...)...)
+	if err != nil {
+		return nil, err
+	}
+	return &node, nil
+}
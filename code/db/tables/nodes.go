@@ -26,12 +26,16 @@ func (t *NodesTable) Schema() string {
 		parent_id VARCHAR NOT NULL,
 		name VARCHAR NOT NULL,
 		path VARCHAR NOT NULL,
-		type VARCHAR NOT NULL CHECK(type IN ('file', 'folder')),
+		type VARCHAR NOT NULL CHECK(type IN ('file', 'folder', 'symlink', 'hardlink', 'shortcut')),
+		target VARCHAR,
 		size BIGINT,
 		level INTEGER NOT NULL,
 		checked BOOLEAN NOT NULL DEFAULT FALSE,
 		secondary_existence_map JSON,
 		child_seed BIGINT,
+		content_seed BIGINT,
+		materialized BOOLEAN NOT NULL DEFAULT FALSE,
+		user_created BOOLEAN NOT NULL DEFAULT FALSE,
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 	`
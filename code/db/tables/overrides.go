@@ -0,0 +1,126 @@
+package tables
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// GenerationOverride replaces fan-out settings for folders matching a path glob and/or level range.
+// Levels refer to the folder being expanded (root = 0), so {"min_level": 6, "max_child_folders": 0}
+// makes every folder at level 6 and deeper file-only.
+type GenerationOverride struct {
+	Path     string `json:"path,omitempty"`      // glob on the folder path; * matches within a segment, ** matches any number of segments
+	MinLevel *int   `json:"min_level,omitempty"` // inclusive
+	MaxLevel *int   `json:"max_level,omitempty"` // inclusive
+
+	MinChildFolders *int `json:"min_child_folders,omitempty"`
+	MaxChildFolders *int `json:"max_child_folders,omitempty"`
+	MinChildFiles   *int `json:"min_child_files,omitempty"`
+	MaxChildFiles   *int `json:"max_child_files,omitempty"`
+}
+
+// Validate checks the override's selector and values
+func (o *GenerationOverride) Validate() error {
+	if o.Path == "" && o.MinLevel == nil && o.MaxLevel == nil {
+		return fmt.Errorf("override needs a path or a level range")
+	}
+	if o.Path != "" {
+		if !strings.HasPrefix(o.Path, "/") {
+			return fmt.Errorf("override path %q must start with /", o.Path)
+		}
+		for _, segment := range splitPath(o.Path) {
+			if _, err := path.Match(segment, ""); err != nil {
+				return fmt.Errorf("override path %q: %w", o.Path, err)
+			}
+		}
+	}
+	if o.MinLevel != nil && o.MaxLevel != nil && *o.MaxLevel < *o.MinLevel {
+		return fmt.Errorf("override level range is empty: min=%d, max=%d", *o.MinLevel, *o.MaxLevel)
+	}
+	for _, value := range []*int{o.MinChildFolders, o.MaxChildFolders, o.MinChildFiles, o.MaxChildFiles} {
+		if value != nil && *value < 0 {
+			return fmt.Errorf("override child counts must not be negative")
+		}
+	}
+	return nil
+}
+
+// Matches returns true if the override applies to the folder at folderPath / level
+func (o *GenerationOverride) Matches(folderPath string, level int) bool {
+	if o.MinLevel != nil && level < *o.MinLevel {
+		return false
+	}
+	if o.MaxLevel != nil && level > *o.MaxLevel {
+		return false
+	}
+	if o.Path != "" && !matchGlob(splitPath(o.Path), splitPath(folderPath)) {
+		return false
+	}
+	return true
+}
+
+// Apply copies the override's values onto config
+func (o *GenerationOverride) Apply(config *PrimaryTableConfig) {
+	if o.MinChildFolders != nil {
+		config.MinChildFolders = *o.MinChildFolders
+	}
+	if o.MaxChildFolders != nil {
+		config.MaxChildFolders = *o.MaxChildFolders
+	}
+	if o.MinChildFiles != nil {
+		config.MinChildFiles = *o.MinChildFiles
+	}
+	if o.MaxChildFiles != nil {
+		config.MaxChildFiles = *o.MaxChildFiles
+	}
+
+	// Keep the ranges usable when an override only sets one side
+	if config.MaxChildFolders < config.MinChildFolders {
+		if o.MaxChildFolders != nil {
+			config.MinChildFolders = config.MaxChildFolders
+		} else {
+			config.MaxChildFolders = config.MinChildFolders
+		}
+	}
+	if config.MaxChildFiles < config.MinChildFiles {
+		if o.MaxChildFiles != nil {
+			config.MinChildFiles = config.MaxChildFiles
+		} else {
+			config.MaxChildFiles = config.MinChildFiles
+		}
+	}
+}
+
+// splitPath splits a slash separated path into its non-empty segments
+func splitPath(p string) []string {
+	var segments []string
+	for _, segment := range strings.Split(p, "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+// matchGlob matches path segments against pattern segments, where ** matches zero or more segments
+func matchGlob(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchGlob(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+		return false
+	}
+	return matchGlob(pattern[1:], segments[1:])
+}
@@ -0,0 +1,202 @@
+package tables
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Voltaic314/GhostFS/code/db"
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+)
+
+// RevisionConfig gives a fraction of files a seeded version history. Histories are generated on
+// demand and only stored once they change (e.g. a revision is restored).
+type RevisionConfig struct {
+	Prob         float64  `json:"prob"`                    // fraction of files with older versions
+	MinRevisions int      `json:"min_revisions,omitempty"` // older versions per file with history (default 1)
+	MaxRevisions int      `json:"max_revisions,omitempty"` // (default 5)
+	Authors      []string `json:"authors,omitempty"`       // modifiers to pick from
+}
+
+const (
+	defaultMinRevisions = 1
+	defaultMaxRevisions = 5
+)
+
+// defaultUsers are the people seeded revisions, locks and sharing refer to
+var defaultUsers = []string{"alice@example.com", "bob@example.com", "carol@example.com", "dave@example.com"}
+
+// Validate checks the revision configuration
+func (c *RevisionConfig) Validate() error {
+	if c.Prob < 0 || c.Prob > 1 {
+		return fmt.Errorf("prob must be between 0.0 and 1.0")
+	}
+	if c.MinRevisions < 0 || c.MaxRevisions < 0 {
+		return fmt.Errorf("min_revisions and max_revisions must not be negative")
+	}
+	if c.MaxRevisions > 0 && c.MaxRevisions < c.MinRevisions {
+		return fmt.Errorf("max_revisions must be at least min_revisions")
+	}
+	return nil
+}
+
+func (c *RevisionConfig) revisionRange() (int, int) {
+	minRevisions, maxRevisions := c.MinRevisions, c.MaxRevisions
+	if minRevisions == 0 {
+		minRevisions = defaultMinRevisions
+	}
+	if maxRevisions == 0 {
+		maxRevisions = defaultMaxRevisions
+	}
+	if maxRevisions < minRevisions {
+		maxRevisions = minRevisions
+	}
+	return minRevisions, maxRevisions
+}
+
+// RevisionsTable stores the version histories that changed from their generated form
+type RevisionsTable struct{}
+
+func (t *RevisionsTable) Name() string {
+	return "revisions"
+}
+
+func (t *RevisionsTable) Schema() string {
+	return `
+		table_name VARCHAR NOT NULL,
+		node_id VARCHAR NOT NULL,
+		number INTEGER NOT NULL,
+		size BIGINT NOT NULL,
+		content_seed BIGINT NOT NULL,
+		modified_at TIMESTAMP NOT NULL,
+		author VARCHAR NOT NULL,
+		PRIMARY KEY (table_name, node_id, number)
+	`
+}
+
+// Init creates the revisions table asynchronously.
+func (t *RevisionsTable) Init(db *db.DB) error {
+	done := make(chan error)
+	go func() {
+		done <- db.CreateTable(t.Name(), t.Schema())
+	}()
+	return <-done
+}
+
+// GenerateRevisions returns a file's seeded version history, oldest first. Files without history
+// (created through the API, or without a revisions config) have a single revision: their current content.
+func (dg *DeterministicGenerator) GenerateRevisions(node dbTypes.Node) []dbTypes.Revision {
+	if node.UserCreated {
+		return dg.SingleRevision(node, "")
+	}
+	rng := rand.New(rand.NewSource(generateDeterministicSeed(dg.masterSeed, "revisions:"+node.ID)))
+	config := dg.config.Revisions
+
+	authors := defaultUsers
+	older := 0
+	if config != nil {
+		if len(config.Authors) > 0 {
+			authors = config.Authors
+		}
+		if rng.Float64() < config.Prob {
+			minRevisions, maxRevisions := config.revisionRange()
+			older = minRevisions + rng.Intn(maxRevisions-minRevisions+1)
+		}
+	}
+
+	// Older versions were saved between the file's creation and its last modification
+	times := make([]time.Time, older)
+	window := node.UpdatedAt.Sub(node.CreatedAt)
+	for i := range times {
+		times[i] = node.CreatedAt
+		if window > 0 {
+			times[i] = node.CreatedAt.Add(time.Duration(rng.Int63n(int64(window))))
+		}
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+
+	revisions := make([]dbTypes.Revision, 0, older+1)
+	for i, modifiedAt := range times {
+		revisions = append(revisions, dbTypes.Revision{
+			NodeID:      node.ID,
+			Number:      i + 1,
+			Size:        dg.sizes.Sample(rng, node.Name),
+			ContentSeed: dg.RevisionContentSeed(node.ID, i+1),
+			ModifiedAt:  modifiedAt,
+			Author:      authors[rng.Intn(len(authors))],
+		})
+	}
+	revisions = append(revisions, dbTypes.Revision{
+		NodeID:      node.ID,
+		Number:      older + 1,
+		Size:        node.Size,
+		ContentSeed: dg.ContentSeed(node),
+		ModifiedAt:  node.UpdatedAt,
+		Author:      authors[rng.Intn(len(authors))],
+	})
+	return finishRevisions(revisions)
+}
+
+// RevisionContentSeed returns the seed of the content a file has in a revision that doesn't reuse
+// the content of another one (seeded older versions and new uploads)
+func (dg *DeterministicGenerator) RevisionContentSeed(nodeID string, number int) int64 {
+	return generateDeterministicSeed(dg.masterSeed, fmt.Sprintf("revision:%s:%d", nodeID, number))
+}
+
+// SingleRevision returns the history of a file that was never changed: its current content.
+// Used for files the generator didn't make (imported, created or copied ones).
+func (dg *DeterministicGenerator) SingleRevision(node dbTypes.Node, author string) []dbTypes.Revision {
+	return finishRevisions([]dbTypes.Revision{{
+		NodeID:      node.ID,
+		Number:      1,
+		Size:        node.Size,
+		ContentSeed: dg.ContentSeed(node),
+		ModifiedAt:  node.UpdatedAt,
+		Author:      author,
+	}})
+}
+
+// GetStoredRevisions returns a file's stored version history, oldest first (empty if it was never changed)
+func GetStoredRevisions(database *db.DB, tableName string, nodeID string) ([]dbTypes.Revision, error) {
+	query := "SELECT node_id, number, size, content_seed, modified_at, author FROM revisions WHERE table_name = ? AND node_id = ? ORDER BY number"
+	rows, err := database.Query("revisions", query, tableName, nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("query revisions of %s: %w", nodeID, err)
+	}
+	defer rows.Close()
+
+	revisions := make([]dbTypes.Revision, 0)
+	for rows.Next() {
+		var revision dbTypes.Revision
+		if err := rows.Scan(&revision.NodeID, &revision.Number, &revision.Size, &revision.ContentSeed, &revision.ModifiedAt, &revision.Author); err != nil {
+			return nil, fmt.Errorf("scan revision of %s: %w", nodeID, err)
+		}
+		revisions = append(revisions, revision)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return finishRevisions(revisions), nil
+}
+
+// RevisionInsertQueries returns the statements that store revisions of a node
+func RevisionInsertQueries(tableName string, revisions []dbTypes.Revision) ([]string, [][]any) {
+	queries := make([]string, len(revisions))
+	params := make([][]any, len(revisions))
+	for i, revision := range revisions {
+		queries[i] = "INSERT OR REPLACE INTO revisions (table_name, node_id, number, size, content_seed, modified_at, author) VALUES (?, ?, ?, ?, ?, ?, ?)"
+		params[i] = []any{tableName, revision.NodeID, revision.Number, revision.Size, revision.ContentSeed, revision.ModifiedAt, revision.Author}
+	}
+	return queries, params
+}
+
+// finishRevisions fills in the IDs and marks the last revision as current
+func finishRevisions(revisions []dbTypes.Revision) []dbTypes.Revision {
+	for i := range revisions {
+		revisions[i].ID = strconv.Itoa(revisions[i].Number)
+		revisions[i].Current = i == len(revisions)-1
+	}
+	return revisions
+}
@@ -0,0 +1,28 @@
+package tables
+
+import "fmt"
+
+// S3Config enables the S3-compatible API. Requests are signed (AWS Signature Version 4) with a
+// single static key pair, like a local MinIO.
+type S3Config struct {
+	Enabled         bool   `json:"enabled"`
+	AccessKeyID     string `json:"access_key_id"`
+	SecretAccessKey string `json:"secret_access_key"`
+	Region          string `json:"region,omitempty"` // signing region and the buckets' location (default us-east-1)
+}
+
+// Validate checks the S3 configuration
+func (c *S3Config) Validate() error {
+	if c.Enabled && (c.AccessKeyID == "" || c.SecretAccessKey == "") {
+		return fmt.Errorf("access_key_id and secret_access_key are required")
+	}
+	return nil
+}
+
+// GetRegion returns the region requests are signed for and buckets are reported in
+func (c *S3Config) GetRegion() string {
+	if c.Region == "" {
+		return "us-east-1"
+	}
+	return c.Region
+}
@@ -0,0 +1,50 @@
+package tables
+
+import "fmt"
+
+// defaultSFTPPort is the port of the SFTP server unless configured (22 needs root)
+const defaultSFTPPort = 2022
+
+// SFTPConfig enables the embedded SFTP server. Every table is a top-level directory named after the
+// table, and users log in with a password or a public key.
+type SFTPConfig struct {
+	Enabled bool       `json:"enabled"`
+	Address string     `json:"address,omitempty"`  // default: the HTTP server's address
+	Port    int        `json:"port,omitempty"`     // default 2022
+	HostKey string     `json:"host_key,omitempty"` // path to a PEM private key (default: a new key every start)
+	Users   []SFTPUser `json:"users"`
+}
+
+// SFTPUser is an account of the SFTP server
+type SFTPUser struct {
+	Username       string   `json:"username"`
+	Password       string   `json:"password,omitempty"`
+	AuthorizedKeys []string `json:"authorized_keys,omitempty"` // public keys, one authorized_keys line each
+}
+
+// Validate checks the SFTP configuration
+func (c *SFTPConfig) Validate() error {
+	if c.Port < 0 || c.Port > 65535 {
+		return fmt.Errorf("invalid port %d", c.Port)
+	}
+	if c.Enabled && len(c.Users) == 0 {
+		return fmt.Errorf("at least one user is required")
+	}
+	for i, user := range c.Users {
+		if user.Username == "" {
+			return fmt.Errorf("user %d has no username", i)
+		}
+		if user.Password == "" && len(user.AuthorizedKeys) == 0 {
+			return fmt.Errorf("user %s needs a password or authorized_keys", user.Username)
+		}
+	}
+	return nil
+}
+
+// GetPort returns the port the SFTP server listens on
+func (c *SFTPConfig) GetPort() int {
+	if c.Port == 0 {
+		return defaultSFTPPort
+	}
+	return c.Port
+}
@@ -0,0 +1,221 @@
+package tables
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/Voltaic314/GhostFS/code/db"
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+)
+
+// Sharing roles and link scopes
+const (
+	RoleEditor    = "editor"
+	RoleCommenter = "commenter"
+	RoleViewer    = "viewer"
+
+	LinkScopeAnyone       = "anyone"
+	LinkScopeOrganization = "organization"
+)
+
+// sharedLinkBaseURL prefixes the URLs of shared links
+const sharedLinkBaseURL = "https://ghostfs.local/s/"
+
+// SharingConfig shares a seeded fraction of the primary table's folders and files with other people
+// and gives some items shared links, so permission migrations have something to move.
+type SharingConfig struct {
+	FolderProb   float64  `json:"folder_prob"`             // fraction of folders shared with members
+	FileProb     float64  `json:"file_prob"`               // fraction of files shared with members
+	LinkProb     float64  `json:"link_prob"`               // fraction of items with a shared link
+	MinMembers   int      `json:"min_members,omitempty"`   // members per shared item (default 1)
+	MaxMembers   int      `json:"max_members,omitempty"`   // (default 4)
+	Users        []string `json:"users,omitempty"`         // owners and members to pick from
+	ExpiryProb   float64  `json:"expiry_prob,omitempty"`   // fraction of links that expire (some already have)
+	PasswordProb float64  `json:"password_prob,omitempty"` // fraction of links with a password
+}
+
+const (
+	defaultMinMembers = 1
+	defaultMaxMembers = 4
+)
+
+// Validate checks the sharing configuration
+func (c *SharingConfig) Validate() error {
+	for name, prob := range map[string]float64{"folder_prob": c.FolderProb, "file_prob": c.FileProb, "link_prob": c.LinkProb, "expiry_prob": c.ExpiryProb, "password_prob": c.PasswordProb} {
+		if prob < 0 || prob > 1 {
+			return fmt.Errorf("%s must be between 0.0 and 1.0", name)
+		}
+	}
+	if c.MinMembers < 0 || c.MaxMembers < 0 {
+		return fmt.Errorf("min_members and max_members must not be negative")
+	}
+	if c.MaxMembers > 0 && c.MaxMembers < c.MinMembers {
+		return fmt.Errorf("max_members must be at least min_members")
+	}
+	return nil
+}
+
+// ValidRole reports whether role can be given to a member (links only take viewer and editor)
+func ValidRole(role string, forLink bool) bool {
+	switch role {
+	case RoleEditor, RoleViewer:
+		return true
+	case RoleCommenter:
+		return !forLink
+	}
+	return false
+}
+
+// SharedLinkURL returns the URL of a shared link
+func SharedLinkURL(linkID string) string {
+	return sharedLinkBaseURL + linkID
+}
+
+// SharingTable stores the sharing of items changed through the API. A row replaces the item's
+// seeded sharing, so unsharing an item stores an empty one.
+type SharingTable struct{}
+
+func (t *SharingTable) Name() string {
+	return "sharing"
+}
+
+func (t *SharingTable) Schema() string {
+	return `
+		table_name VARCHAR NOT NULL,
+		node_id VARCHAR NOT NULL,
+		sharing VARCHAR NOT NULL,
+		PRIMARY KEY (table_name, node_id)
+	`
+}
+
+// Init creates the sharing table asynchronously.
+func (t *SharingTable) Init(db *db.DB) error {
+	done := make(chan error)
+	go func() {
+		done <- db.CreateTable(t.Name(), t.Schema())
+	}()
+	return <-done
+}
+
+// GenerateSharing returns the seeded sharing of a primary table item, or nil if it isn't shared
+func (dg *DeterministicGenerator) GenerateSharing(node dbTypes.Node) *dbTypes.Sharing {
+	config := dg.config.Sharing
+	if config == nil || node.UserCreated || node.Level == 0 {
+		return nil
+	}
+	rng := rand.New(rand.NewSource(generateDeterministicSeed(dg.masterSeed, "sharing:"+node.ID)))
+
+	users := config.Users
+	if len(users) == 0 {
+		users = defaultUsers
+	}
+	sharing := &dbTypes.Sharing{Owner: users[rng.Intn(len(users))]}
+
+	memberProb := config.FileProb
+	if node.Type == NodeTypeFolder {
+		memberProb = config.FolderProb
+	}
+	if rng.Float64() < memberProb {
+		minMembers, maxMembers := config.memberRange()
+		count := minMembers + rng.Intn(maxMembers-minMembers+1)
+		for _, i := range rng.Perm(len(users)) {
+			if len(sharing.Members) == count {
+				break
+			}
+			if users[i] == sharing.Owner {
+				continue
+			}
+			sharing.Members = append(sharing.Members, dbTypes.ShareMember{Email: users[i], Role: seededRole(rng)})
+		}
+	}
+
+	if rng.Float64() < config.LinkProb {
+		link := dbTypes.SharedLink{
+			ID:                fmt.Sprintf("%016x", rng.Uint64()),
+			Scope:             LinkScopeAnyone,
+			Role:              RoleViewer,
+			PasswordProtected: rng.Float64() < config.PasswordProb,
+			CreatedAt:         node.UpdatedAt,
+		}
+		link.URL = SharedLinkURL(link.ID)
+		if rng.Float64() < 0.3 {
+			link.Scope = LinkScopeOrganization
+		}
+		if rng.Float64() < 0.2 {
+			link.Role = RoleEditor
+		}
+		if rng.Float64() < config.ExpiryProb {
+			// Counted from the last modification, so links of older items have expired
+			expiresAt := node.UpdatedAt.Add(time.Duration(7+rng.Intn(359)) * 24 * time.Hour)
+			link.ExpiresAt = &expiresAt
+		}
+		sharing.Links = append(sharing.Links, link)
+	}
+
+	if sharing.IsEmpty() {
+		return nil
+	}
+	return sharing
+}
+
+func (c *SharingConfig) memberRange() (int, int) {
+	minMembers, maxMembers := c.MinMembers, c.MaxMembers
+	if minMembers == 0 {
+		minMembers = defaultMinMembers
+	}
+	if maxMembers == 0 {
+		maxMembers = defaultMaxMembers
+	}
+	if maxMembers < minMembers {
+		maxMembers = minMembers
+	}
+	return minMembers, maxMembers
+}
+
+// seededRole picks a member role: mostly viewers and editors
+func seededRole(rng *rand.Rand) string {
+	switch roll := rng.Float64(); {
+	case roll < 0.5:
+		return RoleViewer
+	case roll < 0.9:
+		return RoleEditor
+	default:
+		return RoleCommenter
+	}
+}
+
+// GetStoredSharing returns the stored sharing of a table's nodes matching where (a condition on
+// node_id), by node ID. Nodes without a row aren't in the map.
+func GetStoredSharing(database *db.DB, tableName string, where string, params ...any) (map[string]*dbTypes.Sharing, error) {
+	query := "SELECT node_id, sharing FROM sharing WHERE table_name = ? AND " + where
+	rows, err := database.Query("sharing", query, append([]any{tableName}, params...)...)
+	if err != nil {
+		return nil, fmt.Errorf("query sharing of %s: %w", tableName, err)
+	}
+	defer rows.Close()
+
+	stored := make(map[string]*dbTypes.Sharing)
+	for rows.Next() {
+		var nodeID, data string
+		if err := rows.Scan(&nodeID, &data); err != nil {
+			return nil, fmt.Errorf("scan sharing of %s: %w", tableName, err)
+		}
+		var sharing dbTypes.Sharing
+		if err := json.Unmarshal([]byte(data), &sharing); err != nil {
+			return nil, fmt.Errorf("decode sharing of %s: %w", nodeID, err)
+		}
+		stored[nodeID] = &sharing
+	}
+	return stored, rows.Err()
+}
+
+// SharingQuery returns the statement that stores a node's sharing
+func SharingQuery(tableName string, nodeID string, sharing dbTypes.Sharing) (string, []any, error) {
+	data, err := json.Marshal(sharing)
+	if err != nil {
+		return "", nil, fmt.Errorf("encode sharing of %s: %w", nodeID, err)
+	}
+	return "INSERT OR REPLACE INTO sharing (table_name, node_id, sharing) VALUES (?, ?, ?)", []any{tableName, nodeID, string(data)}, nil
+}
@@ -0,0 +1,322 @@
+package tables
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Size distribution types
+const (
+	SizeFixed     = "fixed"
+	SizeUniform   = "uniform"
+	SizeLogNormal = "lognormal"
+	SizePareto    = "pareto"
+	SizeHistogram = "histogram"
+)
+
+const (
+	defaultHugeMin = 2 << 30 // 2 GiB
+	defaultHugeMax = 8 << 30 // 8 GiB
+)
+
+// SizeDistribution describes how file sizes are drawn
+type SizeDistribution struct {
+	Type string `json:"type"` // fixed, uniform, lognormal, pareto or histogram
+
+	Value int64   `json:"value,omitempty"` // fixed: the size of every file
+	Min   int64   `json:"min,omitempty"`   // lower bound applied to every distribution
+	Max   int64   `json:"max,omitempty"`   // upper bound applied to every distribution (0 = unbounded)
+	Mu    float64 `json:"mu,omitempty"`    // lognormal: mean of ln(size)
+	Sigma float64 `json:"sigma,omitempty"` // lognormal: standard deviation of ln(size)
+	Alpha float64 `json:"alpha,omitempty"` // pareto: shape
+	Scale float64 `json:"scale,omitempty"` // pareto: minimum size (x_m)
+
+	Histogram     []SizeBucket `json:"histogram,omitempty"`      // histogram: weighted buckets
+	HistogramFile string       `json:"histogram_file,omitempty"` // histogram: CSV of min,max,count rows (e.g. from a real share)
+
+	ZeroProb *float64 `json:"zero_prob,omitempty"` // probability of a 0-byte file (nil = 0, or inherited)
+	HugeProb *float64 `json:"huge_prob,omitempty"` // probability of a multi-GB file (nil = 0, or inherited)
+	HugeMin  int64    `json:"huge_min,omitempty"`  // default 2 GiB
+	HugeMax  int64    `json:"huge_max,omitempty"`  // default 8 GiB
+
+	// e.g. ".mp4" -> lognormal. Entries inherit the zero_prob, huge_prob, huge_min and huge_max they leave
+	// unset; an explicit 0 turns an outlier off.
+	ByExtension map[string]*SizeDistribution `json:"by_extension,omitempty"`
+}
+
+// SizeBucket is one bucket of a size histogram, sizes are drawn uniformly within [Min, Max]
+type SizeBucket struct {
+	Min    int64   `json:"min"`
+	Max    int64   `json:"max"`
+	Weight float64 `json:"weight"`
+}
+
+// SizeSampler draws file sizes from a validated SizeDistribution
+type SizeSampler struct {
+	dist        *SizeDistribution
+	buckets     []SizeBucket
+	cumulative  []float64
+	total       float64
+	byExtension map[string]*SizeSampler
+}
+
+// NewSizeSampler prepares a sampler for the distribution.
+// A nil distribution keeps the legacy 100-999 byte uniform sizes.
+func NewSizeSampler(dist *SizeDistribution) (*SizeSampler, error) {
+	if dist == nil {
+		return &SizeSampler{}, nil
+	}
+	if err := dist.Validate(); err != nil {
+		return nil, err
+	}
+
+	sampler := &SizeSampler{dist: dist}
+
+	if dist.Type == SizeHistogram {
+		buckets := append([]SizeBucket(nil), dist.Histogram...)
+		if dist.HistogramFile != "" {
+			loaded, err := LoadSizeHistogram(dist.HistogramFile)
+			if err != nil {
+				return nil, err
+			}
+			buckets = append(buckets, loaded...)
+		}
+		if len(buckets) == 0 {
+			return nil, fmt.Errorf("histogram size distribution has no buckets")
+		}
+		sampler.buckets = buckets
+		for _, bucket := range buckets {
+			sampler.total += bucket.Weight
+			sampler.cumulative = append(sampler.cumulative, sampler.total)
+		}
+		if sampler.total <= 0 {
+			return nil, fmt.Errorf("histogram size distribution needs a bucket with a positive weight")
+		}
+	}
+
+	if len(dist.ByExtension) > 0 {
+		sampler.byExtension = make(map[string]*SizeSampler)
+		for ext, extDist := range dist.ByExtension {
+			extSampler, err := NewSizeSampler(dist.inheritOutliers(extDist))
+			if err != nil {
+				return nil, fmt.Errorf("size distribution for %s: %w", ext, err)
+			}
+			sampler.byExtension[strings.ToLower(ext)] = extSampler
+		}
+	}
+
+	return sampler, nil
+}
+
+// inheritOutliers returns an extension's distribution with the outlier settings it leaves unset
+// taken from d
+func (d *SizeDistribution) inheritOutliers(ext *SizeDistribution) *SizeDistribution {
+	inherited := *ext
+	if inherited.ZeroProb == nil {
+		inherited.ZeroProb = d.ZeroProb
+	}
+	if inherited.HugeProb == nil {
+		inherited.HugeProb = d.HugeProb
+	}
+	if inherited.HugeMin == 0 {
+		inherited.HugeMin = d.HugeMin
+	}
+	if inherited.HugeMax == 0 {
+		inherited.HugeMax = d.HugeMax
+	}
+	return &inherited
+}
+
+// Validate checks the distribution parameters
+func (d *SizeDistribution) Validate() error {
+	switch d.Type {
+	case SizeFixed:
+		if d.Value < 0 {
+			return fmt.Errorf("fixed size must not be negative")
+		}
+	case SizeUniform:
+		if d.Max <= 0 || d.Max < d.Min {
+			return fmt.Errorf("uniform size distribution needs 0 <= min <= max and max > 0")
+		}
+	case SizeLogNormal:
+		if d.Sigma <= 0 {
+			return fmt.Errorf("lognormal size distribution needs sigma > 0")
+		}
+	case SizePareto:
+		if d.Alpha <= 0 || d.Scale <= 0 {
+			return fmt.Errorf("pareto size distribution needs alpha > 0 and scale > 0")
+		}
+	case SizeHistogram:
+		if len(d.Histogram) == 0 && d.HistogramFile == "" {
+			return fmt.Errorf("histogram size distribution needs histogram or histogram_file")
+		}
+		var total float64
+		for _, bucket := range d.Histogram {
+			if bucket.Min < 0 || bucket.Max < bucket.Min || bucket.Weight < 0 {
+				return fmt.Errorf("invalid histogram bucket: min=%d, max=%d, weight=%f", bucket.Min, bucket.Max, bucket.Weight)
+			}
+			total += bucket.Weight
+		}
+		if total <= 0 && d.HistogramFile == "" {
+			return fmt.Errorf("histogram size distribution needs a bucket with a positive weight")
+		}
+	default:
+		return fmt.Errorf("unknown size distribution type: %q", d.Type)
+	}
+
+	if d.Min < 0 || (d.Max > 0 && d.Max < d.Min) {
+		return fmt.Errorf("invalid size bounds: min=%d, max=%d", d.Min, d.Max)
+	}
+	zeroProb, hugeProb := probability(d.ZeroProb), probability(d.HugeProb)
+	if zeroProb < 0 || hugeProb < 0 || zeroProb+hugeProb > 1 {
+		return fmt.Errorf("zero_prob and huge_prob must be >= 0 and sum to at most 1")
+	}
+	if d.HugeMax > 0 && d.HugeMax < d.HugeMin {
+		return fmt.Errorf("invalid huge file range: min=%d, max=%d", d.HugeMin, d.HugeMax)
+	}
+	for ext, extDist := range d.ByExtension {
+		if extDist == nil {
+			return fmt.Errorf("size distribution for %s is empty", ext)
+		}
+		if err := d.inheritOutliers(extDist).Validate(); err != nil {
+			return fmt.Errorf("size distribution for %s: %w", ext, err)
+		}
+	}
+
+	return nil
+}
+
+// Sample draws a size for a file with the given name
+func (s *SizeSampler) Sample(rng *rand.Rand, name string) int64 {
+	if s.dist == nil {
+		return int64(100 + rng.Intn(900)) // Random size 100-999 bytes
+	}
+
+	if extSampler, ok := s.byExtension[strings.ToLower(path.Ext(name))]; ok {
+		return extSampler.Sample(rng, name)
+	}
+
+	d := s.dist
+	if zeroProb, hugeProb := probability(d.ZeroProb), probability(d.HugeProb); zeroProb > 0 || hugeProb > 0 {
+		roll := rng.Float64()
+		if roll < zeroProb {
+			return 0
+		}
+		if roll < zeroProb+hugeProb {
+			hugeMin, hugeMax := int64(defaultHugeMin), int64(defaultHugeMax)
+			if d.HugeMin > 0 {
+				hugeMin = d.HugeMin
+			}
+			if d.HugeMax > 0 {
+				hugeMax = d.HugeMax
+			}
+			if hugeMax < hugeMin {
+				hugeMax = hugeMin
+			}
+			return sizeBetween(rng, hugeMin, hugeMax)
+		}
+	}
+
+	var size float64
+	switch d.Type {
+	case SizeFixed:
+		return d.Value
+	case SizeUniform:
+		return sizeBetween(rng, d.Min, d.Max)
+	case SizeLogNormal:
+		size = math.Exp(d.Mu + d.Sigma*rng.NormFloat64())
+	case SizePareto:
+		// Inverse transform sampling; 1-U keeps the argument in (0, 1]
+		size = d.Scale / math.Pow(1-rng.Float64(), 1/d.Alpha)
+	case SizeHistogram:
+		roll := rng.Float64() * s.total
+		bucket := s.buckets[len(s.buckets)-1]
+		for i, cumulative := range s.cumulative {
+			if roll < cumulative {
+				bucket = s.buckets[i]
+				break
+			}
+		}
+		return s.clamp(sizeBetween(rng, bucket.Min, bucket.Max))
+	}
+
+	if size > math.MaxInt64/2 {
+		size = math.MaxInt64 / 2
+	}
+	return s.clamp(int64(size))
+}
+
+// probability reads an optional probability, unset = 0
+func probability(p *float64) float64 {
+	if p == nil {
+		return 0
+	}
+	return *p
+}
+
+// sizeBetween draws a size uniformly from [lo, hi], including ranges too wide for Int63n
+// (e.g. 0 to math.MaxInt64)
+func sizeBetween(rng *rand.Rand, lo, hi int64) int64 {
+	span := uint64(hi) - uint64(lo)
+	if span < math.MaxInt64 {
+		return lo + rng.Int63n(int64(span)+1)
+	}
+	offset := rng.Uint64()
+	if span < math.MaxUint64 {
+		offset %= span + 1
+	}
+	return int64(uint64(lo) + offset)
+}
+
+// clamp applies the distribution's min/max bounds
+func (s *SizeSampler) clamp(size int64) int64 {
+	if size < s.dist.Min {
+		return s.dist.Min
+	}
+	if s.dist.Max > 0 && size > s.dist.Max {
+		return s.dist.Max
+	}
+	return size
+}
+
+// LoadSizeHistogram reads a CSV with min,max,count rows (a header row is allowed)
+func LoadSizeHistogram(histogramPath string) ([]SizeBucket, error) {
+	f, err := os.Open(histogramPath)
+	if err != nil {
+		return nil, fmt.Errorf("open size histogram: %w", err)
+	}
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("read size histogram: %w", err)
+	}
+
+	var buckets []SizeBucket
+	for i, record := range records {
+		if len(record) < 3 {
+			return nil, fmt.Errorf("size histogram line %d: expected min,max,count", i+1)
+		}
+		minSize, errMin := strconv.ParseInt(strings.TrimSpace(record[0]), 10, 64)
+		maxSize, errMax := strconv.ParseInt(strings.TrimSpace(record[1]), 10, 64)
+		count, errCount := strconv.ParseFloat(strings.TrimSpace(record[2]), 64)
+		if errMin != nil || errMax != nil || errCount != nil {
+			if i == 0 {
+				continue // header row
+			}
+			return nil, fmt.Errorf("size histogram line %d: invalid number", i+1)
+		}
+		if minSize < 0 || maxSize < minSize || count < 0 {
+			return nil, fmt.Errorf("size histogram line %d: invalid bucket", i+1)
+		}
+		buckets = append(buckets, SizeBucket{Min: minSize, Max: maxSize, Weight: count})
+	}
+
+	return buckets, nil
+}
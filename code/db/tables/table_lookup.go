@@ -1,6 +1,8 @@
 package tables
 
 import (
+	"strings"
+
 	"github.com/Voltaic314/GhostFS/code/db"
 	"github.com/google/uuid"
 )
@@ -91,3 +93,17 @@ func GetAllTableMappingsWithTypes(db *db.DB) (map[string]map[string]string, erro
 func GenerateTableID() string {
 	return uuid.New().String()
 }
+
+// IsInternalTable returns true for the tables GhostFS keeps its own state in, next to the nodes tables
+func IsInternalTable(tableName string) bool {
+	internal := []interface{ Name() string }{
+		&TableLookup{}, &SeedInfoTable{}, &RevisionsTable{}, &TrashTable{}, &LocksTable{}, &SharingTable{}, &ChangesTable{},
+	}
+	for _, table := range internal {
+		// DuckDB table names are case-insensitive
+		if strings.EqualFold(table.Name(), tableName) {
+			return true
+		}
+	}
+	return false
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"sync"
 
 	"github.com/Voltaic314/GhostFS/code/db"
 )
@@ -14,6 +15,8 @@ type TableManager struct {
 	config       *TestConfig
 	tableIDMap   map[string]string // table_id -> table_name cache
 	tableNameMap map[string]string // table_name -> table_id cache
+	fanOut       *FanOut
+	fanOutOnce   sync.Once
 }
 
 // NewTableManager creates a new table manager
@@ -47,6 +50,72 @@ func (tm *TableManager) GetTableNames() []string {
 	return tables
 }
 
+// IsGeneratedTable returns true if the table's children are produced by the deterministic generator.
+// Tables that aren't in the config (e.g. imported tables) are served straight from the database.
+func (tm *TableManager) IsGeneratedTable(tableName string) bool {
+	for _, name := range tm.GetTableNames() {
+		if name == tableName {
+			return true
+		}
+	}
+	return false
+}
+
+// GetNamePolicy returns how sibling names are compared in a table. The primary table and tables
+// outside the config (e.g. imported tables) are case and normalization sensitive.
+func (tm *TableManager) GetNamePolicy(tableName string) NamePolicy {
+	for _, config := range tm.config.Database.Tables.Secondary {
+		if config.TableName == tableName {
+			return config.NamePolicy
+		}
+	}
+	return NamePolicy{}
+}
+
+// GetConstraints returns the resolved constraint profile of a table, or nil if it has none
+func (tm *TableManager) GetConstraints(tableName string) *ConstraintProfile {
+	for _, config := range tm.config.Database.Tables.Secondary {
+		if config.TableName == tableName && config.Constraints != nil {
+			resolved := config.Constraints.Resolved()
+			return &resolved
+		}
+	}
+	return nil
+}
+
+// GetTrashConfig returns the trash configuration of a table, or nil if deletes in it are permanent
+func (tm *TableManager) GetTrashConfig(tableName string) *TrashConfig {
+	if tableName == tm.GetPrimaryTableName() {
+		return tm.config.Database.Tables.Primary.Trash
+	}
+	for _, config := range tm.config.Database.Tables.Secondary {
+		if config.TableName == tableName {
+			return config.Trash
+		}
+	}
+	return nil
+}
+
+// GetS3Config returns the S3 API configuration, or nil if it isn't configured
+func (tm *TableManager) GetS3Config() *S3Config {
+	return tm.config.S3
+}
+
+// GetSFTPConfig returns the SFTP server configuration, or nil if it isn't configured
+func (tm *TableManager) GetSFTPConfig() *SFTPConfig {
+	return tm.config.SFTP
+}
+
+// GetGraphConfig returns the Graph API configuration, or nil if it isn't configured
+func (tm *TableManager) GetGraphConfig() *GraphConfig {
+	return tm.config.Graph
+}
+
+// GetGRPCConfig returns the gRPC server configuration, or nil if it isn't configured
+func (tm *TableManager) GetGRPCConfig() *GRPCConfig {
+	return tm.config.GRPC
+}
+
 // GetTableForNode returns the appropriate table name for a node based on dst_prob
 // Uses weighted random selection based on dst_prob values
 func (tm *TableManager) GetTableForNode(nodeID string) string {
@@ -134,6 +203,70 @@ func (tm *TableManager) ValidateConfig() error {
 	if tm.config.Database.Tables.Primary.TableName == "" {
 		return fmt.Errorf("primary table name cannot be empty")
 	}
+	if err := ValidateNamingProfiles(tm.config.Database.Tables.Primary.NamingProfiles); err != nil {
+		return fmt.Errorf("primary table naming profiles: %w", err)
+	}
+	if fileSizes := tm.config.Database.Tables.Primary.FileSizes; fileSizes != nil {
+		// Preparing the sampler also reads and checks the histogram files
+		if _, err := NewSizeSampler(fileSizes); err != nil {
+			return fmt.Errorf("primary table file sizes: %w", err)
+		}
+	}
+	if timestamps := tm.config.Database.Tables.Primary.Timestamps; timestamps != nil {
+		if err := timestamps.Validate(); err != nil {
+			return fmt.Errorf("primary table timestamps: %w", err)
+		}
+	}
+	if fanOut := tm.config.Database.Tables.Primary.FanOut; fanOut != nil {
+		if err := fanOut.Validate(tm.config.Database.Tables.Primary); err != nil {
+			return fmt.Errorf("primary table fan_out: %w", err)
+		}
+	}
+	if links := tm.config.Database.Tables.Primary.Links; links != nil {
+		if err := links.Validate(); err != nil {
+			return fmt.Errorf("primary table links: %w", err)
+		}
+	}
+	if duplicates := tm.config.Database.Tables.Primary.Duplicates; duplicates != nil {
+		if err := duplicates.Validate(); err != nil {
+			return fmt.Errorf("primary table duplicates: %w", err)
+		}
+	}
+	if nameCollisions := tm.config.Database.Tables.Primary.NameCollisions; nameCollisions != nil {
+		if err := nameCollisions.Validate(); err != nil {
+			return fmt.Errorf("primary table name_collisions: %w", err)
+		}
+	}
+	if violations := tm.config.Database.Tables.Primary.Violations; violations != nil {
+		if err := violations.Validate(); err != nil {
+			return fmt.Errorf("primary table violations: %w", err)
+		}
+	}
+	if revisions := tm.config.Database.Tables.Primary.Revisions; revisions != nil {
+		if err := revisions.Validate(); err != nil {
+			return fmt.Errorf("primary table revisions: %w", err)
+		}
+	}
+	if trash := tm.config.Database.Tables.Primary.Trash; trash != nil {
+		if err := trash.Validate(); err != nil {
+			return fmt.Errorf("primary table trash: %w", err)
+		}
+	}
+	if locks := tm.config.Database.Tables.Primary.Locks; locks != nil {
+		if err := locks.Validate(); err != nil {
+			return fmt.Errorf("primary table locks: %w", err)
+		}
+	}
+	if sharing := tm.config.Database.Tables.Primary.Sharing; sharing != nil {
+		if err := sharing.Validate(); err != nil {
+			return fmt.Errorf("primary table sharing: %w", err)
+		}
+	}
+	for i, override := range tm.config.Database.Tables.Primary.Overrides {
+		if err := override.Validate(); err != nil {
+			return fmt.Errorf("primary table override %d: %w", i, err)
+		}
+	}
 
 	// Validate secondary tables
 	for tableID, config := range tm.config.Database.Tables.Secondary {
@@ -146,6 +279,37 @@ func (tm *TableManager) ValidateConfig() error {
 		if config.DstProb < 0.0 || config.DstProb > 1.0 {
 			return fmt.Errorf("secondary table %s dst_prob must be between 0.0 and 1.0", tableID)
 		}
+		if config.Constraints != nil {
+			if err := config.Constraints.Validate(); err != nil {
+				return fmt.Errorf("secondary table %s constraints: %w", tableID, err)
+			}
+		}
+		if config.Trash != nil {
+			if err := config.Trash.Validate(); err != nil {
+				return fmt.Errorf("secondary table %s trash: %w", tableID, err)
+			}
+		}
+	}
+
+	if s3 := tm.config.S3; s3 != nil {
+		if err := s3.Validate(); err != nil {
+			return fmt.Errorf("s3: %w", err)
+		}
+	}
+	if sftp := tm.config.SFTP; sftp != nil {
+		if err := sftp.Validate(); err != nil {
+			return fmt.Errorf("sftp: %w", err)
+		}
+	}
+	if graph := tm.config.Graph; graph != nil {
+		if err := graph.Validate(); err != nil {
+			return fmt.Errorf("graph: %w", err)
+		}
+	}
+	if grpc := tm.config.GRPC; grpc != nil {
+		if err := grpc.Validate(); err != nil {
+			return fmt.Errorf("grpc: %w", err)
+		}
 	}
 
 	// Check for duplicate table names
@@ -168,6 +332,40 @@ func (tm *TableManager) GetGenerationConfigForTable(tableName string) PrimaryTab
 	return tm.GetPrimaryConfig()
 }
 
+// GetGenerationConfigForFolder returns the generation configuration for the children of a folder,
+// with the fan-out curves for its level and then every matching per-subtree override applied in order
+func (tm *TableManager) GetGenerationConfigForFolder(tableName string, folderPath string, level int) PrimaryTableConfig {
+	config := tm.GetGenerationConfigForTable(tableName)
+	if fanOut := tm.GetFanOut(); fanOut != nil {
+		fanOut.Apply(&config, level)
+	}
+	for _, override := range config.Overrides {
+		if override.Matches(folderPath, level) {
+			override.Apply(&config)
+		}
+	}
+	return config
+}
+
+// GetFanOut returns the calibrated fan-out curves, or nil if fan_out isn't configured
+func (tm *TableManager) GetFanOut() *FanOut {
+	tm.fanOutOnce.Do(func() {
+		tm.fanOut = NewFanOut(tm.GetPrimaryConfig())
+		if tm.fanOut != nil && tm.GetPrimaryConfig().FanOut.TargetNodes > 0 {
+			fmt.Printf("🎯 Fan-out calibrated: scale %.4f, ~%.0f expected nodes\n", tm.fanOut.Scale(), tm.fanOut.ExpectedNodes())
+		}
+	})
+	return tm.fanOut
+}
+
+// GetLeafProb returns the probability that a folder at level gets no subfolders
+func (tm *TableManager) GetLeafProb(level int) float64 {
+	if fanOut := tm.GetFanOut(); fanOut != nil {
+		return fanOut.LeafProb(level)
+	}
+	return 0
+}
+
 // GetSecondaryTableIDs returns the IDs of all secondary tables
 func (tm *TableManager) GetSecondaryTableIDs() []string {
 	var ids []string
@@ -0,0 +1,119 @@
+package tables
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Timestamp distributions
+const (
+	TimestampUniform = "uniform" // spread evenly over the parent's time window
+	TimestampRecent  = "recent"  // skewed towards the end of the parent's time window
+)
+
+// TimestampConfig configures seeded created/modified timestamps
+type TimestampConfig struct {
+	Start        time.Time `json:"start"`                   // RFC3339, creation time of the root
+	End          time.Time `json:"end"`                     // RFC3339, modification time of the root
+	Distribution string    `json:"distribution,omitempty"`  // uniform (default) or recent
+	FutureProb   float64   `json:"future_prob,omitempty"`   // probability of a file modified after End
+	Pre1980Prob  float64   `json:"pre_1980_prob,omitempty"` // probability of a file dated before 1980
+}
+
+// Validate checks the timestamp configuration
+func (c *TimestampConfig) Validate() error {
+	if c.Start.IsZero() || c.End.IsZero() {
+		return fmt.Errorf("timestamps need both start and end")
+	}
+	if !c.End.After(c.Start) {
+		return fmt.Errorf("timestamps end must be after start")
+	}
+	switch c.Distribution {
+	case "", TimestampUniform, TimestampRecent:
+	default:
+		return fmt.Errorf("unknown timestamp distribution: %q", c.Distribution)
+	}
+	if c.FutureProb < 0 || c.Pre1980Prob < 0 || c.FutureProb+c.Pre1980Prob > 1 {
+		return fmt.Errorf("future_prob and pre_1980_prob must be >= 0 and sum to at most 1")
+	}
+	return nil
+}
+
+// TimestampGenerator derives node timestamps from seeded RNGs.
+// Every child is created after its parent and modified before it, so a folder's
+// modified time is always at least the modified time of anything below it
+// (outlier files excepted).
+type TimestampGenerator struct {
+	config *TimestampConfig
+}
+
+// NewTimestampGenerator creates a timestamp generator; a nil config keeps time.Now() timestamps
+func NewTimestampGenerator(config *TimestampConfig) *TimestampGenerator {
+	return &TimestampGenerator{config: config}
+}
+
+// Enabled returns true if timestamps are seeded instead of time.Now()
+func (tg *TimestampGenerator) Enabled() bool {
+	return tg.config != nil
+}
+
+// RootTimes returns the created/modified timestamps for a root node
+func (tg *TimestampGenerator) RootTimes() (time.Time, time.Time) {
+	if !tg.Enabled() {
+		now := time.Now()
+		return now, now
+	}
+	return tg.config.Start.UTC(), tg.config.End.UTC()
+}
+
+// ChildTimes returns created/modified timestamps for a child of a node with the given timestamps
+func (tg *TimestampGenerator) ChildTimes(rng *rand.Rand, parentCreated, parentModified time.Time, isFile bool) (time.Time, time.Time) {
+	if !tg.Enabled() {
+		now := time.Now()
+		return now, now
+	}
+
+	// Keep the parent's window inside the configured range (it may predate the config)
+	start, end := tg.RootTimes()
+	if parentCreated.After(start) && parentCreated.Before(end) {
+		start = parentCreated
+	}
+	if parentModified.After(start) && parentModified.Before(end) {
+		end = parentModified
+	}
+
+	if isFile && (tg.config.FutureProb > 0 || tg.config.Pre1980Prob > 0) {
+		roll := rng.Float64()
+		if roll < tg.config.FutureProb {
+			// Modified up to five years after the configured end
+			created := tg.between(rng, start, end)
+			return created, tg.config.End.UTC().Add(time.Duration(rng.Int63n(int64(5 * 365 * 24 * time.Hour)))).Truncate(time.Second)
+		}
+		if roll < tg.config.FutureProb+tg.config.Pre1980Prob {
+			epoch := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+			created := epoch.Add(time.Duration(rng.Int63n(int64(10 * 365 * 24 * time.Hour)))).Truncate(time.Second)
+			return created, created
+		}
+	}
+
+	created := tg.between(rng, start, end)
+	modified := tg.between(rng, created, end)
+	return created, modified
+}
+
+// between picks a time in [from, to] following the configured distribution
+func (tg *TimestampGenerator) between(rng *rand.Rand, from, to time.Time) time.Time {
+	span := to.Sub(from)
+	if span <= 0 {
+		return from
+	}
+
+	fraction := rng.Float64()
+	if tg.config.Distribution == TimestampRecent {
+		fraction = math.Cbrt(fraction)
+	}
+	// Truncate to seconds, most file systems and APIs don't round-trip anything finer
+	return from.Add(time.Duration(fraction * float64(span))).Truncate(time.Second)
+}
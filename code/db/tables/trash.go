@@ -0,0 +1,212 @@
+package tables
+
+import (
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Voltaic314/GhostFS/code/db"
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+)
+
+// TrashConfig makes deletes in a table soft: items go to the table's trash with their subtree,
+// from where they can be restored or purged, like most cloud providers do.
+type TrashConfig struct {
+	Enabled       bool `json:"enabled"`
+	RetentionDays int  `json:"retention_days,omitempty"` // trashed items older than this are purged (0 = keep)
+}
+
+// Validate checks the trash configuration
+func (c *TrashConfig) Validate() error {
+	if c.RetentionDays < 0 {
+		return fmt.Errorf("retention_days must not be negative")
+	}
+	return nil
+}
+
+// Retention returns how long trashed items are kept (0 = until purged)
+func (c *TrashConfig) Retention() time.Duration {
+	return time.Duration(c.RetentionDays) * 24 * time.Hour
+}
+
+// TrashTable stores deleted nodes of all tables. A trashed item and its stored descendants share
+// the trash_id of the item, so they are restored and purged together.
+type TrashTable struct{}
+
+func (t *TrashTable) Name() string {
+	return "trash"
+}
+
+func (t *TrashTable) Schema() string {
+	return `
+		table_name VARCHAR NOT NULL,
+		trash_id VARCHAR NOT NULL,
+		deleted_at TIMESTAMP NOT NULL,
+		id VARCHAR NOT NULL,
+		parent_id VARCHAR NOT NULL,
+		name VARCHAR NOT NULL,
+		path VARCHAR NOT NULL,
+		type VARCHAR NOT NULL,
+		target VARCHAR,
+		size BIGINT,
+		level INTEGER NOT NULL,
+		checked BOOLEAN NOT NULL DEFAULT FALSE,
+		secondary_existence_map VARCHAR,
+		child_seed BIGINT,
+		content_seed BIGINT,
+		materialized BOOLEAN NOT NULL DEFAULT FALSE,
+		user_created BOOLEAN NOT NULL DEFAULT FALSE,
+		created_at TIMESTAMP,
+		updated_at TIMESTAMP,
+		PRIMARY KEY (table_name, id)
+	`
+}
+
+// Init creates the trash table asynchronously, or adds the columns an existing one lacks.
+func (t *TrashTable) Init(db *db.DB) error {
+	done := make(chan error)
+	go func() {
+		done <- db.CreateTable(t.Name(), t.Schema())
+	}()
+	if err := <-done; err != nil {
+		return err
+	}
+	// Trash tables created before user_created existed (see addedNodeColumns)
+	return db.Write("ALTER TABLE trash ADD COLUMN IF NOT EXISTS user_created BOOLEAN DEFAULT FALSE")
+}
+
+// trashedColumns are the node columns copied between a nodes table and the trash
+const trashedColumns = "id, parent_id, name, path, type, target, size, level, checked, secondary_existence_map, child_seed, content_seed, materialized, user_created, created_at, updated_at"
+
+// TrashQueries returns the statements that move a node and its stored descendants to the trash.
+// They must run in order, in one transaction.
+func TrashQueries(tableName string, node dbTypes.Node, deletedAt time.Time) ([]string, [][]any) {
+	subtree := "id = ? OR starts_with(path, ?)"
+	queries := []string{
+		fmt.Sprintf("INSERT INTO trash (table_name, trash_id, deleted_at, %s) SELECT ?, ?, ?, %s FROM %s WHERE %s",
+			trashedColumns, trashedColumns, tableName, subtree),
+		fmt.Sprintf("DELETE FROM %s WHERE %s", tableName, subtree),
+	}
+	params := [][]any{
+		{tableName, node.ID, deletedAt, node.ID, node.Path + "/"},
+		{node.ID, node.Path + "/"},
+	}
+	return queries, params
+}
+
+// RestoreQueries returns the statements that move a trashed item and its descendants back into
+// its table, as a child of parent named name (paths and levels follow).
+func RestoreQueries(tableName string, item dbTypes.TrashItem, parent dbTypes.Node, name string) ([]string, [][]any) {
+	newPath := buildPath(parent.Path, name)
+	columns := "id, CASE WHEN id = ? THEN ? ELSE parent_id END, CASE WHEN id = ? THEN ? ELSE name END, ? || substr(path, ?), type, target, size, level + ?, " +
+		"checked, secondary_existence_map, child_seed, content_seed, materialized, user_created, created_at, updated_at"
+	queries := []string{
+		fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM trash WHERE table_name = ? AND trash_id = ?", tableName, trashedColumns, columns),
+		"DELETE FROM trash WHERE table_name = ? AND trash_id = ?",
+	}
+	params := [][]any{
+		{item.ID, parent.ID, item.ID, name, newPath, utf8.RuneCountInString(item.Path) + 1, parent.Level + 1 - item.Level, tableName, item.ID},
+		{tableName, item.ID},
+	}
+	return queries, params
+}
+
+// GetTrash lists the items in a table's trash, most recently deleted first
+func GetTrash(database *db.DB, tableName string) ([]dbTypes.TrashItem, error) {
+	return queryTrash(database, tableName, "")
+}
+
+// GetTrashItem returns one item of a table's trash
+func GetTrashItem(database *db.DB, tableName string, itemID string) (*dbTypes.TrashItem, error) {
+	items, err := queryTrash(database, tableName, itemID)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, nil
+	}
+	return &items[0], nil
+}
+
+func queryTrash(database *db.DB, tableName string, itemID string) ([]dbTypes.TrashItem, error) {
+	query := fmt.Sprintf(`SELECT %s, t.deleted_at,
+		(SELECT COUNT(*) FROM trash d WHERE d.table_name = t.table_name AND d.trash_id = t.trash_id)
+		FROM trash t WHERE t.table_name = ? AND t.id = t.trash_id`, nodeColumns)
+	params := []any{tableName}
+	if itemID != "" {
+		query += " AND t.id = ?"
+		params = append(params, itemID)
+	}
+	query += " ORDER BY t.deleted_at DESC, t.path"
+
+	rows, err := database.Query("trash", query, params...)
+	if err != nil {
+		return nil, fmt.Errorf("query trash of %s: %w", tableName, err)
+	}
+	defer rows.Close()
+
+	items := make([]dbTypes.TrashItem, 0)
+	for rows.Next() {
+		var item dbTypes.TrashItem
+		node, err := scanNode(rows, &item.DeletedAt, &item.Items)
+		if err != nil {
+			return nil, fmt.Errorf("scan trash of %s: %w", tableName, err)
+		}
+		item.Node = *node
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// NodeStateTables keep per-node state outside the nodes tables (by table_name and node_id).
+// Their rows go when the node is deleted permanently.
+var NodeStateTables = []string{"revisions", "locks", "sharing"}
+
+// PurgeTrash permanently removes trashed items (with their revisions, locks and sharing). An empty itemID purges the
+// whole trash, a non-zero olderThan only the items deleted before it. Returns the purged items.
+func PurgeTrash(database *db.DB, tableName string, itemID string, olderThan time.Time) (int64, error) {
+	where := "table_name = ?"
+	params := []any{tableName}
+	if itemID != "" {
+		where += " AND trash_id = ?"
+		params = append(params, itemID)
+	}
+	if !olderThan.IsZero() {
+		where += " AND deleted_at < ?"
+		params = append(params, olderThan)
+	}
+
+	for _, related := range NodeStateTables {
+		query := fmt.Sprintf("DELETE FROM %s WHERE table_name = ? AND node_id IN (SELECT id FROM trash WHERE %s)", related, where)
+		if _, err := database.Exec(query, append([]any{tableName}, params...)...); err != nil {
+			return 0, fmt.Errorf("purge %s of %s trash: %w", related, tableName, err)
+		}
+	}
+
+	// Counted (and journaled) on the items themselves, not their descendants
+	rows, err := database.Query("trash", fmt.Sprintf("SELECT id FROM trash WHERE %s AND id = trash_id", where), params...)
+	if err != nil {
+		return 0, fmt.Errorf("list %s trash: %w", tableName, err)
+	}
+	var purgedIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("scan %s trash: %w", tableName, err)
+		}
+		purgedIDs = append(purgedIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("list %s trash: %w", tableName, err)
+	}
+
+	if _, err := database.Exec(fmt.Sprintf("DELETE FROM trash WHERE %s", where), params...); err != nil {
+		return 0, fmt.Errorf("purge %s trash: %w", tableName, err)
+	}
+	if err := RecordChanges(database, tableName, true, purgedIDs...); err != nil {
+		return 0, err
+	}
+	return int64(len(purgedIDs)), nil
+}
@@ -19,6 +19,7 @@ type WriteQueue struct {
 	flushTimer   time.Duration // now just used to store the interval
 	readyToWrite bool          // indicates if queue is ready to be flushed
 	isWriting    bool          // prevents concurrent flushes
+	flushMu      sync.Mutex    // held by the DB while a flush is taken from the queue and executed
 }
 
 // NewWriteQueue creates a new write queue for a specific table
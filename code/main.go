@@ -1,14 +1,33 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	// "github.com/Voltaic314/GhostFS/code/api"
 	// "github.com/Voltaic314/GhostFS/code/db/seed"
 	"github.com/Voltaic314/GhostFS/code/sdk"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch command, args := os.Args[1], os.Args[2:]; command {
+		case "import", "export":
+			// seed.InitDB (run for a missing database) reads its config path from os.Args[1],
+			// subcommands pass theirs with -config
+			os.Args = os.Args[:1]
+			if command == "import" {
+				runImport(args)
+			} else {
+				runExport(args)
+			}
+			return
+		}
+	}
+
 	cfgPath := "config.json"
 	// seed.InitDB(cfgPath)
 	// api.StartServer(cfgPath)
@@ -38,3 +57,95 @@ func main() {
 	}
 	fmt.Println("Found", len(items), "items in root folder")
 }
+
+// runImport handles `ghostfs import -table <name> (-dir <path> | -manifest <file>)`
+func runImport(args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	cfgPath := fs.String("config", "config.json", "config file of the database to import into")
+	tableName := fs.String("table", "", "name of the table to create")
+	dir := fs.String("dir", "", "local directory to mirror")
+	manifest := fs.String("manifest", "", "file listing to import (find -printf, csv or jsonl)")
+	format := fs.String("format", "", "manifest format: find, csv or jsonl (default: guess from extension)")
+	replace := fs.Bool("replace", false, "replace the table if it already exists")
+	fs.Parse(args)
+
+	if *tableName == "" || (*dir == "") == (*manifest == "") {
+		fmt.Fprintln(os.Stderr, "usage: import -table <name> (-dir <path> | -manifest <file> [-format find|csv|jsonl]) [-replace]")
+		os.Exit(2)
+	}
+
+	client, err := sdk.NewGhostFSClient(*cfgPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Close()
+
+	var tableID string
+	if *dir != "" {
+		tableID, err = client.ImportDirectory(*tableName, *dir, *replace)
+	} else {
+		tableID, err = client.ImportManifest(*tableName, *manifest, *format, *replace)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("Imported table", *tableName, "with table_id", tableID)
+}
+
+// runExport handles `ghostfs export -table <name> -out <dir|file.tar|file.zip>`
+func runExport(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	cfgPath := fs.String("config", "config.json", "config file of the database to export from")
+	tableName := fs.String("table", "", "name of the table to export")
+	folderID := fs.String("folder", "", "ID of the folder to export (default: the root)")
+	out := fs.String("out", "", "output directory, or a .tar / .zip file")
+	maxFileSize := fs.Int64("max-file-size", 0, "cap on bytes written per file (0 = full size)")
+	maxDepth := fs.Int("max-depth", 0, "levels below the exported folder (0 = all)")
+	fs.Parse(args)
+
+	if *tableName == "" || *out == "" {
+		fmt.Fprintln(os.Stderr, "usage: export -table <name> -out <dir|file.tar|file.zip> [-folder <id>] [-max-file-size <bytes>] [-max-depth <n>]")
+		os.Exit(2)
+	}
+
+	client, err := sdk.NewGhostFSClient(*cfgPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Close()
+
+	tables, err := client.ListTables()
+	if err != nil {
+		log.Fatal(err)
+	}
+	tableID := ""
+	for _, t := range tables {
+		if t.TableName == *tableName {
+			tableID = t.TableID
+		}
+	}
+	if tableID == "" {
+		log.Fatalf("table %s not found", *tableName)
+	}
+
+	opts := sdk.ExportOptions{FolderID: *folderID, MaxFileSize: *maxFileSize, MaxDepth: *maxDepth}
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(*out)), ".")
+	if format != "tar" && format != "zip" {
+		if _, err := client.ExportDirectory(tableID, *out, opts); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if _, err := client.ExportArchive(tableID, f, format, opts); err != nil {
+		f.Close()
+		log.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -3,13 +3,16 @@ package sdk
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"time"
 
+	"github.com/Voltaic314/GhostFS/code/core/export"
 	"github.com/Voltaic314/GhostFS/code/core/items"
 	coreTables "github.com/Voltaic314/GhostFS/code/core/tables"
 	"github.com/Voltaic314/GhostFS/code/db"
+	"github.com/Voltaic314/GhostFS/code/db/importer"
 	"github.com/Voltaic314/GhostFS/code/db/seed"
 	"github.com/Voltaic314/GhostFS/code/db/tables"
 	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
@@ -40,6 +43,38 @@ type GhostFSClient struct {
 	generator    *tables.DeterministicGenerator
 }
 
+// Client is what both SDK clients implement: GhostFSClient works on the database file in-process,
+// GhostFSHTTPClient talks to a running server's REST API. Imports, exports, the cache and the
+// file system adapters work on the database directly and are only on GhostFSClient.
+type Client interface {
+	ListTables() ([]dbTypes.TableInfo, error)
+	GetRoot(tableID string) (dbTypes.Node, error)
+	ListItems(tableID, folderID string, foldersOnly bool) ([]dbTypes.Node, error)
+	CreateFolder(tableID, parentID, name string) (dbTypes.Node, error)
+	CreateFile(tableID, parentID, name string, size int64) (dbTypes.Node, error)
+	MoveItem(tableID, itemID, parentID, name string) (dbTypes.Node, error)
+	CopyItem(tableID, itemID, parentID, name string) (dbTypes.Node, error)
+	DeleteItem(tableID, itemID string, permanent bool) (bool, error)
+	ListTrash(tableID string) ([]dbTypes.TrashItem, error)
+	RestoreItem(tableID, itemID, parentID, name string) (dbTypes.Node, error)
+	PurgeTrash(tableID, itemID string) (int64, error)
+	LockItem(tableID, itemID, owner string, duration time.Duration) (dbTypes.Lock, error)
+	UnlockItem(tableID, itemID, owner string, force bool) error
+	GetSharing(tableID, itemID string) (dbTypes.Sharing, error)
+	ShareItem(tableID, itemID string, add []dbTypes.ShareMember, remove []string) (dbTypes.Sharing, error)
+	CreateLink(tableID, itemID, scope, role string, expiresAt *time.Time, password bool) (dbTypes.SharedLink, error)
+	DeleteLink(tableID, itemID, linkID string) error
+	ListRevisions(tableID, itemID string) ([]dbTypes.Revision, error)
+	OpenRevision(tableID, itemID, revisionID string) (io.ReadSeeker, dbTypes.Revision, error)
+	RestoreRevision(tableID, itemID, revisionID, author string) (dbTypes.Revision, error)
+	Close() error
+}
+
+var (
+	_ Client = (*GhostFSClient)(nil)
+	_ Client = (*GhostFSHTTPClient)(nil)
+)
+
 // NewGhostFSClient creates a new SDK client with config file
 // It will look for config.json in the current directory and parent directories
 func NewGhostFSClient(configPath string) (*GhostFSClient, error) {
@@ -113,6 +148,11 @@ func NewGhostFSClientWithDB(dbPath string, config SDKTablesConfig) (*GhostFSClie
 		tableManager,
 	)
 
+	// Databases seeded by older versions have nodes tables without the newer columns
+	if err := tables.MigrateNodeTables(database, tableManager.GetTableNames()); err != nil {
+		return nil, fmt.Errorf("failed to migrate nodes tables: %w", err)
+	}
+
 	// Load existing seeds from database
 	tableNames := tableManager.GetTableNames()
 	for _, tableName := range tableNames {
@@ -127,6 +167,23 @@ func NewGhostFSClientWithDB(dbPath string, config SDKTablesConfig) (*GhostFSClie
 		database.InitWriteQueue(tableName, dbTypes.NodeWriteQueue, 1000, 100*time.Millisecond)
 	}
 
+	// Databases seeded before revisions, trash, locks, sharing and changes existed don't have their tables yet
+	if err := (&tables.RevisionsTable{}).Init(database); err != nil {
+		return nil, fmt.Errorf("failed to create revisions table: %w", err)
+	}
+	if err := (&tables.TrashTable{}).Init(database); err != nil {
+		return nil, fmt.Errorf("failed to create trash table: %w", err)
+	}
+	if err := (&tables.LocksTable{}).Init(database); err != nil {
+		return nil, fmt.Errorf("failed to create locks table: %w", err)
+	}
+	if err := (&tables.SharingTable{}).Init(database); err != nil {
+		return nil, fmt.Errorf("failed to create sharing table: %w", err)
+	}
+	if err := (&tables.ChangesTable{}).Init(database); err != nil {
+		return nil, fmt.Errorf("failed to create changes table: %w", err)
+	}
+
 	return &GhostFSClient{
 		tableManager: tableManager,
 		database:     database,
@@ -214,6 +271,262 @@ func (c *GhostFSClient) GetRoot(tableID string) (dbTypes.Node, error) {
 	return resp.Root, nil
 }
 
+// CreateFolder creates an empty folder in a folder
+func (c *GhostFSClient) CreateFolder(tableID, parentID, name string) (dbTypes.Node, error) {
+	return c.createItem(tableID, parentID, items.NewItem{Name: name, Type: tables.NodeTypeFolder})
+}
+
+// CreateFile creates a file of the given size in a folder
+func (c *GhostFSClient) CreateFile(tableID, parentID, name string, size int64) (dbTypes.Node, error) {
+	return c.createItem(tableID, parentID, items.NewItem{Name: name, Type: tables.NodeTypeFile, Size: size})
+}
+
+func (c *GhostFSClient) createItem(tableID, parentID string, item items.NewItem) (dbTypes.Node, error) {
+	req := items.CreateItemsRequest{
+		TableID:  tableID,
+		ParentID: parentID,
+		Items:    []items.NewItem{item},
+	}
+
+	resp, err := items.CreateItems(c.tableManager, c.database, c.generator, req)
+	if err != nil {
+		return dbTypes.Node{}, fmt.Errorf("failed to create %s: %w", item.Name, err)
+	}
+	if result := resp.Results[0]; result.Error != nil {
+		return dbTypes.Node{}, fmt.Errorf("failed to create %s: %w", item.Name, result.Error)
+	}
+
+	return *resp.Results[0].Item, nil
+}
+
+// MoveItem moves an item to another folder (empty parentID = same folder) and/or renames it (empty name = keep)
+func (c *GhostFSClient) MoveItem(tableID, itemID, parentID, name string) (dbTypes.Node, error) {
+	req := items.MoveItemRequest{
+		TableID:  tableID,
+		ItemID:   itemID,
+		ParentID: parentID,
+		Name:     name,
+	}
+
+	resp, err := items.MoveItem(c.tableManager, c.database, c.generator, req)
+	if err != nil {
+		return dbTypes.Node{}, fmt.Errorf("failed to move item: %w", err)
+	}
+
+	return resp.Item, nil
+}
+
+// CopyItem copies an item (folders with their contents) to a folder (empty parentID = same folder)
+func (c *GhostFSClient) CopyItem(tableID, itemID, parentID, name string) (dbTypes.Node, error) {
+	req := items.CopyItemRequest{
+		TableID:  tableID,
+		ItemID:   itemID,
+		ParentID: parentID,
+		Name:     name,
+	}
+
+	resp, err := items.CopyItem(c.tableManager, c.database, c.generator, req)
+	if err != nil {
+		return dbTypes.Node{}, fmt.Errorf("failed to copy item: %w", err)
+	}
+
+	return resp.Item, nil
+}
+
+// DeleteItem deletes an item (folders with their contents). Returns whether it went to the table's
+// trash; deletes in tables without a trash, or with permanent set, can't be undone.
+func (c *GhostFSClient) DeleteItem(tableID, itemID string, permanent bool) (bool, error) {
+	req := items.DeleteItemRequest{
+		TableID:   tableID,
+		ItemID:    itemID,
+		Permanent: permanent,
+	}
+
+	resp, err := items.DeleteItem(c.tableManager, c.database, c.generator, req)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete item: %w", err)
+	}
+
+	return resp.Trashed, nil
+}
+
+// ListTrash lists the items in a table's trash, most recently deleted first
+func (c *GhostFSClient) ListTrash(tableID string) ([]dbTypes.TrashItem, error) {
+	resp, err := items.ListTrash(c.tableManager, c.database, items.ListTrashRequest{TableID: tableID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trash: %w", err)
+	}
+
+	return resp.Items, nil
+}
+
+// RestoreItem moves an item out of the trash (empty parentID / name = where and as it was deleted)
+func (c *GhostFSClient) RestoreItem(tableID, itemID, parentID, name string) (dbTypes.Node, error) {
+	req := items.RestoreTrashItemRequest{
+		TableID:  tableID,
+		ItemID:   itemID,
+		ParentID: parentID,
+		Name:     name,
+	}
+
+	resp, err := items.RestoreTrashItem(c.tableManager, c.database, c.generator, req)
+	if err != nil {
+		return dbTypes.Node{}, fmt.Errorf("failed to restore item: %w", err)
+	}
+
+	return resp.Item, nil
+}
+
+// PurgeTrash permanently deletes an item from the trash (empty itemID = empty the trash).
+// Returns the number of purged items.
+func (c *GhostFSClient) PurgeTrash(tableID, itemID string) (int64, error) {
+	resp, err := items.PurgeTrash(c.tableManager, c.database, items.PurgeTrashRequest{TableID: tableID, ItemID: itemID})
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge trash: %w", err)
+	}
+
+	return resp.Purged, nil
+}
+
+// LockItem acquires an exclusive lock on an item for owner (duration 0 = until released). Writes,
+// moves and deletes of locked items fail with an *items.ItemError with code "locked".
+func (c *GhostFSClient) LockItem(tableID, itemID, owner string, duration time.Duration) (dbTypes.Lock, error) {
+	req := items.LockItemRequest{
+		TableID:  tableID,
+		ItemID:   itemID,
+		Owner:    owner,
+		Duration: duration,
+	}
+
+	resp, err := items.LockItem(c.tableManager, c.database, c.generator, req)
+	if err != nil {
+		return dbTypes.Lock{}, fmt.Errorf("failed to lock item: %w", err)
+	}
+
+	return *resp.Item.Lock, nil
+}
+
+// UnlockItem releases owner's lock on an item (force = release anyone's lock)
+func (c *GhostFSClient) UnlockItem(tableID, itemID, owner string, force bool) error {
+	req := items.UnlockItemRequest{
+		TableID: tableID,
+		ItemID:  itemID,
+		Owner:   owner,
+		Force:   force,
+	}
+
+	if _, err := items.UnlockItem(c.tableManager, c.database, c.generator, req); err != nil {
+		return fmt.Errorf("failed to unlock item: %w", err)
+	}
+
+	return nil
+}
+
+// GetSharing returns who an item is shared with and its shared links
+func (c *GhostFSClient) GetSharing(tableID, itemID string) (dbTypes.Sharing, error) {
+	resp, err := items.GetSharing(c.tableManager, c.database, c.generator, items.GetSharingRequest{TableID: tableID, ItemID: itemID})
+	if err != nil {
+		return dbTypes.Sharing{}, fmt.Errorf("failed to get sharing: %w", err)
+	}
+
+	return resp.Sharing, nil
+}
+
+// ShareItem shares an item with people (or changes their roles) and removes the members in remove
+func (c *GhostFSClient) ShareItem(tableID, itemID string, add []dbTypes.ShareMember, remove []string) (dbTypes.Sharing, error) {
+	req := items.UpdateMembersRequest{
+		TableID: tableID,
+		ItemID:  itemID,
+		Add:     add,
+		Remove:  remove,
+	}
+
+	resp, err := items.UpdateMembers(c.tableManager, c.database, c.generator, req)
+	if err != nil {
+		return dbTypes.Sharing{}, fmt.Errorf("failed to share item: %w", err)
+	}
+
+	return resp.Sharing, nil
+}
+
+// CreateLink adds a shared link to an item (empty scope and role = anyone with the link can view,
+// nil expiresAt = never expires)
+func (c *GhostFSClient) CreateLink(tableID, itemID, scope, role string, expiresAt *time.Time, password bool) (dbTypes.SharedLink, error) {
+	req := items.CreateLinkRequest{
+		TableID:   tableID,
+		ItemID:    itemID,
+		Scope:     scope,
+		Role:      role,
+		ExpiresAt: expiresAt,
+		Password:  password,
+	}
+
+	resp, err := items.CreateLink(c.tableManager, c.database, c.generator, req)
+	if err != nil {
+		return dbTypes.SharedLink{}, fmt.Errorf("failed to create link: %w", err)
+	}
+
+	return resp.Link, nil
+}
+
+// DeleteLink removes a shared link from an item
+func (c *GhostFSClient) DeleteLink(tableID, itemID, linkID string) error {
+	req := items.DeleteLinkRequest{TableID: tableID, ItemID: itemID, LinkID: linkID}
+	if _, err := items.DeleteLink(c.tableManager, c.database, c.generator, req); err != nil {
+		return fmt.Errorf("failed to delete link: %w", err)
+	}
+
+	return nil
+}
+
+// ListRevisions lists the versions of a file, oldest first (the last one is current)
+func (c *GhostFSClient) ListRevisions(tableID, itemID string) ([]dbTypes.Revision, error) {
+	req := items.ListRevisionsRequest{
+		TableID: tableID,
+		ItemID:  itemID,
+	}
+
+	resp, err := items.ListRevisions(c.tableManager, c.database, c.generator, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list revisions: %w", err)
+	}
+
+	return resp.Revisions, nil
+}
+
+// OpenRevision returns a reader over the content of one version of a file
+func (c *GhostFSClient) OpenRevision(tableID, itemID, revisionID string) (io.ReadSeeker, dbTypes.Revision, error) {
+	req := items.OpenRevisionRequest{
+		TableID:    tableID,
+		ItemID:     itemID,
+		RevisionID: revisionID,
+	}
+
+	resp, err := items.OpenRevision(c.tableManager, c.database, c.generator, req)
+	if err != nil {
+		return nil, dbTypes.Revision{}, fmt.Errorf("failed to open revision: %w", err)
+	}
+
+	return resp.Content, resp.Revision, nil
+}
+
+// RestoreRevision makes a version of a file current again and returns the new current revision
+func (c *GhostFSClient) RestoreRevision(tableID, itemID, revisionID, author string) (dbTypes.Revision, error) {
+	req := items.RestoreRevisionRequest{
+		TableID:    tableID,
+		ItemID:     itemID,
+		RevisionID: revisionID,
+		Author:     author,
+	}
+
+	resp, err := items.RestoreRevision(c.tableManager, c.database, c.generator, req)
+	if err != nil {
+		return dbTypes.Revision{}, fmt.Errorf("failed to restore revision: %w", err)
+	}
+
+	return resp.Revision, nil
+}
+
 // ListTables lists all available tables
 func (c *GhostFSClient) ListTables() ([]dbTypes.TableInfo, error) {
 	resp, err := coreTables.ListTables(c.database)
@@ -224,6 +537,71 @@ func (c *GhostFSClient) ListTables() ([]dbTypes.TableInfo, error) {
 	return resp.Tables, nil
 }
 
+// ImportDirectory mirrors the shape of a local directory (names, sizes, timestamps) into a new table.
+// Returns the new table's ID. Set replace to overwrite an existing table with the same name.
+func (c *GhostFSClient) ImportDirectory(tableName, dir string, replace bool) (string, error) {
+	entries, err := importer.ScanDirectory(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to scan directory: %w", err)
+	}
+	return c.importEntries(tableName, entries, replace)
+}
+
+// ImportManifest builds a new table from a file listing ("find", "csv" or "jsonl"; empty format
+// guesses from the file extension). Returns the new table's ID.
+func (c *GhostFSClient) ImportManifest(tableName, manifestPath, format string, replace bool) (string, error) {
+	entries, err := importer.ReadManifestFile(manifestPath, format)
+	if err != nil {
+		return "", fmt.Errorf("failed to read manifest: %w", err)
+	}
+	return c.importEntries(tableName, entries, replace)
+}
+
+func (c *GhostFSClient) importEntries(tableName string, entries []importer.Entry, replace bool) (string, error) {
+	if c.tableManager.IsGeneratedTable(tableName) {
+		return "", fmt.Errorf("table %s is a generated table from the config", tableName)
+	}
+	result, err := importer.Import(c.database, tableName, entries, importer.Options{Replace: replace})
+	if err != nil {
+		return "", fmt.Errorf("failed to import: %w", err)
+	}
+	return result.TableID, nil
+}
+
+// ExportOptions selects what ExportDirectory and ExportArchive write
+type ExportOptions struct {
+	FolderID    string // subtree to export (empty = whole table)
+	MaxFileSize int64  // cap on bytes written per file (0 = full size)
+	MaxDepth    int    // levels below the exported folder (0 = all)
+}
+
+// ExportDirectory materializes a table (or subtree) under dir with deterministic content and seeded timestamps
+func (c *GhostFSClient) ExportDirectory(tableID, dir string, opts ExportOptions) (*export.ExportResponse, error) {
+	resp, err := export.ExportToDirectory(c.tableManager, c.database, c.generator, exportRequest(tableID, opts), dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export: %w", err)
+	}
+	return resp, nil
+}
+
+// ExportArchive streams a table (or subtree) to w as a "tar" or "zip" archive
+func (c *GhostFSClient) ExportArchive(tableID string, w io.Writer, format string, opts ExportOptions) (*export.ExportResponse, error) {
+	resp, err := export.ExportToArchive(c.tableManager, c.database, c.generator, exportRequest(tableID, opts), w, format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export: %w", err)
+	}
+	return resp, nil
+}
+
+func exportRequest(tableID string, opts ExportOptions) export.ExportRequest {
+	return export.ExportRequest{
+		TableID:     tableID,
+		FolderID:    opts.FolderID,
+		MaxFileSize: opts.MaxFileSize,
+		MaxDepth:    opts.MaxDepth,
+	}
+}
+
 // GetCacheStats returns cache statistics
 func (c *GhostFSClient) GetCacheStats() map[string]int {
 	return c.generator.GetCacheStats()
@@ -0,0 +1,296 @@
+package sdk
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Voltaic314/GhostFS/code/core/items"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+)
+
+// TableFS serves a table as a read-only io/fs file system, so fs.WalkDir, testing/fstest, http.FS
+// and anything else that takes an fs.FS can read a GhostFS tree. Files read back as their
+// deterministic content, folders are listed (and generated) on demand. Like the SFTP server,
+// symlinks are followed and shortcuts aren't served.
+type TableFS struct {
+	client  *GhostFSClient
+	tableID string
+}
+
+var (
+	_ fs.ReadDirFS  = (*TableFS)(nil)
+	_ fs.StatFS     = (*TableFS)(nil)
+	_ fs.ReadFileFS = (*TableFS)(nil)
+)
+
+// maxSymlinkHops is how many symlinks are followed before giving up, like a loop
+const maxSymlinkHops = 8
+
+var errSymlinkLoop = errors.New("too many levels of symbolic links")
+
+// maxReadFileSize is the largest file ReadFile reads into memory; bigger ones (e.g. the multi-GB
+// outliers of a size distribution) have to be streamed with Open
+const maxReadFileSize = 512 << 20 // 512 MiB
+
+// FS returns a read-only file system over a table. Names are slash-separated paths relative to the
+// table's root folder, e.g. "folder_0/file_1.txt" ("." = the root).
+func (c *GhostFSClient) FS(tableID string) (*TableFS, error) {
+	if _, err := c.GetRoot(tableID); err != nil {
+		return nil, err
+	}
+	return &TableFS{client: c, tableID: tableID}, nil
+}
+
+// lookup returns the node a name refers to, following symlinks
+func (fsys *TableFS) lookup(op, name string) (dbTypes.Node, error) {
+	if !fs.ValidPath(name) {
+		return dbTypes.Node{}, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	_, node, err := fsys.client.resolvePath(fsys.tableID, path.Join("/", name), true)
+	if err == nil && node == nil {
+		err = fs.ErrNotExist
+	}
+	if err != nil {
+		return dbTypes.Node{}, &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	return *node, nil
+}
+
+// nodeAt returns the node at a path of a table, or nil if there is none. Shortcuts aren't served.
+func (c *GhostFSClient) nodeAt(tableID, nodePath string) (*dbTypes.Node, error) {
+	resp, err := items.GetItemByPath(c.tableManager, c.database, c.generator, items.GetItemByPathRequest{TableID: tableID, Path: nodePath})
+	var itemErr *items.ItemError
+	if errors.As(err, &itemErr) && itemErr.Code == items.ErrCodeNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if resp.Item.Type == tables.NodeTypeShortcut {
+		return nil, nil
+	}
+	return &resp.Item, nil
+}
+
+// resolvePath returns the node at a path (nil if there is none) and the path it was found at. With
+// followLinks, symlinks are resolved: targets are relative to the link's folder, absolute targets
+// start at the table's root.
+func (c *GhostFSClient) resolvePath(tableID, nodePath string, followLinks bool) (string, *dbTypes.Node, error) {
+	node, err := c.nodeAt(tableID, nodePath)
+	for hops := 0; err == nil && followLinks && node != nil && node.Type == tables.NodeTypeSymlink; hops++ {
+		if hops == maxSymlinkHops {
+			return nodePath, nil, errSymlinkLoop
+		}
+		if strings.HasPrefix(node.Target, "/") {
+			nodePath = path.Clean(node.Target)
+		} else {
+			nodePath = path.Join(path.Dir(nodePath), node.Target)
+		}
+		node, err = c.nodeAt(tableID, nodePath)
+	}
+	return nodePath, node, err
+}
+
+// Open opens a file or folder
+func (fsys *TableFS) Open(name string) (fs.File, error) {
+	node, err := fsys.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+	info := fileInfo{name: path.Base(name), node: node}
+	if node.Type == tables.NodeTypeFolder {
+		return &tableDir{fsys: fsys, path: name, info: info}, nil
+	}
+	return &tableFile{path: name, info: info, content: fsys.client.generator.OpenContent(node, node.Size)}, nil
+}
+
+// Stat returns the info of a file or folder, following symlinks
+func (fsys *TableFS) Stat(name string) (fs.FileInfo, error) {
+	node, err := fsys.lookup("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return fileInfo{name: path.Base(name), node: node}, nil
+}
+
+// ReadDir lists a folder, sorted by name. Symlinks are listed as links.
+func (fsys *TableFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	node, err := fsys.lookup("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	if node.Type != tables.NodeTypeFolder {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+	children, err := fsys.client.ListItems(fsys.tableID, node.ID, false)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+	entries := make([]fs.DirEntry, 0, len(children))
+	for _, child := range children {
+		if child.Type != tables.NodeTypeShortcut {
+			entries = append(entries, fileInfo{name: child.Name, node: child})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+// ReadFile reads a whole file. Files over 512 MiB are refused, stream them with Open instead.
+func (fsys *TableFS) ReadFile(name string) ([]byte, error) {
+	node, err := fsys.lookup("readfile", name)
+	if err != nil {
+		return nil, err
+	}
+	if node.Type == tables.NodeTypeFolder {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: errors.New("is a directory")}
+	}
+	if node.Size > maxReadFileSize {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: fmt.Errorf("file is too large to read at once (%d bytes, the limit is %d), use Open", node.Size, maxReadFileSize)}
+	}
+	data := make([]byte, node.Size)
+	if _, err := io.ReadFull(fsys.client.generator.OpenContent(node, node.Size), data); err != nil {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: err}
+	}
+	return data, nil
+}
+
+// fileInfo describes a node. It is both the fs.FileInfo and the fs.DirEntry of the node.
+type fileInfo struct {
+	name string
+	node dbTypes.Node
+}
+
+func (fi fileInfo) Name() string { return fi.name }
+
+func (fi fileInfo) Size() int64 {
+	switch fi.node.Type {
+	case tables.NodeTypeFolder:
+		return 0
+	case tables.NodeTypeSymlink:
+		return int64(len(fi.node.Target))
+	}
+	return fi.node.Size
+}
+
+func (fi fileInfo) Mode() fs.FileMode {
+	switch fi.node.Type {
+	case tables.NodeTypeFolder:
+		return fs.ModeDir | 0o755
+	case tables.NodeTypeSymlink:
+		return fs.ModeSymlink | 0o777
+	}
+	return 0o644
+}
+
+func (fi fileInfo) ModTime() time.Time         { return fi.node.UpdatedAt }
+func (fi fileInfo) IsDir() bool                { return fi.node.Type == tables.NodeTypeFolder }
+func (fi fileInfo) Sys() any                   { return fi.node } // the dbTypes.Node
+func (fi fileInfo) Type() fs.FileMode          { return fi.Mode().Type() }
+func (fi fileInfo) Info() (fs.FileInfo, error) { return fi, nil }
+func (fi fileInfo) String() string             { return fs.FormatFileInfo(fi) }
+
+// tableFile is an open file. Besides fs.File it is an io.ReaderAt and io.Seeker, which http.FS
+// uses for range requests.
+type tableFile struct {
+	path    string
+	info    fileInfo
+	content *tables.ContentReader
+	closed  bool
+}
+
+func (f *tableFile) Stat() (fs.FileInfo, error) {
+	if f.closed {
+		return nil, &fs.PathError{Op: "stat", Path: f.path, Err: fs.ErrClosed}
+	}
+	return f.info, nil
+}
+
+func (f *tableFile) Read(p []byte) (int, error) {
+	if f.closed {
+		return 0, &fs.PathError{Op: "read", Path: f.path, Err: fs.ErrClosed}
+	}
+	return f.content.Read(p)
+}
+
+func (f *tableFile) ReadAt(p []byte, off int64) (int, error) {
+	if f.closed {
+		return 0, &fs.PathError{Op: "read", Path: f.path, Err: fs.ErrClosed}
+	}
+	return f.content.ReadAt(p, off)
+}
+
+func (f *tableFile) Seek(offset int64, whence int) (int64, error) {
+	if f.closed {
+		return 0, &fs.PathError{Op: "seek", Path: f.path, Err: fs.ErrClosed}
+	}
+	return f.content.Seek(offset, whence)
+}
+
+func (f *tableFile) Close() error {
+	if f.closed {
+		return &fs.PathError{Op: "close", Path: f.path, Err: fs.ErrClosed}
+	}
+	f.closed = true
+	return nil
+}
+
+// tableDir is an open folder. It is listed on the first ReadDir.
+type tableDir struct {
+	fsys    *TableFS
+	path    string
+	info    fileInfo
+	entries []fs.DirEntry
+	listed  bool
+	offset  int
+	closed  bool
+}
+
+func (d *tableDir) Stat() (fs.FileInfo, error) {
+	if d.closed {
+		return nil, &fs.PathError{Op: "stat", Path: d.path, Err: fs.ErrClosed}
+	}
+	return d.info, nil
+}
+
+func (d *tableDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.path, Err: errors.New("is a directory")}
+}
+
+// ReadDir returns the next n entries (n <= 0: all the remaining ones), like os.File.ReadDir
+func (d *tableDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if d.closed {
+		return nil, &fs.PathError{Op: "readdir", Path: d.path, Err: fs.ErrClosed}
+	}
+	if !d.listed {
+		entries, err := d.fsys.ReadDir(d.path)
+		if err != nil {
+			return nil, err
+		}
+		d.entries, d.listed = entries, true
+	}
+	rest := d.entries[d.offset:]
+	if n > 0 && len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if n > 0 && n < len(rest) {
+		rest = rest[:n]
+	}
+	d.offset += len(rest)
+	return rest, nil
+}
+
+func (d *tableDir) Close() error {
+	if d.closed {
+		return &fs.PathError{Op: "close", Path: d.path, Err: fs.ErrClosed}
+	}
+	d.closed = true
+	return nil
+}
@@ -0,0 +1,584 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	itemRoutes "github.com/Voltaic314/GhostFS/code/api/routes/items"
+	tableRoutes "github.com/Voltaic314/GhostFS/code/api/routes/tables"
+	"github.com/Voltaic314/GhostFS/code/core/items"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	"github.com/Voltaic314/GhostFS/code/types/api"
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+)
+
+// HTTPClientConfig configures a GhostFSHTTPClient
+type HTTPClientConfig struct {
+	Timeout      time.Duration     // Per request, including reading the response (0 = none)
+	MaxRetries   int               // Retries of failed requests (0 = don't retry)
+	RetryBackoff time.Duration     // Delay before the first retry, doubled after each one (default 100ms)
+	MaxIdleConns int               // Idle connections kept open to the server (default 100)
+	Transport    http.RoundTripper // Optional: replaces the pooled transport
+}
+
+// GhostFSHTTPClient is the SDK client for a running GhostFS server: the GhostFSClient methods
+// over the REST API, so it can share a database with the server and exercises its network layer.
+// Connections are pooled and safe for concurrent use.
+type GhostFSHTTPClient struct {
+	baseURL    string
+	httpClient *http.Client
+	config     HTTPClientConfig
+	ctx        context.Context
+}
+
+// APIError is a failed request, decoded from the server's api.Response. When its item error code
+// is known it also matches *items.ItemError with errors.As, like the in-process client's errors.
+type APIError struct {
+	StatusCode int    // HTTP status (200 for an item that failed in a batch endpoint)
+	Code       string // Item error code, e.g. "not_found", "locked", "name_conflict" (empty = unknown)
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s (HTTP %d)", e.Message, e.StatusCode)
+}
+
+// Unwrap returns the *items.ItemError of the failure, if its code is known
+func (e *APIError) Unwrap() error {
+	if e.Code == "" {
+		return nil
+	}
+	return &items.ItemError{Code: e.Code, Message: e.Message}
+}
+
+// statusCodes are the item error codes that statuses imply when a response has no code
+var statusCodes = map[int]string{
+	http.StatusNotFound: items.ErrCodeNotFound,
+	http.StatusLocked:   items.ErrCodeLocked,
+}
+
+// NewGhostFSHTTPClient creates a client for the server at baseURL, e.g. "http://localhost:8086"
+func NewGhostFSHTTPClient(baseURL string, config HTTPClientConfig) (*GhostFSHTTPClient, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid server URL: %s", baseURL)
+	}
+	if config.MaxRetries < 0 {
+		return nil, fmt.Errorf("max retries can't be negative")
+	}
+	if config.RetryBackoff <= 0 {
+		config.RetryBackoff = 100 * time.Millisecond
+	}
+	if config.MaxIdleConns <= 0 {
+		config.MaxIdleConns = 100
+	}
+
+	transport := config.Transport
+	if transport == nil {
+		pooled := http.DefaultTransport.(*http.Transport).Clone()
+		pooled.MaxIdleConns = config.MaxIdleConns
+		pooled.MaxIdleConnsPerHost = config.MaxIdleConns
+		transport = pooled
+	}
+
+	return &GhostFSHTTPClient{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: &http.Client{Transport: transport, Timeout: config.Timeout},
+		config:     config,
+		ctx:        context.Background(),
+	}, nil
+}
+
+// WithContext returns a copy of the client whose requests use ctx, to cancel them or give them a
+// deadline. The copy shares the connections.
+func (c *GhostFSHTTPClient) WithContext(ctx context.Context) *GhostFSHTTPClient {
+	copied := *c
+	copied.ctx = ctx
+	return &copied
+}
+
+// Close closes the idle connections
+func (c *GhostFSHTTPClient) Close() error {
+	c.httpClient.CloseIdleConnections()
+	return nil
+}
+
+// send sends a JSON request, retrying it on 429 and 503 responses. Idempotent requests are also
+// retried on connection failures and 502 and 504 responses, which other requests may have done.
+func (c *GhostFSHTTPClient) send(method, endpoint string, idempotent bool, body interface{}, header http.Header) (*http.Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	backoff := c.config.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(c.ctx, method, c.baseURL+endpoint, bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		for key, values := range header {
+			req.Header[key] = values
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if attempt == c.config.MaxRetries || c.ctx.Err() != nil || !retryable(resp, err, idempotent) {
+			return resp, err
+		}
+
+		delay := backoff
+		if resp != nil {
+			if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+				delay = time.Duration(seconds) * time.Second
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		select {
+		case <-c.ctx.Done():
+			return nil, c.ctx.Err()
+		case <-time.After(delay):
+		}
+		backoff *= 2
+	}
+}
+
+func retryable(resp *http.Response, err error, idempotent bool) bool {
+	if err != nil {
+		return idempotent
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return idempotent
+	}
+	return false
+}
+
+// call sends a JSON request and decodes the data of its api.Response into data
+func (c *GhostFSHTTPClient) call(method, endpoint string, idempotent bool, body interface{}, data interface{}) error {
+	resp, err := c.send(method, endpoint, idempotent, body, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return decodeResponse(resp, data)
+}
+
+// decodeResponse decodes an api.Response. Failures are *APIErrors.
+func decodeResponse(resp *http.Response, data interface{}) error {
+	apiResp := api.Response{Data: data}
+	err := json.NewDecoder(resp.Body).Decode(&apiResp)
+	if resp.StatusCode >= http.StatusBadRequest || (err == nil && !apiResp.Success) {
+		message := apiResp.Error
+		if message == "" {
+			message = http.StatusText(resp.StatusCode)
+		}
+		code := apiResp.Code
+		if code == "" {
+			code = statusCodes[resp.StatusCode]
+		}
+		return &APIError{StatusCode: resp.StatusCode, Code: code, Message: message}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// itemError returns the failure of an item of a batch endpoint's response, or nil
+func itemError(code, message string) error {
+	if code == "" && message == "" {
+		return nil
+	}
+	return &APIError{StatusCode: http.StatusOK, Code: code, Message: message}
+}
+
+// ListTables lists all available tables
+func (c *GhostFSHTTPClient) ListTables() ([]dbTypes.TableInfo, error) {
+	var data tableRoutes.ListTablesResponseData
+	if err := c.call(http.MethodPost, "/tables/list", true, nil, &data); err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+
+	return data.Tables, nil
+}
+
+// GetRoot gets the root node for a table
+func (c *GhostFSHTTPClient) GetRoot(tableID string) (dbTypes.Node, error) {
+	var data itemRoutes.GetRootResponseData
+	if err := c.call(http.MethodGet, "/items/get_root", true, itemRoutes.GetRootRequest{TableID: tableID}, &data); err != nil {
+		return dbTypes.Node{}, fmt.Errorf("failed to get root: %w", err)
+	}
+
+	return data.Root, nil
+}
+
+// ListItems lists all items (files and folders) in a folder
+func (c *GhostFSHTTPClient) ListItems(tableID, folderID string, foldersOnly bool) ([]dbTypes.Node, error) {
+	req := itemRoutes.ListRequest{
+		TableID:     tableID,
+		FolderID:    folderID,
+		FoldersOnly: foldersOnly,
+	}
+
+	var data itemRoutes.ListResponseData
+	if err := c.call(http.MethodPost, "/items/list", true, req, &data); err != nil {
+		return nil, fmt.Errorf("failed to list items: %w", err)
+	}
+
+	return data.Items, nil
+}
+
+// CreateFolder creates an empty folder in a folder. The returned node has the fields the API
+// reports: ID, parent, name, path and type.
+func (c *GhostFSHTTPClient) CreateFolder(tableID, parentID, name string) (dbTypes.Node, error) {
+	return c.createItem(tableID, parentID, itemRoutes.NewItemRequest{Name: name, Type: tables.NodeTypeFolder})
+}
+
+// CreateFile creates a file of the given size in a folder. The returned node has the fields the
+// API reports: ID, parent, name, path, type and size.
+func (c *GhostFSHTTPClient) CreateFile(tableID, parentID, name string, size int64) (dbTypes.Node, error) {
+	return c.createItem(tableID, parentID, itemRoutes.NewItemRequest{Name: name, Type: tables.NodeTypeFile, Size: size})
+}
+
+func (c *GhostFSHTTPClient) createItem(tableID, parentID string, item itemRoutes.NewItemRequest) (dbTypes.Node, error) {
+	req := itemRoutes.CreateRequest{
+		TableID:  tableID,
+		ParentID: parentID,
+		Items:    []itemRoutes.NewItemRequest{item},
+	}
+
+	var data itemRoutes.CreateResponseData
+	if err := c.call(http.MethodPost, "/items/new", false, req, &data); err != nil {
+		return dbTypes.Node{}, fmt.Errorf("failed to create %s: %w", item.Name, err)
+	}
+	created := data.Items[0]
+	if err := itemError(created.Code, created.Error); err != nil {
+		return dbTypes.Node{}, fmt.Errorf("failed to create %s: %w", item.Name, err)
+	}
+
+	return dbTypes.Node{ID: created.ID, ParentID: parentID, Name: created.Name, Path: created.Path, Type: created.Type, Size: created.Size}, nil
+}
+
+// MoveItem moves an item to another folder (empty parentID = same folder) and/or renames it (empty
+// name = keep). The returned node has the fields the API reports: ID, parent, name and path.
+func (c *GhostFSHTTPClient) MoveItem(tableID, itemID, parentID, name string) (dbTypes.Node, error) {
+	req := itemRoutes.MoveRequest{
+		TableID: tableID,
+		Items:   []itemRoutes.MoveItemRequest{{ID: itemID, ParentID: parentID, Name: name}},
+	}
+
+	var data itemRoutes.MoveResponseData
+	if err := c.call(http.MethodPost, "/items/move", false, req, &data); err != nil {
+		return dbTypes.Node{}, fmt.Errorf("failed to move item: %w", err)
+	}
+	moved := data.Items[0]
+	if err := itemError(moved.Code, moved.Error); err != nil {
+		return dbTypes.Node{}, fmt.Errorf("failed to move item: %w", err)
+	}
+
+	return dbTypes.Node{ID: moved.ID, ParentID: moved.ParentID, Name: moved.Name, Path: moved.Path}, nil
+}
+
+// CopyItem copies an item (folders with their contents) to a folder (empty parentID = same folder).
+// The returned node has the fields the API reports: ID, parent, name and path.
+func (c *GhostFSHTTPClient) CopyItem(tableID, itemID, parentID, name string) (dbTypes.Node, error) {
+	req := itemRoutes.CopyRequest{
+		TableID: tableID,
+		Items:   []itemRoutes.CopyItemRequest{{ID: itemID, ParentID: parentID, Name: name}},
+	}
+
+	var data itemRoutes.CopyResponseData
+	if err := c.call(http.MethodPost, "/items/copy", false, req, &data); err != nil {
+		return dbTypes.Node{}, fmt.Errorf("failed to copy item: %w", err)
+	}
+	copied := data.Items[0]
+	if err := itemError(copied.Code, copied.Error); err != nil {
+		return dbTypes.Node{}, fmt.Errorf("failed to copy item: %w", err)
+	}
+
+	return dbTypes.Node{ID: copied.ID, ParentID: copied.ParentID, Name: copied.Name, Path: copied.Path}, nil
+}
+
+// DeleteItem deletes an item (folders with their contents). Returns whether it went to the table's
+// trash; deletes in tables without a trash, or with permanent set, can't be undone.
+func (c *GhostFSHTTPClient) DeleteItem(tableID, itemID string, permanent bool) (bool, error) {
+	req := itemRoutes.DeleteRequest{
+		TableID:   tableID,
+		ItemIDs:   []string{itemID},
+		Permanent: permanent,
+	}
+
+	var data itemRoutes.DeleteResponseData
+	if err := c.call(http.MethodPost, "/items/delete", false, req, &data); err != nil {
+		return false, fmt.Errorf("failed to delete item: %w", err)
+	}
+	deleted := data.Items[0]
+	if err := itemError(deleted.Code, deleted.Error); err != nil {
+		return false, fmt.Errorf("failed to delete item: %w", err)
+	}
+
+	return deleted.Trashed, nil
+}
+
+// ListTrash lists the items in a table's trash, most recently deleted first
+func (c *GhostFSHTTPClient) ListTrash(tableID string) ([]dbTypes.TrashItem, error) {
+	var data itemRoutes.ListTrashResponseData
+	if err := c.call(http.MethodPost, "/items/list_trash", true, itemRoutes.ListTrashRequest{TableID: tableID}, &data); err != nil {
+		return nil, fmt.Errorf("failed to list trash: %w", err)
+	}
+
+	return data.Items, nil
+}
+
+// RestoreItem moves an item out of the trash (empty parentID / name = where and as it was deleted)
+func (c *GhostFSHTTPClient) RestoreItem(tableID, itemID, parentID, name string) (dbTypes.Node, error) {
+	req := itemRoutes.RestoreRequest{
+		TableID:  tableID,
+		ItemID:   itemID,
+		ParentID: parentID,
+		Name:     name,
+	}
+
+	var data itemRoutes.RestoreResponseData
+	if err := c.call(http.MethodPost, "/items/restore", false, req, &data); err != nil {
+		return dbTypes.Node{}, fmt.Errorf("failed to restore item: %w", err)
+	}
+
+	return data.Item, nil
+}
+
+// PurgeTrash permanently deletes an item from the trash (empty itemID = empty the trash).
+// Returns the number of purged items.
+func (c *GhostFSHTTPClient) PurgeTrash(tableID, itemID string) (int64, error) {
+	var data itemRoutes.PurgeResponseData
+	if err := c.call(http.MethodPost, "/items/purge", false, itemRoutes.PurgeRequest{TableID: tableID, ItemID: itemID}, &data); err != nil {
+		return 0, fmt.Errorf("failed to purge trash: %w", err)
+	}
+
+	return data.Purged, nil
+}
+
+// LockItem acquires an exclusive lock on an item for owner (duration 0 = until released, rounded up
+// to whole seconds). Writes, moves and deletes of locked items fail with code "locked".
+func (c *GhostFSHTTPClient) LockItem(tableID, itemID, owner string, duration time.Duration) (dbTypes.Lock, error) {
+	req := itemRoutes.LockRequest{
+		TableID:         tableID,
+		ItemID:          itemID,
+		Owner:           owner,
+		DurationSeconds: int64((duration + time.Second - 1) / time.Second),
+	}
+
+	var data itemRoutes.LockResponseData
+	if err := c.call(http.MethodPost, "/items/lock", true, req, &data); err != nil {
+		return dbTypes.Lock{}, fmt.Errorf("failed to lock item: %w", err)
+	}
+	if data.Item.Lock == nil {
+		return dbTypes.Lock{}, fmt.Errorf("failed to lock item: the server returned no lock")
+	}
+
+	return *data.Item.Lock, nil
+}
+
+// UnlockItem releases owner's lock on an item (force = release anyone's lock)
+func (c *GhostFSHTTPClient) UnlockItem(tableID, itemID, owner string, force bool) error {
+	req := itemRoutes.UnlockRequest{
+		TableID: tableID,
+		ItemID:  itemID,
+		Owner:   owner,
+		Force:   force,
+	}
+
+	if err := c.call(http.MethodPost, "/items/unlock", false, req, nil); err != nil {
+		return fmt.Errorf("failed to unlock item: %w", err)
+	}
+
+	return nil
+}
+
+// GetSharing returns who an item is shared with and its shared links
+func (c *GhostFSHTTPClient) GetSharing(tableID, itemID string) (dbTypes.Sharing, error) {
+	var data itemRoutes.SharingResponseData
+	if err := c.call(http.MethodPost, "/items/sharing", true, itemRoutes.SharingRequest{TableID: tableID, ItemID: itemID}, &data); err != nil {
+		return dbTypes.Sharing{}, fmt.Errorf("failed to get sharing: %w", err)
+	}
+
+	return data.Sharing, nil
+}
+
+// ShareItem shares an item with people (or changes their roles) and removes the members in remove
+func (c *GhostFSHTTPClient) ShareItem(tableID, itemID string, add []dbTypes.ShareMember, remove []string) (dbTypes.Sharing, error) {
+	req := itemRoutes.MembersRequest{
+		TableID: tableID,
+		ItemID:  itemID,
+		Add:     add,
+		Remove:  remove,
+	}
+
+	var data itemRoutes.SharingResponseData
+	if err := c.call(http.MethodPost, "/items/sharing/members", true, req, &data); err != nil {
+		return dbTypes.Sharing{}, fmt.Errorf("failed to share item: %w", err)
+	}
+
+	return data.Sharing, nil
+}
+
+// CreateLink adds a shared link to an item (empty scope and role = anyone with the link can view,
+// nil expiresAt = never expires)
+func (c *GhostFSHTTPClient) CreateLink(tableID, itemID, scope, role string, expiresAt *time.Time, password bool) (dbTypes.SharedLink, error) {
+	req := itemRoutes.CreateLinkRequest{
+		TableID:   tableID,
+		ItemID:    itemID,
+		Scope:     scope,
+		Role:      role,
+		ExpiresAt: expiresAt,
+		Password:  password,
+	}
+
+	var data itemRoutes.CreateLinkResponseData
+	if err := c.call(http.MethodPost, "/items/sharing/links/create", false, req, &data); err != nil {
+		return dbTypes.SharedLink{}, fmt.Errorf("failed to create link: %w", err)
+	}
+
+	return data.Link, nil
+}
+
+// DeleteLink removes a shared link from an item
+func (c *GhostFSHTTPClient) DeleteLink(tableID, itemID, linkID string) error {
+	req := itemRoutes.DeleteLinkRequest{TableID: tableID, ItemID: itemID, LinkID: linkID}
+	if err := c.call(http.MethodPost, "/items/sharing/links/delete", false, req, nil); err != nil {
+		return fmt.Errorf("failed to delete link: %w", err)
+	}
+
+	return nil
+}
+
+// ListRevisions lists the versions of a file, oldest first (the last one is current)
+func (c *GhostFSHTTPClient) ListRevisions(tableID, itemID string) ([]dbTypes.Revision, error) {
+	var data itemRoutes.RevisionsResponseData
+	if err := c.call(http.MethodPost, "/items/revisions", true, itemRoutes.RevisionsRequest{TableID: tableID, ItemID: itemID}, &data); err != nil {
+		return nil, fmt.Errorf("failed to list revisions: %w", err)
+	}
+
+	return data.Revisions, nil
+}
+
+// OpenRevision returns a reader over the content of one version of a file. The content is
+// downloaded as it's read, with Range requests after seeks; the reader is also an io.Closer, which
+// releases the download when it isn't read to the end.
+func (c *GhostFSHTTPClient) OpenRevision(tableID, itemID, revisionID string) (io.ReadSeeker, dbTypes.Revision, error) {
+	revisions, err := c.ListRevisions(tableID, itemID)
+	if err != nil {
+		return nil, dbTypes.Revision{}, fmt.Errorf("failed to open revision: %w", err)
+	}
+	for _, revision := range revisions {
+		if revision.ID == revisionID {
+			req := itemRoutes.RevisionRequest{TableID: tableID, ItemID: itemID, RevisionID: revisionID}
+			return &revisionReader{client: c, req: req, size: revision.Size}, revision, nil
+		}
+	}
+
+	err = &APIError{StatusCode: http.StatusNotFound, Code: items.ErrCodeNotFound, Message: fmt.Sprintf("revision %s not found", revisionID)}
+	return nil, dbTypes.Revision{}, fmt.Errorf("failed to open revision: %w", err)
+}
+
+// RestoreRevision makes a version of a file current again and returns the new current revision
+func (c *GhostFSHTTPClient) RestoreRevision(tableID, itemID, revisionID, author string) (dbTypes.Revision, error) {
+	req := itemRoutes.RevisionRequest{
+		TableID:    tableID,
+		ItemID:     itemID,
+		RevisionID: revisionID,
+		Author:     author,
+	}
+
+	var data itemRoutes.RestoreRevisionResponseData
+	if err := c.call(http.MethodPost, "/items/revisions/restore", false, req, &data); err != nil {
+		return dbTypes.Revision{}, fmt.Errorf("failed to restore revision: %w", err)
+	}
+
+	return data.Revision, nil
+}
+
+// revisionReader reads a revision's content from /items/revisions/download, from the offset on
+type revisionReader struct {
+	client *GhostFSHTTPClient
+	req    itemRoutes.RevisionRequest
+	size   int64
+	offset int64
+	body   io.ReadCloser
+}
+
+func (r *revisionReader) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		r.Close()
+		return 0, io.EOF
+	}
+	if r.body == nil {
+		header := http.Header{"Range": {fmt.Sprintf("bytes=%d-", r.offset)}}
+		resp, err := r.client.send(http.MethodPost, "/items/revisions/download", true, r.req, header)
+		if err != nil {
+			return 0, err
+		}
+		switch resp.StatusCode {
+		case http.StatusPartialContent:
+		case http.StatusOK:
+			// The whole content, skip to the offset
+			if _, err := io.CopyN(io.Discard, resp.Body, r.offset); err != nil {
+				resp.Body.Close()
+				return 0, err
+			}
+		default:
+			defer resp.Body.Close()
+			return 0, decodeResponse(resp, nil)
+		}
+		r.body = resp.Body
+	}
+
+	n, err := r.body.Read(p)
+	r.offset += int64(n)
+	if err == io.EOF && r.offset < r.size {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+func (r *revisionReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.size
+	case io.SeekStart:
+	default:
+		return 0, fmt.Errorf("invalid whence: %d", whence)
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("negative position: %d", offset)
+	}
+	if offset != r.offset {
+		r.Close()
+		r.offset = offset
+	}
+	return offset, nil
+}
+
+// Close releases the download, reads after it start a new one
+func (r *revisionReader) Close() error {
+	if r.body == nil {
+		return nil
+	}
+	err := r.body.Close()
+	r.body = nil
+	return err
+}
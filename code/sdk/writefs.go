@@ -0,0 +1,625 @@
+package sdk
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Voltaic314/GhostFS/code/core/items"
+	"github.com/Voltaic314/GhostFS/code/db/tables"
+	dbTypes "github.com/Voltaic314/GhostFS/code/types/db"
+)
+
+// FileSystem is a writable file system, shaped after the os package (and afero). It is implemented
+// on GhostFS tables (TableFileSystem) and on a directory of the real file system (OSFileSystem), so
+// code written against it, like a migration engine, can run in-process against GhostFS in tests and
+// against disks in production. Names are slash-separated paths relative to the file system's root;
+// "/", "" and "." are the root.
+type FileSystem interface {
+	Create(name string) (File, error)
+	Mkdir(name string, perm fs.FileMode) error
+	MkdirAll(name string, perm fs.FileMode) error
+	Open(name string) (File, error)
+	OpenFile(name string, flag int, perm fs.FileMode) (File, error)
+	Remove(name string) error
+	RemoveAll(name string) error
+	Rename(oldname, newname string) error
+	Stat(name string) (fs.FileInfo, error)
+	Chtimes(name string, atime time.Time, mtime time.Time) error
+}
+
+// File is an open file or folder of a FileSystem. *os.File implements it.
+type File interface {
+	fs.ReadDirFile
+	io.ReaderAt
+	io.Writer
+	io.WriterAt
+	io.Seeker
+	Name() string
+	Truncate(size int64) error
+}
+
+var (
+	_ FileSystem = (*TableFileSystem)(nil)
+	_ FileSystem = (*OSFileSystem)(nil)
+	_ File       = (*tableHandle)(nil)
+	_ File       = (*os.File)(nil)
+)
+
+var (
+	errIsDir       = errors.New("is a directory")
+	errNotDir      = errors.New("not a directory")
+	errNotEmpty    = errors.New("directory not empty")
+	errNotReadable = errors.New("not open for reading")
+	errNotWritable = errors.New("not open for writing")
+)
+
+// itemErrors are the fs errors that item errors match, so errors.Is(err, fs.ErrExist) etc. work
+// like they do with the os package
+var itemErrors = map[string]error{
+	items.ErrCodeNotFound:     fs.ErrNotExist,
+	items.ErrCodeNameConflict: fs.ErrExist,
+	items.ErrCodeInvalidSize:  fs.ErrInvalid,
+	items.ErrCodeLocked:       fs.ErrPermission,
+	items.ErrCodeNotAllowed:   fs.ErrPermission,
+}
+
+func fsError(err error) error {
+	var itemErr *items.ItemError
+	if errors.As(err, &itemErr) {
+		if fsErr, ok := itemErrors[itemErr.Code]; ok {
+			return fmt.Errorf("%w: %w", fsErr, itemErr)
+		}
+	}
+	return err
+}
+
+func pathError(op, name string, err error) error {
+	return &fs.PathError{Op: op, Path: name, Err: fsError(err)}
+}
+
+// TableFileSystem is a FileSystem over a table. Like the SFTP server, writes only keep the size of
+// what was written: a file is created or gets a new revision with that size when it's closed, and
+// reads back as seeded content. Symlinks are followed, except by Remove and Rename, and shortcuts
+// aren't served. Permissions aren't stored and access times are ignored.
+type TableFileSystem struct {
+	client  *GhostFSClient
+	tableID string
+	reader  *TableFS
+}
+
+// FileSystem returns a writable file system over a table
+func (c *GhostFSClient) FileSystem(tableID string) (*TableFileSystem, error) {
+	reader, err := c.FS(tableID)
+	if err != nil {
+		return nil, err
+	}
+	return &TableFileSystem{client: c, tableID: tableID, reader: reader}, nil
+}
+
+// nodePath returns the node path of a name
+func nodePath(name string) string {
+	return path.Clean("/" + name)
+}
+
+// node returns the node a name refers to. Missing nodes fail with fs.ErrNotExist.
+func (fsys *TableFileSystem) node(op, name string, followLinks bool) (string, *dbTypes.Node, error) {
+	p, node, err := fsys.client.resolvePath(fsys.tableID, nodePath(name), followLinks)
+	if err == nil && node == nil {
+		err = fs.ErrNotExist
+	}
+	if err != nil {
+		return p, nil, pathError(op, name, err)
+	}
+	return p, node, nil
+}
+
+// parentFolder returns the folder a new item at a path goes into. Missing parents aren't created.
+func (fsys *TableFileSystem) parentFolder(op, name, p string) (*dbTypes.Node, error) {
+	_, parent, err := fsys.client.resolvePath(fsys.tableID, path.Dir(p), true)
+	if err != nil {
+		return nil, pathError(op, name, err)
+	}
+	if parent == nil || parent.Type != tables.NodeTypeFolder {
+		return nil, pathError(op, name, fs.ErrNotExist)
+	}
+	return parent, nil
+}
+
+// createItem creates one file or folder
+func (fsys *TableFileSystem) createItem(parent *dbTypes.Node, item items.NewItem) (*dbTypes.Node, error) {
+	c := fsys.client
+	resp, err := items.CreateItems(c.tableManager, c.database, c.generator, items.CreateItemsRequest{
+		TableID:  fsys.tableID,
+		ParentID: parent.ID,
+		Items:    []items.NewItem{item},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result := resp.Results[0]; result.Error != nil {
+		return nil, result.Error
+	}
+	return resp.Results[0].Item, nil
+}
+
+// checkEmpty fails if a folder has children
+func (fsys *TableFileSystem) checkEmpty(op, name string, folder *dbTypes.Node) error {
+	children, err := fsys.client.ListItems(fsys.tableID, folder.ID, false)
+	if err != nil {
+		return pathError(op, name, err)
+	}
+	if len(children) > 0 {
+		return pathError(op, name, errNotEmpty)
+	}
+	return nil
+}
+
+func (fsys *TableFileSystem) deleteItem(op, name string, node *dbTypes.Node) error {
+	c := fsys.client
+	if _, err := items.DeleteItem(c.tableManager, c.database, c.generator, items.DeleteItemRequest{TableID: fsys.tableID, ItemID: node.ID}); err != nil {
+		return pathError(op, name, err)
+	}
+	return nil
+}
+
+// Create creates or truncates a file, opened for reading and writing
+func (fsys *TableFileSystem) Create(name string) (File, error) {
+	return fsys.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o666)
+}
+
+// Open opens a file or folder for reading
+func (fsys *TableFileSystem) Open(name string) (File, error) {
+	return fsys.OpenFile(name, os.O_RDONLY, 0)
+}
+
+// OpenFile opens a file with the os package's flags (O_RDONLY, O_WRONLY, O_RDWR, O_APPEND,
+// O_CREATE, O_EXCL, O_TRUNC). New files are created when they're closed; locked files can't be
+// opened for writing. perm is ignored.
+func (fsys *TableFileSystem) OpenFile(name string, flag int, perm fs.FileMode) (File, error) {
+	p, node, err := fsys.client.resolvePath(fsys.tableID, nodePath(name), true)
+	if err != nil {
+		return nil, pathError("open", name, err)
+	}
+	access := flag & (os.O_RDONLY | os.O_WRONLY | os.O_RDWR)
+	h := &tableHandle{
+		fsys:     fsys,
+		name:     name,
+		readable: access != os.O_WRONLY,
+		writable: access != os.O_RDONLY,
+		append:   flag&os.O_APPEND != 0,
+		node:     node,
+	}
+
+	switch {
+	case node == nil && flag&os.O_CREATE == 0:
+		return nil, pathError("open", name, fs.ErrNotExist)
+	case node == nil:
+		if h.parent, err = fsys.parentFolder("open", name, p); err != nil {
+			return nil, err
+		}
+		h.newNode = dbTypes.Node{Name: path.Base(p), Path: p, Type: tables.NodeTypeFile, UpdatedAt: time.Now()}
+		h.dirty = true
+	case flag&os.O_CREATE != 0 && flag&os.O_EXCL != 0:
+		return nil, pathError("open", name, fs.ErrExist)
+	case node.Type == tables.NodeTypeFolder:
+		if h.writable {
+			return nil, pathError("open", name, errIsDir)
+		}
+		fsPath := strings.TrimPrefix(p, "/")
+		if fsPath == "" {
+			fsPath = "."
+		}
+		h.dir = &tableDir{fsys: fsys.reader, path: fsPath, info: fileInfo{name: path.Base(p), node: *node}}
+	default:
+		if h.writable && node.Lock != nil {
+			return nil, pathError("open", name, fmt.Errorf("%w: %s is locked by %s", fs.ErrPermission, node.Path, node.Lock.Owner))
+		}
+		if h.writable && node.Type != tables.NodeTypeFile {
+			return nil, pathError("open", name, fmt.Errorf("%w: a %s can't be written", fs.ErrPermission, node.Type))
+		}
+		h.size = node.Size
+		h.content = fsys.client.generator.OpenContent(*node, node.Size)
+		if h.writable && flag&os.O_TRUNC != 0 {
+			h.size, h.dirty = 0, true
+		}
+	}
+	return h, nil
+}
+
+// Mkdir creates a folder. Its parent must exist.
+func (fsys *TableFileSystem) Mkdir(name string, perm fs.FileMode) error {
+	p := nodePath(name)
+	existing, err := fsys.client.nodeAt(fsys.tableID, p)
+	if err != nil {
+		return pathError("mkdir", name, err)
+	}
+	if existing != nil {
+		return pathError("mkdir", name, fs.ErrExist)
+	}
+	parent, err := fsys.parentFolder("mkdir", name, p)
+	if err != nil {
+		return err
+	}
+	if _, err := fsys.createItem(parent, items.NewItem{Name: path.Base(p), Type: tables.NodeTypeFolder}); err != nil {
+		return pathError("mkdir", name, err)
+	}
+	return nil
+}
+
+// MkdirAll creates a folder and any missing parents. It does nothing if the folder exists.
+func (fsys *TableFileSystem) MkdirAll(name string, perm fs.FileMode) error {
+	folder, err := fsys.client.GetRoot(fsys.tableID)
+	if err != nil {
+		return pathError("mkdir", name, err)
+	}
+	p := "/"
+	for _, part := range strings.Split(strings.TrimPrefix(nodePath(name), "/"), "/") {
+		if part == "" {
+			continue
+		}
+		p = path.Join(p, part)
+		_, node, err := fsys.client.resolvePath(fsys.tableID, p, true)
+		if err != nil {
+			return pathError("mkdir", p, err)
+		}
+		if node == nil {
+			if node, err = fsys.createItem(&folder, items.NewItem{Name: part, Type: tables.NodeTypeFolder}); err != nil {
+				return pathError("mkdir", p, err)
+			}
+		}
+		if node.Type != tables.NodeTypeFolder {
+			return pathError("mkdir", p, errNotDir)
+		}
+		folder = *node
+	}
+	return nil
+}
+
+// Remove deletes a file, a link or an empty folder. Tables with a trash keep it there.
+func (fsys *TableFileSystem) Remove(name string) error {
+	_, node, err := fsys.node("remove", name, false)
+	if err != nil {
+		return err
+	}
+	if node.Type == tables.NodeTypeFolder {
+		if err := fsys.checkEmpty("remove", name, node); err != nil {
+			return err
+		}
+	}
+	return fsys.deleteItem("remove", name, node)
+}
+
+// RemoveAll deletes an item and, for folders, everything in it. It does nothing if the item doesn't
+// exist.
+func (fsys *TableFileSystem) RemoveAll(name string) error {
+	_, node, err := fsys.client.resolvePath(fsys.tableID, nodePath(name), false)
+	if err != nil {
+		return pathError("removeall", name, err)
+	}
+	if node == nil {
+		return nil
+	}
+	return fsys.deleteItem("removeall", name, node)
+}
+
+// Rename moves an item within the table. Like os.Rename, an existing file at newname is replaced,
+// and so is an empty folder when a folder is moved.
+func (fsys *TableFileSystem) Rename(oldname, newname string) error {
+	linkError := func(err error) error {
+		var pathErr *fs.PathError
+		if errors.As(err, &pathErr) {
+			err = pathErr.Err
+		}
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: err}
+	}
+	from, node, err := fsys.node("rename", oldname, false)
+	if err != nil {
+		return linkError(err)
+	}
+	to := nodePath(newname)
+	if to == from {
+		return nil
+	}
+	if to == "/" || strings.HasPrefix(to, from+"/") {
+		return linkError(fs.ErrInvalid)
+	}
+
+	existing, err := fsys.client.nodeAt(fsys.tableID, to)
+	if err != nil {
+		return linkError(fsError(err))
+	}
+	if existing != nil {
+		switch {
+		case existing.ID == node.ID:
+			return nil
+		case existing.Type == tables.NodeTypeFolder && node.Type != tables.NodeTypeFolder:
+			return linkError(fs.ErrExist)
+		case existing.Type != tables.NodeTypeFolder && node.Type == tables.NodeTypeFolder:
+			return linkError(errNotDir)
+		case existing.Type == tables.NodeTypeFolder:
+			if err := fsys.checkEmpty("rename", newname, existing); err != nil {
+				return linkError(err)
+			}
+		}
+	}
+	parent, err := fsys.parentFolder("rename", newname, to)
+	if err != nil {
+		return linkError(err)
+	}
+	// The existing item is only deleted once the move itself is known to succeed
+	req := items.MoveItemRequest{TableID: fsys.tableID, ItemID: node.ID, ParentID: parent.ID, Name: path.Base(to)}
+	if existing != nil {
+		req.ReplaceID = existing.ID
+	}
+	c := fsys.client
+	if _, err := items.MoveItem(c.tableManager, c.database, c.generator, req); err != nil {
+		return linkError(fsError(err))
+	}
+	return nil
+}
+
+// Stat returns the info of a file or folder, following symlinks
+func (fsys *TableFileSystem) Stat(name string) (fs.FileInfo, error) {
+	p, node, err := fsys.node("stat", name, true)
+	if err != nil {
+		return nil, err
+	}
+	return fileInfo{name: path.Base(p), node: *node}, nil
+}
+
+// Chtimes sets an item's modified time. Access times aren't stored, atime is ignored.
+func (fsys *TableFileSystem) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	_, node, err := fsys.node("chtimes", name, true)
+	if err != nil {
+		return err
+	}
+	c := fsys.client
+	if _, err := items.SetModTime(c.tableManager, c.database, c.generator, items.SetModTimeRequest{TableID: fsys.tableID, ItemID: node.ID, ModTime: mtime}); err != nil {
+		return pathError("chtimes", name, err)
+	}
+	return nil
+}
+
+// tableHandle is an open file or folder of a TableFileSystem
+type tableHandle struct {
+	fsys     *TableFileSystem
+	name     string
+	readable bool
+	writable bool
+	append   bool
+	closed   bool
+
+	// Folders
+	dir *tableDir
+
+	// Files. node is nil for a new file, newNode is created in parent when it's closed. Reads serve
+	// the content from before the writes.
+	node    *dbTypes.Node
+	newNode dbTypes.Node
+	parent  *dbTypes.Node
+	content *tables.ContentReader
+	size    int64
+	offset  int64
+	dirty   bool
+}
+
+// check fails if the handle is closed, or a folder for file operations
+func (h *tableHandle) check(op string) error {
+	if h.closed {
+		return &fs.PathError{Op: op, Path: h.name, Err: fs.ErrClosed}
+	}
+	if h.dir != nil && op != "stat" && op != "readdir" && op != "close" {
+		return &fs.PathError{Op: op, Path: h.name, Err: errIsDir}
+	}
+	return nil
+}
+
+func (h *tableHandle) Name() string { return h.name }
+
+// Stat returns the file's info, with the size of what has been written
+func (h *tableHandle) Stat() (fs.FileInfo, error) {
+	if err := h.check("stat"); err != nil {
+		return nil, err
+	}
+	if h.dir != nil {
+		return h.dir.info, nil
+	}
+	node := h.newNode
+	if h.node != nil {
+		node = *h.node
+	}
+	node.Size = h.size
+	return fileInfo{name: node.Name, node: node}, nil
+}
+
+func (h *tableHandle) ReadDir(n int) ([]fs.DirEntry, error) {
+	if err := h.check("readdir"); err != nil {
+		return nil, err
+	}
+	if h.dir == nil {
+		return nil, &fs.PathError{Op: "readdir", Path: h.name, Err: errNotDir}
+	}
+	return h.dir.ReadDir(n)
+}
+
+func (h *tableHandle) Read(p []byte) (int, error) {
+	n, err := h.ReadAt(p, h.offset)
+	h.offset += int64(n)
+	return n, err
+}
+
+func (h *tableHandle) ReadAt(p []byte, off int64) (int, error) {
+	if err := h.check("read"); err != nil {
+		return 0, err
+	}
+	if !h.readable {
+		return 0, &fs.PathError{Op: "read", Path: h.name, Err: errNotReadable}
+	}
+	if off < 0 {
+		return 0, &fs.PathError{Op: "read", Path: h.name, Err: fs.ErrInvalid}
+	}
+	if h.content == nil || off >= h.content.Size() {
+		return 0, io.EOF
+	}
+	return h.content.ReadAt(p, off)
+}
+
+// Write records a write at the offset. Only the file's size is tracked: the furthest byte written.
+func (h *tableHandle) Write(p []byte) (int, error) {
+	if h.append {
+		h.offset = h.size
+	}
+	n, err := h.write("write", p, h.offset)
+	h.offset += int64(n)
+	return n, err
+}
+
+func (h *tableHandle) WriteAt(p []byte, off int64) (int, error) {
+	if h.append {
+		return 0, &fs.PathError{Op: "writeat", Path: h.name, Err: errors.New("invalid use of WriteAt on file opened with O_APPEND")}
+	}
+	return h.write("writeat", p, off)
+}
+
+func (h *tableHandle) write(op string, p []byte, off int64) (int, error) {
+	if err := h.check(op); err != nil {
+		return 0, err
+	}
+	if !h.writable {
+		return 0, &fs.PathError{Op: op, Path: h.name, Err: errNotWritable}
+	}
+	if off < 0 {
+		return 0, &fs.PathError{Op: op, Path: h.name, Err: fs.ErrInvalid}
+	}
+	h.size = max(h.size, off+int64(len(p)))
+	h.dirty = true
+	return len(p), nil
+}
+
+func (h *tableHandle) Seek(offset int64, whence int) (int64, error) {
+	if err := h.check("seek"); err != nil {
+		return 0, err
+	}
+	switch whence {
+	case io.SeekCurrent:
+		offset += h.offset
+	case io.SeekEnd:
+		offset += h.size
+	case io.SeekStart:
+	default:
+		return 0, &fs.PathError{Op: "seek", Path: h.name, Err: fs.ErrInvalid}
+	}
+	if offset < 0 {
+		return 0, &fs.PathError{Op: "seek", Path: h.name, Err: fs.ErrInvalid}
+	}
+	h.offset = offset
+	return offset, nil
+}
+
+// Truncate changes the file's size, saved when it's closed
+func (h *tableHandle) Truncate(size int64) error {
+	if err := h.check("truncate"); err != nil {
+		return err
+	}
+	if !h.writable {
+		return &fs.PathError{Op: "truncate", Path: h.name, Err: errNotWritable}
+	}
+	if size < 0 {
+		return &fs.PathError{Op: "truncate", Path: h.name, Err: fs.ErrInvalid}
+	}
+	h.size, h.dirty = size, true
+	return nil
+}
+
+// Close closes the file. A new file is created now, a written one gets a revision of its new size.
+func (h *tableHandle) Close() error {
+	if err := h.check("close"); err != nil {
+		return err
+	}
+	h.closed = true
+	if !h.dirty {
+		return nil
+	}
+	if h.node == nil {
+		if _, err := h.fsys.createItem(h.parent, items.NewItem{Name: h.newNode.Name, Type: tables.NodeTypeFile, Size: h.size}); err != nil {
+			return pathError("close", h.name, err)
+		}
+		return nil
+	}
+	c := h.fsys.client
+	if _, err := items.UpdateFile(c.tableManager, c.database, c.generator, items.UpdateFileRequest{TableID: h.fsys.tableID, ItemID: h.node.ID, Size: h.size}); err != nil {
+		return pathError("close", h.name, err)
+	}
+	return nil
+}
+
+// OSFileSystem is a FileSystem over a directory of the real file system, using the os package.
+// Names can't leave the directory; errors carry the full OS paths.
+type OSFileSystem struct {
+	root string
+}
+
+// NewOSFileSystem returns a file system rooted at a directory
+func NewOSFileSystem(root string) *OSFileSystem {
+	return &OSFileSystem{root: root}
+}
+
+// path returns the OS path of a name
+func (fsys *OSFileSystem) path(name string) string {
+	return filepath.Join(fsys.root, filepath.FromSlash(nodePath(name)))
+}
+
+// osFile returns an *os.File as a File, keeping failed opens a nil interface
+func osFile(f *os.File, err error) (File, error) {
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (fsys *OSFileSystem) Create(name string) (File, error) {
+	return osFile(os.Create(fsys.path(name)))
+}
+
+func (fsys *OSFileSystem) Mkdir(name string, perm fs.FileMode) error {
+	return os.Mkdir(fsys.path(name), perm)
+}
+
+func (fsys *OSFileSystem) MkdirAll(name string, perm fs.FileMode) error {
+	return os.MkdirAll(fsys.path(name), perm)
+}
+
+func (fsys *OSFileSystem) Open(name string) (File, error) {
+	return osFile(os.Open(fsys.path(name)))
+}
+
+func (fsys *OSFileSystem) OpenFile(name string, flag int, perm fs.FileMode) (File, error) {
+	return osFile(os.OpenFile(fsys.path(name), flag, perm))
+}
+
+func (fsys *OSFileSystem) Remove(name string) error {
+	return os.Remove(fsys.path(name))
+}
+
+func (fsys *OSFileSystem) RemoveAll(name string) error {
+	return os.RemoveAll(fsys.path(name))
+}
+
+func (fsys *OSFileSystem) Rename(oldname, newname string) error {
+	return os.Rename(fsys.path(oldname), fsys.path(newname))
+}
+
+func (fsys *OSFileSystem) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(fsys.path(name))
+}
+
+func (fsys *OSFileSystem) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	return os.Chtimes(fsys.path(name), atime, mtime)
+}
@@ -9,6 +9,7 @@ import (
 type BaseResponse struct {
 	Success bool   `json:"success"`
 	Error   string `json:"error,omitempty"`
+	Code    string `json:"code,omitempty"` // Item error code of failed item operations, e.g. "locked"
 }
 
 // Response represents a complete API response with optional data
@@ -35,6 +36,13 @@ func NewErrorResponse(errorMsg string) Response {
 	}
 }
 
+// NewItemErrorResponse creates an error response for a failed item operation, with its error code
+func NewItemErrorResponse(code string, errorMsg string) Response {
+	resp := NewErrorResponse(errorMsg)
+	resp.Code = code
+	return resp
+}
+
 // SendJSON writes a JSON response to the HTTP response writer
 func (r Response) SendJSON(w http.ResponseWriter, statusCode int) {
 	w.Header().Set("Content-Type", "application/json")
@@ -0,0 +1,12 @@
+package db
+
+import "time"
+
+// Change is an entry of a table's change journal: an item that was created, modified, moved,
+// trashed or restored, or removed for good
+type Change struct {
+	Seq       int64     `json:"seq"` // position in the table's journal, increasing
+	NodeID    string    `json:"node_id"`
+	Removed   bool      `json:"removed"`
+	ChangedAt time.Time `json:"changed_at"`
+}
@@ -0,0 +1,10 @@
+package db
+
+import "time"
+
+// Lock is an exclusive lock on a file or folder, like a SharePoint checkout or an open Office document
+type Lock struct {
+	Owner      string     `json:"owner"`
+	AcquiredAt time.Time  `json:"acquired_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"` // nil = held until released
+}
@@ -8,12 +8,18 @@ type Node struct {
 	ParentID              string    `json:"parent_id" db:"parent_id"`
 	Name                  string    `json:"name" db:"name"`
 	Path                  string    `json:"path" db:"path"`
-	Type                  string    `json:"type" db:"type"` // "file" or "folder"
+	Type                  string    `json:"type" db:"type"`                           // "file", "folder", "symlink", "hardlink" or "shortcut"
+	Target                string    `json:"target,omitempty" db:"target"`             // link target: relative path (symlink) or node ID (hardlink, shortcut)
+	ContentSeed           int64     `json:"content_seed,omitempty" db:"content_seed"` // seed of the file's content (0 = derived from the ID)
 	Size                  int64     `json:"size" db:"size"`
 	Level                 int       `json:"level" db:"level"`
 	Checked               bool      `json:"checked" db:"checked"`
 	SecondaryExistenceMap string    `json:"secondary_existence_map,omitempty" db:"secondary_existence_map"` // JSON string
 	ChildSeed             *int64    `json:"child_seed,omitempty" db:"child_seed"`                           // Optional child generation seed
+	Materialized          bool      `json:"materialized,omitempty" db:"materialized"`                       // children live in the database instead of being generated
+	UserCreated           bool      `json:"user_created,omitempty" db:"user_created"`                       // created or copied through the API, so it has no seeded history, lock or sharing
+	Lock                  *Lock     `json:"lock,omitempty" db:"-"`                                          // set when the item is locked (only filled in by listings and path lookups)
+	Sharing               *Sharing  `json:"sharing,omitempty" db:"-"`                                       // set when the item is shared (only filled in by listings and path lookups)
 	CreatedAt             time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt             time.Time `json:"updated_at" db:"updated_at"`
 }
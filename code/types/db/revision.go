@@ -0,0 +1,15 @@
+package db
+
+import "time"
+
+// Revision represents one version of a file. The highest number is the file's current content.
+type Revision struct {
+	ID          string    `json:"id"`
+	NodeID      string    `json:"node_id"`
+	Number      int       `json:"number"`
+	Size        int64     `json:"size"`
+	ContentSeed int64     `json:"content_seed"`
+	ModifiedAt  time.Time `json:"modified_at"`
+	Author      string    `json:"author,omitempty"`
+	Current     bool      `json:"current"`
+}
@@ -0,0 +1,33 @@
+package db
+
+import "time"
+
+// Sharing is who an item is shared with and its shared links. Members of a shared folder also
+// have access to its contents; they're only listed on the folder.
+type Sharing struct {
+	Owner   string        `json:"owner,omitempty"`
+	Members []ShareMember `json:"members,omitempty"`
+	Links   []SharedLink  `json:"links,omitempty"`
+}
+
+// ShareMember is a person an item is shared with
+type ShareMember struct {
+	Email string `json:"email"`
+	Role  string `json:"role"` // "editor", "commenter" or "viewer"
+}
+
+// SharedLink is a link that gives access to an item without being a member
+type SharedLink struct {
+	ID                string     `json:"id"`
+	URL               string     `json:"url"`
+	Scope             string     `json:"scope"` // "anyone" or "organization"
+	Role              string     `json:"role"`  // "viewer" or "editor"
+	PasswordProtected bool       `json:"password_protected,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	ExpiresAt         *time.Time `json:"expires_at,omitempty"`
+}
+
+// IsEmpty reports whether the item isn't shared at all
+func (s *Sharing) IsEmpty() bool {
+	return s == nil || (len(s.Members) == 0 && len(s.Links) == 0)
+}
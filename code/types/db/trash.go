@@ -0,0 +1,12 @@
+package db
+
+import "time"
+
+// TrashItem is an item deleted to its table's trash. Node is the item as it was deleted, so its
+// Path and ParentID are where it was before.
+type TrashItem struct {
+	Node
+	DeletedAt time.Time  `json:"deleted_at"`
+	PurgeAt   *time.Time `json:"purge_at,omitempty"` // when retention purges it (nil = kept until purged)
+	Items     int        `json:"items"`              // stored nodes in the deleted subtree, including the item
+}